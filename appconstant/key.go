@@ -2,11 +2,114 @@ package appconstant
 
 const (
 	// Context Key
-	AccountIdCtxKey      = "account_id"
-	EmailCtxKey          = "email"
-	IsKycCompletedCtxKey = "is_kyc_completed"
+	AccountIdCtxKey          = "account_id"
+	EmailCtxKey              = "email"
+	IsKycCompletedCtxKey     = "is_kyc_completed"
+	IsEmailVerifiedCtxKey    = "is_email_verified"
+	IsMfaAuthenticatedCtxKey = "is_mfa_authenticated"
+
+	// Request Header
+	PartnerKeyHeader = "X-Partner-Key"
 
 	// Media Key
 	KYCIdentityCardPhotoTag = "kyc_identity_card_photo"
 	KYCSelfiePhotoTag       = "kyc_selfie_photo"
+
+	// KYC Status
+	KycStatusApproved      = "approved"
+	KycStatusPendingReview = "pending_review"
+	KycStatusRejected      = "rejected"
+
+	// Media Size
+	MediaSizeOriginal  = "original"
+	MediaSizeThumbnail = "thumbnail"
+	MediaSizePreview   = "preview"
+
+	// Health Status
+	HealthStatusOk          = "ok"
+	HealthStatusUnavailable = "unavailable"
+	HealthStatusDraining    = "draining"
+
+	// Signed Token Purpose
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeMfaChallenge      = "mfa_challenge"
+	TokenPurposeDataExport        = "data_export"
+
+	// Email Reuse Policy
+	EmailReuseOnClose         = "on_close"
+	EmailReuseOnAnonymisation = "on_anonymisation"
+	EmailReuseNever           = "never"
+
+	// Data Export Status
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusCompleted  = "completed"
+	DataExportStatusFailed     = "failed"
+	DataExportStatusExpired    = "expired"
+
+	// Data Export Requester
+	DataExportRequestedByAccount = "account"
+	DataExportRequestedByCli     = "cli"
+
+	// Audit Actor
+	AuditActorAccount   = "account"
+	AuditActorAnonymous = "anonymous"
+	AuditActorCli       = "cli"
+	AuditActorAdmin     = "admin"
+
+	// Audited Entity, consumers and limits are identified by their account_id
+	AuditEntityAccount      = "account"
+	AuditEntityConsumer     = "consumer"
+	AuditEntityAccountLimit = "account_limit"
+	AuditEntityTransaction  = "transaction"
+	AuditEntityPartner      = "partner"
+	AuditEntityWebhook      = "partner_webhook"
+	AuditEntityDataExport   = "data_export"
+
+	// Audit Action
+	AuditActionAccountRegistered    = "account.registered"
+	AuditActionLoginSucceeded       = "account.login_succeeded"
+	AuditActionLoginFailed          = "account.login_failed"
+	AuditActionKycSubmitted         = "kyc.submitted"
+	AuditActionKycApproved          = "kyc.approved"
+	AuditActionKycRejected          = "kyc.rejected"
+	AuditActionLimitCreated         = "limit.created"
+	AuditActionLimitUpdated         = "limit.updated"
+	AuditActionTransactionCreated   = "transaction.created"
+	AuditActionTransactionCancelled = "transaction.cancelled"
+	AuditActionTransactionPaidOff   = "transaction.paid_off"
+	AuditActionPartnerCreated       = "partner.created"
+	AuditActionWebhookCreated       = "partner_webhook.created"
+	AuditActionWebhookDeleted       = "partner_webhook.deleted"
+	AuditActionDataExportRequested  = "data_export.requested"
+	AuditActionDataExportDownloaded = "data_export.downloaded"
+
+	// Domain Event Type
+	EventTypeAccountRegistered        = "account.registered"
+	EventTypeKycCompleted             = "kyc.completed"
+	EventTypeTransactionCreated       = "transaction.created"
+	EventTypeTransactionStatusChanged = "transaction.status_changed"
+
+	// Domain Event Aggregate, identified by the id of the row
+	EventAggregateAccount     = "account"
+	EventAggregateTransaction = "transaction"
+
+	// Transaction Status
+	TransactionStatusApproved  = "approved"
+	TransactionStatusCancelled = "cancelled"
+	TransactionStatusPaidOff   = "paid_off"
+
+	// Partner Webhook Event
+	WebhookEventTransactionApproved  = "transaction.approved"
+	WebhookEventTransactionCancelled = "transaction.cancelled"
+	WebhookEventTransactionPaidOff   = "transaction.paid_off"
+
+	// Webhook Delivery Status
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+
+	// Seconds verifiers may cache the jwks for
+	JwksMaxAgeSeconds = 300
 )
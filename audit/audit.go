@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+// key of the HMAC chaining the events, set once on startup before any event is hashed
+var key []byte
+
+type requestKey struct{}
+
+type actorKey struct{}
+
+// Request is where a change came from, the audit events of the change carry it
+type Request struct {
+	Id        string
+	IpAddress string
+}
+
+// Actor made the change. Without one in the context, the account the change is about is the actor.
+type Actor struct {
+	Type string
+	Id   *int64
+}
+
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+func RequestFrom(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey{}).(Request)
+
+	return request
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+
+	return actor, ok
+}
+
+// SetKey sets the key hashing the events from audit.key. Events hashed with another key fail verification.
+func SetKey(k string) {
+	key = []byte(k)
+}
+
+// Hash chains the event to the hash of the previous event. Every field but the hash itself is covered, so changing
+// an event, or deleting one, breaks the chain from there on. The hash is keyed, so it cannot be recomputed over a
+// changed event without the key.
+func Hash(event entity.AuditEvent) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("[audit][Hash] key is not set")
+	}
+
+	event.Hash = ""
+
+	// The diff is compacted by json.Marshal, its whitespace does not change the hash
+	b, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("[audit][Hash][json.Marshal] error: %w | audit_event_id: %v", err, event.Id)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"flag"
+
+	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+func (a *app) accountService() service.AccountService {
+	jwtKeyring, err := server.NewKeyring(a.config.Jwt)
+	if err != nil {
+		a.log.Fatalf("[cli][accountService][server.NewKeyring] error: %s", err.Error())
+	}
+
+	return service.NewAccountService(
+		repository.NewSqlTransaction(a.db, a.driver),
+		hHelper.NewHashHelper(a.config.Hash),
+		jwtKeyring,
+		repository.NewAccountRepository(a.driver, a.db),
+		repository.NewConsumerRepository(a.driver, a.db),
+		repository.NewRefreshTokenRepository(a.driver, a.db),
+		repository.NewLoginAttemptRepository(a.driver, a.db),
+		repository.NewAccountMfaRepository(a.driver, a.db),
+		server.NewNotifier(a.config, a.log),
+		server.LoginProtectionOpt(a.config.LoginProtection),
+		server.EmailVerificationOpt(a.config.EmailVerification),
+		server.PasswordResetOpt(a.config.PasswordReset),
+		server.MfaOpt(a.config.Mfa),
+		server.AccountClosureOpt(a.config.AccountClosure, a.config.Retention),
+	)
+}
+
+func account(args []string) {
+	if len(args) == 0 {
+		exitUsage("account requires an action: create, disable or unlock")
+	}
+
+	flags := flag.NewFlagSet("account "+args[0], flag.ExitOnError)
+	email := flags.String("email", "", "account email")
+	password := flags.String("password", "", "account password, only for create")
+	ip := flags.String("ip", "", "IP address to unlock as well, only for unlock")
+	flags.Parse(args[1:])
+
+	if *email == "" {
+		exitUsage("--email is required")
+	}
+
+	ctx := cliContext()
+
+	switch args[0] {
+	case "create":
+		if *password == "" {
+			exitUsage("--password is required")
+		}
+
+		app := newApp()
+		defer app.close()
+
+		_, err := app.accountService().RegisterAccount(ctx, entity.Account{
+			Email:    *email,
+			Password: *password,
+		}, entity.ClientInfo{})
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		app.log.Infof("[cli][account] account %s created", *email)
+
+	case "disable":
+		app := newApp()
+		defer app.close()
+
+		err := app.accountService().DisableAccount(ctx, *email)
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		app.log.Infof("[cli][account] account %s disabled", *email)
+
+	case "unlock":
+		app := newApp()
+		defer app.close()
+
+		err := app.accountService().UnlockLogin(ctx, *email, *ip)
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		app.log.Infof("[cli][account] login of %s unlocked", *email)
+
+	default:
+		exitUsage("unknown account action: %s", args[0])
+	}
+}
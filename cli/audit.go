@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+// auditCommand verifies the hash chain of the audit log, it exits with 1 when the chain is broken so it can be scheduled
+func auditCommand(args []string) {
+	if len(args) == 0 || args[0] != "verify" {
+		exitUsage("audit requires an action: verify")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	auditService := service.NewAuditService(repository.NewAuditEventRepository(app.driver, app.db))
+
+	verification, err := auditService.VerifyChain(context.Background())
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(verification)
+
+	if !verification.IsValid {
+		app.close()
+		os.Exit(1)
+	}
+}
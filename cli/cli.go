@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: xyz-credit-plus-be [--config path] [--set key=value ...] <command> [arguments]
+
+Options:
+  --config path                           config file, KREDIT_PLUS_USERS_SERVICE_CONFIG by default
+  --set key=value                         override a config value by its dotted path, e.g. --set jwt.issuer=xyz
+
+Commands:
+  serve                                   run the HTTP server (default)
+  config check                            validate the config and print it with secrets redacted
+  migrate up                              apply all pending migrations
+  migrate down [steps]                    revert the latest migrations, 1 by default
+  migrate status                          list migrations and when they were applied
+  seed [--env name] [--dir path] [--file path]
+       [--generate n] [--transactions n] [--photos] [--random-seed n]
+                                          load fixtures idempotently, optionally with synthetic accounts
+  account create --email --password       register a new account
+  account disable --email                 disable an account so it cannot login, and sign out its sessions
+  account unlock --email [--ip]           clear failed logins of an email, and of an IP address when given
+  limit set --account id [--1m] [--2m] [--3m] [--4m]
+                                          set the limit of an account, the limits not given are kept
+  kyc approve --account id                approve a KYC pending review
+  kyc reject --account id                 reject a KYC pending review
+  export --account id                     generate the personal data export of an account and print its download link
+  retention run                           apply the retention policies and print what was removed
+  audit verify                            check the hash chain of the audit log, exits with 1 when it is broken
+  outbox relay                            publish the due domain events and print how many were published
+  webhook dispatch                        send the due webhook deliveries to the partners and print how many were delivered
+  jwt rotate                              generate a signing key ahead of the rotation schedule
+  jwt jwks                                print the public keys verifying access tokens
+`
+
+// Config layers given before the command, shared by every command
+var loadOpt config.LoadOpt
+
+type app struct {
+	log    *logrus.Logger
+	config config.ServiceConfig
+	db     *sql.DB
+	driver repository.Driver
+}
+
+func newApp() *app {
+	log := helper.NewLogrus()
+
+	config := config.Init(log, loadOpt)
+
+	audit.SetKey(config.Audit.Key)
+
+	db, driver, err := server.ConnectDB(config)
+	if err != nil {
+		log.Fatalf("[cli][newApp][server.ConnectDB] error: %s", err.Error())
+	}
+
+	return &app{
+		log:    log,
+		config: config,
+		db:     db,
+		driver: driver,
+	}
+}
+
+func (a *app) close() {
+	a.db.Close()
+}
+
+// cliContext records the changes of a command as made by an operator on the command line
+func cliContext() context.Context {
+	return audit.WithActor(context.Background(), audit.Actor{Type: appconstant.AuditActorCli})
+}
+
+func printJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func exitUsage(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n\n", args...)
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// parseGlobalFlags consumes the options given before the command
+func parseGlobalFlags(args []string) []string {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+
+		switch name {
+		case "--config", "--set":
+		default:
+			return args
+		}
+
+		if !hasValue {
+			if len(args) < 2 {
+				exitUsage("%s requires a value", name)
+			}
+
+			value = args[1]
+			args = args[1:]
+		}
+
+		args = args[1:]
+
+		if name == "--config" {
+			loadOpt.File = value
+		} else {
+			loadOpt.Sets = append(loadOpt.Sets, value)
+		}
+	}
+
+	return args
+}
+
+// Run runs the command given in args. The HTTP server is served when no command is given.
+func Run(args []string) {
+	args = parseGlobalFlags(args)
+
+	if len(args) == 0 {
+		server.Init(loadOpt)
+		return
+	}
+
+	switch args[0] {
+	case "serve":
+		server.Init(loadOpt)
+
+	case "config":
+		configCommand(args[1:])
+
+	case "migrate":
+		migrate(args[1:])
+
+	case "seed":
+		seed(args[1:])
+
+	case "account":
+		account(args[1:])
+
+	case "limit":
+		limit(args[1:])
+
+	case "kyc":
+		kyc(args[1:])
+
+	case "export":
+		export(args[1:])
+
+	case "retention":
+		retention(args[1:])
+
+	case "audit":
+		auditCommand(args[1:])
+
+	case "outbox":
+		outbox(args[1:])
+
+	case "webhook":
+		webhookCommand(args[1:])
+
+	case "jwt":
+		jwtCommand(args[1:])
+
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+
+	default:
+		exitUsage("unknown command: %s", args[0])
+	}
+}
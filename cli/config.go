@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+)
+
+func configCommand(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		exitUsage("config requires an action: check")
+	}
+
+	// Loaded without connecting to the database, so the config can be checked anywhere
+	c, err := config.Load(loadOpt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// Printed through a pointer so durations are marshalled as duration strings
+	redacted := c.Redacted()
+	printJSON(&redacted)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+// export generates the personal data export of an account right away, instead of waiting for the worker of the server
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	accountId := flags.Int64("account", 0, "account id")
+	flags.Parse(args)
+
+	if *accountId <= 0 {
+		exitUsage("--account is required")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	dataExportService := service.NewDataExportService(
+		repository.NewAccountRepository(app.driver, app.db),
+		repository.NewConsumerRepository(app.driver, app.db),
+		repository.NewConsumerHistoryRepository(app.driver, app.db),
+		repository.NewAccountLimitRepository(app.driver, app.db),
+		repository.NewTransactionRepository(app.driver, app.db),
+		repository.NewRefreshTokenRepository(app.driver, app.db),
+		repository.NewDataExportRepository(app.driver, app.db),
+		server.NewMediaRepository(app.config),
+		server.NewArchiveRepository(app.config),
+		server.NewNotifier(app.config, app.log),
+		repository.NewSqlTransaction(app.db, app.driver),
+		server.DataExportOpt(app.config.DataExport),
+	)
+
+	ctx := cliContext()
+
+	requested, err := dataExportService.RequestExport(ctx, *accountId, appconstant.DataExportRequestedByCli)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	generated, err := dataExportService.GenerateExport(ctx, requested.Id)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	if generated.Status != appconstant.DataExportStatusCompleted {
+		app.log.Fatalf("[cli][export] data export %v failed: %s", generated.Id, generated.Failure)
+	}
+
+	link, err := dataExportService.DownloadLink(*generated)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(struct {
+		Export *entity.DataExport `json:"export"`
+		Link   string             `json:"download_link"`
+	}{
+		Export: generated,
+		Link:   link,
+	})
+}
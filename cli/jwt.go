@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+)
+
+func jwtCommand(args []string) {
+	if len(args) == 0 {
+		exitUsage("jwt requires an action: rotate or jwks")
+	}
+
+	// Keys live in jwt.keys_dir, the database is not needed
+	log := helper.NewLogrus()
+	c := config.Init(log, loadOpt)
+
+	jwtKeyring, err := server.NewKeyring(c.Jwt)
+	if err != nil {
+		log.Fatalf("[cli][jwtCommand][server.NewKeyring] error: %s", err.Error())
+	}
+
+	switch args[0] {
+	case "rotate":
+		kid, err := jwtKeyring.Generate(context.Background())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		fmt.Fprintf(os.Stdout, "generated key %s, it signs after jwt.publish_ahead_s\n", kid)
+
+	case "jwks":
+		printJSON(jwtKeyring.Jwks())
+
+	default:
+		exitUsage("unknown jwt action: %s", args[0])
+	}
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+func kyc(args []string) {
+	if len(args) == 0 || (args[0] != "approve" && args[0] != "reject") {
+		exitUsage("kyc requires an action: approve or reject")
+	}
+
+	flags := flag.NewFlagSet("kyc "+args[0], flag.ExitOnError)
+	accountId := flags.Int64("account", 0, "account id")
+	flags.Parse(args[1:])
+
+	if *accountId <= 0 {
+		exitUsage("--account is required")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	consumerService := service.NewConsumerService(
+		repository.NewSqlTransaction(app.db, app.driver),
+		repository.NewConsumerRepository(app.driver, app.db),
+		server.NewMediaRepository(app.config),
+		repository.NewAccountLimitRepository(app.driver, app.db),
+	)
+
+	isApproved := args[0] == "approve"
+
+	err := consumerService.ReviewKyc(cliContext(), *accountId, isApproved)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	app.log.Infof("[cli][kyc] KYC of account %v %sd", *accountId, args[0])
+}
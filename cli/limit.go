@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+func limit(args []string) {
+	if len(args) == 0 || args[0] != "set" {
+		exitUsage("limit requires an action: set")
+	}
+
+	flags := flag.NewFlagSet("limit set", flag.ExitOnError)
+	accountId := flags.Int64("account", 0, "account id")
+	limit1M := flags.Float64("1m", 0, "limit of 1 month installment")
+	limit2M := flags.Float64("2m", 0, "limit of 2 months installment")
+	limit3M := flags.Float64("3m", 0, "limit of 3 months installment")
+	limit4M := flags.Float64("4m", 0, "limit of 4 months installment")
+	flags.Parse(args[1:])
+
+	if *accountId <= 0 {
+		exitUsage("--account is required")
+	}
+
+	req := entity.SetAccountLimitReq{
+		AccountId: *accountId,
+	}
+
+	// Only the limits given are overridden, the service keeps the others
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "1m":
+			req.Limit1M = limit1M
+		case "2m":
+			req.Limit2M = limit2M
+		case "3m":
+			req.Limit3M = limit3M
+		case "4m":
+			req.Limit4M = limit4M
+		}
+	})
+
+	if req.Limit1M == nil && req.Limit2M == nil && req.Limit3M == nil && req.Limit4M == nil {
+		exitUsage("limit set requires at least one of --1m, --2m, --3m or --4m")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	accountLimitService := service.NewAccountLimitService(
+		repository.NewSqlTransaction(app.db, app.driver),
+		repository.NewConsumerRepository(app.driver, app.db),
+	)
+
+	updated, err := accountLimitService.SetLimit(cliContext(), req)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(updated)
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+)
+
+func migrate(args []string) {
+	if len(args) == 0 {
+		exitUsage("migrate requires an action: up, down or status")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	migrator, err := migration.NewMigrator(app.driver, app.db)
+	if err != nil {
+		app.log.Fatalf("[cli][migrate][migration.NewMigrator] error: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			app.log.Infof("[cli][migrate] applied migration %v_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+	case "down":
+		steps := 1
+
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				exitUsage("invalid steps: %s", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			app.log.Infof("[cli][migrate] reverted migration %v_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = time.UnixMilli(*s.AppliedAt).Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(os.Stdout, "%06d %-45s %s\n", s.Version, s.Name, appliedAt)
+		}
+
+	default:
+		exitUsage("unknown migrate action: %s", args[0])
+	}
+}
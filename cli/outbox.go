@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+// outbox publishes the due domain events right away, instead of waiting for the relay of the server
+func outbox(args []string) {
+	if len(args) == 0 || args[0] != "relay" {
+		exitUsage("outbox requires an action: relay")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	outboxService := service.NewOutboxService(
+		repository.NewOutboxRepository(app.driver, app.db),
+		server.NewPublisher(app.config.Outbox),
+		server.OutboxOpt(app.config.Outbox),
+	)
+
+	result, err := outboxService.Relay(context.Background())
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(result)
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+// retention applies the retention policies right away, instead of waiting for the job of the server
+func retention(args []string) {
+	if len(args) == 0 || args[0] != "run" {
+		exitUsage("retention requires an action: run")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	retentionService := service.NewRetentionService(
+		app.accountService(),
+		repository.NewRefreshTokenRepository(app.driver, app.db),
+		repository.NewConsumerRepository(app.driver, app.db),
+		server.NewMediaRepository(app.config),
+		repository.NewOutboxRepository(app.driver, app.db),
+		repository.NewWebhookDeliveryRepository(app.driver, app.db),
+		server.RetentionOpt(app.config.Retention),
+	)
+
+	report, err := retentionService.Run(context.Background())
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(report)
+}
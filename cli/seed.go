@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"flag"
+
+	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/fixture"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+)
+
+func seed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	env := flags.String("env", "", "bundled fixture set to load, the configured environment is used when empty")
+	dir := flags.String("dir", "", "directory of JSON or YAML fixture files to load instead of a bundled set")
+	file := flags.String("file", "", "single JSON or YAML fixture file to load instead of a bundled set")
+	generate := flags.Int("generate", 0, "number of synthetic accounts to generate in addition to the fixtures")
+	transactions := flags.Int("transactions", 0, "number of transactions of every synthetic account")
+	photos := flags.Bool("photos", false, "store KYC photos for synthetic accounts")
+	randomSeed := flags.Int64("random-seed", 1, "seed of the synthetic data, the same seed generates the same accounts")
+	flags.Parse(args)
+
+	app := newApp()
+	defer app.close()
+
+	var (
+		f   *fixture.Fixture
+		err error
+	)
+
+	switch {
+	case *file != "":
+		f, err = fixture.LoadFile(*file)
+
+	case *dir != "":
+		f, err = fixture.LoadDir(*dir)
+
+	case *generate > 0 && *env == "":
+		f = &fixture.Fixture{}
+
+	default:
+		environment := *env
+		if environment == "" {
+			environment = app.config.Seeding.Environment
+		}
+		if environment == "" {
+			environment = "development"
+		}
+
+		f, err = fixture.LoadEnvironment(environment)
+	}
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	seeder := fixture.NewSeeder(app.db, app.driver, hHelper.NewHashHelper(app.config.Hash), server.NewMediaRepository(app.config))
+
+	if *generate > 0 {
+		passwordHash, err := seeder.HashPassword(context.Background(), fixture.SyntheticEmail(1), "@abcD1234")
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		generated, err := fixture.Generate(fixture.GenerateOpt{
+			Accounts:               *generate,
+			TransactionsPerAccount: *transactions,
+			PasswordHash:           passwordHash,
+			WithPhotos:             *photos,
+			Seed:                   *randomSeed,
+		})
+		if err != nil {
+			app.log.Fatal(err.Error())
+		}
+
+		f.Merge(generated)
+	}
+
+	result, err := seeder.Seed(context.Background(), f)
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(result)
+}
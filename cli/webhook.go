@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/server"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+	"github.com/michaelyusak/xyz-kredit-plus/webhook"
+)
+
+// webhookCommand sends the due webhook deliveries right away, instead of waiting for the dispatcher of the server
+func webhookCommand(args []string) {
+	if len(args) == 0 || args[0] != "dispatch" {
+		exitUsage("webhook requires an action: dispatch")
+	}
+
+	app := newApp()
+	defer app.close()
+
+	webhookService := service.NewWebhookService(
+		repository.NewSqlTransaction(app.db, app.driver),
+		repository.NewPartnerRepository(app.driver, app.db),
+		repository.NewPartnerWebhookRepository(app.driver, app.db),
+		repository.NewWebhookDeliveryRepository(app.driver, app.db),
+		webhook.NewHttpSender(time.Duration(app.config.Webhook.Timeout)),
+		server.WebhookOpt(app.config.Webhook),
+	)
+
+	result, err := webhookService.Dispatch(context.Background())
+	if err != nil {
+		app.log.Fatal(err.Error())
+	}
+
+	printJSON(result)
+}
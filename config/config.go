@@ -1,12 +1,12 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/michaelyusak/go-helper/entity"
 	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -14,39 +14,401 @@ type LocalStorageConfig struct {
 	Path string `json:"path"`
 }
 
-type ServiceConfig struct {
-	Port              string             `json:"port"`
-	GracefulPeriod    entity.Duration    `json:"graceful_perion_s"`
-	ContextTimeout    entity.Duration    `json:"context_timeout_s"`
-	AllowedOrigins    []string           `json:"allowed_origins"`
-	MySQL             entity.DBConfig    `json:"mysql"`
-	Jwt               helper.JwtConfig   `json:"jwt"`
-	Hash              helper.HashConfig  `json:"hash"`
-	LocalMediaStorage LocalStorageConfig `json:"local_media_storage"`
-	IsEnableSeeding   bool               `json:"is_enable_seeding"`
+type MediaVariantConfig struct {
+	ThumbnailSize int `json:"thumbnail_px"`
+	PreviewSize   int `json:"preview_px"`
 }
 
-func Init(log *logrus.Logger) ServiceConfig {
-	configPath := os.Getenv("KREDIT_PLUS_USERS_SERVICE_CONFIG")
+type MediaSweeperConfig struct {
+	IsEnabled   bool            `json:"is_enabled"`
+	Interval    entity.Duration `json:"interval_s"`
+	GracePeriod entity.Duration `json:"grace_period_s"`
+}
 
-	var config ServiceConfig
+type SeedingConfig struct {
+	Environment string `json:"environment"`
+	Dir         string `json:"dir"`
+}
 
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		log.WithFields(logrus.Fields{
-			"error": fmt.Sprintf("[config][Init][Read] error: %s", err.Error()),
-		}).Fatal("error initiating config file")
+type SQLiteConfig struct {
+	Path string `json:"path"`
+}
+
+type LoginProtectionConfig struct {
+	AccountMaxAttempts int             `json:"account_max_attempts"`
+	IpMaxAttempts      int             `json:"ip_max_attempts"`
+	DelayAfterAttempts int             `json:"delay_after_attempts"`
+	BaseDelay          entity.Duration `json:"base_delay_s"`
+	MaxDelay           entity.Duration `json:"max_delay_s"`
+	Lockout            entity.Duration `json:"lockout_s"`
+	Window             entity.Duration `json:"window_s"`
+}
+
+type TracingConfig struct {
+	IsEnabled   bool    `json:"is_enabled"`
+	ServiceName string  `json:"service_name"`
+	Protocol    string  `json:"protocol"`
+	Endpoint    string  `json:"endpoint"`
+	IsInsecure  bool    `json:"is_insecure"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+type RedisConfig struct {
+	Addr     string `json:"addr"`
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+}
+
+// A token bucket per client of a route group, keyed by ip or by the authenticated account_id
+type RateLimitRuleConfig struct {
+	Rate  float64 `json:"rate_per_s"`
+	Burst int     `json:"burst"`
+	KeyBy string  `json:"key_by"`
+}
+
+type RateLimitConfig struct {
+	IsEnabled   bool                `json:"is_enabled"`
+	Store       string              `json:"store"`
+	Redis       RedisConfig         `json:"redis"`
+	Account     RateLimitRuleConfig `json:"account"`
+	Consumer    RateLimitRuleConfig `json:"consumer"`
+	Transaction RateLimitRuleConfig `json:"transaction"`
+}
+
+type SmtpConfig struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Bounds connecting to the server and sending one email
+	Timeout entity.Duration `json:"timeout_s"`
+}
+
+type NotifierConfig struct {
+	Driver string             `json:"driver"`
+	From   string             `json:"from"`
+	File   LocalStorageConfig `json:"file"`
+	Smtp   SmtpConfig         `json:"smtp"`
+}
+
+type EmailVerificationConfig struct {
+	Key              string          `json:"key"`
+	TokenTtl         entity.Duration `json:"token_ttl_s"`
+	ResendCooldown   entity.Duration `json:"resend_cooldown_s"`
+	LinkUrl          string          `json:"link_url"`
+	IsRequiredForKyc bool            `json:"is_required_for_kyc"`
+}
+
+type PasswordResetConfig struct {
+	Key            string          `json:"key"`
+	TokenTtl       entity.Duration `json:"token_ttl_s"`
+	ResendCooldown entity.Duration `json:"resend_cooldown_s"`
+	LinkUrl        string          `json:"link_url"`
+}
+
+type JwtConfig struct {
+	Issuer string `json:"issuer"`
+	// HS512, RS256 or EdDSA
+	Algorithm string `json:"algorithm"`
+	// Signs with HS512. With an asymmetric algorithm, tokens signed with it are still accepted until it is removed.
+	Key string `json:"key"`
+	// Private keys of the asymmetric algorithms as <kid>.pem, shared by every instance
+	KeysDir          string          `json:"keys_dir"`
+	RotationInterval entity.Duration `json:"rotation_interval_s"`
+	PublishAhead     entity.Duration `json:"publish_ahead_s"`
+	Retention        entity.Duration `json:"retention_s"`
+	// How often the keys written by other instances are picked up and a rotation is checked for
+	ReloadInterval entity.Duration `json:"reload_interval_s"`
+}
+
+type MfaConfig struct {
+	Key          string          `json:"key"`
+	Issuer       string          `json:"issuer"`
+	ChallengeTtl entity.Duration `json:"challenge_ttl_s"`
+	// Transactions with a greater OTR need a login completed with a second factor, 0 never requires it
+	RequiredAboveOtr float64 `json:"required_above_otr"`
+}
 
-		return config
+type AccountClosureConfig struct {
+	// When the email of a closed account can register again: on_close, on_anonymisation or never
+	EmailReuse string `json:"email_reuse"`
+}
+
+type RetentionPolicyConfig struct {
+	// Records are removed once they were expired, closed or rejected this long
+	RetainFor entity.Duration `json:"retain_s"`
+}
+
+type RetentionConfig struct {
+	Interval entity.Duration `json:"interval_s"`
+	// Refresh tokens are deleted
+	RefreshTokens RetentionPolicyConfig `json:"refresh_tokens"`
+	// Personal data of closed accounts is anonymised
+	ClosedAccounts RetentionPolicyConfig `json:"closed_accounts"`
+	// KYC photos of rejected submissions are deleted
+	RejectedKycPhotos RetentionPolicyConfig `json:"rejected_kyc_photos"`
+	// Outbox events are deleted once published
+	PublishedEvents RetentionPolicyConfig `json:"published_events"`
+	// Webhook deliveries are deleted once delivered, dead ones are kept for redelivery
+	DeliveredWebhooks RetentionPolicyConfig `json:"delivered_webhooks"`
+}
+
+type DataExportConfig struct {
+	// Signs the download links
+	Key string `json:"key"`
+	// Archives are stored apart from the media, so the media sweeper leaves them alone
+	Path string `json:"path"`
+	// Emailed once the archive is ready, with the token appended as ?token=
+	LinkUrl string `json:"link_url"`
+	// Archives can be downloaded this long, then they are deleted
+	Ttl            entity.Duration `json:"ttl_s"`
+	WorkerInterval entity.Duration `json:"worker_interval_s"`
+}
+
+type OutboxWebhookConfig struct {
+	Url string `json:"url"`
+	// Sent as a bearer token when set
+	Token string `json:"token"`
+}
+
+type OutboxKafkaConfig struct {
+	// Base url of a Kafka REST proxy speaking the v2 API
+	RestProxyUrl string `json:"rest_proxy_url"`
+	Topic        string `json:"topic"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+}
+
+type OutboxConfig struct {
+	// Sink the relay publishes the domain events to: stdout, webhook or kafka
+	Driver  string              `json:"driver"`
+	Webhook OutboxWebhookConfig `json:"webhook"`
+	Kafka   OutboxKafkaConfig   `json:"kafka"`
+	// How long the sink has to accept an event
+	Timeout       entity.Duration `json:"timeout_s"`
+	RelayInterval entity.Duration `json:"relay_interval_s"`
+	// Events loaded per query by the relay
+	BatchSize int `json:"batch_size"`
+	// Wait after the first failed attempt of an event, doubled after every further failure up to max_backoff_s
+	BaseBackoff entity.Duration `json:"base_backoff_s"`
+	MaxBackoff  entity.Duration `json:"max_backoff_s"`
+}
+
+type WebhookConfig struct {
+	// Encrypts the secrets signing the deliveries
+	Key string `json:"key"`
+	// How long a partner has to accept a delivery
+	Timeout          entity.Duration `json:"timeout_s"`
+	DispatchInterval entity.Duration `json:"dispatch_interval_s"`
+	// Deliveries loaded per query by the dispatcher
+	BatchSize int `json:"batch_size"`
+	// Wait after the first failed attempt of a delivery, doubled after every further failure up to max_backoff_s
+	BaseBackoff entity.Duration `json:"base_backoff_s"`
+	MaxBackoff  entity.Duration `json:"max_backoff_s"`
+	// A delivery is given up as dead after this many failed attempts
+	MaxAttempts int `json:"max_attempts"`
+}
+
+type HealthConfig struct {
+	// How long readiness fails before the server stops accepting requests on shutdown, so the load balancer takes the instance out of rotation first.
+	// It is part of graceful_period_s and must be less than it
+	DrainDelay entity.Duration `json:"drain_delay_s"`
+}
+
+type AdminConfig struct {
+	// Authorises the admin API as a bearer token, the admin API is disabled while it is empty
+	ApiKey string `json:"api_key"`
+}
+
+type AuditConfig struct {
+	// Keys the HMAC chaining the audit events, events hashed with another key fail verification
+	Key string `json:"key"`
+}
+
+type ServiceConfig struct {
+	Port              string                  `json:"port"`
+	GracefulPeriod    entity.Duration         `json:"graceful_period_s"`
+	ContextTimeout    entity.Duration         `json:"context_timeout_s"`
+	AllowedOrigins    []string                `json:"allowed_origins"`
+	TrustedProxies    []string                `json:"trusted_proxies"`
+	Database          string                  `json:"database"`
+	MySQL             entity.DBConfig         `json:"mysql"`
+	Postgres          entity.DBConfig         `json:"postgres"`
+	SQLite            SQLiteConfig            `json:"sqlite"`
+	Jwt               JwtConfig               `json:"jwt"`
+	Hash              helper.HashConfig       `json:"hash"`
+	LocalMediaStorage LocalStorageConfig      `json:"local_media_storage"`
+	MediaVariant      MediaVariantConfig      `json:"media_variant"`
+	MediaSweeper      MediaSweeperConfig      `json:"media_sweeper"`
+	IsEnableMigration bool                    `json:"is_enable_migration"`
+	IsEnableSeeding   bool                    `json:"is_enable_seeding"`
+	Seeding           SeedingConfig           `json:"seeding"`
+	Tracing           TracingConfig           `json:"tracing"`
+	LoginProtection   LoginProtectionConfig   `json:"login_protection"`
+	RateLimit         RateLimitConfig         `json:"rate_limit"`
+	Notifier          NotifierConfig          `json:"notifier"`
+	EmailVerification EmailVerificationConfig `json:"email_verification"`
+	PasswordReset     PasswordResetConfig     `json:"password_reset"`
+	Mfa               MfaConfig               `json:"mfa"`
+	AccountClosure    AccountClosureConfig    `json:"account_closure"`
+	DataExport        DataExportConfig        `json:"data_export"`
+	Retention         RetentionConfig         `json:"retention"`
+	Outbox            OutboxConfig            `json:"outbox"`
+	Webhook           WebhookConfig           `json:"webhook"`
+	Health            HealthConfig            `json:"health"`
+	Admin             AdminConfig             `json:"admin"`
+	Audit             AuditConfig             `json:"audit"`
+}
+
+// Default is the first layer of the config, every later layer overrides it
+func Default() ServiceConfig {
+	return ServiceConfig{
+		Port:           ":8080",
+		GracefulPeriod: entity.Duration(30 * time.Second),
+		ContextTimeout: entity.Duration(2 * time.Minute),
+		Database:       "mysql",
+		MySQL: entity.DBConfig{
+			Port: "3306",
+		},
+		Postgres: entity.DBConfig{
+			Port: "5432",
+		},
+		Jwt: JwtConfig{
+			Algorithm:        "HS512",
+			KeysDir:          "/app/keys/",
+			RotationInterval: entity.Duration(30 * 24 * time.Hour),
+			PublishAhead:     entity.Duration(time.Hour),
+			Retention:        entity.Duration(48 * time.Hour),
+			ReloadInterval:   entity.Duration(time.Minute),
+		},
+		Hash: helper.HashConfig{
+			HashCost: 12,
+		},
+		LocalMediaStorage: LocalStorageConfig{
+			Path: "/app/assets/",
+		},
+		MediaVariant: MediaVariantConfig{
+			ThumbnailSize: 160,
+			PreviewSize:   640,
+		},
+		MediaSweeper: MediaSweeperConfig{
+			IsEnabled:   true,
+			Interval:    entity.Duration(time.Hour),
+			GracePeriod: entity.Duration(time.Hour),
+		},
+		IsEnableMigration: true,
+		Seeding: SeedingConfig{
+			Environment: "development",
+		},
+		LoginProtection: LoginProtectionConfig{
+			AccountMaxAttempts: 10,
+			IpMaxAttempts:      50,
+			DelayAfterAttempts: 3,
+			BaseDelay:          entity.Duration(time.Second),
+			MaxDelay:           entity.Duration(time.Minute),
+			Lockout:            entity.Duration(15 * time.Minute),
+			Window:             entity.Duration(15 * time.Minute),
+		},
+		Tracing: TracingConfig{
+			ServiceName: "xyz-kredit-plus",
+			Protocol:    "grpc",
+			SampleRatio: 1,
+		},
+		Notifier: NotifierConfig{
+			Driver: "log",
+			From:   "XYZ Kredit Plus <no-reply@kredit-plus.xyz>",
+			Smtp: SmtpConfig{
+				Port:    "587",
+				Timeout: entity.Duration(10 * time.Second),
+			},
+		},
+		EmailVerification: EmailVerificationConfig{
+			TokenTtl:       entity.Duration(24 * time.Hour),
+			ResendCooldown: entity.Duration(time.Minute),
+		},
+		PasswordReset: PasswordResetConfig{
+			TokenTtl:       entity.Duration(time.Hour),
+			ResendCooldown: entity.Duration(time.Minute),
+		},
+		Mfa: MfaConfig{
+			Issuer:           "XYZ Kredit Plus",
+			ChallengeTtl:     entity.Duration(5 * time.Minute),
+			RequiredAboveOtr: 10000000,
+		},
+		AccountClosure: AccountClosureConfig{
+			EmailReuse: appconstant.EmailReuseOnAnonymisation,
+		},
+		DataExport: DataExportConfig{
+			Path:           "/app/exports/",
+			Ttl:            entity.Duration(7 * 24 * time.Hour),
+			WorkerInterval: entity.Duration(30 * time.Second),
+		},
+		Retention: RetentionConfig{
+			Interval: entity.Duration(time.Hour),
+			RefreshTokens: RetentionPolicyConfig{
+				RetainFor: entity.Duration(30 * 24 * time.Hour),
+			},
+			ClosedAccounts: RetentionPolicyConfig{
+				RetainFor: entity.Duration(90 * 24 * time.Hour),
+			},
+			RejectedKycPhotos: RetentionPolicyConfig{
+				RetainFor: entity.Duration(30 * 24 * time.Hour),
+			},
+			PublishedEvents: RetentionPolicyConfig{
+				RetainFor: entity.Duration(7 * 24 * time.Hour),
+			},
+			DeliveredWebhooks: RetentionPolicyConfig{
+				RetainFor: entity.Duration(30 * 24 * time.Hour),
+			},
+		},
+		Outbox: OutboxConfig{
+			Driver:        "stdout",
+			Timeout:       entity.Duration(10 * time.Second),
+			RelayInterval: entity.Duration(5 * time.Second),
+			BatchSize:     100,
+			BaseBackoff:   entity.Duration(5 * time.Second),
+			MaxBackoff:    entity.Duration(time.Hour),
+		},
+		Webhook: WebhookConfig{
+			Timeout:          entity.Duration(10 * time.Second),
+			DispatchInterval: entity.Duration(5 * time.Second),
+			BatchSize:        100,
+			BaseBackoff:      entity.Duration(30 * time.Second),
+			MaxBackoff:       entity.Duration(6 * time.Hour),
+			MaxAttempts:      10,
+		},
+		Health: HealthConfig{
+			DrainDelay: entity.Duration(5 * time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			IsEnabled: true,
+			Store:     "memory",
+			Account: RateLimitRuleConfig{
+				Rate:  0.5,
+				Burst: 10,
+				KeyBy: "ip",
+			},
+			Consumer: RateLimitRuleConfig{
+				Rate:  1,
+				Burst: 20,
+				KeyBy: "account_id",
+			},
+			Transaction: RateLimitRuleConfig{
+				Rate:  0.5,
+				Burst: 10,
+				KeyBy: "account_id",
+			},
+		},
 	}
+}
 
-	err = json.Unmarshal(configData, &config)
+// Init loads and validates the config, the process exits when it is invalid
+func Init(log *logrus.Logger, opt LoadOpt) ServiceConfig {
+	config, err := Load(opt)
 	if err != nil {
 		log.WithFields(logrus.Fields{
-			"error": fmt.Sprintf("[config][Init][Unmarshal] error: %s", err.Error()),
-		}).Fatal("error initiating config file")
-
-		return config
+			"error": fmt.Sprintf("[config][Init][Load] error: %s", err.Error()),
+		}).Fatal("error initiating config")
 	}
 
 	return config
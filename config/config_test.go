@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(file, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return file
+}
+
+const validConfig = `{
+	"database": "sqlite",
+	"sqlite": {"path": "/tmp/kredit_plus_xyz.db"},
+	"jwt": {"issuer": "kredit-plus-xyz", "key": "from-file"},
+	"email_verification": {"key": "from-file", "link_url": "http://localhost:5173/verify-email"},
+	"password_reset": {"key": "from-file", "link_url": "http://localhost:5173/reset-password"},
+	"mfa": {"key": "from-file"},
+	"data_export": {"key": "from-file", "link_url": "http://localhost:5173/data-export"},
+	"webhook": {"key": "from-file"},
+	"audit": {"key": "from-file"}
+}`
+
+func TestLoad_Layers(t *testing.T) {
+	t.Setenv(fileEnv, "")
+	t.Setenv(envPrefix+"JWT_ISSUER", "from-env")
+	t.Setenv(envPrefix+"MEDIA_SWEEPER_INTERVAL_S", "5m")
+	t.Setenv(envPrefix+"ALLOWED_ORIGINS", "http://a.example, http://b.example")
+
+	config, err := Load(LoadOpt{
+		File: writeFile(t, "config.json", validConfig),
+		Sets: []string{"jwt.issuer=from-flag", "hash.hash_cost=10"},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if config.Jwt.Key != "from-file" {
+		t.Errorf("jwt.key = %q, want value from file", config.Jwt.Key)
+	}
+
+	if config.Jwt.Issuer != "from-flag" {
+		t.Errorf("jwt.issuer = %q, want flag to override env", config.Jwt.Issuer)
+	}
+
+	if time.Duration(config.MediaSweeper.Interval) != 5*time.Minute {
+		t.Errorf("media_sweeper.interval_s = %v, want 5m", time.Duration(config.MediaSweeper.Interval))
+	}
+
+	if len(config.AllowedOrigins) != 2 || config.AllowedOrigins[1] != "http://b.example" {
+		t.Errorf("allowed_origins = %v", config.AllowedOrigins)
+	}
+
+	if config.Hash.HashCost != 10 {
+		t.Errorf("hash.hash_cost = %v, want 10", config.Hash.HashCost)
+	}
+
+	if config.MediaVariant.PreviewSize != 640 {
+		t.Errorf("media_variant.preview_px = %v, want default 640", config.MediaVariant.PreviewSize)
+	}
+}
+
+func TestLoad_SecretFile(t *testing.T) {
+	t.Setenv(fileEnv, "")
+
+	secret := writeFile(t, "jwt_key", "from-secret\n")
+
+	config, err := Load(LoadOpt{
+		File: writeFile(t, "config.json", validConfig),
+		Sets: []string{"jwt.key=file:" + secret},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if config.Jwt.Key != "from-secret" {
+		t.Errorf("jwt.key = %q, want content of the secret file", config.Jwt.Key)
+	}
+
+	t.Setenv(envPrefix+"JWT_KEY"+secretFileEnvSuffix, writeFile(t, "jwt_key_env", "from-env-secret"))
+
+	config, err = Load(LoadOpt{File: writeFile(t, "config.json", validConfig)})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if config.Jwt.Key != "from-env-secret" {
+		t.Errorf("jwt.key = %q, want content of the _FILE env", config.Jwt.Key)
+	}
+}
+
+func TestLoad_UnknownKey(t *testing.T) {
+	t.Setenv(fileEnv, "")
+
+	_, err := Load(LoadOpt{File: writeFile(t, "config.json", `{"graceful_perion_s": "30s"}`)})
+	if err == nil || !strings.Contains(err.Error(), "graceful_perion_s") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+
+	_, err = Load(LoadOpt{File: writeFile(t, "config.json", validConfig), Sets: []string{"jwt.unknown=x"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown config key") {
+		t.Fatalf("err = %v, want unknown config key error", err)
+	}
+}
+
+func TestValidate_AggregatesErrors(t *testing.T) {
+	config := Default()
+	config.Port = "8080"
+	config.ContextTimeout = 0
+	config.Hash.HashCost = 2
+	config.LocalMediaStorage.Path = "/app/assets"
+	config.AccountClosure.EmailReuse = "always"
+	config.Retention.RefreshTokens.RetainFor = -1
+	config.Outbox.Driver = "kafka"
+	config.Admin.ApiKey = "short"
+	config.TrustedProxies = []string{"10.0.0.0/8", "proxy.internal"}
+	config.Health.DrainDelay = config.GracefulPeriod
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate: want error")
+	}
+
+	for _, key := range []string{
+		"port",
+		"context_timeout_s",
+		"mysql.host",
+		"mysql.db_name",
+		"jwt.issuer",
+		"jwt.key",
+		"hash.hash_cost",
+		"local_media_storage.path",
+		"account_closure.email_reuse",
+		"retention.refresh_tokens.retain_s",
+		"outbox.kafka.rest_proxy_url",
+		"outbox.kafka.topic",
+		"admin.api_key",
+		"webhook.key",
+		"audit.key",
+		"trusted_proxies",
+		"health.drain_delay_s",
+	} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("error does not mention %s:\n%s", key, err.Error())
+		}
+	}
+}
+
+func TestValidate_AsymmetricJwt(t *testing.T) {
+	t.Setenv(fileEnv, "")
+
+	_, err := Load(LoadOpt{
+		File: writeFile(t, "config.json", validConfig),
+		Sets: []string{"jwt.algorithm=EdDSA", "jwt.key=", "jwt.publish_ahead_s=1m"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "jwt.publish_ahead_s:") || strings.Contains(err.Error(), "jwt.key:") {
+		t.Fatalf("err = %v, want only jwt.publish_ahead_s invalid", err)
+	}
+
+	_, err = Load(LoadOpt{
+		File: writeFile(t, "config.json", validConfig),
+		Sets: []string{"jwt.algorithm=RS512"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "jwt.algorithm:") {
+		t.Fatalf("err = %v, want jwt.algorithm invalid", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	config := Default()
+	config.Jwt.Key = "secret"
+	config.MySQL.Password = "secret"
+
+	redacted := config.Redacted()
+
+	if redacted.Jwt.Key == "secret" || redacted.MySQL.Password == "secret" {
+		t.Errorf("secrets are not redacted: %+v", redacted)
+	}
+
+	if config.Jwt.Key != "secret" {
+		t.Errorf("Redacted modified the original config")
+	}
+}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/entity"
+)
+
+const (
+	// Env holding the path of the config file
+	fileEnv = "KREDIT_PLUS_USERS_SERVICE_CONFIG"
+	// Prefix of the env overriding a single value, e.g. KREDIT_PLUS_USERS_SERVICE_MYSQL_PASSWORD
+	envPrefix = "KREDIT_PLUS_USERS_SERVICE_"
+	// Secrets given as file:/path are read from the file, and from the file in {env}_FILE for env overrides
+	secretFilePrefix    = "file:"
+	secretFileEnvSuffix = "_FILE"
+)
+
+// Values read from files when given as a secret reference, by their dotted path
+var secretKeys = []string{
+	"mysql.password",
+	"postgres.password",
+	"jwt.key",
+	"rate_limit.redis.password",
+	"notifier.smtp.password",
+	"email_verification.key",
+	"password_reset.key",
+	"mfa.key",
+	"data_export.key",
+	"webhook.key",
+	"outbox.webhook.token",
+	"outbox.kafka.password",
+	"admin.api_key",
+	"audit.key",
+}
+
+type LoadOpt struct {
+	// File overrides the path given in KREDIT_PLUS_USERS_SERVICE_CONFIG, the file is optional when neither is given
+	File string
+	// Overrides applied last as key=value, keys are dotted paths e.g. mysql.password=secret
+	Sets []string
+}
+
+// Load builds the config from layers: defaults, the config file, env overrides, then flag overrides.
+// Secret references are resolved afterwards, and the result is validated with every problem reported at once.
+func Load(opt LoadOpt) (ServiceConfig, error) {
+	config := Default()
+
+	file := opt.File
+	if file == "" {
+		file = os.Getenv(fileEnv)
+	}
+
+	if file != "" {
+		err := loadFile(&config, file)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	var errs []error
+
+	errs = append(errs, applyEnv(&config)...)
+
+	for _, set := range opt.Sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: override must be key=value", set))
+			continue
+		}
+
+		err := Set(&config, key, value)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, resolveSecrets(&config)...)
+
+	if len(errs) > 0 {
+		return config, errors.Join(errs...)
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Unknown keys are rejected, so a misspelled key is not silently ignored
+func loadFile(config *ServiceConfig, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("[config][loadFile][os.ReadFile] error: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(config)
+	if err != nil {
+		return fmt.Errorf("[config][loadFile][Decode] error: %w | file: %s", err, file)
+	}
+
+	return nil
+}
+
+// walk calls fn for every value of the config with its dotted path
+func walk(v reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Struct {
+			walk(value, key+".", fn)
+			continue
+		}
+
+		fn(key, value)
+	}
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func applyEnv(config *ServiceConfig) []error {
+	var errs []error
+
+	walk(reflect.ValueOf(config).Elem(), "", func(key string, value reflect.Value) {
+		raw, ok := os.LookupEnv(envName(key))
+		if !ok {
+			return
+		}
+
+		err := setValue(value, raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", envName(key), err))
+		}
+	})
+
+	return errs
+}
+
+// Set overrides a single value by its dotted path, e.g. jwt.key
+func Set(config *ServiceConfig, key, raw string) error {
+	var isFound bool
+	var err error
+
+	walk(reflect.ValueOf(config).Elem(), "", func(k string, value reflect.Value) {
+		if k != key {
+			return
+		}
+
+		isFound = true
+		err = setValue(value, raw)
+	})
+
+	if !isFound {
+		return fmt.Errorf("%s: unknown config key", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return nil
+}
+
+func setValue(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(entity.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+
+		value.SetBool(b)
+
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+
+		value.SetInt(n)
+
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+
+		value.SetFloat(f)
+
+	case reflect.Slice:
+		var items []string
+
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		value.Set(reflect.ValueOf(items))
+
+	default:
+		return fmt.Errorf("unsupported config type %s", value.Type())
+	}
+
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Secrets are read from {env}_FILE when it is set, otherwise from the file of a file:/path value
+func resolveSecrets(config *ServiceConfig) []error {
+	var errs []error
+
+	walk(reflect.ValueOf(config).Elem(), "", func(key string, value reflect.Value) {
+		if !isSecret(key) {
+			return
+		}
+
+		if file, ok := os.LookupEnv(envName(key) + secretFileEnvSuffix); ok {
+			secret, err := readSecretFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", envName(key)+secretFileEnvSuffix, err))
+				return
+			}
+
+			value.SetString(secret)
+			return
+		}
+
+		if file, ok := strings.CutPrefix(value.String(), secretFilePrefix); ok {
+			secret, err := readSecretFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+
+			value.SetString(secret)
+		}
+	})
+
+	return errs
+}
+
+func isSecret(key string) bool {
+	for _, k := range secretKeys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redacted returns a copy of the config safe to print, secrets are masked
+func (c ServiceConfig) Redacted() ServiceConfig {
+	walk(reflect.ValueOf(&c).Elem(), "", func(key string, value reflect.Value) {
+		if isSecret(key) && value.String() != "" {
+			value.SetString("******")
+		}
+	})
+
+	return c
+}
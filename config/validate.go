@@ -0,0 +1,452 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Validate reports every invalid value at once instead of stopping at the first one
+func (c ServiceConfig) Validate() error {
+	var errs []error
+
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Port); err != nil {
+		invalid("port", "must be host:port or :port, got %q", c.Port)
+	}
+
+	if c.GracefulPeriod <= 0 {
+		invalid("graceful_period_s", "must be greater than 0")
+	}
+
+	if c.ContextTimeout <= 0 {
+		invalid("context_timeout_s", "must be greater than 0")
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("allowed_origins", "%q must be * or scheme://host", origin)
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			invalid("trusted_proxies", "%q must be an IP or a CIDR", proxy)
+		}
+	}
+
+	switch c.Database {
+	case "mysql":
+		validateDB(c.MySQL.Host, c.MySQL.Port, c.MySQL.DbName, c.MySQL.Username, "mysql", invalid)
+
+	case "postgres":
+		validateDB(c.Postgres.Host, c.Postgres.Port, c.Postgres.DbName, c.Postgres.Username, "postgres", invalid)
+
+	case "sqlite":
+		if c.SQLite.Path == "" {
+			invalid("sqlite.path", "is required")
+		}
+
+	default:
+		invalid("database", "must be one of mysql, postgres, sqlite, got %q", c.Database)
+	}
+
+	if c.Jwt.Issuer == "" {
+		invalid("jwt.issuer", "is required")
+	}
+
+	switch c.Jwt.Algorithm {
+	case "HS512":
+		if c.Jwt.Key == "" {
+			invalid("jwt.key", "is required for HS512")
+		}
+
+	case "RS256", "EdDSA":
+		if c.Jwt.KeysDir == "" {
+			invalid("jwt.keys_dir", "is required for %s", c.Jwt.Algorithm)
+		}
+
+		if c.Jwt.RotationInterval < 0 {
+			invalid("jwt.rotation_interval_s", "must not be negative")
+		} else if c.Jwt.RotationInterval > 0 && c.Jwt.RotationInterval <= c.Jwt.PublishAhead {
+			invalid("jwt.rotation_interval_s", "must be greater than jwt.publish_ahead_s")
+		}
+
+		// Verifiers cache the jwks, a new key must be in their cache before it signs
+		if jwksMaxAge := appconstant.JwksMaxAgeSeconds * time.Second; time.Duration(c.Jwt.PublishAhead) < jwksMaxAge {
+			invalid("jwt.publish_ahead_s", "must be at least %s", jwksMaxAge)
+		}
+
+		if time.Duration(c.Jwt.Retention) < 24*time.Hour {
+			invalid("jwt.retention_s", "must be at least 24h, the lifetime of a refresh token")
+		}
+
+		if c.Jwt.ReloadInterval <= 0 {
+			invalid("jwt.reload_interval_s", "must be greater than 0")
+		}
+
+	default:
+		invalid("jwt.algorithm", "must be one of HS512, RS256, EdDSA, got %q", c.Jwt.Algorithm)
+	}
+
+	if c.Hash.HashCost < bcrypt.MinCost || c.Hash.HashCost > bcrypt.MaxCost {
+		invalid("hash.hash_cost", "must be between %v and %v", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if c.LocalMediaStorage.Path == "" {
+		invalid("local_media_storage.path", "is required")
+	} else if !strings.HasSuffix(c.LocalMediaStorage.Path, "/") {
+		invalid("local_media_storage.path", "must end with /")
+	}
+
+	if c.MediaVariant.ThumbnailSize <= 0 {
+		invalid("media_variant.thumbnail_px", "must be greater than 0")
+	}
+
+	if c.MediaVariant.PreviewSize <= 0 {
+		invalid("media_variant.preview_px", "must be greater than 0")
+	}
+
+	if c.MediaSweeper.IsEnabled && c.MediaSweeper.Interval <= 0 {
+		invalid("media_sweeper.interval_s", "must be greater than 0 when the sweeper is enabled")
+	}
+
+	if c.MediaSweeper.GracePeriod < 0 {
+		invalid("media_sweeper.grace_period_s", "must not be negative")
+	}
+
+	if c.IsEnableSeeding && c.Seeding.Environment == "" && c.Seeding.Dir == "" {
+		invalid("seeding", "environment or dir is required when seeding is enabled")
+	}
+
+	if c.LoginProtection.AccountMaxAttempts <= 0 {
+		invalid("login_protection.account_max_attempts", "must be greater than 0")
+	}
+
+	if c.LoginProtection.IpMaxAttempts <= 0 {
+		invalid("login_protection.ip_max_attempts", "must be greater than 0")
+	}
+
+	if c.LoginProtection.DelayAfterAttempts <= 0 || c.LoginProtection.DelayAfterAttempts > c.LoginProtection.AccountMaxAttempts {
+		invalid("login_protection.delay_after_attempts", "must be between 1 and account_max_attempts")
+	}
+
+	if c.LoginProtection.BaseDelay <= 0 || c.LoginProtection.MaxDelay < c.LoginProtection.BaseDelay {
+		invalid("login_protection.max_delay_s", "must not be less than base_delay_s, which must be greater than 0")
+	}
+
+	if c.LoginProtection.Lockout <= 0 {
+		invalid("login_protection.lockout_s", "must be greater than 0")
+	}
+
+	if c.LoginProtection.Window <= 0 {
+		invalid("login_protection.window_s", "must be greater than 0")
+	}
+
+	if c.Tracing.IsEnabled {
+		if c.Tracing.ServiceName == "" {
+			invalid("tracing.service_name", "is required when tracing is enabled")
+		}
+
+		if c.Tracing.Protocol != "grpc" && c.Tracing.Protocol != "http" {
+			invalid("tracing.protocol", "must be grpc or http, got %q", c.Tracing.Protocol)
+		}
+
+		if c.Tracing.Endpoint == "" {
+			invalid("tracing.endpoint", "is required when tracing is enabled")
+		}
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	if c.RateLimit.IsEnabled {
+		switch c.RateLimit.Store {
+		case "memory":
+		case "redis":
+			if c.RateLimit.Redis.Addr == "" {
+				invalid("rate_limit.redis.addr", "is required when the store is redis")
+			}
+
+		default:
+			invalid("rate_limit.store", "must be memory or redis, got %q", c.RateLimit.Store)
+		}
+
+		validateRateLimitRule(c.RateLimit.Account, "rate_limit.account", invalid)
+		validateRateLimitRule(c.RateLimit.Consumer, "rate_limit.consumer", invalid)
+		validateRateLimitRule(c.RateLimit.Transaction, "rate_limit.transaction", invalid)
+
+		// account routes are not authenticated, there is no account_id to key on
+		if c.RateLimit.Account.KeyBy == "account_id" {
+			invalid("rate_limit.account.key_by", "must be ip, the account routes are not authenticated")
+		}
+	}
+
+	switch c.Notifier.Driver {
+	case "log":
+	case "file":
+		if c.Notifier.File.Path == "" {
+			invalid("notifier.file.path", "is required when the driver is file")
+		}
+
+	case "smtp":
+		if c.Notifier.Smtp.Host == "" {
+			invalid("notifier.smtp.host", "is required when the driver is smtp")
+		}
+
+		if c.Notifier.Smtp.Port == "" {
+			invalid("notifier.smtp.port", "is required when the driver is smtp")
+		}
+
+		if c.Notifier.Smtp.Timeout <= 0 {
+			invalid("notifier.smtp.timeout_s", "must be greater than 0")
+		}
+
+	default:
+		invalid("notifier.driver", "must be one of log, file, smtp, got %q", c.Notifier.Driver)
+	}
+
+	if _, err := mail.ParseAddress(c.Notifier.From); err != nil {
+		invalid("notifier.from", "must be an email address, got %q", c.Notifier.From)
+	}
+
+	if c.EmailVerification.Key == "" {
+		invalid("email_verification.key", "is required")
+	}
+
+	if c.EmailVerification.TokenTtl <= 0 {
+		invalid("email_verification.token_ttl_s", "must be greater than 0")
+	}
+
+	if c.EmailVerification.ResendCooldown < 0 {
+		invalid("email_verification.resend_cooldown_s", "must not be negative")
+	}
+
+	if u, err := url.Parse(c.EmailVerification.LinkUrl); err != nil || u.Scheme == "" || u.Host == "" {
+		invalid("email_verification.link_url", "must be scheme://host/path, got %q", c.EmailVerification.LinkUrl)
+	}
+
+	if c.PasswordReset.Key == "" {
+		invalid("password_reset.key", "is required")
+	}
+
+	if c.PasswordReset.TokenTtl <= 0 {
+		invalid("password_reset.token_ttl_s", "must be greater than 0")
+	}
+
+	if c.PasswordReset.ResendCooldown < 0 {
+		invalid("password_reset.resend_cooldown_s", "must not be negative")
+	}
+
+	if u, err := url.Parse(c.PasswordReset.LinkUrl); err != nil || u.Scheme == "" || u.Host == "" {
+		invalid("password_reset.link_url", "must be scheme://host/path, got %q", c.PasswordReset.LinkUrl)
+	}
+
+	if c.Mfa.Key == "" {
+		invalid("mfa.key", "is required")
+	}
+
+	if c.Mfa.Issuer == "" || strings.Contains(c.Mfa.Issuer, ":") {
+		invalid("mfa.issuer", "must be set and must not contain ':', got %q", c.Mfa.Issuer)
+	}
+
+	if c.Mfa.ChallengeTtl <= 0 {
+		invalid("mfa.challenge_ttl_s", "must be greater than 0")
+	}
+
+	if c.Mfa.RequiredAboveOtr < 0 {
+		invalid("mfa.required_above_otr", "must not be negative")
+	}
+
+	switch c.AccountClosure.EmailReuse {
+	case appconstant.EmailReuseOnClose, appconstant.EmailReuseOnAnonymisation, appconstant.EmailReuseNever:
+	default:
+		invalid("account_closure.email_reuse", "must be one of on_close, on_anonymisation, never, got %q", c.AccountClosure.EmailReuse)
+	}
+
+	if c.Retention.Interval <= 0 {
+		invalid("retention.interval_s", "must be greater than 0")
+	}
+
+	if c.Retention.RefreshTokens.RetainFor < 0 {
+		invalid("retention.refresh_tokens.retain_s", "must not be negative")
+	}
+
+	if c.Retention.ClosedAccounts.RetainFor < 0 {
+		invalid("retention.closed_accounts.retain_s", "must not be negative")
+	}
+
+	if c.Retention.RejectedKycPhotos.RetainFor < 0 {
+		invalid("retention.rejected_kyc_photos.retain_s", "must not be negative")
+	}
+
+	if c.Retention.PublishedEvents.RetainFor < 0 {
+		invalid("retention.published_events.retain_s", "must not be negative")
+	}
+
+	if c.Retention.DeliveredWebhooks.RetainFor < 0 {
+		invalid("retention.delivered_webhooks.retain_s", "must not be negative")
+	}
+
+	if c.DataExport.Key == "" {
+		invalid("data_export.key", "is required")
+	}
+
+	if c.DataExport.Path == "" {
+		invalid("data_export.path", "is required")
+	} else if !strings.HasSuffix(c.DataExport.Path, "/") {
+		invalid("data_export.path", "must end with /")
+	} else if c.DataExport.Path == c.LocalMediaStorage.Path {
+		invalid("data_export.path", "must differ from local_media_storage.path, the media sweeper would delete the archives")
+	}
+
+	if u, err := url.Parse(c.DataExport.LinkUrl); err != nil || u.Scheme == "" || u.Host == "" {
+		invalid("data_export.link_url", "must be scheme://host/path, got %q", c.DataExport.LinkUrl)
+	}
+
+	if c.DataExport.Ttl <= 0 {
+		invalid("data_export.ttl_s", "must be greater than 0")
+	}
+
+	if c.DataExport.WorkerInterval <= 0 {
+		invalid("data_export.worker_interval_s", "must be greater than 0")
+	}
+
+	switch c.Outbox.Driver {
+	case "stdout":
+	case "webhook":
+		if u, err := url.Parse(c.Outbox.Webhook.Url); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("outbox.webhook.url", "must be scheme://host/path when the driver is webhook, got %q", c.Outbox.Webhook.Url)
+		}
+
+	case "kafka":
+		if u, err := url.Parse(c.Outbox.Kafka.RestProxyUrl); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("outbox.kafka.rest_proxy_url", "must be scheme://host when the driver is kafka, got %q", c.Outbox.Kafka.RestProxyUrl)
+		}
+
+		if c.Outbox.Kafka.Topic == "" {
+			invalid("outbox.kafka.topic", "is required when the driver is kafka")
+		}
+
+	default:
+		invalid("outbox.driver", "must be one of stdout, webhook, kafka, got %q", c.Outbox.Driver)
+	}
+
+	if c.Outbox.Timeout <= 0 {
+		invalid("outbox.timeout_s", "must be greater than 0")
+	}
+
+	if c.Outbox.RelayInterval <= 0 {
+		invalid("outbox.relay_interval_s", "must be greater than 0")
+	}
+
+	if c.Outbox.BatchSize <= 0 {
+		invalid("outbox.batch_size", "must be greater than 0")
+	}
+
+	if c.Outbox.BaseBackoff <= 0 {
+		invalid("outbox.base_backoff_s", "must be greater than 0")
+	}
+
+	if c.Outbox.MaxBackoff < c.Outbox.BaseBackoff {
+		invalid("outbox.max_backoff_s", "must not be less than outbox.base_backoff_s")
+	}
+
+	if c.Webhook.Key == "" {
+		invalid("webhook.key", "is required")
+	}
+
+	if c.Webhook.Timeout <= 0 {
+		invalid("webhook.timeout_s", "must be greater than 0")
+	}
+
+	if c.Webhook.DispatchInterval <= 0 {
+		invalid("webhook.dispatch_interval_s", "must be greater than 0")
+	}
+
+	if c.Webhook.BatchSize <= 0 {
+		invalid("webhook.batch_size", "must be greater than 0")
+	}
+
+	if c.Webhook.BaseBackoff <= 0 {
+		invalid("webhook.base_backoff_s", "must be greater than 0")
+	}
+
+	if c.Webhook.MaxBackoff < c.Webhook.BaseBackoff {
+		invalid("webhook.max_backoff_s", "must not be less than webhook.base_backoff_s")
+	}
+
+	if c.Webhook.MaxAttempts <= 0 {
+		invalid("webhook.max_attempts", "must be greater than 0")
+	}
+
+	if c.Health.DrainDelay < 0 {
+		invalid("health.drain_delay_s", "must not be negative")
+	}
+
+	if c.Health.DrainDelay >= c.GracefulPeriod {
+		invalid("health.drain_delay_s", "must be less than graceful_period_s")
+	}
+
+	if c.Admin.ApiKey != "" && len(c.Admin.ApiKey) < 32 {
+		invalid("admin.api_key", "must be at least 32 characters")
+	}
+
+	if c.Audit.Key == "" {
+		invalid("audit.key", "is required")
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateRateLimitRule(rule RateLimitRuleConfig, prefix string, invalid func(key, format string, args ...any)) {
+	if rule.Rate <= 0 {
+		invalid(prefix+".rate_per_s", "must be greater than 0")
+	}
+
+	if rule.Burst <= 0 {
+		invalid(prefix+".burst", "must be greater than 0")
+	}
+
+	if rule.KeyBy != "ip" && rule.KeyBy != "account_id" {
+		invalid(prefix+".key_by", "must be ip or account_id, got %q", rule.KeyBy)
+	}
+}
+
+func validateDB(host, port, dbName, username, prefix string, invalid func(key, format string, args ...any)) {
+	if host == "" {
+		invalid(prefix+".host", "is required")
+	}
+
+	if port == "" {
+		invalid(prefix+".port", "is required")
+	}
+
+	if dbName == "" {
+		invalid(prefix+".db_name", "is required")
+	}
+
+	if username == "" {
+		invalid(prefix+".username", "is required")
+	}
+}
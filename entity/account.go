@@ -1,15 +1,57 @@
 package entity
 
 type Account struct {
-	Id        int64  `json:"-"`
-	Email     string `json:"email" example:"user@example.com" binding:"required,email"`
-	Password  string `json:"password" binding:"required"`
-	CreatedAt int64  `json:"created_at"`
-	UpdatedAt int64  `json:"updated_at"`
-	DeletedAt *int64  `json:"-"`
+	Id         int64  `json:"-"`
+	Email      string `json:"email" example:"user@example.com" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DisabledAt *int64 `json:"-"`
+	VerifiedAt *int64 `json:"-"`
+	// When the latest verification email was sent, only its token can verify the email
+	VerificationSentAt *int64 `json:"-"`
+	// When the latest password reset email was sent, cleared once the reset is used
+	PasswordResetSentAt *int64 `json:"-"`
+	// Hash of the email of a closed account, kept while the email is not free for reuse
+	EmailHash *string `json:"-"`
+	// When the personal data of the closed account was anonymised
+	AnonymisedAt *int64 `json:"-"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+	// When the account was closed
+	DeletedAt *int64 `json:"-"`
 }
 
 type LoginRegisterReq struct {
-	Email     string `json:"email" example:"user@example.com" binding:"required,email"`
-	Password  string `json:"password" example:"@abcD1234" binding:"required"`
-}
\ No newline at end of file
+	Email    string `json:"email" example:"user@example.com" binding:"required,email"`
+	Password string `json:"password" example:"@abcD1234" binding:"required"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" example:"user@example.com" binding:"required,email"`
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" example:"@abcD1234" binding:"required"`
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" example:"@abcD1234" binding:"required"`
+}
+
+type CloseAccountReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type AccountClosure struct {
+	// When the personal data of the account will be anonymised
+	AnonymiseAt int64 `json:"anonymise_at"`
+}
+
+type AnonymiseResult struct {
+	Anonymised int `json:"anonymised"`
+}
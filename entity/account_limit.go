@@ -11,3 +11,12 @@ type AccountLimit struct {
 	UpdatedAt int64   `json:"updated_at"`
 	DeletedAt *int64  `json:"-"`
 }
+
+// SetAccountLimitReq overrides the limits given, the others keep their current value
+type SetAccountLimitReq struct {
+	AccountId int64
+	Limit1M   *float64
+	Limit2M   *float64
+	Limit3M   *float64
+	Limit4M   *float64
+}
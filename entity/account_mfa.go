@@ -0,0 +1,43 @@
+package entity
+
+type AccountMfa struct {
+	AccountId int64
+	// TOTP secret, encrypted
+	Secret string
+	// Nil until the first code is confirmed, two-factor authentication is only enabled once confirmed
+	ConfirmedAt *int64
+	// Latest TOTP time step used, a code is not accepted twice
+	LastUsedStep int64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+type MfaEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri" example:"otpauth://totp/XYZ%20Kredit%20Plus:user@example.com?algorithm=SHA1&digits=6&issuer=XYZ%20Kredit%20Plus&period=30&secret=JBSWY3DPEHPK3PXP"`
+}
+
+type MfaRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MfaCodeReq struct {
+	Code string `json:"code" example:"123456" binding:"required"`
+}
+
+type MfaLoginReq struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// A TOTP code or a recovery code
+	Code string `json:"code" example:"123456" binding:"required"`
+}
+
+type MfaChallenge struct {
+	Token     string `json:"token"`
+	ExpiredAt int64  `json:"expired_at"`
+}
+
+// LoginResult carries the tokens, or the challenge to complete with a second factor when two-factor authentication is enabled
+type LoginResult struct {
+	*TokenData
+	MfaChallenge *MfaChallenge `json:"mfa_challenge,omitempty"`
+}
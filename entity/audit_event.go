@@ -0,0 +1,62 @@
+package entity
+
+import "encoding/json"
+
+// AuditEvent records who changed what. Events are only ever appended, each one carries the hash of the previous event
+// so a modified or deleted event breaks the chain.
+type AuditEvent struct {
+	Id int64 `json:"event_id"`
+	// account, anonymous, cli or admin
+	ActorType string `json:"actor_type"`
+	ActorId   *int64 `json:"actor_id"`
+	Action    string `json:"action" example:"limit.updated"`
+	// account, consumer, account_limit, transaction, partner or partner_webhook
+	EntityType string `json:"entity_type"`
+	EntityId   *int64 `json:"entity_id"`
+	// The changed fields with their value before and after the change
+	Diff      json.RawMessage `json:"diff" swaggertype:"object"`
+	RequestId string          `json:"request_id"`
+	IpAddress string          `json:"ip_address" example:"203.0.113.7"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditEventFilter narrows the events, zero values match every event. Events are listed newest first from BeforeId.
+type AuditEventFilter struct {
+	ActorType  string `form:"actor_type"`
+	ActorId    int64  `form:"actor_id"`
+	Action     string `form:"action"`
+	EntityType string `form:"entity_type"`
+	EntityId   int64  `form:"entity_id"`
+	// Unix milliseconds, from is inclusive and to exclusive
+	CreatedFrom int64 `form:"created_from"`
+	CreatedTo   int64 `form:"created_to"`
+	BeforeId    int64 `form:"before_id"`
+	Limit       int   `form:"limit"`
+}
+
+type AuditEvents struct {
+	Events []AuditEvent `json:"events"`
+	// Pass as before_id to get the next page, 0 on the last page
+	NextBeforeId int64 `json:"next_before_id"`
+}
+
+// AuditChainHead is the latest event of the chain, the next event is chained to it
+type AuditChainHead struct {
+	LastEventId int64
+	LastHash    string
+}
+
+type AuditVerification struct {
+	IsValid       bool  `json:"is_valid"`
+	EventsChecked int64 `json:"events_checked"`
+	// The first event that does not match the chain
+	BrokenAtId *int64 `json:"broken_at_id"`
+	Reason     string `json:"reason,omitempty"`
+}
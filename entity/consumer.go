@@ -8,10 +8,34 @@ type Consumer struct {
 	LegalName         string `json:"legal_name" binding:"required" validate:"required"`
 	PlaceOfBirth      string `json:"place_of_birth" binding:"required" validate:"required"`
 	DateOfBirth       string `json:"date_of_birth" binding:"required" validate:"required"`
-	Salary            int64  `json:"salary" binding:"required" validate:"required"`
+	Salary            int64  `json:"salary" binding:"required,gt=0" validate:"required,gt=0"`
 	IdentityCardPhoto Media  `json:"identity_card_photo"`
 	SelfiePhoto       Media  `json:"selfie_photo"`
+	KycStatus         string `json:"-"`
+	Version           int    `json:"-"`
 	CreatedAt         int64  `json:"-"`
 	UpdatedAt         int64  `json:"-"`
 	DeletedAt         *int64 `json:"-"`
 }
+
+type ConsumerHistory struct {
+	Id                   int64
+	ConsumerId           int64
+	AccountId            int64
+	Version              int
+	IdentityNumber       string
+	FullName             string
+	LegalName            string
+	PlaceOfBirth         string
+	DateOfBirth          string
+	Salary               int64
+	IdentityCardPhotoKey string
+	SelfiePhotoKey       string
+	KycStatus            string
+	CreatedAt            int64
+}
+
+type UpdateConsumerProfileReq struct {
+	FullName string `json:"full_name" example:"user test"`
+	Salary   int64  `json:"salary" example:"800000" binding:"omitempty,gt=0"`
+}
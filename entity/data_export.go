@@ -0,0 +1,76 @@
+package entity
+
+// DataExport is a request for an archive of the personal data of an account. It is kept after the archive expired,
+// as the record of who asked for the data and when it was downloaded.
+type DataExport struct {
+	Id        int64 `json:"export_id"`
+	AccountId int64 `json:"-"`
+	// account when requested by its owner, cli when requested by an operator
+	RequestedBy string `json:"requested_by"`
+	// pending, processing, completed, failed or expired
+	Status     string `json:"status"`
+	ArchiveKey string `json:"-"`
+	// Why the archive could not be generated
+	Failure     string `json:"-"`
+	CompletedAt *int64 `json:"completed_at"`
+	// The archive can be downloaded until then, it is deleted afterwards
+	ExpiredAt     *int64 `json:"expired_at"`
+	DownloadedAt  *int64 `json:"downloaded_at"`
+	DownloadCount int    `json:"download_count"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"-"`
+}
+
+type DataExports struct {
+	Exports []DataExport `json:"exports"`
+}
+
+type DataExportResult struct {
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Expired   int `json:"expired"`
+}
+
+// DataExportAccount is the account.json of an archive
+type DataExportAccount struct {
+	AccountId  int64  `json:"account_id"`
+	Email      string `json:"email"`
+	VerifiedAt *int64 `json:"verified_at"`
+	DisabledAt *int64 `json:"disabled_at"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// DataExportConsumer is the consumer.json of an archive, the photos are files of the kyc directory
+type DataExportConsumer struct {
+	IdentityNumber        string `json:"nik"`
+	FullName              string `json:"full_name"`
+	LegalName             string `json:"legal_name"`
+	PlaceOfBirth          string `json:"place_of_birth"`
+	DateOfBirth           string `json:"date_of_birth"`
+	Salary                int64  `json:"salary"`
+	KycStatus             string `json:"kyc_status"`
+	IdentityCardPhotoFile string `json:"identity_card_photo_file"`
+	SelfiePhotoFile       string `json:"selfie_photo_file"`
+	CreatedAt             int64  `json:"created_at"`
+	UpdatedAt             int64  `json:"updated_at"`
+}
+
+// DataExportConsumerHistory is an entry of the consumer_histories.json of an archive, one per earlier KYC submission
+type DataExportConsumerHistory struct {
+	Version               int    `json:"version"`
+	IdentityNumber        string `json:"nik"`
+	FullName              string `json:"full_name"`
+	LegalName             string `json:"legal_name"`
+	PlaceOfBirth          string `json:"place_of_birth"`
+	DateOfBirth           string `json:"date_of_birth"`
+	Salary                int64  `json:"salary"`
+	KycStatus             string `json:"kyc_status"`
+	IdentityCardPhotoFile string `json:"identity_card_photo_file"`
+	SelfiePhotoFile       string `json:"selfie_photo_file"`
+	CreatedAt             int64  `json:"created_at"`
+}
+
+type DownloadDataExportReq struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -1,7 +1,12 @@
 package entity
 
 type JwtClaims struct {
-	AccountId      int64  `json:"account_id"`
-	Email          string `json:"email"`
-	IsKycCompleted bool   `json:"is_kyc_completed"`
+	AccountId       int64  `json:"account_id"`
+	Email           string `json:"email"`
+	IsKycCompleted  bool   `json:"is_kyc_completed"`
+	IsEmailVerified bool   `json:"is_email_verified"`
+	// Set only on tokens issued after a second factor was checked
+	IsMfaAuthenticated bool `json:"is_mfa_authenticated"`
+	// Key of the session the token was issued with, checked on every request
+	SessionKey string `json:"session_key"`
 }
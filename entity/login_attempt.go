@@ -0,0 +1,11 @@
+package entity
+
+// LoginAttempt tracks failed logins of an email or an IP address
+type LoginAttempt struct {
+	Key          string
+	FailedCount  int
+	LastFailedAt int64
+	LockedUntil  *int64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
@@ -11,3 +11,15 @@ type Media struct {
 	File   multipart.File        `json:"-"`
 	Header *multipart.FileHeader `json:"-"`
 }
+
+type MediaContent struct {
+	Key         string
+	ContentType string
+	Bytes       []byte
+}
+
+type MediaSweepResult struct {
+	Promoted  int `json:"promoted"`
+	Discarded int `json:"discarded"`
+	Deleted   int `json:"deleted"`
+}
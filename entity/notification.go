@@ -0,0 +1,7 @@
+package entity
+
+type Notification struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
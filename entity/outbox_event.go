@@ -0,0 +1,76 @@
+package entity
+
+import "encoding/json"
+
+// OutboxEvent is a domain event waiting in the outbox. It is written in the transaction of the change it reports, then
+// published by the relay until a sink accepts it.
+type OutboxEvent struct {
+	Id            int64
+	EventType     string
+	AggregateType string
+	AggregateId   int64
+	Payload       json.RawMessage
+	Attempts      int
+	// The relay skips the event until then, it is pushed back while the event is being published and after a failure
+	NextAttemptAt int64
+	LastError     string
+	PublishedAt   *int64
+	CreatedAt     int64
+	UpdatedAt     int64
+}
+
+// DomainEvent is what the sinks receive. Delivery is at least once, consumers skip an event_id they already handled.
+type DomainEvent struct {
+	Id            int64           `json:"event_id"`
+	Type          string          `json:"event_type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   int64           `json:"aggregate_id"`
+	OccurredAt    int64           `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+type AccountRegisteredEvent struct {
+	AccountId int64  `json:"account_id"`
+	Email     string `json:"email"`
+}
+
+// KycCompletedEvent carries the limit granted when the KYC is approved
+type KycCompletedEvent struct {
+	AccountId int64       `json:"account_id"`
+	KycStatus string      `json:"kyc_status"`
+	Version   int         `json:"version"`
+	Limit     *EventLimit `json:"limit,omitempty"`
+}
+
+type EventLimit struct {
+	Limit1M float64 `json:"limit_1_m"`
+	Limit2M float64 `json:"limit_2_m"`
+	Limit3M float64 `json:"limit_3_m"`
+	Limit4M float64 `json:"limit_4_m"`
+}
+
+type TransactionCreatedEvent struct {
+	TransactionId     int64   `json:"transaction_id"`
+	AccountId         int64   `json:"account_id"`
+	ContactNumber     string  `json:"contact_number"`
+	OTR               float64 `json:"otr"`
+	InstallmentMonths int     `json:"installment_months"`
+	AdminFee          float64 `json:"admin_fee"`
+	TotalInstallment  float64 `json:"total_installment"`
+	TotalInterest     float64 `json:"total_interest"`
+	AssetName         string  `json:"asset_name"`
+	DueAt             int64   `json:"due_at"`
+}
+
+// TransactionStatusChangedEvent reports a transaction cancelled or paid off
+type TransactionStatusChangedEvent struct {
+	TransactionId int64  `json:"transaction_id"`
+	AccountId     int64  `json:"account_id"`
+	Status        string `json:"status"`
+}
+
+// OutboxRelayResult tells what one run of the relay published
+type OutboxRelayResult struct {
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+}
@@ -0,0 +1,119 @@
+package entity
+
+import "encoding/json"
+
+// Partner is a merchant booking transactions for its customers. It authenticates with an api key, only its hash is
+// stored.
+type Partner struct {
+	Id         int64  `json:"partner_id"`
+	Name       string `json:"name"`
+	ApiKeyHash string `json:"-"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+type CreatePartnerReq struct {
+	Name string `json:"name" example:"dog house store" binding:"required,max=64"`
+}
+
+// CreatedPartner carries the api key, it is only shown once
+type CreatedPartner struct {
+	Partner
+	ApiKey string `json:"api_key"`
+}
+
+type Partners struct {
+	Partners []Partner `json:"partners"`
+}
+
+// PartnerWebhook subscribes an url of the partner to events of the transactions it booked
+type PartnerWebhook struct {
+	Id        int64  `json:"webhook_id"`
+	PartnerId int64  `json:"partner_id"`
+	Url       string `json:"url"`
+	// Signs the deliveries, only shown when the webhook is created
+	Secret     string   `json:"-"`
+	EventTypes []string `json:"event_types"`
+	CreatedAt  int64    `json:"created_at"`
+	UpdatedAt  int64    `json:"updated_at"`
+	DeletedAt  *int64   `json:"-"`
+}
+
+type CreatePartnerWebhookReq struct {
+	Url string `json:"url" example:"https://partner.example.com/webhooks" binding:"required,max=255"`
+	// transaction.approved, transaction.cancelled or transaction.paid_off
+	EventTypes []string `json:"event_types" example:"transaction.approved,transaction.cancelled,transaction.paid_off" binding:"required"`
+}
+
+// CreatedPartnerWebhook carries the signing secret, it is only shown once
+type CreatedPartnerWebhook struct {
+	PartnerWebhook
+	Secret string `json:"secret"`
+}
+
+type PartnerWebhooks struct {
+	Webhooks []PartnerWebhook `json:"webhooks"`
+}
+
+// WebhookDelivery is an event on its way to a webhook. It is retried with a backoff until the partner accepts it, and
+// given up as dead after too many attempts.
+type WebhookDelivery struct {
+	Id        int64  `json:"delivery_id"`
+	WebhookId int64  `json:"webhook_id"`
+	PartnerId int64  `json:"partner_id"`
+	EventType string `json:"event_type"`
+	// The body sent to the webhook
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// pending, delivered or dead
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// The dispatcher skips the delivery until then, it is pushed back while the delivery is being sent and after a failure
+	NextAttemptAt  int64  `json:"next_attempt_at"`
+	LastError      string `json:"last_error"`
+	LastStatusCode *int   `json:"last_status_code"`
+	DeliveredAt    *int64 `json:"delivered_at"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// WebhookDeliveryFilter narrows the deliveries, zero values match every delivery. Deliveries are listed newest first
+// from BeforeId.
+type WebhookDeliveryFilter struct {
+	Status    string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	PartnerId int64  `form:"partner_id"`
+	BeforeId  int64  `form:"before_id"`
+	Limit     int    `form:"limit"`
+}
+
+type WebhookDeliveries struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	// Pass as before_id to get the next page, 0 on the last page
+	NextBeforeId int64 `json:"next_before_id"`
+}
+
+// WebhookPayload is the body of a delivery. Deliveries are at least once and may arrive out of order, partners skip
+// an X-Webhook-Id they already handled and compare occurred_at.
+type WebhookPayload struct {
+	EventType  string             `json:"event_type"`
+	OccurredAt int64              `json:"occurred_at"`
+	Data       WebhookTransaction `json:"data"`
+}
+
+type WebhookTransaction struct {
+	TransactionId    int64   `json:"transaction_id"`
+	PartnerReference string  `json:"partner_reference"`
+	Status           string  `json:"status"`
+	OTR              float64 `json:"otr"`
+	AdminFee         float64 `json:"admin_fee"`
+	TotalInstallment float64 `json:"total_installment"`
+	TotalInterest    float64 `json:"total_interest"`
+	AssetName        string  `json:"asset_name"`
+	DueAt            int64   `json:"due_at"`
+}
+
+// WebhookDispatchResult tells what one run of the dispatcher sent
+type WebhookDispatchResult struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+	Dead      int `json:"dead"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+// RetentionReport tells what one run of the retention job removed
+type RetentionReport struct {
+	StartedAt                int64 `json:"started_at"`
+	FinishedAt               int64 `json:"finished_at"`
+	RefreshTokensDeleted     int64 `json:"refresh_tokens_deleted"`
+	AccountsAnonymised       int   `json:"accounts_anonymised"`
+	KycPhotosDeleted         int   `json:"kyc_photos_deleted"`
+	OutboxEventsDeleted      int64 `json:"outbox_events_deleted"`
+	WebhookDeliveriesDeleted int64 `json:"webhook_deliveries_deleted"`
+}
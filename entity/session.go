@@ -0,0 +1,25 @@
+package entity
+
+// ClientInfo describes the client of a request that issues tokens, it is stored with the session
+type ClientInfo struct {
+	IpAddress string
+	UserAgent string
+}
+
+// Session is a refresh token with the client it was issued to
+type Session struct {
+	Id        int64  `json:"session_id"`
+	AccountId int64  `json:"-"`
+	UserAgent string `json:"user_agent" example:"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X)"`
+	IpAddress string `json:"ip_address" example:"203.0.113.7"`
+	// Identifies the device of the client, empty without a user agent
+	DeviceHash string `json:"-"`
+	// Carried by the tokens of the session, so an access token stops working once its session is revoked
+	Key       string `json:"-"`
+	ExpiredAt int64  `json:"expired_at"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type Sessions struct {
+	Sessions []Session `json:"sessions"`
+}
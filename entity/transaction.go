@@ -1,7 +1,7 @@
 package entity
 
 type Transaction struct {
-	Id                int64   `json:"-"`
+	Id                int64   `json:"transaction_id"`
 	AccountId         int64   `json:"-"`
 	ContactNumber     string  `json:"contact_number" binding:"required"`
 	OTR               float64 `json:"otr" binding:"required,gt=0"`
@@ -10,9 +10,19 @@ type Transaction struct {
 	TotalInstallemnt  float64 `json:"total_installment" binding:"required,gt=0"`
 	TotalInterest     float64 `json:"total_interest" binding:"required,gt=0"`
 	AssetName         string  `json:"asset_name" binding:"required"`
-	CreatedAt         int64   `json:"created_at"`
-	UpdatedAt         int64   `json:"updated_at"`
-	DeletedAt         *int64  `json:"-"`
+	DueAt             int64   `json:"due_at"`
+	// approved, cancelled or paid_off
+	Status    string `json:"status"`
+	PartnerId *int64 `json:"-"`
+	// Set by the partner booking the transaction, it is sent back in the webhooks
+	PartnerReference string `json:"partner_reference" binding:"max=64"`
+	CreatedAt        int64  `json:"created_at"`
+	UpdatedAt        int64  `json:"updated_at"`
+	DeletedAt        *int64 `json:"-"`
+	// Whether the token of the request was issued after a second factor was checked
+	IsMfaAuthenticated bool `json:"-"`
+	// Api key of the partner booking the transaction, empty when the account books it
+	PartnerApiKey string `json:"-"`
 }
 
 type CreateTransactionReq struct {
@@ -24,4 +34,5 @@ type CreateTransactionReq struct {
 	TotalInstallemnt  float64 `json:"total_installment" example:"1000000000" binding:"required,gt=0"`
 	TotalInterest     float64 `json:"total_interest" example:"999000000" binding:"required,gt=0"`
 	AssetName         string  `json:"asset_name" example:"dog house" binding:"required"`
+	PartnerReference  string  `json:"partner_reference" example:"order-1234" binding:"max=64"`
 }
\ No newline at end of file
@@ -0,0 +1,192 @@
+package fixture
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture sets bundled into the binary, one directory per environment
+//
+//go:embed sets
+var sets embed.FS
+
+type Fixture struct {
+	Accounts []AccountFixture `json:"accounts" yaml:"accounts"`
+}
+
+// AccountFixture describes an account and everything owned by it. Accounts are matched by email.
+// Either a plain password, which is hashed on load, or an already hashed password can be given.
+type AccountFixture struct {
+	Email        string               `json:"email" yaml:"email"`
+	Password     string               `json:"password" yaml:"password"`
+	PasswordHash string               `json:"password_hash" yaml:"password_hash"`
+	Consumer     *ConsumerFixture     `json:"consumer" yaml:"consumer"`
+	Limit        *LimitFixture        `json:"limit" yaml:"limit"`
+	Transactions []TransactionFixture `json:"transactions" yaml:"transactions"`
+}
+
+// ConsumerFixture describes the KYC data of an account. Photos are paths relative to the fixture file.
+type ConsumerFixture struct {
+	IdentityNumber    string `json:"nik" yaml:"nik"`
+	FullName          string `json:"full_name" yaml:"full_name"`
+	LegalName         string `json:"legal_name" yaml:"legal_name"`
+	PlaceOfBirth      string `json:"place_of_birth" yaml:"place_of_birth"`
+	DateOfBirth       string `json:"date_of_birth" yaml:"date_of_birth"`
+	Salary            int64  `json:"salary" yaml:"salary"`
+	KycStatus         string `json:"kyc_status" yaml:"kyc_status"`
+	IdentityCardPhoto string `json:"identity_card_photo" yaml:"identity_card_photo"`
+	SelfiePhoto       string `json:"selfie_photo" yaml:"selfie_photo"`
+
+	identityCardPhotoBytes []byte
+	selfiePhotoBytes       []byte
+}
+
+type LimitFixture struct {
+	Limit1M float64 `json:"limit_1_m" yaml:"limit_1_m"`
+	Limit2M float64 `json:"limit_2_m" yaml:"limit_2_m"`
+	Limit3M float64 `json:"limit_3_m" yaml:"limit_3_m"`
+	Limit4M float64 `json:"limit_4_m" yaml:"limit_4_m"`
+}
+
+// TransactionFixture describes a transaction of an account. Transactions are matched by contact number, asset name and OTR.
+type TransactionFixture struct {
+	ContactNumber    string  `json:"contact_number" yaml:"contact_number"`
+	OTR              float64 `json:"otr" yaml:"otr"`
+	AdminFee         float64 `json:"admin_fee" yaml:"admin_fee"`
+	TotalInstallment float64 `json:"total_installment" yaml:"total_installment"`
+	TotalInterest    float64 `json:"total_interest" yaml:"total_interest"`
+	AssetName        string  `json:"asset_name" yaml:"asset_name"`
+}
+
+// LoadEnvironment loads the bundled fixture set of an environment
+func LoadEnvironment(environment string) (*Fixture, error) {
+	sub, err := fs.Sub(sets, path.Join("sets", environment))
+	if err != nil {
+		return nil, fmt.Errorf("[fixture][LoadEnvironment][fs.Sub] error: %w | environment: %s", err, environment)
+	}
+
+	fixture, err := load(sub)
+	if err != nil {
+		return nil, fmt.Errorf("[fixture][LoadEnvironment][load] error: %w | environment: %s", err, environment)
+	}
+
+	return fixture, nil
+}
+
+// LoadDir loads every fixture file in a directory
+func LoadDir(dir string) (*Fixture, error) {
+	fixture, err := load(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("[fixture][LoadDir][load] error: %w | dir: %s", err, dir)
+	}
+
+	return fixture, nil
+}
+
+// LoadFile loads a single fixture file
+func LoadFile(file string) (*Fixture, error) {
+	fixture, err := loadFile(os.DirFS(filepath.Dir(file)), filepath.Base(file))
+	if err != nil {
+		return nil, fmt.Errorf("[fixture][LoadFile][loadFile] error: %w | file: %s", err, file)
+	}
+
+	return fixture, nil
+}
+
+// Merge appends the accounts of other fixtures
+func (f *Fixture) Merge(others ...*Fixture) {
+	for _, other := range others {
+		f.Accounts = append(f.Accounts, other.Accounts...)
+	}
+}
+
+func load(fsys fs.FS) (*Fixture, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("[fs.ReadDir] error: %w", err)
+	}
+
+	var names []string
+
+	for _, e := range entries {
+		switch path.Ext(e.Name()) {
+		case ".json", ".yaml", ".yml":
+			names = append(names, e.Name())
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no fixture file found")
+	}
+
+	sort.Strings(names)
+
+	var fixture Fixture
+
+	for _, name := range names {
+		f, err := loadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		fixture.Merge(f)
+	}
+
+	return &fixture, nil
+}
+
+func loadFile(fsys fs.FS, name string) (*Fixture, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("[fs.ReadFile] error: %w | file: %s", err, name)
+	}
+
+	var fixture Fixture
+
+	switch path.Ext(name) {
+	case ".json":
+		err = json.Unmarshal(data, &fixture)
+
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fixture)
+
+	default:
+		return nil, fmt.Errorf("unsupported fixture file: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[Unmarshal] error: %w | file: %s", err, name)
+	}
+
+	dir := path.Dir(name)
+
+	for i := range fixture.Accounts {
+		consumer := fixture.Accounts[i].Consumer
+		if consumer == nil {
+			continue
+		}
+
+		if consumer.IdentityCardPhoto != "" {
+			consumer.identityCardPhotoBytes, err = fs.ReadFile(fsys, path.Join(dir, consumer.IdentityCardPhoto))
+			if err != nil {
+				return nil, fmt.Errorf("[fs.ReadFile] error: %w | file: %s", err, consumer.IdentityCardPhoto)
+			}
+		}
+
+		if consumer.SelfiePhoto != "" {
+			consumer.selfiePhotoBytes, err = fs.ReadFile(fsys, path.Join(dir, consumer.SelfiePhoto))
+			if err != nil {
+				return nil, fmt.Errorf("[fs.ReadFile] error: %w | file: %s", err, consumer.SelfiePhoto)
+			}
+		}
+	}
+
+	return &fixture, nil
+}
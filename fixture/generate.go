@@ -0,0 +1,114 @@
+package fixture
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+)
+
+type GenerateOpt struct {
+	Accounts               int
+	TransactionsPerAccount int
+	// Hash of the password of every generated account, hashed once instead of once per account
+	PasswordHash string
+	WithPhotos   bool
+	// Seed of the random generator, the same seed generates the same data so loading it again is idempotent
+	Seed int64
+}
+
+var (
+	syntheticPlaces = []string{"jakarta", "bandung", "surabaya", "medan", "makassar", "denpasar"}
+	syntheticAssets = []string{"motorcycle", "smartphone", "laptop", "refrigerator", "television", "washing machine"}
+)
+
+func syntheticPhoto(rnd *rand.Rand) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 40))
+
+	fill := color.RGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 40; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SyntheticEmail is the email of the generated account n, counting from 1
+func SyntheticEmail(n int) string {
+	return fmt.Sprintf("synthetic-%06d@example.com", n)
+}
+
+// Generate synthetic accounts for load testing. Generated emails are synthetic-{n}@example.com.
+func Generate(opt GenerateOpt) (*Fixture, error) {
+	rnd := rand.New(rand.NewSource(opt.Seed))
+
+	fixture := Fixture{
+		Accounts: make([]AccountFixture, 0, opt.Accounts),
+	}
+
+	for i := 1; i <= opt.Accounts; i++ {
+		salary := int64(3000000 + rnd.Intn(27)*1000000)
+		ratio := float64(salary) / 600000
+
+		account := AccountFixture{
+			Email:        SyntheticEmail(i),
+			PasswordHash: opt.PasswordHash,
+			Consumer: &ConsumerFixture{
+				IdentityNumber: fmt.Sprintf("%016d", rnd.Int63n(1e16)),
+				FullName:       fmt.Sprintf("synthetic user %06d", i),
+				LegalName:      fmt.Sprintf("synthetic legal %06d", i),
+				PlaceOfBirth:   syntheticPlaces[rnd.Intn(len(syntheticPlaces))],
+				DateOfBirth:    fmt.Sprintf("%02d-%02d-%d", 1+rnd.Intn(28), 1+rnd.Intn(12), 1960+rnd.Intn(45)),
+				Salary:         salary,
+			},
+			Limit: &LimitFixture{
+				Limit1M: 600000 * ratio,
+				Limit2M: 800000 * ratio,
+				Limit3M: 1000000 * ratio,
+				Limit4M: 1200000 * ratio,
+			},
+		}
+
+		if opt.WithPhotos {
+			var err error
+
+			account.Consumer.identityCardPhotoBytes, err = syntheticPhoto(rnd)
+			if err != nil {
+				return nil, fmt.Errorf("[fixture][Generate][syntheticPhoto][identityCard] error: %w", err)
+			}
+
+			account.Consumer.selfiePhotoBytes, err = syntheticPhoto(rnd)
+			if err != nil {
+				return nil, fmt.Errorf("[fixture][Generate][syntheticPhoto][selfie] error: %w", err)
+			}
+		}
+
+		for j := 1; j <= opt.TransactionsPerAccount; j++ {
+			otr := float64(100000 + rnd.Intn(20)*50000)
+
+			account.Transactions = append(account.Transactions, TransactionFixture{
+				ContactNumber:    fmt.Sprintf("08%010d", rnd.Int63n(1e10)),
+				OTR:              otr,
+				AdminFee:         otr * 0.05,
+				TotalInstallment: otr * 1.1,
+				TotalInterest:    otr * 0.1,
+				AssetName:        fmt.Sprintf("%s %d", syntheticAssets[rnd.Intn(len(syntheticAssets))], j),
+			})
+		}
+
+		fixture.Accounts = append(fixture.Accounts, account)
+	}
+
+	return &fixture, nil
+}
@@ -0,0 +1,415 @@
+package fixture
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+type SeedResult struct {
+	AccountsInserted     int `json:"accounts_inserted"`
+	AccountsUpdated      int `json:"accounts_updated"`
+	ConsumersInserted    int `json:"consumers_inserted"`
+	ConsumersUpdated     int `json:"consumers_updated"`
+	LimitsInserted       int `json:"limits_inserted"`
+	LimitsUpdated        int `json:"limits_updated"`
+	TransactionsInserted int `json:"transactions_inserted"`
+	MediaStored          int `json:"media_stored"`
+}
+
+type Seeder struct {
+	db        *sql.DB
+	driver    repository.Driver
+	hash      hHelper.HashHelper
+	mediaRepo repository.MediaRepository
+}
+
+func NewSeeder(db *sql.DB, driver repository.Driver, hash hHelper.HashHelper, mediaRepo repository.MediaRepository) *Seeder {
+	return &Seeder{
+		db:        db,
+		driver:    driver,
+		hash:      hash,
+		mediaRepo: mediaRepo,
+	}
+}
+
+// Seed upserts every account of the fixture, each account in its own transaction.
+// Loading the same fixture again leaves the data unchanged.
+func (s *Seeder) Seed(ctx context.Context, fixture *Fixture) (SeedResult, error) {
+	var result SeedResult
+
+	for _, account := range fixture.Accounts {
+		err := s.seedAccount(ctx, account, &result)
+		if err != nil {
+			return result, fmt.Errorf("[fixture][Seed][seedAccount] error: %w | email: %s", err, account.Email)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Seeder) seedAccount(ctx context.Context, fixture AccountFixture, result *SeedResult) error {
+	if fixture.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	if fixture.Password == "" && fixture.PasswordHash == "" {
+		return fmt.Errorf("password or password_hash is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("[db.BeginTx] error: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var counts SeedResult
+
+	accountId, err := s.upsertAccount(ctx, repository.NewAccountRepository(s.driver, tx), fixture, &counts)
+	if err != nil {
+		return err
+	}
+
+	if fixture.Consumer != nil {
+		err = s.upsertConsumer(ctx, repository.NewConsumerRepository(s.driver, tx), accountId, *fixture.Consumer, &counts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if fixture.Limit != nil {
+		err = s.upsertLimit(ctx, repository.NewAccountLimitRepository(s.driver, tx), accountId, *fixture.Limit, &counts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(fixture.Transactions) > 0 {
+		err = s.insertTransactions(ctx, repository.NewTransactionRepository(s.driver, tx), accountId, fixture.Transactions, &counts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Counted once committed, so a failed commit is reported and leaves the result unchanged
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("[tx.Commit] error: %w", err)
+	}
+
+	result.AccountsInserted += counts.AccountsInserted
+	result.AccountsUpdated += counts.AccountsUpdated
+	result.ConsumersInserted += counts.ConsumersInserted
+	result.ConsumersUpdated += counts.ConsumersUpdated
+	result.LimitsInserted += counts.LimitsInserted
+	result.LimitsUpdated += counts.LimitsUpdated
+	result.TransactionsInserted += counts.TransactionsInserted
+	result.MediaStored += counts.MediaStored
+
+	return nil
+}
+
+// HashPassword hashes a password shared by many fixture accounts once. The stored hash of the account of email is
+// reused while it still matches, so loading the accounts again leaves their passwords unchanged.
+func (s *Seeder) HashPassword(ctx context.Context, email, password string) (string, error) {
+	existing, err := repository.NewAccountRepository(s.driver, s.db).GetAccountByEmail(ctx, email, false)
+	if err != nil {
+		return "", fmt.Errorf("[fixture][HashPassword][repo.GetAccountByEmail] error: %w | email: %s", err, email)
+	}
+
+	if existing != nil {
+		isValid, _ := s.hash.Check(password, []byte(existing.Password))
+		if isValid {
+			return existing.Password, nil
+		}
+	}
+
+	hashed, err := s.hash.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("[fixture][HashPassword][hash.Hash] error: %w", err)
+	}
+
+	return hashed, nil
+}
+
+// The stored password is only replaced when it does not match the fixture, since bcrypt hashes differ on every run
+func (s *Seeder) upsertAccount(ctx context.Context, repo repository.AccountRepository, fixture AccountFixture, result *SeedResult) (int64, error) {
+	existing, err := repo.GetAccountByEmail(ctx, fixture.Email, true)
+	if err != nil {
+		return 0, fmt.Errorf("[repo.GetAccountByEmail] error: %w", err)
+	}
+
+	if existing != nil {
+		if fixture.PasswordHash != "" {
+			if fixture.PasswordHash == existing.Password {
+				return existing.Id, nil
+			}
+
+			err = repo.UpdatePassword(ctx, existing.Id, fixture.PasswordHash)
+			if err != nil {
+				return 0, fmt.Errorf("[repo.UpdatePassword] error: %w", err)
+			}
+
+			result.AccountsUpdated++
+
+			return existing.Id, nil
+		}
+
+		isValid, _ := s.hash.Check(fixture.Password, []byte(existing.Password))
+		if isValid {
+			return existing.Id, nil
+		}
+
+		hashed, err := s.hash.Hash(fixture.Password)
+		if err != nil {
+			return 0, fmt.Errorf("[hash.Hash] error: %w", err)
+		}
+
+		err = repo.UpdatePassword(ctx, existing.Id, hashed)
+		if err != nil {
+			return 0, fmt.Errorf("[repo.UpdatePassword] error: %w", err)
+		}
+
+		result.AccountsUpdated++
+
+		return existing.Id, nil
+	}
+
+	password := fixture.PasswordHash
+
+	if password == "" {
+		password, err = s.hash.Hash(fixture.Password)
+		if err != nil {
+			return 0, fmt.Errorf("[hash.Hash] error: %w", err)
+		}
+	}
+
+	// Fixture emails cannot receive a verification email, they are verified from the start
+	verifiedAt := time.Now().UnixMilli()
+
+	accountId, err := repo.InsertAccount(ctx, entity.Account{
+		Email:      fixture.Email,
+		Password:   password,
+		VerifiedAt: &verifiedAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("[repo.InsertAccount] error: %w", err)
+	}
+
+	result.AccountsInserted++
+
+	return accountId, nil
+}
+
+func (s *Seeder) storePhoto(ctx context.Context, key string, data []byte, result *SeedResult) error {
+	var extension string
+
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		extension = ".jpg"
+
+	case "image/png":
+		extension = ".png"
+
+	default:
+		return fmt.Errorf("photo must be a jpeg or png image | key: %s", key)
+	}
+
+	// The key holds the version of the consumer, a photo stored under it by an earlier run is kept
+	existing, err := s.mediaRepo.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("[mediaRepo.Get] error: %w", err)
+	}
+	if existing != nil {
+		return nil
+	}
+
+	err = s.mediaRepo.Store(ctx, repository.MediaOpt{
+		Key:       key,
+		Extension: extension,
+		Bytes:     data,
+	})
+	if err != nil {
+		return fmt.Errorf("[mediaRepo.Store] error: %w", err)
+	}
+
+	result.MediaStored++
+
+	return nil
+}
+
+func (s *Seeder) upsertConsumer(ctx context.Context, repo repository.ConsumerRepository, accountId int64, fixture ConsumerFixture, result *SeedResult) error {
+	existing, err := repo.GetConsumerByAccountId(ctx, accountId, true)
+	if err != nil {
+		return fmt.Errorf("[repo.GetConsumerByAccountId] error: %w", err)
+	}
+
+	consumer := entity.Consumer{
+		AccountId:      accountId,
+		IdentityNumber: fixture.IdentityNumber,
+		FullName:       fixture.FullName,
+		LegalName:      fixture.LegalName,
+		PlaceOfBirth:   fixture.PlaceOfBirth,
+		DateOfBirth:    fixture.DateOfBirth,
+		Salary:         fixture.Salary,
+		KycStatus:      fixture.KycStatus,
+		Version:        1,
+	}
+
+	if consumer.KycStatus == "" {
+		consumer.KycStatus = appconstant.KycStatusApproved
+	}
+
+	if existing != nil {
+		consumer.Id = existing.Id
+		consumer.Version = existing.Version
+		consumer.IdentityCardPhoto.Key = existing.IdentityCardPhoto.Key
+		consumer.SelfiePhoto.Key = existing.SelfiePhoto.Key
+	}
+
+	if len(fixture.identityCardPhotoBytes) > 0 {
+		consumer.IdentityCardPhoto.Key = helper.KycPhotoKey(accountId, appconstant.KYCIdentityCardPhotoTag, consumer.Version)
+
+		err = s.storePhoto(ctx, consumer.IdentityCardPhoto.Key, fixture.identityCardPhotoBytes, result)
+		if err != nil {
+			return fmt.Errorf("[storePhoto][identityCard] error: %w", err)
+		}
+	}
+
+	if len(fixture.selfiePhotoBytes) > 0 {
+		consumer.SelfiePhoto.Key = helper.KycPhotoKey(accountId, appconstant.KYCSelfiePhotoTag, consumer.Version)
+
+		err = s.storePhoto(ctx, consumer.SelfiePhoto.Key, fixture.selfiePhotoBytes, result)
+		if err != nil {
+			return fmt.Errorf("[storePhoto][selfie] error: %w", err)
+		}
+	}
+
+	if existing == nil {
+		err = repo.InsertConsumer(ctx, consumer)
+		if err != nil {
+			return fmt.Errorf("[repo.InsertConsumer] error: %w", err)
+		}
+
+		result.ConsumersInserted++
+
+		return nil
+	}
+
+	if existing.IdentityNumber == consumer.IdentityNumber &&
+		existing.FullName == consumer.FullName &&
+		existing.LegalName == consumer.LegalName &&
+		existing.PlaceOfBirth == consumer.PlaceOfBirth &&
+		existing.DateOfBirth == consumer.DateOfBirth &&
+		existing.Salary == consumer.Salary &&
+		existing.KycStatus == consumer.KycStatus &&
+		existing.IdentityCardPhoto.Key == consumer.IdentityCardPhoto.Key &&
+		existing.SelfiePhoto.Key == consumer.SelfiePhoto.Key {
+		return nil
+	}
+
+	err = repo.UpdateConsumer(ctx, consumer)
+	if err != nil {
+		return fmt.Errorf("[repo.UpdateConsumer] error: %w", err)
+	}
+
+	result.ConsumersUpdated++
+
+	return nil
+}
+
+func (s *Seeder) upsertLimit(ctx context.Context, repo repository.AccountLimitRepository, accountId int64, fixture LimitFixture, result *SeedResult) error {
+	existing, err := repo.GetAccountLimitByAccountId(ctx, accountId, true)
+	if err != nil {
+		return fmt.Errorf("[repo.GetAccountLimitByAccountId] error: %w", err)
+	}
+
+	limit := entity.AccountLimit{
+		AccountId: accountId,
+		Limit1M:   fixture.Limit1M,
+		Limit2M:   fixture.Limit2M,
+		Limit3M:   fixture.Limit3M,
+		Limit4M:   fixture.Limit4M,
+	}
+
+	if existing == nil {
+		err = repo.InsertLimit(ctx, limit)
+		if err != nil {
+			return fmt.Errorf("[repo.InsertLimit] error: %w", err)
+		}
+
+		result.LimitsInserted++
+
+		return nil
+	}
+
+	if existing.Limit1M == limit.Limit1M &&
+		existing.Limit2M == limit.Limit2M &&
+		existing.Limit3M == limit.Limit3M &&
+		existing.Limit4M == limit.Limit4M {
+		return nil
+	}
+
+	limit.Id = existing.Id
+
+	err = repo.UpdateLimit(ctx, limit)
+	if err != nil {
+		return fmt.Errorf("[repo.UpdateLimit] error: %w", err)
+	}
+
+	result.LimitsUpdated++
+
+	return nil
+}
+
+// Transactions are never updated, a transaction is only inserted when no transaction with the same contact number, asset name and OTR exists
+func (s *Seeder) insertTransactions(ctx context.Context, repo repository.TransactionRepository, accountId int64, fixtures []TransactionFixture, result *SeedResult) error {
+	existing, err := repo.GetTransactionsByAccountId(ctx, accountId)
+	if err != nil {
+		return fmt.Errorf("[repo.GetTransactionsByAccountId] error: %w", err)
+	}
+
+	seen := map[TransactionFixture]bool{}
+
+	for _, t := range existing {
+		seen[TransactionFixture{ContactNumber: t.ContactNumber, AssetName: t.AssetName, OTR: t.OTR}] = true
+	}
+
+	for _, fixture := range fixtures {
+		key := TransactionFixture{ContactNumber: fixture.ContactNumber, AssetName: fixture.AssetName, OTR: fixture.OTR}
+		if seen[key] {
+			continue
+		}
+
+		_, err = repo.InsertTransaction(ctx, entity.Transaction{
+			AccountId:        accountId,
+			ContactNumber:    fixture.ContactNumber,
+			OTR:              fixture.OTR,
+			AdminFee:         fixture.AdminFee,
+			TotalInstallemnt: fixture.TotalInstallment,
+			TotalInterest:    fixture.TotalInterest,
+			AssetName:        fixture.AssetName,
+		})
+		if err != nil {
+			return fmt.Errorf("[repo.InsertTransaction] error: %w", err)
+		}
+
+		seen[key] = true
+		result.TransactionsInserted++
+	}
+
+	return nil
+}
@@ -2,11 +2,15 @@ package handler
 
 import (
 	"context"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/go-helper/apperror"
 	_ "github.com/michaelyusak/go-helper/dto"
 	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
 	"github.com/michaelyusak/xyz-kredit-plus/service"
 )
@@ -35,6 +39,7 @@ func NewAccountHandler(accountService service.AccountService, ctxTimeout time.Du
 // @Produce  json
 // @Param request body entity.LoginRegisterReq true "Register request body"
 // @Success 200 {object} dto.Response{message=string,data=entity.TokenData} "Success"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
 // @Router /account/register [post]
 func (h *AccountHandler) Register(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/json")
@@ -50,7 +55,7 @@ func (h *AccountHandler) Register(ctx *gin.Context) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
 	defer cancel()
 
-	token, err := h.accountService.RegisterAccount(ctxWithTimeout, newAccount)
+	token, err := h.accountService.RegisterAccount(ctxWithTimeout, newAccount, clientInfo(ctx))
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -61,12 +66,14 @@ func (h *AccountHandler) Register(ctx *gin.Context) {
 
 // Account godoc
 // @Summary Login to an account
-// @Description Login to an existing account
+// @Description Login to an existing account. With two-factor authentication enabled, only an mfa challenge is returned, to complete at /account/login/mfa. Signing in from a device the account never used emails an alert.
 // @Tags accounts
 // @Accept  json
 // @Produce  json
 // @Param request body entity.LoginRegisterReq true "Register request body"
-// @Success 200 {object} dto.Response{message=string,data=entity.TokenData} "Success"
+// @Success 200 {object} dto.Response{message=string,data=entity.LoginResult} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Invalid credentials"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed login attempts or requests"
 // @Router /account/login [post]
 func (h *AccountHandler) Login(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/json")
@@ -82,7 +89,186 @@ func (h *AccountHandler) Login(ctx *gin.Context) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
 	defer cancel()
 
-	token, err := h.accountService.Login(ctxWithTimeout, req)
+	result, err := h.accountService.Login(ctxWithTimeout, req, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *result)
+}
+
+// Account godoc
+// @Summary Verify the email of an account
+// @Description Consumes the token sent by email. Only the latest token is valid and only once.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param request body entity.VerifyEmailReq true "Verify email request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.TokenData} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired token, or email already verified"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/verify-email [post]
+func (h *AccountHandler) VerifyEmail(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var req entity.VerifyEmailReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	token, err := h.accountService.VerifyEmail(ctxWithTimeout, req.Token, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *token)
+}
+
+// Account godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification email, invalidating the previous one. Limited to one email per cooldown.
+// @Tags accounts
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Email already verified"
+// @Failure 429 {object} dto.ErrorResponse "Verification email sent too recently or too many requests"
+// @Router /account/resend-verification [post]
+func (h *AccountHandler) ResendVerificationEmail(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err := h.accountService.ResendVerificationEmail(ctxWithTimeout, accountId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, nil)
+}
+
+// Account godoc
+// @Summary Ask for a password reset link
+// @Description Emails a reset link if the email belongs to an enabled account, at most once per cooldown. Responds the same for any email.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param request body entity.ForgotPasswordReq true "Forgot password request body"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/forgot-password [post]
+func (h *AccountHandler) ForgotPassword(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var req entity.ForgotPasswordReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.accountService.ForgotPassword(ctxWithTimeout, req.Email)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, nil)
+}
+
+// Account godoc
+// @Summary Reset the password
+// @Description Sets a new password with the token of the latest reset link, once. Every session is signed out.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param request body entity.ResetPasswordReq true "Reset password request body"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid password, or invalid or expired token"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/reset-password [post]
+func (h *AccountHandler) ResetPassword(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var req entity.ResetPasswordReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.accountService.ResetPassword(ctxWithTimeout, req.Token, req.NewPassword)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, nil)
+}
+
+// Account godoc
+// @Summary Change the password
+// @Description Replaces the password after checking the current one. Every other session is signed out and new tokens are returned.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param request body entity.ChangePasswordReq true "Change password request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.TokenData} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid current or new password"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/change-password [post]
+func (h *AccountHandler) ChangePassword(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	var req entity.ChangePasswordReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	token, err := h.accountService.ChangePassword(ctxWithTimeout, accountId, req, clientInfo(ctx))
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -90,3 +276,243 @@ func (h *AccountHandler) Login(ctx *gin.Context) {
 
 	helper.ResponseOK(ctx, *token)
 }
+
+// Account godoc
+// @Summary Complete a login with a second factor
+// @Description Completes the mfa challenge returned by login with a code of the authenticator app or an unused recovery code. Wrong codes count as failed logins.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param request body entity.MfaLoginReq true "Mfa login request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.TokenData} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Invalid or expired challenge, or invalid code"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed login attempts or requests"
+// @Router /account/login/mfa [post]
+func (h *AccountHandler) CompleteMfaLogin(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var req entity.MfaLoginReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	token, err := h.accountService.CompleteMfaLogin(ctxWithTimeout, req.ChallengeToken, req.Code, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *token)
+}
+
+// Account godoc
+// @Summary Enroll an authenticator app
+// @Description Returns a TOTP secret and its provisioning URI, replacing an unconfirmed enrolment. Two-factor authentication is enabled once a code is confirmed.
+// @Tags accounts
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.Response{message=string,data=entity.MfaEnrollment} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Two-factor authentication already enabled"
+// @Failure 403 {object} dto.ErrorResponse "Email not verified"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/mfa/enroll [post]
+func (h *AccountHandler) EnrollMfa(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	enrollment, err := h.accountService.EnrollMfa(ctxWithTimeout, accountId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *enrollment)
+}
+
+// Account godoc
+// @Summary Confirm an authenticator app
+// @Description Enables two-factor authentication with a code of the enrolled app and returns the recovery codes, shown only once.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param request body entity.MfaCodeReq true "Mfa code request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.MfaRecoveryCodes} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Not enrolled, already enabled, or invalid code"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/mfa/confirm [post]
+func (h *AccountHandler) ConfirmMfa(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	var req entity.MfaCodeReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	recoveryCodes, err := h.accountService.ConfirmMfa(ctxWithTimeout, accountId, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *recoveryCodes)
+}
+
+// Account godoc
+// @Summary List the sessions of an account
+// @Description Lists the sessions still signed in, newest first, with the device and IP address they were issued to.
+// @Tags accounts
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.Response{message=string,data=entity.Sessions} "Success"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/sessions [get]
+func (h *AccountHandler) GetSessions(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	sessions, err := h.accountService.GetSessions(ctxWithTimeout, accountId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *sessions)
+}
+
+// Account godoc
+// @Summary Sign out a session
+// @Description Revokes the refresh token of a session of the account, its access token is rejected from then on.
+// @Tags accounts
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Session id"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 404 {object} dto.ErrorResponse "Session not found or already signed out"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/sessions/{id} [delete]
+func (h *AccountHandler) RevokeSession(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.accountService.RevokeSession(ctxWithTimeout, accountId, sessionId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, nil)
+}
+
+// Account godoc
+// @Summary Close the account
+// @Description Closes the account after checking the password, refused while a transaction is still approved, until it is paid off or cancelled. Every session is signed out and the personal data is anonymised once the retention period passed.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param request body entity.CloseAccountReq true "Close account request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.AccountClosure} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid password"
+// @Failure 409 {object} dto.ErrorResponse "Account has outstanding transactions"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/close [post]
+func (h *AccountHandler) CloseAccount(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	var req entity.CloseAccountReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	closure, err := h.accountService.CloseAccount(ctxWithTimeout, accountId, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *closure)
+}
+
+// clientInfo describes the client of the request, stored with the session it is issued
+func clientInfo(ctx *gin.Context) entity.ClientInfo {
+	return entity.ClientInfo{
+		IpAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/go-helper/apperror"
+	_ "github.com/michaelyusak/go-helper/dto"
+	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+type AuditHandler struct {
+	ctxTimeout   time.Duration
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService, ctxTimeout time.Duration) *AuditHandler {
+	if ctxTimeout <= 0 {
+		ctxTimeout = 30 * time.Second
+	}
+
+	return &AuditHandler{
+		auditService: auditService,
+		ctxTimeout:   ctxTimeout,
+	}
+}
+
+// Audit godoc
+// @Summary List audit events
+// @Description Lists the audit events matching the filters, newest first. Pass next_before_id of a page as before_id to get the next one.
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param actor_type query string false "account, anonymous, cli or admin"
+// @Param actor_id query int false "Id of the actor"
+// @Param action query string false "e.g. limit.updated"
+// @Param entity_type query string false "account, consumer, account_limit, transaction, partner or partner_webhook"
+// @Param entity_id query int false "Id of the entity, the account_id for consumer and account_limit"
+// @Param created_from query int false "Unix milliseconds, inclusive"
+// @Param created_to query int false "Unix milliseconds, exclusive"
+// @Param before_id query int false "Lists events older than this one"
+// @Param limit query int false "Events per page, 50 by default and 200 at most"
+// @Success 200 {object} dto.Response{message=string,data=entity.AuditEvents} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/audit-events [get]
+func (h *AuditHandler) GetEvents(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var filter entity.AuditEventFilter
+
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[audit_handler][GetEvents][ShouldBindQuery] Error: %s", err.Error()),
+			ResponseMessage: "invalid filter",
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	events, err := h.auditService.GetEvents(ctxWithTimeout, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *events)
+}
+
+// Audit godoc
+// @Summary Verify the audit log
+// @Description Recomputes the hash chain of the audit events and reports the first event that was modified or deleted
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Success 200 {object} dto.Response{message=string,data=entity.AuditVerification} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/audit-events/verify [get]
+func (h *AuditHandler) VerifyChain(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	verification, err := h.auditService.VerifyChain(ctxWithTimeout)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *verification)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -48,6 +49,7 @@ func NewConsumerHandler(consumerService service.ConsumerService, ctxTimeout time
 // @Param selfie_photo formData file false "Selfie photo"
 // @Success 200 {object} dto.Response{message=string,data=nil} "Success"
 // @Failure 400 {object} dto.ErrorResponse "Invalid request or validation error"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
 // @Router /consumer/process-kyc [post]
 func (h *ConsumerHandler) ProcessKyc(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/json")
@@ -61,89 +63,316 @@ func (h *ConsumerHandler) ProcessKyc(ctx *gin.Context) {
 		return
 	}
 
-	var consumerData entity.Consumer
+	consumerData, err := h.bindKycForm(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-	data := ctx.Request.FormValue("data")
-	if data == "" {
-		ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
-			ResponseMessage: "data cannot be empty",
+	consumerData.AccountId = accountId
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.consumerService.ProcessKyc(ctxWithTimeout, *consumerData)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hHelper.ResponseOK(ctx, nil)
+}
+
+// Consumer godoc
+// @Summary Get a KYC photo of an account
+// @Description Get the identity card or selfie photo submitted during KYC. Use size to get a smaller variant for browsing.
+// @Tags consumers
+// @Produce  image/png
+// @Produce  image/jpeg
+// @Param Authorization header string true "Bearer token"
+// @Param photo path string true "Photo type" Enums(identity-card, selfie)
+// @Param size query string false "Photo size" Enums(original, preview, thumbnail)
+// @Success 200 {file} file "Photo"
+// @Failure 400 {object} dto.ErrorResponse "Invalid photo type or size"
+// @Failure 404 {object} dto.ErrorResponse "Photo not found"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /consumer/kyc-photo/{photo} [get]
+func (h *ConsumerHandler) GetKycPhoto(ctx *gin.Context) {
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
 		}))
 		return
 	}
 
-	err := json.Unmarshal([]byte(data), &consumerData)
+	h.getKycPhoto(ctx, accountId)
+}
+
+// Consumer godoc
+// @Summary Get a KYC photo of any account
+// @Description Get the identity card or selfie photo a consumer submitted during KYC, so a reviewer can check it before approving. Use size to get a smaller variant for browsing.
+// @Tags admin
+// @Produce  image/png
+// @Produce  image/jpeg
+// @Param Authorization header string true "Bearer admin api key"
+// @Param account_id path int true "Account id"
+// @Param photo path string true "Photo type" Enums(identity-card, selfie)
+// @Param size query string false "Photo size" Enums(original, preview, thumbnail)
+// @Success 200 {file} file "Photo"
+// @Failure 400 {object} dto.ErrorResponse "Invalid photo type or size"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Photo not found"
+// @Router /admin/consumers/{account_id}/kyc-photo/{photo} [get]
+func (h *ConsumerHandler) GetConsumerKycPhoto(ctx *gin.Context) {
+	accountId, err := strconv.ParseInt(ctx.Param("account_id"), 10, 64)
 	if err != nil {
-		ctx.Error(err)
+		ctx.Error(apperror.NotFoundError())
 		return
 	}
 
-	err = validator.New().Struct(consumerData)
+	h.getKycPhoto(ctx, accountId)
+}
+
+func (h *ConsumerHandler) getKycPhoto(ctx *gin.Context, accountId int64) {
+	var photoTag string
+
+	switch ctx.Param("photo") {
+	case "identity-card":
+		photoTag = appconstant.KYCIdentityCardPhotoTag
+
+	case "selfie":
+		photoTag = appconstant.KYCSelfiePhotoTag
+
+	default:
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	media, err := h.consumerService.GetKycPhoto(ctxWithTimeout, accountId, photoTag, ctx.Query("size"))
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	consumerData.AccountId = accountId
+	ctx.Data(http.StatusOK, media.ContentType, media.Bytes)
+}
+
+// Bind consumer data and KYC photos from a multipart KYC form
+func (h *ConsumerHandler) bindKycForm(ctx *gin.Context) (*entity.Consumer, error) {
+	var consumerData entity.Consumer
+
+	data := ctx.Request.FormValue("data")
+	if data == "" {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "data cannot be empty",
+		})
+	}
+
+	err := json.Unmarshal([]byte(data), &consumerData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validator.New().Struct(consumerData)
+	if err != nil {
+		return nil, err
+	}
 
 	fileSizeLimit := 3 * 1024 * 1024 // 3MB
 
 	identityCardPhotoFile, identityCardPhotoHeader, err := ctx.Request.FormFile("identity_card_photo")
 	if err != nil {
 		if !errors.Is(err, http.ErrMissingFile) {
-			ctx.Error(err)
-			return
+			return nil, err
 		}
 
 		if consumerData.IdentityCardPhoto.Base64 == "" {
-			ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
 				ResponseMessage: "identity card photo is required",
-			}))
-			return
+			})
 		}
 	}
 	if identityCardPhotoFile != nil && identityCardPhotoHeader != nil {
 		if identityCardPhotoHeader.Size > int64(fileSizeLimit) {
-			ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
 				ResponseMessage: fmt.Sprintf(
 					"identity card photo file size %.2fMB exceeded limit of %s",
-					float32(identityCardPhotoHeader.Size/(1024*1024)),
+					float32(identityCardPhotoHeader.Size)/(1024*1024),
 					"3MB"),
-			}))
+			})
 		}
 	}
 
 	selfiePhotoFile, selfiePhotoHeader, err := ctx.Request.FormFile("selfie_photo")
 	if err != nil {
 		if !errors.Is(err, http.ErrMissingFile) {
-			ctx.Error(err)
-			return
+			return nil, err
 		}
 
 		if consumerData.SelfiePhoto.Base64 == "" {
-			ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
 				ResponseMessage: "selfie photo is required",
-			}))
-			return
+			})
 		}
 	}
 	if selfiePhotoFile != nil && selfiePhotoHeader != nil {
 		if selfiePhotoHeader.Size > int64(fileSizeLimit) {
-			ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
 				ResponseMessage: fmt.Sprintf(
 					"selfie photo file size %.2fMB exceeded limit of %s",
-					float32(selfiePhotoHeader.Size/(1024*1024)),
+					float32(selfiePhotoHeader.Size)/(1024*1024),
 					"3MB"),
-			}))
+			})
 		}
 	}
 
 	consumerData.IdentityCardPhoto.File = identityCardPhotoFile
 	consumerData.SelfiePhoto.File = selfiePhotoFile
 
+	return &consumerData, nil
+}
+
+// Consumer godoc
+// @Summary Update consumer profile
+// @Description Update profile fields that do not require KYC review. Account limit is recalculated when salary changes.
+// @Tags consumers
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param request body entity.UpdateConsumerProfileReq true "Update profile request body"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request or validation error"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /consumer/profile [patch]
+func (h *ConsumerHandler) UpdateProfile(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	var req entity.UpdateConsumerProfileReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.consumerService.UpdateProfile(ctxWithTimeout, accountId, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hHelper.ResponseOK(ctx, nil)
+}
+
+// Consumer godoc
+// @Summary Resubmit a KYC for an account
+// @Description Resubmit consumer data and photos of a completed KYC, e.g. to fix a typo or replace an expired photo.
+// @Description The previous data is kept as history and the KYC is pending review until it is approved.
+// @Tags consumers
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Param data formData string true "Consumer data in JSON format (same as entity.Consumer)" example={"nik": "124","full_name": "user test","legal_name": "user test legal","place_of_birth": "bumi","date_of_birth": "12-07-2001","salary": 600000,"identity_card_photo": {"base64":""},"selfie_photo": {"base64": ""}}
+// @Param identity_card_photo formData file false "Identity card photo"
+// @Param selfie_photo formData file false "Selfie photo"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request or validation error"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /consumer/resubmit-kyc [post]
+func (h *ConsumerHandler) ResubmitKyc(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	consumerData, err := h.bindKycForm(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consumerData.AccountId = accountId
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.consumerService.ResubmitKyc(ctxWithTimeout, *consumerData)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hHelper.ResponseOK(ctx, nil)
+}
+
+// Consumer godoc
+// @Summary Approve a KYC
+// @Description Approves the KYC of an account pending review
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param account_id path int true "Account id"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "KYC is not pending review"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "KYC not found"
+// @Router /admin/consumers/{account_id}/kyc/approve [post]
+func (h *ConsumerHandler) ApproveKyc(ctx *gin.Context) {
+	h.reviewKyc(ctx, true)
+}
+
+// Consumer godoc
+// @Summary Reject a KYC
+// @Description Rejects the KYC of an account pending review
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param account_id path int true "Account id"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 400 {object} dto.ErrorResponse "KYC is not pending review"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "KYC not found"
+// @Router /admin/consumers/{account_id}/kyc/reject [post]
+func (h *ConsumerHandler) RejectKyc(ctx *gin.Context) {
+	h.reviewKyc(ctx, false)
+}
+
+func (h *ConsumerHandler) reviewKyc(ctx *gin.Context, isApproved bool) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, err := strconv.ParseInt(ctx.Param("account_id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
 	defer cancel()
 
-	err = h.consumerService.ProcessKyc(ctxWithTimeout, consumerData)
+	err = h.consumerService.ReviewKyc(ctxWithTimeout, accountId, isApproved)
 	if err != nil {
 		ctx.Error(err)
 		return
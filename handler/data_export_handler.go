@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/go-helper/apperror"
+	_ "github.com/michaelyusak/go-helper/dto"
+	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+type DataExportHandler struct {
+	ctxTimeout        time.Duration
+	dataExportService service.DataExportService
+}
+
+func NewDataExportHandler(dataExportService service.DataExportService, ctxTimeout time.Duration) *DataExportHandler {
+	if ctxTimeout <= 0 {
+		ctxTimeout = 30 * time.Second
+	}
+
+	return &DataExportHandler{
+		dataExportService: dataExportService,
+		ctxTimeout:        ctxTimeout,
+	}
+}
+
+// DataExport godoc
+// @Summary Request an export of the personal data
+// @Description Queues an archive of the account, consumer record, limit, transactions and KYC photos. A download link is emailed once it is generated. While an export is still queued or generating, it is returned instead of queueing another.
+// @Tags data exports
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.Response{message=string,data=entity.DataExport} "Success"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/data-exports [post]
+func (h *DataExportHandler) RequestExport(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	export, err := h.dataExportService.RequestExport(ctxWithTimeout, accountId, appconstant.DataExportRequestedByAccount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *export)
+}
+
+// DataExport godoc
+// @Summary List the personal data exports
+// @Description Lists the exports of the account, newest first, with when each archive was downloaded
+// @Tags data exports
+// @Produce  json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.Response{message=string,data=entity.DataExports} "Success"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/data-exports [get]
+func (h *DataExportHandler) GetExports(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	accountId, ok := ctx.Value(appconstant.AccountIdCtxKey).(int64)
+	if !ok {
+		ctx.Error(apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusUnauthorized,
+			ResponseMessage: http.StatusText(http.StatusUnauthorized),
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	exports, err := h.dataExportService.GetExports(ctxWithTimeout, accountId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *exports)
+}
+
+// DataExport godoc
+// @Summary Download a personal data export
+// @Description Downloads the archive with the token of the emailed link, no bearer token is needed. The token is sent in the body so it stays out of access logs.
+// @Tags data exports
+// @Accept  json
+// @Produce  application/zip
+// @Param request body entity.DownloadDataExportReq true "Download data export request body"
+// @Success 200 {file} file "Archive"
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired download link"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Router /account/data-exports/download [post]
+func (h *DataExportHandler) DownloadExport(ctx *gin.Context) {
+	var req entity.DownloadDataExportReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	archive, err := h.dataExportService.DownloadExport(ctxWithTimeout, req.Token)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.Key))
+	ctx.Data(http.StatusOK, archive.ContentType, archive.Bytes)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/go-helper/dto"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+	"github.com/sirupsen/logrus"
+)
+
+type HealthHandler struct {
+	healthService service.HealthService
+	log           *logrus.Logger
+}
+
+func NewHealthHandler(healthService service.HealthService, log *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		log:           log,
+	}
+}
+
+func respondHealth(ctx *gin.Context, report *entity.HealthReport) {
+	status := http.StatusOK
+	if report.Status != appconstant.HealthStatusOk {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(status, dto.Response{Message: report.Status, Data: report})
+}
+
+// Liveness probe, fails only when the process cannot serve requests
+func (h *HealthHandler) Healthz(ctx *gin.Context) {
+	respondHealth(ctx, h.healthService.Liveness(ctx.Request.Context()))
+}
+
+// Readiness probe, responds 503 when a dependency is unavailable or the server is shutting down
+func (h *HealthHandler) Readyz(ctx *gin.Context) {
+	report := h.healthService.Readiness(ctx.Request.Context())
+
+	for _, dependency := range report.Dependencies {
+		if dependency.Error == "" {
+			continue
+		}
+
+		h.log.WithFields(logrus.Fields{
+			"dependency": dependency.Name,
+			"error":      dependency.Error,
+		}).Warn("dependency unavailable")
+	}
+
+	respondHealth(ctx, report)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+// KeySet publishes the public keys verifying access tokens
+type KeySet interface {
+	Jwks() entity.Jwks
+}
+
+type JwksHandler struct {
+	keySet KeySet
+}
+
+func NewJwksHandler(keySet KeySet) *JwksHandler {
+	return &JwksHandler{
+		keySet: keySet,
+	}
+}
+
+// Jwks godoc
+// @Summary Public keys verifying access tokens
+// @Description JSON Web Key Set of the keys verifying access tokens, by kid. A new key is listed before it signs, and an old one until its tokens expired. Empty with HS512.
+// @Tags well-known
+// @Produce  json
+// @Success 200 {object} entity.Jwks "Success"
+// @Router /.well-known/jwks.json [get]
+func (h *JwksHandler) Jwks(ctx *gin.Context) {
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", appconstant.JwksMaxAgeSeconds))
+
+	ctx.JSON(http.StatusOK, h.keySet.Jwks())
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -36,10 +37,13 @@ func NewTransactionHandler(transctionService service.TransactionService, ctxTime
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Bearer token"  // Bearer token for authentication
+// @Param X-Partner-Key header string false "Api key of the partner booking the transaction, it is called back about the transaction"
 // @Param request body entity.CreateTransactionReq true "Transaction request body"
 // @Success 200 {object} dto.Response{message=string,data=entity.Transaction} "Transaction created successfully"
 // @Failure 400 {object} dto.ErrorResponse "validation error"
-// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized or invalid partner api key"
+// @Failure 403 {object} dto.ErrorResponse "two-factor authentication required, account closed or KYC not approved"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
 // @Router /transaction/create [post]
 func (h *TransactionHandler) CreateTransaction(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/json")
@@ -62,6 +66,8 @@ func (h *TransactionHandler) CreateTransaction(ctx *gin.Context) {
 	}
 
 	req.AccountId = accountId
+	req.IsMfaAuthenticated, _ = ctx.Value(appconstant.IsMfaAuthenticatedCtxKey).(bool)
+	req.PartnerApiKey = ctx.GetHeader(appconstant.PartnerKeyHeader)
 
 	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
 	defer cancel()
@@ -74,3 +80,69 @@ func (h *TransactionHandler) CreateTransaction(ctx *gin.Context) {
 
 	hHelper.ResponseOK(ctx, *transaction)
 }
+
+// Transaction godoc
+// @Summary Cancel a transaction
+// @Description Cancels an approved transaction and calls its partner back. The limit it used is given back.
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Transaction id"
+// @Success 200 {object} dto.Response{message=string,data=entity.Transaction} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Transaction not found"
+// @Failure 409 {object} dto.ErrorResponse "Transaction is not approved"
+// @Router /admin/transactions/{id}/cancel [post]
+func (h *TransactionHandler) CancelTransaction(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	transactionId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	transaction, err := h.transactionService.CancelTransaction(ctxWithTimeout, transactionId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hHelper.ResponseOK(ctx, *transaction)
+}
+
+// Transaction godoc
+// @Summary Pay off a transaction
+// @Description Marks an approved transaction whose installments were all paid as paid off and calls its partner back
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Transaction id"
+// @Success 200 {object} dto.Response{message=string,data=entity.Transaction} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Transaction not found"
+// @Failure 409 {object} dto.ErrorResponse "Transaction is not approved"
+// @Router /admin/transactions/{id}/pay-off [post]
+func (h *TransactionHandler) PayOffTransaction(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	transactionId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	transaction, err := h.transactionService.PayOffTransaction(ctxWithTimeout, transactionId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hHelper.ResponseOK(ctx, *transaction)
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/go-helper/apperror"
+	_ "github.com/michaelyusak/go-helper/dto"
+	"github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+)
+
+type WebhookHandler struct {
+	ctxTimeout     time.Duration
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService, ctxTimeout time.Duration) *WebhookHandler {
+	if ctxTimeout <= 0 {
+		ctxTimeout = 30 * time.Second
+	}
+
+	return &WebhookHandler{
+		webhookService: webhookService,
+		ctxTimeout:     ctxTimeout,
+	}
+}
+
+// Webhook godoc
+// @Summary Create a partner
+// @Description Creates a partner booking transactions for its customers. The api key is only shown once, the partner sends it in the X-Partner-Key header.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param request body entity.CreatePartnerReq true "Create partner request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.CreatedPartner} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/partners [post]
+func (h *WebhookHandler) CreatePartner(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var req entity.CreatePartnerReq
+
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	partner, err := h.webhookService.CreatePartner(ctxWithTimeout, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *partner)
+}
+
+// Webhook godoc
+// @Summary List partners
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Success 200 {object} dto.Response{message=string,data=entity.Partners} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/partners [get]
+func (h *WebhookHandler) GetPartners(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	partners, err := h.webhookService.GetPartners(ctxWithTimeout)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *partners)
+}
+
+// Webhook godoc
+// @Summary Subscribe a webhook
+// @Description Subscribes an url of the partner to events of the transactions it booked. The secret signing the deliveries is only shown once.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Partner id"
+// @Param request body entity.CreatePartnerWebhookReq true "Create webhook request body"
+// @Success 200 {object} dto.Response{message=string,data=entity.CreatedPartnerWebhook} "Success"
+// @Failure 400 {object} dto.ErrorResponse "Bad request"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Partner not found"
+// @Router /admin/partners/{id}/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	partnerId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	var req entity.CreatePartnerWebhookReq
+
+	err = ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	webhook, err := h.webhookService.CreateWebhook(ctxWithTimeout, partnerId, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *webhook)
+}
+
+// Webhook godoc
+// @Summary List the webhooks of a partner
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Partner id"
+// @Success 200 {object} dto.Response{message=string,data=entity.PartnerWebhooks} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/partners/{id}/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	partnerId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	webhooks, err := h.webhookService.GetWebhooks(ctxWithTimeout, partnerId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *webhooks)
+}
+
+// Webhook godoc
+// @Summary Unsubscribe a webhook
+// @Description Deletes the webhook, its pending deliveries are given up as dead
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Partner id"
+// @Param webhook_id path int true "Webhook id"
+// @Success 200 {object} dto.Response{message=string,data=nil} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found or already deleted"
+// @Router /admin/partners/{id}/webhooks/{webhook_id} [delete]
+func (h *WebhookHandler) DeleteWebhook(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	partnerId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	webhookId, err := strconv.ParseInt(ctx.Param("webhook_id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	err = h.webhookService.DeleteWebhook(ctxWithTimeout, partnerId, webhookId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, nil)
+}
+
+// Webhook godoc
+// @Summary List webhook deliveries
+// @Description Lists the webhook deliveries matching the filters, newest first. Filter on status dead for the deliveries given up after too many attempts. Pass next_before_id of a page as before_id to get the next one.
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param status query string false "pending, delivered or dead"
+// @Param partner_id query int false "Id of the partner"
+// @Param before_id query int false "Lists deliveries older than this one"
+// @Param limit query int false "Deliveries per page, 50 by default and 200 at most"
+// @Success 200 {object} dto.Response{message=string,data=entity.WebhookDeliveries} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Router /admin/webhook-deliveries [get]
+func (h *WebhookHandler) GetDeliveries(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	var filter entity.WebhookDeliveryFilter
+
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		ctx.Error(apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[webhook_handler][GetDeliveries][ShouldBindQuery] Error: %s", err.Error()),
+			ResponseMessage: "invalid filter",
+		}))
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	deliveries, err := h.webhookService.GetDeliveries(ctxWithTimeout, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *deliveries)
+}
+
+// Webhook godoc
+// @Summary Redeliver a webhook delivery
+// @Description Sends a delivered or dead delivery again on the next run of the dispatcher, with a fresh count of attempts
+// @Tags admin
+// @Produce  json
+// @Param Authorization header string true "Bearer admin api key"
+// @Param id path int true "Delivery id"
+// @Success 200 {object} dto.Response{message=string,data=entity.WebhookDelivery} "Success"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Delivery not found"
+// @Failure 409 {object} dto.ErrorResponse "Delivery is still pending or its webhook was deleted"
+// @Router /admin/webhook-deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json")
+
+	deliveryId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.NotFoundError())
+		return
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Request.Context(), h.ctxTimeout)
+	defer cancel()
+
+	delivery, err := h.webhookService.Redeliver(ctxWithTimeout, deliveryId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	helper.ResponseOK(ctx, *delivery)
+}
@@ -1,7 +1,11 @@
 package helper
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"net/http"
 	"slices"
@@ -17,6 +21,23 @@ var allowedMimeTypes = map[string]string{
 	"text/plain":      ".txt",
 }
 
+// Largest image accepted in pixels, decoding it takes 4 bytes per pixel whatever the size of the file
+const MaxImagePixels = 25_000_000
+
+// ValidateImageDimensions reads the header of an image and rejects one larger than MaxImagePixels before it is decoded
+func ValidateImageDimensions(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode image config: %w", err)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return fmt.Errorf("image dimensions %vx%v exceeded limit of %v pixels", config.Width, config.Height, MaxImagePixels)
+	}
+
+	return nil
+}
+
 // Naive check for embedded script content
 func hasMaliciousContent(data []byte) bool {
 	content := strings.ToLower(string(data))
@@ -43,6 +64,13 @@ func ValidateFileBytes(data []byte, allowedExt []string) (string, error) {
 		}
 	}
 
+	if strings.HasPrefix(contentType, "image/") {
+		err := ValidateImageDimensions(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+	}
+
 	return ext, nil
 }
 
@@ -81,5 +109,16 @@ func ValidateFileMultipart(media entity.Media, allowedExt []string) (string, err
 		}
 	}
 
+	if strings.HasPrefix(contentType, "image/") {
+		err := ValidateImageDimensions(media.File)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := media.File.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to rewind file: %w", err)
+		}
+	}
+
 	return ext, nil
 }
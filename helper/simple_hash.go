@@ -3,6 +3,7 @@ package helper
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 )
 
 func HashSHA256(str string) string {
@@ -10,3 +11,13 @@ func HashSHA256(str string) string {
 
 	return hex.EncodeToString(hash[:])
 }
+
+// Media key of a KYC photo. The first submission keeps the original key format,
+// later submissions get their own key so photos of prior versions are kept.
+func KycPhotoKey(accountId int64, tag string, version int) string {
+	if version <= 1 {
+		return HashSHA256(fmt.Sprintf("%v%s", accountId, tag))
+	}
+
+	return HashSHA256(fmt.Sprintf("%v%s%v", accountId, tag, version))
+}
@@ -0,0 +1,202 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	keyFileExt = ".pem"
+	// Generated kids start with their creation time, other kids are dated by the modification time of their file
+	kidTimeLayout = "20060102T150405Z"
+	rsaKeyBits    = 2048
+)
+
+func newKid(now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return "", fmt.Errorf("[keyring][newKid][rand.Read] error: %w", err)
+	}
+
+	return now.UTC().Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix), nil
+}
+
+func keyPath(dir, kid string) string {
+	return filepath.Join(dir, kid+keyFileExt)
+}
+
+// loadKeys reads every PKCS #8 private key of dir, which must all match the algorithm
+func loadKeys(dir, algorithm string) ([]key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[keyring][loadKeys][os.ReadDir] error: %w", err)
+	}
+
+	var keys []key
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != keyFileExt {
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, keyFileExt)
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			// Deleted by another instance since it was listed
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, fmt.Errorf("[keyring][loadKeys][os.ReadFile] error: %w | kid: %s", err, kid)
+		}
+
+		private, err := parsePrivateKey(data, algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("[keyring][loadKeys][parsePrivateKey] error: %w | kid: %s", err, kid)
+		}
+
+		createdAt, err := time.Parse(kidTimeLayout, strings.SplitN(kid, "-", 2)[0])
+		if err != nil {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, fmt.Errorf("[keyring][loadKeys][entry.Info] error: %w | kid: %s", err, kid)
+			}
+
+			createdAt = info.ModTime()
+		}
+
+		keys = append(keys, key{
+			kid:       kid,
+			createdAt: createdAt,
+			private:   private,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].createdAt.Equal(keys[j].createdAt) {
+			return keys[i].kid < keys[j].kid
+		}
+
+		return keys[i].createdAt.Before(keys[j].createdAt)
+	})
+
+	return keys, nil
+}
+
+func parsePrivateKey(data []byte, algorithm string) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM file")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("[x509.ParsePKCS8PrivateKey] error: %w", err)
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == AlgorithmRS256 {
+			return private, nil
+		}
+
+	case ed25519.PrivateKey:
+		if algorithm == AlgorithmEdDSA {
+			return private, nil
+		}
+	}
+
+	return nil, fmt.Errorf("key of type %T does not match algorithm %s", parsed, algorithm)
+}
+
+// generateKey writes a new key of the algorithm, through a temporary file so other instances never read it partially
+func generateKey(dir, algorithm string, now time.Time) (key, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+
+	default:
+		err = fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey] error: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][x509.MarshalPKCS8PrivateKey] error: %w", err)
+	}
+
+	kid, err := newKid(now)
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][newKid] error: %w", err)
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][os.MkdirAll] error: %w", err)
+	}
+
+	file, err := os.CreateTemp(dir, "."+kid+"-*")
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][os.CreateTemp] error: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	err = pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err != nil {
+		file.Close()
+		return key{}, fmt.Errorf("[keyring][generateKey][pem.Encode] error: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][file.Close] error: %w", err)
+	}
+
+	err = os.Rename(file.Name(), keyPath(dir, kid))
+	if err != nil {
+		return key{}, fmt.Errorf("[keyring][generateKey][os.Rename] error: %w", err)
+	}
+
+	return key{
+		kid:       kid,
+		createdAt: now.UTC().Truncate(time.Second),
+		private:   private,
+	}, nil
+}
+
+func deleteKey(dir, kid string) error {
+	err := os.Remove(keyPath(dir, kid))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("[keyring][deleteKey][os.Remove] error: %w", err)
+	}
+
+	return nil
+}
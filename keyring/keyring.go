@@ -0,0 +1,298 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+const (
+	AlgorithmHS512 = "HS512"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+type Opt struct {
+	Issuer    string
+	Algorithm string
+	// Signs with HS512. With an asymmetric algorithm, tokens signed with it are still accepted until it is removed.
+	Secret []byte
+	// Private keys as <kid>.pem, shared by every instance
+	Dir string
+	// A new key is generated once the newest key is this old, 0 only generates the first key
+	RotationInterval time.Duration
+	// A new key is published this long before it signs, so verifiers have fetched it by then
+	PublishAhead time.Duration
+	// A key keeps verifying this long after the next key started signing, then it is deleted
+	Retention time.Duration
+}
+
+type key struct {
+	kid       string
+	createdAt time.Time
+	private   crypto.Signer
+}
+
+// Keyring signs tokens with the current key of the algorithm and verifies them with any key not yet retired.
+// It implements the JWTHelper of go-helper, with the same claims, so verifying a token does not depend on the algorithm.
+type Keyring struct {
+	opt    Opt
+	method jwt.SigningMethod
+	now    func() time.Time
+
+	mu sync.RWMutex
+	// Sorted by creation, oldest first
+	keys []key
+}
+
+func New(opt Opt) (*Keyring, error) {
+	return newKeyring(opt, time.Now)
+}
+
+func newKeyring(opt Opt, now func() time.Time) (*Keyring, error) {
+	k := &Keyring{
+		opt: opt,
+		now: now,
+	}
+
+	switch opt.Algorithm {
+	case AlgorithmHS512:
+		k.method = jwt.SigningMethodHS512
+
+		if len(opt.Secret) == 0 {
+			return nil, errors.New("[keyring][New] a secret is required for HS512")
+		}
+
+		return k, nil
+
+	case AlgorithmRS256:
+		k.method = jwt.SigningMethodRS256
+
+	case AlgorithmEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+
+	default:
+		return nil, fmt.Errorf("[keyring][New] unsupported algorithm %q", opt.Algorithm)
+	}
+
+	err := k.Rotate(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("[keyring][New][Rotate] error: %w", err)
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) IsAsymmetric() bool {
+	return k.opt.Algorithm != AlgorithmHS512
+}
+
+// signingKey is the newest key published for long enough, or the oldest key while none is
+func (k *Keyring) signingKey(keys []key, now time.Time) (key, bool) {
+	if len(keys) == 0 {
+		return key{}, false
+	}
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].createdAt.Add(k.opt.PublishAhead).After(now) {
+			return keys[i], true
+		}
+	}
+
+	return keys[0], true
+}
+
+// isRetired reports whether the key at i stopped verifying, which is Retention after the next key started signing
+func (k *Keyring) isRetired(keys []key, i int, now time.Time) bool {
+	if i+1 >= len(keys) {
+		return false
+	}
+
+	return !keys[i+1].createdAt.Add(k.opt.PublishAhead + k.opt.Retention).After(now)
+}
+
+// Rotate reloads the keys written by every instance, generates a key when the newest one is due for rotation and
+// deletes retired keys. It does nothing for HS512.
+func (k *Keyring) Rotate(ctx context.Context) error {
+	if !k.IsAsymmetric() {
+		return nil
+	}
+
+	now := k.now()
+
+	keys, err := loadKeys(k.opt.Dir, k.opt.Algorithm)
+	if err != nil {
+		return fmt.Errorf("[keyring][Rotate][loadKeys] error: %w", err)
+	}
+
+	isDue := len(keys) == 0 || (k.opt.RotationInterval > 0 && !keys[len(keys)-1].createdAt.Add(k.opt.RotationInterval).After(now))
+
+	if isDue {
+		generated, err := generateKey(k.opt.Dir, k.opt.Algorithm, now)
+		if err != nil {
+			return fmt.Errorf("[keyring][Rotate][generateKey] error: %w", err)
+		}
+
+		keys = append(keys, generated)
+	}
+
+	active := keys[:0:0]
+
+	for i, key := range keys {
+		if !k.isRetired(keys, i, now) {
+			active = append(active, key)
+			continue
+		}
+
+		err = deleteKey(k.opt.Dir, key.kid)
+		if err != nil {
+			return fmt.Errorf("[keyring][Rotate][deleteKey] error: %w | kid: %s", err, key.kid)
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = active
+	k.mu.Unlock()
+
+	return nil
+}
+
+// Generate adds a key ahead of the schedule, e.g. when a key may be compromised. Like any new key, it is published
+// PublishAhead before it signs.
+func (k *Keyring) Generate(ctx context.Context) (string, error) {
+	if !k.IsAsymmetric() {
+		return "", errors.New("[keyring][Generate] HS512 has no keys to generate")
+	}
+
+	generated, err := generateKey(k.opt.Dir, k.opt.Algorithm, k.now())
+	if err != nil {
+		return "", fmt.Errorf("[keyring][Generate][generateKey] error: %w", err)
+	}
+
+	err = k.Rotate(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[keyring][Generate][Rotate] error: %w", err)
+	}
+
+	return generated.kid, nil
+}
+
+func (k *Keyring) CreateAndSign(customClaimBytes []byte, expiredAt int64) (string, error) {
+	token := jwt.NewWithClaims(k.method, jwt.MapClaims{
+		"iss":  k.opt.Issuer,
+		"exp":  expiredAt,
+		"data": string(customClaimBytes),
+	})
+
+	if !k.IsAsymmetric() {
+		return token.SignedString(k.opt.Secret)
+	}
+
+	k.mu.RLock()
+	signing, ok := k.signingKey(k.keys, k.now())
+	k.mu.RUnlock()
+
+	if !ok {
+		return "", errors.New("[keyring][CreateAndSign] no signing key")
+	}
+
+	token.Header["kid"] = signing.kid
+
+	return token.SignedString(signing.private)
+}
+
+func (k *Keyring) verifyingKey(token *jwt.Token) (any, error) {
+	if token.Method.Alg() == AlgorithmHS512 {
+		if len(k.opt.Secret) == 0 {
+			return nil, errors.New("HS512 tokens are not accepted")
+		}
+
+		return k.opt.Secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.kid == kid {
+			return key.private.Public(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+func (k *Keyring) ParseAndVerify(signed string) ([]byte, error) {
+	methods := []string{k.method.Alg()}
+	if k.IsAsymmetric() && len(k.opt.Secret) > 0 {
+		methods = append(methods, AlgorithmHS512)
+	}
+
+	token, err := jwt.Parse(signed, k.verifyingKey,
+		jwt.WithValidMethods(methods),
+		jwt.WithIssuer(k.opt.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(k.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[keyring][ParseAndVerify][jwt.Parse] error: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("[keyring][ParseAndVerify] unexpected claims")
+	}
+
+	data, ok := claims["data"].(string)
+	if !ok {
+		return nil, errors.New("[keyring][ParseAndVerify] missing data claim")
+	}
+
+	return []byte(data), nil
+}
+
+// Jwks lists the public keys of every key not yet retired, including a key published ahead of signing. It is empty for HS512.
+func (k *Keyring) Jwks() entity.Jwks {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := entity.Jwks{
+		Keys: []entity.Jwk{},
+	}
+
+	for _, key := range k.keys {
+		jwk := entity.Jwk{
+			Use: "sig",
+			Alg: k.opt.Algorithm,
+			Kid: key.kid,
+		}
+
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,225 @@
+package keyring
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func testOpt(dir, algorithm string) Opt {
+	return Opt{
+		Issuer:           "test",
+		Algorithm:        algorithm,
+		Dir:              dir,
+		RotationInterval: 30 * 24 * time.Hour,
+		PublishAhead:     time.Hour,
+		Retention:        48 * time.Hour,
+	}
+}
+
+func newTestKeyring(t *testing.T, opt Opt) (*Keyring, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+
+	k, err := newKeyring(opt, func() time.Time { return clock.now })
+	if err != nil {
+		t.Fatalf("newKeyring() error = %v", err)
+	}
+
+	return k, clock
+}
+
+func sign(t *testing.T, k *Keyring, data string) string {
+	t.Helper()
+
+	token, err := k.CreateAndSign([]byte(data), time.Now().Add(time.Hour).UnixMilli())
+	if err != nil {
+		t.Fatalf("CreateAndSign() error = %v", err)
+	}
+
+	return token
+}
+
+func assertVerifies(t *testing.T, k *Keyring, token, want string) {
+	t.Helper()
+
+	data, err := k.ParseAndVerify(token)
+	if err != nil {
+		t.Fatalf("ParseAndVerify() error = %v", err)
+	}
+
+	if string(data) != want {
+		t.Errorf("ParseAndVerify() = %q, want %q", data, want)
+	}
+}
+
+func kids(k *Keyring) []string {
+	var kids []string
+
+	for _, jwk := range k.Jwks().Keys {
+		kids = append(kids, jwk.Kid)
+	}
+
+	return kids
+}
+
+func TestKeyring_HS512(t *testing.T) {
+	opt := testOpt("", AlgorithmHS512)
+	opt.Secret = []byte("secret")
+
+	k, _ := newTestKeyring(t, opt)
+
+	assertVerifies(t, k, sign(t, k, "claims"), "claims")
+
+	if jwks := k.Jwks(); len(jwks.Keys) != 0 {
+		t.Errorf("jwks = %+v, want no key published for HS512", jwks)
+	}
+}
+
+func TestKeyring_Algorithms(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		wantKty   string
+	}{
+		{algorithm: AlgorithmRS256, wantKty: "RSA"},
+		{algorithm: AlgorithmEdDSA, wantKty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			k, _ := newTestKeyring(t, testOpt(t.TempDir(), tt.algorithm))
+
+			assertVerifies(t, k, sign(t, k, "claims"), "claims")
+
+			jwks := k.Jwks()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != tt.wantKty || jwks.Keys[0].Alg != tt.algorithm || jwks.Keys[0].Kid == "" {
+				t.Errorf("jwks = %+v, want one %s key", jwks, tt.wantKty)
+			}
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	k, clock := newTestKeyring(t, testOpt(dir, AlgorithmEdDSA))
+
+	first := kids(k)[0]
+	firstToken := sign(t, k, "first")
+
+	clock.advance(30 * 24 * time.Hour)
+
+	err := k.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	published := kids(k)
+	if len(published) != 2 || published[0] != first {
+		t.Fatalf("kids = %v, want the first key and a new one", published)
+	}
+
+	// Published ahead, the first key still signs
+	if token := sign(t, k, "claims"); !isSignedBy(t, k, token, first) {
+		t.Error("new key signs before it was published for PublishAhead")
+	}
+
+	clock.advance(time.Hour)
+
+	if token := sign(t, k, "claims"); !isSignedBy(t, k, token, published[1]) {
+		t.Error("new key does not sign once published for PublishAhead")
+	}
+
+	assertVerifies(t, k, firstToken, "first")
+
+	clock.advance(48 * time.Hour)
+
+	err = k.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	if got := kids(k); len(got) != 1 || got[0] != published[1] {
+		t.Errorf("kids = %v, want only the new key after retention", got)
+	}
+
+	if _, err := k.ParseAndVerify(firstToken); err == nil {
+		t.Error("token of a retired key verified")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, first+keyFileExt)); !os.IsNotExist(err) {
+		t.Errorf("retired key file stat error = %v, want deleted", err)
+	}
+}
+
+func TestKeyring_SharedDir(t *testing.T) {
+	dir := t.TempDir()
+
+	first, _ := newTestKeyring(t, testOpt(dir, AlgorithmRS256))
+	second, _ := newTestKeyring(t, testOpt(dir, AlgorithmRS256))
+
+	if got, want := kids(second), kids(first); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("kids = %v, want the key of the first instance %v", got, want)
+	}
+
+	assertVerifies(t, second, sign(t, first, "claims"), "claims")
+}
+
+func TestKeyring_LegacySecret(t *testing.T) {
+	legacyOpt := testOpt("", AlgorithmHS512)
+	legacyOpt.Secret = []byte("secret")
+
+	legacy, _ := newTestKeyring(t, legacyOpt)
+	legacyToken := sign(t, legacy, "legacy")
+
+	opt := testOpt(t.TempDir(), AlgorithmRS256)
+
+	k, _ := newTestKeyring(t, opt)
+	if _, err := k.ParseAndVerify(legacyToken); err == nil {
+		t.Error("HS512 token verified without a secret")
+	}
+
+	opt.Secret = []byte("secret")
+
+	k, _ = newTestKeyring(t, opt)
+	assertVerifies(t, k, legacyToken, "legacy")
+}
+
+func TestKeyring_MismatchedKey(t *testing.T) {
+	dir := t.TempDir()
+
+	newTestKeyring(t, testOpt(dir, AlgorithmEdDSA))
+
+	_, err := newKeyring(testOpt(dir, AlgorithmRS256), time.Now)
+	if err == nil {
+		t.Error("newKeyring() error = nil, want error for an EdDSA key with RS256")
+	}
+}
+
+func isSignedBy(t *testing.T, k *Keyring, token, kid string) bool {
+	t.Helper()
+
+	_, err := k.ParseAndVerify(token)
+	if err != nil {
+		t.Fatalf("ParseAndVerify() error = %v", err)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+
+	return parsed.Header["kid"] == kid
+}
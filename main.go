@@ -1,6 +1,10 @@
 package main
 
-import "github.com/michaelyusak/xyz-kredit-plus/server"
+import (
+	"os"
+
+	"github.com/michaelyusak/xyz-kredit-plus/cli"
+)
 
 // @title Your Project API
 // @version 1.0
@@ -9,5 +13,5 @@ import "github.com/michaelyusak/xyz-kredit-plus/server"
 // @BasePath /v1
 // @schemes http
 func main() {
-	server.Init()
+	cli.Run(os.Args[1:])
 }
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kredit_plus"
+
+// Registry holds every metric of the service, served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	accountsRegistered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accounts_registered_total",
+		Help:      "Accounts registered.",
+	})
+
+	kycOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kyc_outcomes_total",
+		Help:      "KYC submissions and reviews by resulting KYC status.",
+	}, []string{"status"})
+
+	transactionsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Transactions created by installment months.",
+	}, []string{"installment_months"})
+
+	limitInsufficientRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "limit_insufficient_rejections_total",
+		Help:      "Transactions rejected due to insufficient limit by installment months.",
+	}, []string{"installment_months"})
+
+	otrBooked = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "otr_booked_total",
+		Help:      "Sum of OTR of every created transaction.",
+	})
+
+	retentionRemoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retention_removed_total",
+		Help:      "Records deleted or anonymised by the retention job by policy.",
+	}, []string{"policy"})
+
+	outboxAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_publish_attempts_total",
+		Help:      "Attempts of the outbox relay to publish a domain event by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_attempts_total",
+		Help:      "Attempts of the webhook dispatcher to deliver an event to a partner by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		accountsRegistered,
+		kycOutcomes,
+		transactionsCreated,
+		limitInsufficientRejections,
+		otrBooked,
+		retentionRemoved,
+		outboxAttempts,
+		webhookDeliveries,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+func ObserveHTTPRequest(method, route string, status int, latency time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(latency.Seconds())
+}
+
+func RecordRegistration() {
+	accountsRegistered.Inc()
+}
+
+func RecordKycOutcome(kycStatus string) {
+	kycOutcomes.WithLabelValues(kycStatus).Inc()
+}
+
+func RecordTransactionCreated(installmentMonths int, otr float64) {
+	transactionsCreated.WithLabelValues(strconv.Itoa(installmentMonths)).Inc()
+	otrBooked.Add(otr)
+}
+
+func RecordLimitInsufficient(installmentMonths int) {
+	limitInsufficientRejections.WithLabelValues(strconv.Itoa(installmentMonths)).Inc()
+}
+
+func RecordRetentionRemoved(policy string, removed int) {
+	retentionRemoved.WithLabelValues(policy).Add(float64(removed))
+}
+
+// RecordOutboxAttempt counts a publish attempt, outcome is published or failed
+func RecordOutboxAttempt(eventType, outcome string) {
+	outboxAttempts.WithLabelValues(eventType, outcome).Inc()
+}
+
+// RecordWebhookDelivery counts a delivery attempt, outcome is delivered, failed or dead
+func RecordWebhookDelivery(eventType, outcome string) {
+	webhookDeliveries.WithLabelValues(eventType, outcome).Inc()
+}
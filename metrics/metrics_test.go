@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// value returns the sum of every series of a counter or the sample count of a histogram
+func value(t *testing.T, name string) float64 {
+	t.Helper()
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	var sum float64
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			if m.GetCounter() != nil {
+				sum += m.GetCounter().GetValue()
+			}
+			if m.GetHistogram() != nil {
+				sum += float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return sum
+}
+
+func TestRecord(t *testing.T) {
+	RecordRegistration()
+	RecordKycOutcome("approved")
+	RecordTransactionCreated(3, 500000)
+	RecordTransactionCreated(1, 250000)
+	RecordLimitInsufficient(4)
+	RecordRetentionRemoved("refresh_tokens", 3)
+	RecordOutboxAttempt("transaction.created", "failed")
+	RecordOutboxAttempt("transaction.created", "published")
+	RecordWebhookDelivery("transaction.approved", "delivered")
+	ObserveHTTPRequest("POST", "/v1/transaction/create", 200, 15*time.Millisecond)
+
+	for name, want := range map[string]float64{
+		"kredit_plus_accounts_registered_total":           1,
+		"kredit_plus_kyc_outcomes_total":                  1,
+		"kredit_plus_transactions_created_total":          2,
+		"kredit_plus_otr_booked_total":                    750000,
+		"kredit_plus_limit_insufficient_rejections_total": 1,
+		"kredit_plus_retention_removed_total":             3,
+		"kredit_plus_outbox_publish_attempts_total":       2,
+		"kredit_plus_webhook_delivery_attempts_total":     1,
+		"kredit_plus_http_request_duration_seconds":       1,
+	} {
+		if got := value(t, name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
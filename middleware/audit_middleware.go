@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	hAppconstant "github.com/michaelyusak/go-helper/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+)
+
+// Audit carries the request ID and the IP address of the client to the audit events recorded by the request.
+// It must run after the request ID middleware.
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithRequest(c.Request.Context(), audit.Request{
+			Id:        c.GetString(hAppconstant.RequestId),
+			IpAddress: c.ClientIP(),
+		}))
+
+		c.Next()
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -10,10 +12,18 @@ import (
 	hDto "github.com/michaelyusak/go-helper/dto"
 	hHelper "github.com/michaelyusak/go-helper/helper"
 	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
 )
 
-func AuthMiddleware(jwtHelper hHelper.JWTHelper) gin.HandlerFunc {
+// SessionChecker tells whether the session an access token was issued with is still signed in
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error)
+}
+
+// AuthMiddleware authorises the access token, rejecting it once its session is revoked, e.g. signed out or after a
+// password change
+func AuthMiddleware(jwtHelper hHelper.JWTHelper, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.Request.Header.Get(hAppconstant.Authorization)
 		t := strings.Split(authHeader, " ")
@@ -47,9 +57,40 @@ func AuthMiddleware(jwtHelper hHelper.JWTHelper) gin.HandlerFunc {
 			return
 		}
 
+		isActive, err := sessions.IsSessionActive(c.Request.Context(), claims.AccountId, claims.SessionKey)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !isActive {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, hDto.ErrorResponse{Message: hAppconstant.MsgUnauthorized})
+			return
+		}
+
 		c.Set(appconstant.AccountIdCtxKey, claims.AccountId)
 		c.Set(appconstant.EmailCtxKey, claims.Email)
 		c.Set(appconstant.IsKycCompletedCtxKey, claims.IsKycCompleted)
+		c.Set(appconstant.IsEmailVerifiedCtxKey, claims.IsEmailVerified)
+		c.Set(appconstant.IsMfaAuthenticatedCtxKey, claims.IsMfaAuthenticated)
+
+		c.Next()
+	}
+}
+
+// AdminAuthMiddleware authorises the admin API with the api key as a bearer token, every request is rejected while the
+// key is empty. The admin is the actor of the changes it makes.
+func AdminAuthMiddleware(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.Request.Header.Get(hAppconstant.Authorization)
+		t := strings.Split(authHeader, " ")
+
+		if apiKey == "" || len(t) != 2 || t[0] != hAppconstant.Bearer || subtle.ConstantTimeCompare([]byte(t[1]), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, hDto.ErrorResponse{Message: hAppconstant.MsgUnauthorized})
+			return
+		}
+
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{Type: appconstant.AuditActorAdmin}))
 
 		c.Next()
 	}
@@ -67,3 +108,17 @@ func KycFilter() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// EmailVerifiedFilter rejects accounts whose email is not verified, tokens issued before verification carry it as unverified
+func EmailVerifiedFilter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isEmailVerified, _ := c.Value(appconstant.IsEmailVerifiedCtxKey).(bool)
+
+		if !isEmailVerified {
+			c.AbortWithStatusJSON(http.StatusForbidden, hDto.ErrorResponse{Message: "email not verified"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	hDto "github.com/michaelyusak/go-helper/dto"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RateLimitKeyByIp        = "ip"
+	RateLimitKeyByAccountId = "account_id"
+)
+
+// RateLimit takes a token from the client's bucket of the group. Keying by account_id needs the auth middleware to run first,
+// requests without an account_id fall back to the ip.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit, keyBy string, log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("%s:%s", group, rateLimitKey(c, keyBy))
+
+		result, err := store.Take(c, key, limit)
+		if err != nil {
+			// fail open, an unavailable store must not take the service down with it
+			log.WithFields(logrus.Fields{
+				"error": fmt.Sprintf("[rate_limit_middleware][RateLimit][store.Take] error: %s", err.Error()),
+				"group": group,
+			}).Warn("rate limit store unavailable")
+
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			retryAfter := max(1, int(math.Ceil(result.RetryAfter.Seconds())))
+
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, hDto.ErrorResponse{Message: "too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context, keyBy string) string {
+	if keyBy == RateLimitKeyByAccountId {
+		if accountId, ok := c.Value(appconstant.AccountIdCtxKey).(int64); ok {
+			return fmt.Sprintf("account_id:%v", accountId)
+		}
+	}
+
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,195 @@
+package migration
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations of every database are kept in their own directory, sql/{database}
+//
+//go:embed sql
+var files embed.FS
+
+// Migration file names follow {version}_{name}.{up|down}.sql, e.g. 000001_create_tables.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Opening of a PostgreSQL dollar quoted string, e.g. $$ or $body$
+var dollarTagPattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+type Migrator interface {
+	Up(ctx context.Context) ([]Migration, error)
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt *int64 `json:"applied_at"`
+}
+
+// Load reads the embedded migrations of a database sorted by version. Every migration must have both up and down file.
+func Load(database string) ([]Migration, error) {
+	dir := path.Join("sql", database)
+
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Load][fs.ReadDir] error: %w | database: %s", err, database)
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, e := range entries {
+		match := fileNamePattern.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("[migration][Load] invalid migration file name: %s", e.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("[migration][Load][strconv.ParseInt] error: %w | file: %s", err, e.Name())
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("[migration][Load][fs.ReadFile] error: %w | file: %s", err, e.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("[migration][Load] duplicate migration version: %v", version)
+		}
+
+		switch match[3] {
+		case "up":
+			m.Up = string(content)
+
+		case "down":
+			m.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("[migration][Load] migration %v_%s must have both up and down file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Split a migration script into statements, the driver does not run multiple statements at once.
+// A ; only ends a statement outside of quotes, dollar quotes and comments, comments are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	var stmt strings.Builder
+
+	flush := func() {
+		s := strings.TrimSpace(stmt.String())
+		if s != "" {
+			statements = append(statements, s)
+		}
+
+		stmt.Reset()
+	}
+
+	for i := 0; i < len(script); {
+		rest := script[i:]
+
+		switch {
+		case rest[0] == ';':
+			flush()
+			i++
+
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			i += end
+
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+
+			stmt.WriteByte(' ')
+			i += end
+
+		case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+			n := quotedLength(rest)
+
+			stmt.WriteString(rest[:n])
+			i += n
+
+		case rest[0] == '$' && dollarTagPattern.MatchString(rest):
+			tag := dollarTagPattern.FindString(rest)
+
+			n := len(rest)
+			if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+				n = len(tag) + end + len(tag)
+			}
+
+			stmt.WriteString(rest[:n])
+			i += n
+
+		default:
+			stmt.WriteByte(rest[0])
+			i++
+		}
+	}
+
+	flush()
+
+	return statements
+}
+
+// Length of the quoted string or identifier s starts with, a doubled quote or a backslash escapes the next character
+func quotedLength(s string) int {
+	quote := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			script: "CREATE TABLE a (id INT);\n\nCREATE INDEX idx_a ON a (id);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE INDEX idx_a ON a (id)"},
+		},
+		{
+			name:   "semicolons in quotes",
+			script: "INSERT INTO a VALUES ('a;b', 'it''s;', 'c\\';d');\nSELECT \"x;y\", `z;w` FROM a;",
+			want:   []string{"INSERT INTO a VALUES ('a;b', 'it''s;', 'c\\';d')", "SELECT \"x;y\", `z;w` FROM a"},
+		},
+		{
+			name:   "comments",
+			script: "-- drop a; first\nDROP TABLE a; -- trailing;\n/* block;\ncomment */ DROP TABLE b;\n-- last comment",
+			want:   []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name:   "dollar quotes",
+			script: "CREATE FUNCTION f() RETURNS INT AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql;\nDO $$ BEGIN PERFORM 1; END $$;",
+			want:   []string{"CREATE FUNCTION f() RETURNS INT AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "DO $$ BEGIN PERFORM 1; END $$"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.script)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus_BeforeFirstRun(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigratorSqlite(db)
+	if err != nil {
+		t.Fatalf("NewMigratorSqlite() error = %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("status = %+v, want pending before the first run", status)
+		}
+	}
+
+	// Status only reads, the table is created by the first run
+	hasTable, err := dialectSqlite{}.hasMigrationsTable(ctx, db)
+	if err != nil || hasTable {
+		t.Fatalf("hasMigrationsTable() = %v, %v, want schema_migrations not created by Status", hasTable, err)
+	}
+
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if len(statuses) == 0 || len(statuses) != len(migrator.migrations) {
+		t.Fatalf("statuses = %+v, want one per migration", statuses)
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("status = %+v, want applied after Up", status)
+		}
+	}
+}
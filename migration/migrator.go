@@ -0,0 +1,256 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// dialect holds what differs between databases when migrating
+type dialect interface {
+	name() string
+	// lock takes the lock held for a whole run so only one runner migrates at a time
+	lock(ctx context.Context, conn *sql.Conn) error
+	unlock(conn *sql.Conn)
+	// bind rewrites ? placeholders of a query to the placeholders of the database
+	bind(query string) string
+	// transactional databases run every migration in a transaction together with recording its version
+	transactional() bool
+	// hasMigrationsTable reports whether schema_migrations was created, without creating it
+	hasMigrationsTable(ctx context.Context, db repository.DBTX) (bool, error)
+}
+
+type sqlMigrator struct {
+	db         *sql.DB
+	migrations []Migration
+	dialect    dialect
+}
+
+func NewMigrator(driver repository.Driver, db *sql.DB) (*sqlMigrator, error) {
+	switch driver {
+	case repository.DriverMysql:
+		return NewMigratorMysql(db)
+
+	case repository.DriverPostgres:
+		return NewMigratorPostgres(db)
+
+	case repository.DriverSqlite:
+		return NewMigratorSqlite(db)
+	}
+
+	return nil, fmt.Errorf("[migration][NewMigrator] unsupported database: %s", driver)
+}
+
+func newSqlMigrator(db *sql.DB, dialect dialect) (*sqlMigrator, error) {
+	migrations, err := Load(dialect.name())
+	if err != nil {
+		return nil, fmt.Errorf("[Load] error: %w", err)
+	}
+
+	return &sqlMigrator{
+		db:         db,
+		migrations: migrations,
+		dialect:    dialect,
+	}, nil
+}
+
+// Every step of a run uses the returned connection, since locks may belong to a connection
+func (m *sqlMigrator) lock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[db.Conn] error: %w", err)
+	}
+
+	err = m.dialect.lock(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at BIGINT NOT NULL
+		)
+	`)
+	if err != nil {
+		m.unlock(conn)
+		return nil, fmt.Errorf("[CREATE TABLE schema_migrations] error: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (m *sqlMigrator) unlock(conn *sql.Conn) {
+	m.dialect.unlock(conn)
+	conn.Close()
+}
+
+func (m *sqlMigrator) applied(ctx context.Context, dbtx repository.DBTX) (map[int64]int64, error) {
+	rows, err := dbtx.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]int64{}
+
+	for rows.Next() {
+		var version, appliedAt int64
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("[rows.Scan] error: %w", err)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[rows.Err] error: %w", err)
+	}
+
+	return applied, nil
+}
+
+// Run the statements of a script, then record or remove the version. On databases without transactional DDL
+// statements run one by one and the version is only recorded after the last one succeeded.
+func (m *sqlMigrator) run(ctx context.Context, conn *sql.Conn, script, record string, args ...any) (err error) {
+	var dbtx repository.DBTX = conn
+
+	if m.dialect.transactional() {
+		var tx *sql.Tx
+
+		tx, err = conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("[BeginTx] error: %w", err)
+		}
+
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+
+			err = tx.Commit()
+		}()
+
+		dbtx = tx
+	}
+
+	for _, stmt := range splitStatements(script) {
+		_, err = dbtx.ExecContext(ctx, stmt)
+		if err != nil {
+			return fmt.Errorf("[ExecContext] error: %w | statement: %s", err, stmt)
+		}
+	}
+
+	_, err = dbtx.ExecContext(ctx, m.dialect.bind(record), args...)
+	if err != nil {
+		return fmt.Errorf("[ExecContext] error: %w | statement: %s", err, record)
+	}
+
+	return nil
+}
+
+func (m *sqlMigrator) Up(ctx context.Context) ([]Migration, error) {
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Up][lock] error: %w", err)
+	}
+	defer m.unlock(conn)
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Up][applied] error: %w", err)
+	}
+
+	var done []Migration
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = m.run(ctx, conn, migration.Up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			migration.Version, migration.Name, time.Now().UnixMilli())
+		if err != nil {
+			return done, fmt.Errorf("[migration][Up][run] error: %w | version: %v", err, migration.Version)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func (m *sqlMigrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Down][lock] error: %w", err)
+	}
+	defer m.unlock(conn)
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Down][applied] error: %w", err)
+	}
+
+	var done []Migration
+
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err = m.run(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("[migration][Down][run] error: %w | version: %v", err, migration.Version)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status only reads schema_migrations, without the lock nor creating the table, so checking it does not wait for
+// a running migration. Before the first run every migration is pending.
+func (m *sqlMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	hasTable, err := m.dialect.hasMigrationsTable(ctx, m.db)
+	if err != nil {
+		return nil, fmt.Errorf("[migration][Status][hasMigrationsTable] error: %w", err)
+	}
+
+	applied := map[int64]int64{}
+
+	if hasTable {
+		applied, err = m.applied(ctx, m.db)
+		if err != nil {
+			return nil, fmt.Errorf("[migration][Status][applied] error: %w", err)
+		}
+	}
+
+	var statuses []MigrationStatus
+
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,68 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+const (
+	mysqlLockName           = "schema_migrations"
+	mysqlLockTimeoutSeconds = 60
+)
+
+type dialectMysql struct{}
+
+func NewMigratorMysql(db *sql.DB) (*sqlMigrator, error) {
+	migrator, err := newSqlMigrator(db, dialectMysql{})
+	if err != nil {
+		return nil, fmt.Errorf("[migration][NewMigratorMysql][newSqlMigrator] error: %w", err)
+	}
+
+	return migrator, nil
+}
+
+func (dialectMysql) name() string {
+	return "mysql"
+}
+
+// MySQL named locks belong to a connection and are released when it is closed
+func (dialectMysql) lock(ctx context.Context, conn *sql.Conn) error {
+	var acquired sql.NullInt64
+
+	err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, mysqlLockName, mysqlLockTimeoutSeconds).Scan(&acquired)
+	if err != nil {
+		return fmt.Errorf("[GET_LOCK] error: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("timeout waiting for migration lock held by another runner")
+	}
+
+	return nil
+}
+
+func (dialectMysql) unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, mysqlLockName)
+}
+
+func (dialectMysql) bind(query string) string {
+	return query
+}
+
+// MySQL commits DDL implicitly
+func (dialectMysql) transactional() bool {
+	return false
+}
+
+func (dialectMysql) hasMigrationsTable(ctx context.Context, db repository.DBTX) (bool, error) {
+	var count int64
+
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("[QueryRowContext] error: %w", err)
+	}
+
+	return count > 0, nil
+}
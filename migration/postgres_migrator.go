@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// Key of the advisory lock, any constant shared by every runner works
+const postgresLockKey = 7265706
+
+type dialectPostgres struct{}
+
+func NewMigratorPostgres(db *sql.DB) (*sqlMigrator, error) {
+	migrator, err := newSqlMigrator(db, dialectPostgres{})
+	if err != nil {
+		return nil, fmt.Errorf("[migration][NewMigratorPostgres][newSqlMigrator] error: %w", err)
+	}
+
+	return migrator, nil
+}
+
+func (dialectPostgres) name() string {
+	return "postgres"
+}
+
+// Session advisory locks belong to a connection and are released when it is closed
+func (dialectPostgres) lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresLockKey)
+	if err != nil {
+		return fmt.Errorf("[pg_advisory_lock] error: %w", err)
+	}
+
+	return nil
+}
+
+func (dialectPostgres) unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresLockKey)
+}
+
+func (dialectPostgres) bind(query string) string {
+	var sb strings.Builder
+
+	n := 0
+
+	for _, r := range query {
+		if r != '?' {
+			sb.WriteRune(r)
+			continue
+		}
+
+		n++
+		sb.WriteString("$" + strconv.Itoa(n))
+	}
+
+	return sb.String()
+}
+
+func (dialectPostgres) transactional() bool {
+	return true
+}
+
+func (dialectPostgres) hasMigrationsTable(ctx context.Context, db repository.DBTX) (bool, error) {
+	var count int64
+
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("[QueryRowContext] error: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -1,10 +1,4 @@
-DROP TABLE IF EXISTS accounts;
-DROP TABLE IF EXISTS consumers;
-DROP TABLE IF EXISTS refresh_tokens;
-DROP TABLE IF EXISTS accounts_limits;
-DROP TABLE IF EXISTS transactions;
-
-CREATE TABLE accounts (
+CREATE TABLE IF NOT EXISTS accounts (
     account_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     email VARCHAR(255) NOT NULL,
     password VARCHAR(255) NOT NULL,
@@ -14,7 +8,7 @@ CREATE TABLE accounts (
     INDEX idx_account_email (email)
 );
 
-CREATE TABLE consumers (
+CREATE TABLE IF NOT EXISTS consumers (
     consumer_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     account_id BIGINT NOT NULL,
     identity_number VARCHAR(100) NOT NULL,
@@ -33,7 +27,7 @@ CREATE TABLE consumers (
     INDEX idx_consumer_account_id (account_id)
 );
 
-CREATE TABLE refresh_tokens (
+CREATE TABLE IF NOT EXISTS refresh_tokens (
     refresh_token_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     refresh_token VARCHAR(500) NOT NULL DEFAULT '',
     account_id BIGINT NOT NULL,
@@ -43,7 +37,7 @@ CREATE TABLE refresh_tokens (
     INDEX idx_refresh_token (refresh_token)
 );
 
-CREATE TABLE account_limits (
+CREATE TABLE IF NOT EXISTS account_limits (
     account_limit_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     account_id BIGINT NOT NULL,
     account_limit_1_m FLOAT NOT NULL,
@@ -56,7 +50,7 @@ CREATE TABLE account_limits (
     INDEX idx_account_limit_account_id (account_id)
 );
 
-CREATE TABLE transactions (
+CREATE TABLE IF NOT EXISTS transactions (
     transaction_id BIGINT PRIMARY KEY AUTO_INCREMENT,
     account_id BIGINT NOT NULL,
     contact_number VARCHAR(255) NOT NULL,
@@ -70,3 +64,4 @@ CREATE TABLE transactions (
     deleted_at BIGINT DEFAULT NULL,
     INDEX idx_transaction_account_id (account_id)
 );
+
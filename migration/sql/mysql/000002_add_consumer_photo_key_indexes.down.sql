@@ -0,0 +1,3 @@
+ALTER TABLE consumers
+    DROP INDEX idx_consumer_identity_card_photo_key,
+    DROP INDEX idx_consumer_selfie_photo_key;
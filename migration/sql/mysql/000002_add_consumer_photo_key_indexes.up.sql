@@ -0,0 +1,3 @@
+ALTER TABLE consumers
+    ADD INDEX idx_consumer_identity_card_photo_key (identity_card_photo_key),
+    ADD INDEX idx_consumer_selfie_photo_key (selfie_photo_key);
@@ -0,0 +1,23 @@
+ALTER TABLE consumers
+    ADD COLUMN kyc_status VARCHAR(20) NOT NULL DEFAULT 'approved' AFTER selfie_photo_key,
+    ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER kyc_status;
+
+CREATE TABLE consumer_histories (
+    consumer_history_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    consumer_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    identity_number VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    legal_name VARCHAR(255) NOT NULL,
+    place_of_birth VARCHAR(100) NOT NULL,
+    date_of_birth VARCHAR(50) NOT NULL,
+    salary BIGINT NOT NULL,
+    identity_card_photo_key VARCHAR(255) NOT NULL,
+    selfie_photo_key VARCHAR(255) NOT NULL,
+    kyc_status VARCHAR(20) NOT NULL,
+    created_at BIGINT NOT NULL,
+    INDEX idx_consumer_history_consumer_id (consumer_id),
+    INDEX idx_consumer_history_identity_card_photo_key (identity_card_photo_key),
+    INDEX idx_consumer_history_selfie_photo_key (selfie_photo_key)
+);
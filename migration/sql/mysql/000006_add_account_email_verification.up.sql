@@ -0,0 +1,6 @@
+ALTER TABLE accounts
+    ADD COLUMN verified_at BIGINT DEFAULT NULL AFTER disabled_at,
+    ADD COLUMN verification_sent_at BIGINT DEFAULT NULL AFTER verified_at;
+
+UPDATE accounts
+    SET verified_at = created_at;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_mfa_recovery_codes;
+
+DROP TABLE IF EXISTS account_mfa;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS account_mfa (
+    account_id BIGINT PRIMARY KEY,
+    secret VARCHAR(255) NOT NULL,
+    confirmed_at BIGINT DEFAULT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS account_mfa_recovery_codes (
+    recovery_code_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_account_mfa_recovery_code_account_id (account_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '' AFTER revoked_at,
+    ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '' AFTER user_agent,
+    ADD COLUMN device_hash VARCHAR(128) NOT NULL DEFAULT '' AFTER ip_address;
@@ -0,0 +1,10 @@
+ALTER TABLE transactions
+    DROP COLUMN due_at;
+
+DROP INDEX idx_account_deleted_at ON accounts;
+
+DROP INDEX idx_account_email_hash ON accounts;
+
+ALTER TABLE accounts
+    DROP COLUMN anonymised_at,
+    DROP COLUMN email_hash;
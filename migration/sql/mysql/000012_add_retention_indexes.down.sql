@@ -0,0 +1,5 @@
+DROP INDEX idx_consumer_history_kyc_status ON consumer_histories;
+
+DROP INDEX idx_consumer_kyc_status ON consumers;
+
+DROP INDEX idx_refresh_token_expired_at ON refresh_tokens;
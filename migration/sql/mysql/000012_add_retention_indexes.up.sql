@@ -0,0 +1,5 @@
+CREATE INDEX idx_refresh_token_expired_at ON refresh_tokens (expired_at);
+
+CREATE INDEX idx_consumer_kyc_status ON consumers (kyc_status);
+
+CREATE INDEX idx_consumer_history_kyc_status ON consumer_histories (kyc_status);
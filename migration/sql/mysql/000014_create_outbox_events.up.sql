@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    outbox_event_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    published_at BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_outbox_event_aggregate (aggregate_type, aggregate_id),
+    INDEX idx_outbox_event_next_attempt_at (next_attempt_at),
+    INDEX idx_outbox_event_published_at (published_at)
+);
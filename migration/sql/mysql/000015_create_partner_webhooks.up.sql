@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS partners (
+    partner_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    api_key_hash CHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_partner_api_key_hash (api_key_hash)
+);
+
+CREATE TABLE IF NOT EXISTS partner_webhooks (
+    partner_webhook_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    partner_id BIGINT NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL,
+    INDEX idx_partner_webhook_partner_id (partner_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    webhook_delivery_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    partner_webhook_id BIGINT NOT NULL,
+    partner_id BIGINT NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    last_status_code INT DEFAULT NULL,
+    delivered_at BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    INDEX idx_webhook_delivery_status (status),
+    INDEX idx_webhook_delivery_next_attempt_at (next_attempt_at),
+    INDEX idx_webhook_delivery_delivered_at (delivered_at)
+);
+
+ALTER TABLE transactions
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'approved' AFTER due_at,
+    ADD COLUMN partner_id BIGINT DEFAULT NULL AFTER status,
+    ADD COLUMN partner_reference VARCHAR(64) NOT NULL DEFAULT '' AFTER partner_id;
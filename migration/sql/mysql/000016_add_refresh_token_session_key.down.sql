@@ -0,0 +1,3 @@
+DROP INDEX idx_refresh_token_session_key ON refresh_tokens;
+
+ALTER TABLE refresh_tokens DROP COLUMN session_key;
@@ -0,0 +1,6 @@
+ALTER TABLE transactions
+    ADD COLUMN installment_months INT NOT NULL DEFAULT 0 AFTER otr;
+
+-- The installment term of existing transactions was not stored, assume the longest one of 4 months like their due_at
+UPDATE transactions
+    SET installment_months = 4;
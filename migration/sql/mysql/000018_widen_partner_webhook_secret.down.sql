@@ -0,0 +1,2 @@
+ALTER TABLE partner_webhooks
+    MODIFY COLUMN secret VARCHAR(128) NOT NULL;
@@ -0,0 +1,3 @@
+-- Secrets are stored encrypted, which is longer than the secret
+ALTER TABLE partner_webhooks
+    MODIFY COLUMN secret VARCHAR(255) NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS account_limits;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS consumers;
+DROP TABLE IF EXISTS accounts;
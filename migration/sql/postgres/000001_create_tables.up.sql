@@ -0,0 +1,71 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    account_id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_email ON accounts (email);
+
+CREATE TABLE IF NOT EXISTS consumers (
+    consumer_id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL,
+    identity_number VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    legal_name VARCHAR(255) NOT NULL,
+    place_of_birth VARCHAR(100) NOT NULL,
+    date_of_birth VARCHAR(50) NOT NULL,
+    salary BIGINT NOT NULL,
+    identity_card_photo_key VARCHAR(255) NOT NULL,
+    selfie_photo_key VARCHAR(255) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_consumer_identity_number ON consumers (identity_number);
+CREATE INDEX IF NOT EXISTS idx_consumer_full_name ON consumers (full_name);
+CREATE INDEX IF NOT EXISTS idx_consumer_account_id ON consumers (account_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    refresh_token_id BIGSERIAL PRIMARY KEY,
+    refresh_token VARCHAR(500) NOT NULL DEFAULT '',
+    account_id BIGINT NOT NULL,
+    expired_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token ON refresh_tokens (refresh_token);
+
+CREATE TABLE IF NOT EXISTS account_limits (
+    account_limit_id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL,
+    account_limit_1_m DOUBLE PRECISION NOT NULL,
+    account_limit_2_m DOUBLE PRECISION NOT NULL,
+    account_limit_3_m DOUBLE PRECISION NOT NULL,
+    account_limit_4_m DOUBLE PRECISION NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_limit_account_id ON account_limits (account_id);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    transaction_id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL,
+    contact_number VARCHAR(255) NOT NULL,
+    otr DOUBLE PRECISION NOT NULL,
+    admin_fee DOUBLE PRECISION NOT NULL,
+    total_installment DOUBLE PRECISION NOT NULL,
+    total_interest DOUBLE PRECISION NOT NULL,
+    asset_name VARCHAR(255) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_account_id ON transactions (account_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_consumer_identity_card_photo_key;
+DROP INDEX IF EXISTS idx_consumer_selfie_photo_key;
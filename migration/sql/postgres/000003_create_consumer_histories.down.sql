@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS consumer_histories;
+
+ALTER TABLE consumers
+    DROP COLUMN version,
+    DROP COLUMN kyc_status;
@@ -0,0 +1,24 @@
+ALTER TABLE consumers
+    ADD COLUMN kyc_status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE consumer_histories (
+    consumer_history_id BIGSERIAL PRIMARY KEY,
+    consumer_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    identity_number VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    legal_name VARCHAR(255) NOT NULL,
+    place_of_birth VARCHAR(100) NOT NULL,
+    date_of_birth VARCHAR(50) NOT NULL,
+    salary BIGINT NOT NULL,
+    identity_card_photo_key VARCHAR(255) NOT NULL,
+    selfie_photo_key VARCHAR(255) NOT NULL,
+    kyc_status VARCHAR(20) NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX idx_consumer_history_consumer_id ON consumer_histories (consumer_id);
+CREATE INDEX idx_consumer_history_identity_card_photo_key ON consumer_histories (identity_card_photo_key);
+CREATE INDEX idx_consumer_history_selfie_photo_key ON consumer_histories (selfie_photo_key);
@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+    DROP COLUMN disabled_at;
@@ -0,0 +1,8 @@
+CREATE TABLE login_attempts (
+    attempt_key VARCHAR(320) PRIMARY KEY,
+    failed_count INT NOT NULL,
+    last_failed_at BIGINT NOT NULL,
+    locked_until BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
@@ -0,0 +1,7 @@
+DROP INDEX idx_refresh_token_account_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN revoked_at;
+
+ALTER TABLE accounts
+    DROP COLUMN password_reset_sent_at;
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    ADD COLUMN device_hash VARCHAR(128) NOT NULL DEFAULT '';
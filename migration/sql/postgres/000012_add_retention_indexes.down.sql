@@ -0,0 +1,5 @@
+DROP INDEX idx_consumer_history_kyc_status;
+
+DROP INDEX idx_consumer_kyc_status;
+
+DROP INDEX idx_refresh_token_expired_at;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    audit_event_id BIGINT PRIMARY KEY,
+    actor_type VARCHAR(16) NOT NULL,
+    actor_id BIGINT DEFAULT NULL,
+    action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id BIGINT DEFAULT NULL,
+    diff TEXT NOT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_actor ON audit_events (actor_type, actor_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_entity ON audit_events (entity_type, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_action ON audit_events (action);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_created_at ON audit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    audit_chain_id INT PRIMARY KEY,
+    last_event_id BIGINT NOT NULL,
+    last_hash CHAR(64) NOT NULL
+);
+
+INSERT INTO audit_chain (audit_chain_id, last_event_id, last_hash) VALUES (1, 0, '');
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    outbox_event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    published_at BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_event_aggregate ON outbox_events (aggregate_type, aggregate_id);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_event_next_attempt_at ON outbox_events (next_attempt_at);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_event_published_at ON outbox_events (published_at);
@@ -0,0 +1,10 @@
+ALTER TABLE transactions
+    DROP COLUMN partner_reference,
+    DROP COLUMN partner_id,
+    DROP COLUMN status;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS partner_webhooks;
+
+DROP TABLE IF EXISTS partners;
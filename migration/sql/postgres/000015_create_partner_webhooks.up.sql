@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS partners (
+    partner_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    api_key_hash CHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_partner_api_key_hash ON partners (api_key_hash);
+
+CREATE TABLE IF NOT EXISTS partner_webhooks (
+    partner_webhook_id BIGSERIAL PRIMARY KEY,
+    partner_id BIGINT NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    deleted_at BIGINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_partner_webhook_partner_id ON partner_webhooks (partner_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    webhook_delivery_id BIGSERIAL PRIMARY KEY,
+    partner_webhook_id BIGINT NOT NULL,
+    partner_id BIGINT NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    last_status_code INT DEFAULT NULL,
+    delivered_at BIGINT DEFAULT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_status ON webhook_deliveries (status);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_delivered_at ON webhook_deliveries (delivered_at);
+
+ALTER TABLE transactions
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'approved',
+    ADD COLUMN partner_id BIGINT DEFAULT NULL,
+    ADD COLUMN partner_reference VARCHAR(64) NOT NULL DEFAULT '';
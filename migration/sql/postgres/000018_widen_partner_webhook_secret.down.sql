@@ -0,0 +1,2 @@
+ALTER TABLE partner_webhooks
+    ALTER COLUMN secret TYPE VARCHAR(128);
@@ -0,0 +1,3 @@
+-- Secrets are stored encrypted, which is longer than the secret
+ALTER TABLE partner_webhooks
+    ALTER COLUMN secret TYPE VARCHAR(255);
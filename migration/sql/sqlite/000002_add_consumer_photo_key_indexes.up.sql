@@ -0,0 +1,2 @@
+CREATE INDEX idx_consumer_identity_card_photo_key ON consumers (identity_card_photo_key);
+CREATE INDEX idx_consumer_selfie_photo_key ON consumers (selfie_photo_key);
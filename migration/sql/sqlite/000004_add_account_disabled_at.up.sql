@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN disabled_at BIGINT DEFAULT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE accounts DROP COLUMN verification_sent_at;
+ALTER TABLE accounts DROP COLUMN verified_at;
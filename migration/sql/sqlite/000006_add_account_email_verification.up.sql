@@ -0,0 +1,4 @@
+ALTER TABLE accounts ADD COLUMN verified_at BIGINT DEFAULT NULL;
+ALTER TABLE accounts ADD COLUMN verification_sent_at BIGINT DEFAULT NULL;
+
+UPDATE accounts SET verified_at = created_at;
@@ -0,0 +1,5 @@
+ALTER TABLE accounts ADD COLUMN password_reset_sent_at BIGINT DEFAULT NULL;
+
+ALTER TABLE refresh_tokens ADD COLUMN revoked_at BIGINT DEFAULT NULL;
+
+CREATE INDEX idx_refresh_token_account_id ON refresh_tokens (account_id);
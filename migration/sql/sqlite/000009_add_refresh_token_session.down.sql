@@ -0,0 +1,5 @@
+ALTER TABLE refresh_tokens DROP COLUMN device_hash;
+
+ALTER TABLE refresh_tokens DROP COLUMN ip_address;
+
+ALTER TABLE refresh_tokens DROP COLUMN user_agent;
@@ -0,0 +1,9 @@
+ALTER TABLE transactions DROP COLUMN due_at;
+
+DROP INDEX idx_account_deleted_at;
+
+DROP INDEX idx_account_email_hash;
+
+ALTER TABLE accounts DROP COLUMN anonymised_at;
+
+ALTER TABLE accounts DROP COLUMN email_hash;
@@ -0,0 +1,12 @@
+ALTER TABLE accounts ADD COLUMN email_hash VARCHAR(128) DEFAULT NULL;
+
+ALTER TABLE accounts ADD COLUMN anonymised_at BIGINT DEFAULT NULL;
+
+CREATE INDEX idx_account_email_hash ON accounts (email_hash);
+
+CREATE INDEX idx_account_deleted_at ON accounts (deleted_at);
+
+ALTER TABLE transactions ADD COLUMN due_at BIGINT NOT NULL DEFAULT 0;
+
+-- The installment term of existing transactions was not stored, assume the longest one of 4 months of 31 days
+UPDATE transactions SET due_at = created_at + 10713600000;
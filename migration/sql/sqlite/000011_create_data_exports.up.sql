@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    data_export_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id BIGINT NOT NULL,
+    requested_by VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    archive_key VARCHAR(128) NOT NULL DEFAULT '',
+    failure VARCHAR(255) NOT NULL DEFAULT '',
+    completed_at BIGINT DEFAULT NULL,
+    expired_at BIGINT DEFAULT NULL,
+    downloaded_at BIGINT DEFAULT NULL,
+    download_count INT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_export_account_id ON data_exports (account_id);
+
+CREATE INDEX IF NOT EXISTS idx_data_export_status ON data_exports (status);
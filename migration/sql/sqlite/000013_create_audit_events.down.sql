@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS audit_chain;
+
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,11 @@
+ALTER TABLE transactions DROP COLUMN partner_reference;
+
+ALTER TABLE transactions DROP COLUMN partner_id;
+
+ALTER TABLE transactions DROP COLUMN status;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS partner_webhooks;
+
+DROP TABLE IF EXISTS partners;
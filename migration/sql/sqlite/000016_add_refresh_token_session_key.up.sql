@@ -0,0 +1,3 @@
+ALTER TABLE refresh_tokens ADD COLUMN session_key VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_refresh_token_session_key ON refresh_tokens (session_key);
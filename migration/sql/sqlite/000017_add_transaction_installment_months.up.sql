@@ -0,0 +1,4 @@
+ALTER TABLE transactions ADD COLUMN installment_months INT NOT NULL DEFAULT 0;
+
+-- The installment term of existing transactions was not stored, assume the longest one of 4 months like their due_at
+UPDATE transactions SET installment_months = 4;
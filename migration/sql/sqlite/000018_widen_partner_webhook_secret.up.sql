@@ -0,0 +1,2 @@
+-- Secrets are stored encrypted, which is longer than the secret. SQLite does not enforce the length of VARCHAR, the
+-- column is kept as is.
@@ -0,0 +1,51 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+type dialectSqlite struct{}
+
+func NewMigratorSqlite(db *sql.DB) (*sqlMigrator, error) {
+	migrator, err := newSqlMigrator(db, dialectSqlite{})
+	if err != nil {
+		return nil, fmt.Errorf("[migration][NewMigratorSqlite][newSqlMigrator] error: %w", err)
+	}
+
+	return migrator, nil
+}
+
+func (dialectSqlite) name() string {
+	return "sqlite"
+}
+
+// SQLite has no named lock. Every migration runs in a transaction holding the write lock of the database,
+// so a concurrent runner fails recording an already applied version and its changes are rolled back.
+func (dialectSqlite) lock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+func (dialectSqlite) unlock(conn *sql.Conn) {}
+
+func (dialectSqlite) bind(query string) string {
+	return query
+}
+
+func (dialectSqlite) transactional() bool {
+	return true
+}
+
+func (dialectSqlite) hasMigrationsTable(ctx context.Context, db repository.DBTX) (bool, error) {
+	var count int64
+
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("[QueryRowContext] error: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type fileNotifier struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+	log  *logrus.Logger
+}
+
+// NewFileNotifier writes every notification as an .eml file into dir instead of sending it, for development
+func NewFileNotifier(dir, from string, log *logrus.Logger) *fileNotifier {
+	return &fileNotifier{
+		dir:  dir,
+		from: from,
+		log:  log,
+	}
+}
+
+func (n *fileNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	now := time.Now()
+
+	name := fmt.Sprintf("%d-%d.eml", now.UnixMilli(), n.seq.Add(1))
+
+	err := os.MkdirAll(n.dir, 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(n.dir, name), message(n.from, notification, now), 0o644)
+	}
+	if err != nil {
+		n.log.WithFields(logrus.Fields{
+			"error":   fmt.Sprintf("[file_notifier][Notify][os.WriteFile] error: %s", err.Error()),
+			"subject": notification.Subject,
+		}).Error("failed to deliver notification")
+
+		return fmt.Errorf("[file_notifier][Notify][os.WriteFile] error: %w", err)
+	}
+
+	return nil
+}
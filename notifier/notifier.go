@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier delivers a notification to the owner of an account. Implementations log delivery failures themselves,
+// since callers usually carry on when a notification cannot be delivered.
+type Notifier interface {
+	Notify(ctx context.Context, notification entity.Notification) error
+}
+
+type logNotifier struct {
+	log *logrus.Logger
+}
+
+// NewLogNotifier writes notifications to the log instead of delivering them, for development
+func NewLogNotifier(log *logrus.Logger) *logNotifier {
+	return &logNotifier{
+		log: log,
+	}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.log.WithFields(logrus.Fields{
+		"recipient": notification.Recipient,
+		"subject":   notification.Subject,
+	}).Info(notification.Body)
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type SmtpOpt struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// Bounds connecting to the server and sending one email, so a server that stops answering does not hold the
+	// request sending it
+	Timeout time.Duration
+}
+
+type smtpNotifier struct {
+	opt SmtpOpt
+	log *logrus.Logger
+}
+
+// NewSmtpNotifier sends notifications as emails, upgrading to TLS when the server supports STARTTLS
+func NewSmtpNotifier(opt SmtpOpt, log *logrus.Logger) *smtpNotifier {
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+
+	return &smtpNotifier{
+		opt: opt,
+		log: log,
+	}
+}
+
+func (n *smtpNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	err := n.send(ctx, notification)
+	if err != nil {
+		n.log.WithFields(logrus.Fields{
+			"error":   fmt.Sprintf("[smtp_notifier][Notify][send] error: %s", err.Error()),
+			"subject": notification.Subject,
+		}).Error("failed to deliver notification")
+
+		return fmt.Errorf("[smtp_notifier][Notify][send] error: %w", err)
+	}
+
+	return nil
+}
+
+// send works like smtp.SendMail, with the whole exchange bounded by the timeout
+func (n *smtpNotifier) send(ctx context.Context, notification entity.Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, n.opt.Timeout)
+	defer cancel()
+
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.opt.Host, n.opt.Port))
+	if err != nil {
+		return fmt.Errorf("[net.Dialer.DialContext] error: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return fmt.Errorf("[net.Conn.SetDeadline] error: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, n.opt.Host)
+	if err != nil {
+		return fmt.Errorf("[smtp.NewClient] error: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: n.opt.Host})
+		if err != nil {
+			return fmt.Errorf("[smtp.Client.StartTLS] error: %w", err)
+		}
+	}
+
+	if n.opt.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", n.opt.Username, n.opt.Password, n.opt.Host))
+		if err != nil {
+			return fmt.Errorf("[smtp.Client.Auth] error: %w", err)
+		}
+	}
+
+	// The envelope takes the bare address, the From header keeps the name
+	from := n.opt.From
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
+	}
+
+	err = client.Mail(from)
+	if err != nil {
+		return fmt.Errorf("[smtp.Client.Mail] error: %w", err)
+	}
+
+	err = client.Rcpt(notification.Recipient)
+	if err != nil {
+		return fmt.Errorf("[smtp.Client.Rcpt] error: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("[smtp.Client.Data] error: %w", err)
+	}
+
+	_, err = w.Write(message(n.opt.From, notification, time.Now()))
+	if err != nil {
+		return fmt.Errorf("[smtp.Client.Data][Write] error: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("[smtp.Client.Data][Close] error: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// A server accepting the connection but never answering holds the notification only until the timeout
+func TestSmtpNotifier_Timeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	n := NewSmtpNotifier(SmtpOpt{
+		Host:    host,
+		Port:    port,
+		From:    "XYZ Kredit Plus <no-reply@kredit-plus.xyz>",
+		Timeout: 100 * time.Millisecond,
+	}, log)
+
+	start := time.Now()
+
+	err = n.Notify(context.Background(), entity.Notification{Recipient: "user@example.com", Subject: "subject", Body: "body"})
+	if err == nil {
+		t.Fatal("Notify() error = nil, want the timeout")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Notify() returned after %s, want it bounded by the timeout", elapsed)
+	}
+}
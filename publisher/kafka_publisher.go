@@ -0,0 +1,115 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+const kafkaJsonContentType = "application/vnd.kafka.json.v2+json"
+
+type KafkaOpt struct {
+	// Base url of a REST proxy speaking the Confluent REST Proxy v2 API, such as the Confluent REST Proxy or Redpanda
+	RestProxyUrl string
+	Topic        string
+	Username     string
+	Password     string
+	Timeout      time.Duration
+}
+
+type kafkaPublisher struct {
+	opt    KafkaOpt
+	client *http.Client
+}
+
+// NewKafkaPublisher produces every event to the topic through a Kafka REST proxy. Records are keyed by their aggregate,
+// so the events of an account land on the same partition in order.
+func NewKafkaPublisher(opt KafkaOpt) *kafkaPublisher {
+	return &kafkaPublisher{
+		opt: opt,
+		client: &http.Client{
+			Timeout: opt.Timeout,
+		},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string             `json:"key"`
+	Value entity.DomainEvent `json:"value"`
+}
+
+type kafkaProduceReq struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// The proxy answers 200 even when a record was refused by the broker, the error is reported per record
+type kafkaProduceRes struct {
+	Offsets []struct {
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, event entity.DomainEvent) error {
+	body, err := json.Marshal(kafkaProduceReq{
+		Records: []kafkaRecord{
+			{
+				Key:   fmt.Sprintf("%s:%d", event.AggregateType, event.AggregateId),
+				Value: event,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("[kafka_publisher][Publish][json.Marshal] error: %w | event_id: %v", err, event.Id)
+	}
+
+	endpoint := strings.TrimSuffix(p.opt.RestProxyUrl, "/") + "/topics/" + url.PathEscape(p.opt.Topic)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("[kafka_publisher][Publish][http.NewRequestWithContext] error: %w | event_id: %v", err, event.Id)
+	}
+
+	req.Header.Set("Content-Type", kafkaJsonContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	if p.opt.Username != "" {
+		req.SetBasicAuth(p.opt.Username, p.opt.Password)
+	}
+
+	respBody, err := send(p.client, req, "kafka_publisher", event.Id)
+	if err != nil {
+		return err
+	}
+
+	var res kafkaProduceRes
+
+	err = json.Unmarshal(respBody, &res)
+	if err != nil {
+		return fmt.Errorf("[kafka_publisher][Publish][json.Unmarshal] error: %w | event_id: %v", err, event.Id)
+	}
+
+	if len(res.Offsets) == 0 {
+		return fmt.Errorf("[kafka_publisher][Publish] no offset returned | event_id: %v", event.Id)
+	}
+
+	for _, offset := range res.Offsets {
+		if offset.ErrorCode != nil || offset.Error != nil {
+			message := ""
+			if offset.Error != nil {
+				message = *offset.Error
+			}
+
+			return fmt.Errorf("[kafka_publisher][Publish] record refused: %s | event_id: %v", message, event.Id)
+		}
+	}
+
+	return nil
+}
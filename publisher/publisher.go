@@ -0,0 +1,46 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+// Publisher hands a domain event to a sink. An error means the event may not have been delivered, the relay retries
+// it later, so a sink can receive an event more than once.
+type Publisher interface {
+	Publish(ctx context.Context, event entity.DomainEvent) error
+}
+
+type stdoutPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutPublisher writes every event as a line of JSON to w, for development and for log shippers
+func NewStdoutPublisher(w io.Writer) *stdoutPublisher {
+	return &stdoutPublisher{
+		w: w,
+	}
+}
+
+func (p *stdoutPublisher) Publish(ctx context.Context, event entity.DomainEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("[stdout_publisher][Publish][json.Marshal] error: %w | event_id: %v", err, event.Id)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("[stdout_publisher][Publish][w.Write] error: %w | event_id: %v", err, event.Id)
+	}
+
+	return nil
+}
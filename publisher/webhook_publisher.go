@@ -0,0 +1,79 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type WebhookOpt struct {
+	Url string
+	// Sent as a bearer token when set
+	Token   string
+	Timeout time.Duration
+}
+
+type webhookPublisher struct {
+	opt    WebhookOpt
+	client *http.Client
+}
+
+// NewWebhookPublisher POSTs every event as JSON to the url, any 2xx response means the event was delivered
+func NewWebhookPublisher(opt WebhookOpt) *webhookPublisher {
+	return &webhookPublisher{
+		opt: opt,
+		client: &http.Client{
+			Timeout: opt.Timeout,
+		},
+	}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event entity.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("[webhook_publisher][Publish][json.Marshal] error: %w | event_id: %v", err, event.Id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opt.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("[webhook_publisher][Publish][http.NewRequestWithContext] error: %w | event_id: %v", err, event.Id)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	// Lets the receiver drop an event it already handled
+	req.Header.Set("X-Event-Id", strconv.FormatInt(event.Id, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	if p.opt.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.opt.Token)
+	}
+
+	_, err = send(p.client, req, "webhook_publisher", event.Id)
+
+	return err
+}
+
+// send does the request and returns the start of the response body, a response other than 2xx is an error
+func send(client *http.Client, req *http.Request, name string, eventId int64) ([]byte, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("[%s][Publish][client.Do] error: %w | event_id: %v", name, err, eventId)
+	}
+	defer res.Body.Close()
+
+	// Read even when unused so the connection is reused
+	respBody, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("[%s][Publish] unexpected status %d: %s | event_id: %v", name, res.StatusCode, bytes.TrimSpace(respBody), eventId)
+	}
+
+	return respBody, nil
+}
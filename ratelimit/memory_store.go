@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Idle buckets are removed at most once per sweep interval
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	bucket
+	expireAt time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets: map[string]*memoryBucket{},
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.Burst), updatedAt: now}}
+		s.buckets[key] = b
+	}
+
+	result := b.take(limit, now)
+	b.expireAt = now.Add(fullAfter(limit))
+
+	return result, nil
+}
+
+// sweep removes buckets that are full again, a new bucket starts full so nothing is lost
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if now.After(b.expireAt) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
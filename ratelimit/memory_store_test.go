@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestMemoryStore() (*memoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+
+	store := NewMemoryStore()
+	store.now = func() time.Time { return clock.now }
+
+	return store, clock
+}
+
+func TestMemoryStoreBurstThenRejects(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 0; i < limit.Burst; i++ {
+		result, err := store.Take(context.Background(), "ip:1", limit)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+
+		if !result.Allowed {
+			t.Fatalf("take %d: expected allowed within burst", i)
+		}
+
+		if result.Remaining != limit.Burst-i-1 {
+			t.Fatalf("take %d: expected %d remaining, got %d", i, limit.Burst-i-1, result.Remaining)
+		}
+	}
+
+	result, err := store.Take(context.Background(), "ip:1", limit)
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+
+	if result.Allowed {
+		t.Fatal("expected rejection once the burst is spent")
+	}
+
+	if result.RetryAfter != time.Second {
+		t.Fatalf("expected retry after 1s, got %v", result.RetryAfter)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	store, clock := newTestMemoryStore()
+	limit := Limit{Rate: 2, Burst: 2}
+
+	for i := 0; i < limit.Burst; i++ {
+		store.Take(context.Background(), "ip:1", limit)
+	}
+
+	clock.advance(250 * time.Millisecond)
+
+	result, _ := store.Take(context.Background(), "ip:1", limit)
+	if result.Allowed {
+		t.Fatal("expected rejection with half a token")
+	}
+
+	if result.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("expected retry after 250ms, got %v", result.RetryAfter)
+	}
+
+	clock.advance(250 * time.Millisecond)
+
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	if !result.Allowed {
+		t.Fatal("expected a refilled token to be allowed")
+	}
+
+	clock.advance(time.Hour)
+
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	if !result.Allowed || result.Remaining != limit.Burst-1 {
+		t.Fatalf("expected refill capped at the burst, got %+v", result)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	store.Take(context.Background(), "account_id:1", limit)
+
+	result, _ := store.Take(context.Background(), "account_id:1", limit)
+	if result.Allowed {
+		t.Fatal("expected account 1 to be limited")
+	}
+
+	result, _ = store.Take(context.Background(), "account_id:2", limit)
+	if !result.Allowed {
+		t.Fatal("expected account 2 to have its own bucket")
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	store, clock := newTestMemoryStore()
+	limit := Limit{Rate: 1, Burst: 5}
+
+	store.Take(context.Background(), "ip:1", limit)
+
+	clock.advance(memorySweepInterval + time.Second)
+
+	store.Take(context.Background(), "ip:2", limit)
+
+	if _, ok := store.buckets["ip:1"]; ok {
+		t.Fatal("expected the refilled bucket to be swept")
+	}
+
+	if _, ok := store.buckets["ip:2"]; !ok {
+		t.Fatal("expected the active bucket to be kept")
+	}
+}
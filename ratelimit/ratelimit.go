@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit of a token bucket, the bucket holds up to Burst tokens and refills at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// How long until a token is available, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. The in-memory store limits each instance on its own, a shared store limits all instances together.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of a token bucket, shared by the stores so they compute limits the same way
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// take refills the bucket for the time passed since its last update then takes a token if there is one
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return Result{
+			RetryAfter: time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second)),
+		}
+	}
+
+	b.tokens--
+
+	return Result{
+		Allowed:   true,
+		Remaining: int(b.tokens),
+	}
+}
+
+// fullAfter is how long an idle bucket takes to refill completely, after which it can be forgotten
+func fullAfter(limit Limit) time.Duration {
+	return time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript runs the token bucket atomically on Redis, so every instance shares the same buckets.
+// The bucket expires once it would be full again.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or burst
+local updated_at = tonumber(bucket[2]) or now
+
+if now > updated_at then
+	tokens = math.min(burst, tokens + (now - updated_at) / 1000 * rate)
+	updated_at = now
+end
+
+local allowed = 0
+local retry_after = 0
+
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", updated_at)
+redis.call("PEXPIRE", KEYS[1], ttl)
+
+return {allowed, math.floor(tokens), retry_after}
+`)
+
+type redisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisStore(client redis.Scripter, prefix string) *redisStore {
+	return &redisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	ttl := fullAfter(limit).Milliseconds() + 1
+
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Rate, limit.Burst, time.Now().UnixMilli(), ttl,
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("[ratelimit][redisStore][Take][takeScript.Run] error: %w", err)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+func TestAccountLimitRepository_UpdateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	accountLimitRepo := repository.NewAccountLimitRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+
+	limit, err := accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, false)
+	if err != nil || limit != nil {
+		t.Fatalf("GetAccountLimitByAccountId() without a limit = %+v, %v, want nil", limit, err)
+	}
+
+	err = accountLimitRepo.InsertLimit(ctx, entity.AccountLimit{AccountId: accountId, Limit1M: 100000, Limit2M: 200000, Limit3M: 500000, Limit4M: 700000})
+	if err != nil {
+		t.Fatalf("InsertLimit() error = %v", err)
+	}
+
+	limit, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, true)
+	if err != nil || limit == nil {
+		t.Fatalf("GetAccountLimitByAccountId() = %+v, %v, want the limit", limit, err)
+	}
+
+	limit.Limit2M = 50000
+
+	err = accountLimitRepo.UpdateLimit(ctx, *limit)
+	if err != nil {
+		t.Fatalf("UpdateLimit() error = %v", err)
+	}
+
+	limit, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, false)
+	if err != nil || limit == nil || limit.Limit1M != 100000 || limit.Limit2M != 50000 || limit.Limit4M != 700000 {
+		t.Fatalf("GetAccountLimitByAccountId() after update = %+v, %v, want only the 2 months limit changed", limit, err)
+	}
+
+	err = accountLimitRepo.DeleteLimit(ctx, accountId)
+	if err != nil {
+		t.Fatalf("DeleteLimit() error = %v", err)
+	}
+
+	limit, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, false)
+	if err != nil || limit != nil {
+		t.Errorf("GetAccountLimitByAccountId() after delete = %+v, %v, want nil", limit, err)
+	}
+}
@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// insertAccount inserts an account with the email and returns its id
+func insertAccount(t *testing.T, db *sql.DB, email string) int64 {
+	t.Helper()
+
+	accountId, err := repository.NewAccountRepository(repository.DriverSqlite, db).InsertAccount(context.Background(), entity.Account{Email: email, Password: "hash"})
+	if err != nil {
+		t.Fatalf("InsertAccount() error = %v", err)
+	}
+
+	return accountId
+}
+
+func TestAccountRepository_DisableAccount(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	accountRepo := repository.NewAccountRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+
+	account, err := accountRepo.GetAccountByEmail(ctx, "user@example.com", true)
+	if err != nil || account == nil || account.Id != accountId || account.DisabledAt != nil {
+		t.Fatalf("GetAccountByEmail() = %+v, %v, want the enabled account %v", account, err, accountId)
+	}
+
+	err = accountRepo.DisableAccount(ctx, accountId)
+	if err != nil {
+		t.Fatalf("DisableAccount() error = %v", err)
+	}
+
+	account, err = accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil || account == nil || account.DisabledAt == nil {
+		t.Errorf("GetAccountById() = %+v, %v, want a disabled account", account, err)
+	}
+}
+
+// A closed account holds its email until the hash is cleared, closing it again reports false
+func TestAccountRepository_CloseAccount(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	accountRepo := repository.NewAccountRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+	emailHash := "hash-of-user@example.com"
+
+	isHeld, err := accountRepo.IsEmailHeld(ctx, emailHash)
+	if err != nil || isHeld {
+		t.Errorf("IsEmailHeld() of an open account = %v, %v, want false", isHeld, err)
+	}
+
+	isClosed, err := accountRepo.CloseAccount(ctx, accountId, &emailHash)
+	if err != nil || !isClosed {
+		t.Fatalf("CloseAccount() = %v, %v, want true", isClosed, err)
+	}
+
+	isClosed, err = accountRepo.CloseAccount(ctx, accountId, &emailHash)
+	if err != nil || isClosed {
+		t.Errorf("CloseAccount() of a closed account = %v, %v, want false", isClosed, err)
+	}
+
+	isHeld, err = accountRepo.IsEmailHeld(ctx, emailHash)
+	if err != nil || !isHeld {
+		t.Errorf("IsEmailHeld() of a closed account = %v, %v, want true", isHeld, err)
+	}
+}
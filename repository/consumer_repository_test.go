@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+func TestConsumerRepository_UpdateConsumer(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	consumerRepo := repository.NewConsumerRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+
+	err := consumerRepo.InsertConsumer(ctx, entity.Consumer{
+		AccountId:         accountId,
+		IdentityNumber:    "3201010101010001",
+		FullName:          "user test",
+		LegalName:         "user test",
+		PlaceOfBirth:      "Jakarta",
+		DateOfBirth:       "1990-01-01",
+		Salary:            600000,
+		IdentityCardPhoto: entity.Media{Key: "identity.jpg"},
+		SelfiePhoto:       entity.Media{Key: "selfie.jpg"},
+		KycStatus:         appconstant.KycStatusPendingReview,
+		Version:           1,
+	})
+	if err != nil {
+		t.Fatalf("InsertConsumer() error = %v", err)
+	}
+
+	consumer, err := consumerRepo.GetConsumerByAccountId(ctx, accountId, true)
+	if err != nil || consumer == nil || consumer.IdentityCardPhoto.Key != "identity.jpg" || consumer.KycStatus != appconstant.KycStatusPendingReview {
+		t.Fatalf("GetConsumerByAccountId() = %+v, %v, want the pending consumer", consumer, err)
+	}
+
+	consumer.Salary = 800000
+	consumer.KycStatus = appconstant.KycStatusApproved
+	consumer.Version++
+
+	err = consumerRepo.UpdateConsumer(ctx, *consumer)
+	if err != nil {
+		t.Fatalf("UpdateConsumer() error = %v", err)
+	}
+
+	consumer, err = consumerRepo.GetConsumerByAccountId(ctx, accountId, false)
+	if err != nil || consumer == nil || consumer.Salary != 800000 || consumer.KycStatus != appconstant.KycStatusApproved || consumer.Version != 2 {
+		t.Errorf("GetConsumerByAccountId() after update = %+v, %v, want the approved consumer at version 2", consumer, err)
+	}
+
+	isReferenced, err := consumerRepo.IsMediaKeyReferenced(ctx, "selfie.jpg")
+	if err != nil || !isReferenced {
+		t.Errorf("IsMediaKeyReferenced() of the selfie = %v, %v, want true", isReferenced, err)
+	}
+
+	isReferenced, err = consumerRepo.IsMediaKeyReferenced(ctx, "orphan.jpg")
+	if err != nil || isReferenced {
+		t.Errorf("IsMediaKeyReferenced() of an orphan = %v, %v, want false", isReferenced, err)
+	}
+
+	err = consumerRepo.DeleteConsumer(ctx, accountId)
+	if err != nil {
+		t.Fatalf("DeleteConsumer() error = %v", err)
+	}
+
+	consumer, err = consumerRepo.GetConsumerByAccountId(ctx, accountId, false)
+	if err != nil || consumer != nil {
+		t.Errorf("GetConsumerByAccountId() after delete = %+v, %v, want nil", consumer, err)
+	}
+}
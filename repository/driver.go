@@ -0,0 +1,215 @@
+package repository
+
+// Database the SQL repositories are implemented for
+type Driver string
+
+const (
+	DriverMysql    Driver = "mysql"
+	DriverPostgres Driver = "postgres"
+	DriverSqlite   Driver = "sqlite"
+)
+
+func (d Driver) IsValid() bool {
+	switch d {
+	case DriverMysql, DriverPostgres, DriverSqlite:
+		return true
+	}
+
+	return false
+}
+
+func NewAccountRepository(driver Driver, dbtx DBTX) AccountRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewAccountRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewAccountRepositorySqlite(dbtx)
+	}
+
+	return NewAccountRepositoryMysql(dbtx)
+}
+
+func NewConsumerRepository(driver Driver, dbtx DBTX) ConsumerRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewConsumerRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewConsumerRepositorySqlite(dbtx)
+	}
+
+	return NewConsumerRepositoryMysql(dbtx)
+}
+
+func NewConsumerHistoryRepository(driver Driver, dbtx DBTX) ConsumerHistoryRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewConsumerHistoryRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewConsumerHistoryRepositorySqlite(dbtx)
+	}
+
+	return NewConsumerHistoryRepositoryMysql(dbtx)
+}
+
+func NewRefreshTokenRepository(driver Driver, dbtx DBTX) RefreshTokenRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewRefreshTokenRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewRefreshTokenRepositorySqlite(dbtx)
+	}
+
+	return NewRefreshTokenRepositoryMysql(dbtx)
+}
+
+func NewAccountLimitRepository(driver Driver, dbtx DBTX) AccountLimitRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewAccountLimitRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewAccountLimitRepositorySqlite(dbtx)
+	}
+
+	return NewAccountLimitRepositoryMysql(dbtx)
+}
+
+func NewTransactionRepository(driver Driver, dbtx DBTX) TransactionRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewTransactionRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewTransactionRepositorySqlite(dbtx)
+	}
+
+	return NewTransactionRepositoryMysql(dbtx)
+}
+
+func NewLoginAttemptRepository(driver Driver, dbtx DBTX) LoginAttemptRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewLoginAttemptRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewLoginAttemptRepositorySqlite(dbtx)
+	}
+
+	return NewLoginAttemptRepositoryMysql(dbtx)
+}
+
+func NewAccountMfaRepository(driver Driver, dbtx DBTX) AccountMfaRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewAccountMfaRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewAccountMfaRepositorySqlite(dbtx)
+	}
+
+	return NewAccountMfaRepositoryMysql(dbtx)
+}
+
+func NewDataExportRepository(driver Driver, dbtx DBTX) DataExportRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewDataExportRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewDataExportRepositorySqlite(dbtx)
+	}
+
+	return NewDataExportRepositoryMysql(dbtx)
+}
+
+func NewAuditEventRepository(driver Driver, dbtx DBTX) AuditEventRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewAuditEventRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewAuditEventRepositorySqlite(dbtx)
+	}
+
+	return NewAuditEventRepositoryMysql(dbtx)
+}
+
+func NewOutboxRepository(driver Driver, dbtx DBTX) OutboxRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewOutboxRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewOutboxRepositorySqlite(dbtx)
+	}
+
+	return NewOutboxRepositoryMysql(dbtx)
+}
+
+func NewPartnerRepository(driver Driver, dbtx DBTX) PartnerRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewPartnerRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewPartnerRepositorySqlite(dbtx)
+	}
+
+	return NewPartnerRepositoryMysql(dbtx)
+}
+
+func NewPartnerWebhookRepository(driver Driver, dbtx DBTX) PartnerWebhookRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewPartnerWebhookRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewPartnerWebhookRepositorySqlite(dbtx)
+	}
+
+	return NewPartnerWebhookRepositoryMysql(dbtx)
+}
+
+func NewWebhookDeliveryRepository(driver Driver, dbtx DBTX) WebhookDeliveryRepository {
+	dbtx = traced(driver, dbtx)
+
+	switch driver {
+	case DriverPostgres:
+		return NewWebhookDeliveryRepositoryPostgres(dbtx)
+
+	case DriverSqlite:
+		return NewWebhookDeliveryRepositorySqlite(dbtx)
+	}
+
+	return NewWebhookDeliveryRepositoryMysql(dbtx)
+}
@@ -16,28 +16,159 @@ type DBTX interface {
 
 type AccountRepository interface {
 	GetAccountByEmail(ctx context.Context, email string, forUpdate bool) (*entity.Account, error)
+	GetAccountById(ctx context.Context, accountId int64, forUpdate bool) (*entity.Account, error)
 	InsertAccount(ctx context.Context, account entity.Account) (int64, error)
+	DisableAccount(ctx context.Context, accountId int64) error
+	UpdatePassword(ctx context.Context, accountId int64, password string) error
+	SetVerificationSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error)
+	VerifyEmail(ctx context.Context, accountId, sentAt int64) (bool, error)
+	SetPasswordResetSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error)
+	ResetPassword(ctx context.Context, accountId, sentAt int64, password string) (bool, error)
+	CloseAccount(ctx context.Context, accountId int64, emailHash *string) (bool, error)
+	IsEmailHeld(ctx context.Context, emailHash string) (bool, error)
+	GetAccountIdsToAnonymise(ctx context.Context, closedBefore int64, limit int) ([]int64, error)
+	AnonymiseAccount(ctx context.Context, accountId int64, isEmailHashKept bool) error
 }
 
 type RefreshTokenRepository interface {
-	InsertToken(ctx context.Context, token string, accountId, expiredAt int64) error
+	InsertToken(ctx context.Context, token string, session entity.Session) error
+	RevokeAccountTokens(ctx context.Context, accountId int64) error
+	DeleteAccountTokens(ctx context.Context, accountId int64) error
+	DeleteExpiredTokens(ctx context.Context, expiredBefore int64, limit int) (int64, error)
+	GetActiveSessions(ctx context.Context, accountId int64) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, accountId, sessionId int64) (bool, error)
+	IsNewDevice(ctx context.Context, accountId int64, deviceHash string) (bool, error)
+	IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error)
+}
+
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, key string) (*entity.LoginAttempt, error)
+	IncrementFailedAttempt(ctx context.Context, key string, windowStart int64) (*entity.LoginAttempt, error)
+	LockLoginAttempt(ctx context.Context, key string, lockedUntil int64) error
+	DeleteLoginAttempt(ctx context.Context, key string) error
 }
 
 type ConsumerRepository interface {
 	GetConsumerByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.Consumer, error)
 	InsertConsumer(ctx context.Context, consumerData entity.Consumer) error
+	UpdateConsumer(ctx context.Context, consumerData entity.Consumer) error
+	IsMediaKeyReferenced(ctx context.Context, key string) (bool, error)
+	DeleteConsumer(ctx context.Context, accountId int64) error
+	AnonymiseConsumer(ctx context.Context, accountId int64) error
+	GetRejectedPhotoKeys(ctx context.Context, rejectedBefore int64, limit int) ([]string, error)
+	ClearRejectedPhotoKey(ctx context.Context, key string, rejectedBefore int64) error
+}
+
+type ConsumerHistoryRepository interface {
+	InsertHistory(ctx context.Context, history entity.ConsumerHistory) error
+	AnonymiseHistories(ctx context.Context, accountId int64) error
+	GetHistoriesByAccountId(ctx context.Context, accountId int64) ([]entity.ConsumerHistory, error)
 }
 
 type MediaRepository interface {
 	Store(ctx context.Context, media MediaOpt) error
+	Stage(ctx context.Context, media MediaOpt) error
+	Promote(ctx context.Context, key string) error
+	Discard(ctx context.Context, key string) error
+	Delete(ctx context.Context, key string) error
+	Get(ctx context.Context, key string) (*MediaOpt, error)
+	List(ctx context.Context, staged bool) ([]MediaStat, error)
 }
 
 type AccountLimitRepository interface {
 	GetAccountLimitByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.AccountLimit, error)
 	UpdateLimit(ctx context.Context, accountLimit entity.AccountLimit) error
 	InsertLimit(ctx context.Context, accountLimit entity.AccountLimit) error
+	DeleteLimit(ctx context.Context, accountId int64) error
 }
 
 type TransactionRepository interface {
 	InsertTransaction(ctx context.Context, transaction entity.Transaction) (int64, error)
+	GetTransactionsByAccountId(ctx context.Context, accountId int64) ([]entity.Transaction, error)
+	HasOutstandingTransactions(ctx context.Context, accountId int64) (bool, error)
+	AnonymiseTransactions(ctx context.Context, accountId int64) error
+	GetTransactionById(ctx context.Context, transactionId int64, forUpdate bool) (*entity.Transaction, error)
+	UpdateStatus(ctx context.Context, transactionId int64, status string) error
+}
+
+type AccountMfaRepository interface {
+	GetMfa(ctx context.Context, accountId int64) (*entity.AccountMfa, error)
+	SavePendingMfa(ctx context.Context, accountId int64, secret string) error
+	ConfirmMfa(ctx context.Context, accountId int64) (bool, error)
+	UseTotpStep(ctx context.Context, accountId, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, accountId int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, accountId int64, codeHash string) (bool, error)
+	DeleteMfa(ctx context.Context, accountId int64) error
+}
+
+type DataExportRepository interface {
+	InsertExport(ctx context.Context, export entity.DataExport) (int64, error)
+	GetExportById(ctx context.Context, exportId int64) (*entity.DataExport, error)
+	GetExportsByAccountId(ctx context.Context, accountId int64) ([]entity.DataExport, error)
+	GetExportIdsToProcess(ctx context.Context, staleBefore int64, limit int) ([]int64, error)
+	ClaimExport(ctx context.Context, exportId, staleBefore int64) (bool, error)
+	CompleteExport(ctx context.Context, exportId int64, archiveKey string, expiredAt int64) error
+	FailExport(ctx context.Context, exportId int64, failure string) error
+	RecordDownload(ctx context.Context, exportId int64) error
+	GetExpiredExports(ctx context.Context, now int64, limit int) ([]entity.DataExport, error)
+	ExpireExport(ctx context.Context, exportId int64) error
+}
+
+// AuditEventRepository only appends, events are never updated or deleted
+type AuditEventRepository interface {
+	// LockChainHead reserves the id of the next event and returns the head it is chained to. The head stays locked until
+	// the transaction ends, so events are chained one at a time.
+	LockChainHead(ctx context.Context) (*entity.AuditChainHead, error)
+	// InsertEvent appends the event reserved by LockChainHead and makes it the head of the chain
+	InsertEvent(ctx context.Context, event entity.AuditEvent) error
+	GetEvents(ctx context.Context, filter entity.AuditEventFilter) ([]entity.AuditEvent, error)
+	// GetEventsAfter lists the events following afterId, oldest first
+	GetEventsAfter(ctx context.Context, afterId int64, limit int) ([]entity.AuditEvent, error)
+	GetChainHead(ctx context.Context) (*entity.AuditChainHead, error)
+}
+
+// OutboxRepository keeps the domain events until a relay publishes them
+type OutboxRepository interface {
+	InsertEvent(ctx context.Context, event entity.OutboxEvent) (int64, error)
+	// GetDueEvents lists the unpublished events due at now, oldest first. An event waits while an earlier event of its
+	// aggregate is unpublished.
+	GetDueEvents(ctx context.Context, now int64, limit int) ([]entity.OutboxEvent, error)
+	// ClaimEvent pushes the next attempt of a due event to leaseUntil, reports false when another relay claimed it first
+	ClaimEvent(ctx context.Context, eventId, now, leaseUntil int64) (bool, error)
+	MarkPublished(ctx context.Context, eventId int64) error
+	MarkFailed(ctx context.Context, eventId int64, lastError string, nextAttemptAt int64) error
+	DeletePublishedEvents(ctx context.Context, publishedBefore int64, limit int) (int64, error)
+}
+
+type PartnerRepository interface {
+	InsertPartner(ctx context.Context, partner entity.Partner) (int64, error)
+	GetPartnerById(ctx context.Context, partnerId int64) (*entity.Partner, error)
+	GetPartnerByApiKeyHash(ctx context.Context, apiKeyHash string) (*entity.Partner, error)
+	GetPartners(ctx context.Context) ([]entity.Partner, error)
+}
+
+type PartnerWebhookRepository interface {
+	InsertWebhook(ctx context.Context, webhook entity.PartnerWebhook) (int64, error)
+	// GetWebhookById finds deleted webhooks too, so deliveries to them can be given up
+	GetWebhookById(ctx context.Context, webhookId int64) (*entity.PartnerWebhook, error)
+	GetWebhooksByPartnerId(ctx context.Context, partnerId int64) ([]entity.PartnerWebhook, error)
+	DeleteWebhook(ctx context.Context, partnerId, webhookId int64) (bool, error)
+}
+
+// WebhookDeliveryRepository keeps the deliveries to the partner webhooks until they are delivered or given up
+type WebhookDeliveryRepository interface {
+	InsertDelivery(ctx context.Context, delivery entity.WebhookDelivery) (int64, error)
+	GetDeliveryById(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error)
+	// GetDueDeliveries lists the pending deliveries due at now, oldest first
+	GetDueDeliveries(ctx context.Context, now int64, limit int) ([]entity.WebhookDelivery, error)
+	// ClaimDelivery pushes the next attempt of a due delivery to leaseUntil, reports false when another dispatcher
+	// claimed it first
+	ClaimDelivery(ctx context.Context, deliveryId, now, leaseUntil int64) (bool, error)
+	MarkDelivered(ctx context.Context, deliveryId int64, statusCode int) error
+	MarkFailed(ctx context.Context, deliveryId int64, lastError string, statusCode *int, nextAttemptAt int64) error
+	MarkDead(ctx context.Context, deliveryId int64, lastError string, statusCode *int) error
+	// Redeliver makes a delivered or dead delivery pending again with no attempts, reports false when it is pending
+	Redeliver(ctx context.Context, deliveryId, now int64) (bool, error)
+	DeleteDeliveredDeliveries(ctx context.Context, deliveredBefore int64, limit int) (int64, error)
 }
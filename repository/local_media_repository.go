@@ -2,13 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
 )
 
+const localMediaStagingDir = "staging/"
+
 type mediaRepositoryLocal struct {
 	storagePath string
 }
@@ -26,33 +31,205 @@ type MediaOpt struct {
 	File      *entity.Media
 }
 
-func (r *mediaRepositoryLocal) Store(ctx context.Context, media MediaOpt) error {
+type MediaStat struct {
+	Key       string
+	UpdatedAt int64
+}
+
+func (r *mediaRepositoryLocal) stagingPath() string {
+	return r.storagePath + localMediaStagingDir
+}
+
+func (r *mediaRepositoryLocal) write(dir string, media MediaOpt) error {
 	if media.Key == "" || media.Extension == "" {
-		return fmt.Errorf("[local_media_repository][Store] media key and extension is required")
+		return fmt.Errorf("media key and extension is required")
 	}
 
-	fullPath := r.storagePath + media.Key + media.Extension
+	fullPath := dir + media.Key + media.Extension
 
 	switch {
 	case len(media.Bytes) > 0:
 		if err := os.WriteFile(fullPath, media.Bytes, 0644); err != nil {
-			return fmt.Errorf("[local_media_repository][Store][os.WriteFile] error: %w", err)
+			return fmt.Errorf("[os.WriteFile] error: %w", err)
 		}
 		return nil
 
 	case media.File != nil && media.File.File != nil:
 		dstFile, err := os.Create(fullPath)
 		if err != nil {
-			return fmt.Errorf("[local_media_repository][Store][os.Create] error: %w", err)
+			return fmt.Errorf("[os.Create] error: %w", err)
 		}
 		defer dstFile.Close()
 
 		if _, err := io.Copy(dstFile, media.File.File); err != nil {
-			return fmt.Errorf("[local_media_repository][Store][io.Copy] error: %w", err)
+			return fmt.Errorf("[io.Copy] error: %w", err)
 		}
 		return nil
 
 	default:
-		return fmt.Errorf("[local_media_repository][Store] no file data provided")
+		return fmt.Errorf("no file data provided")
+	}
+}
+
+// find returns the path of the file stored under key in dir, or an empty string if there is none.
+func (r *mediaRepositoryLocal) find(dir, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("media key is required")
 	}
+
+	matches, err := filepath.Glob(dir + key + ".*")
+	if err != nil {
+		return "", fmt.Errorf("[filepath.Glob] error: %w", err)
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+
+	return matches[0], nil
+}
+
+func (r *mediaRepositoryLocal) remove(dir, key string) error {
+	fullPath, err := r.find(dir, key)
+	if err != nil {
+		return err
+	}
+	if fullPath == "" {
+		return nil
+	}
+
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("[os.Remove] error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Store(ctx context.Context, media MediaOpt) error {
+	err := os.MkdirAll(r.storagePath, 0755)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Store][os.MkdirAll] error: %w", err)
+	}
+
+	err = r.write(r.storagePath, media)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Store][write] error: %w | key: %s", err, media.Key)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Stage(ctx context.Context, media MediaOpt) error {
+	err := os.MkdirAll(r.stagingPath(), 0755)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Stage][os.MkdirAll] error: %w", err)
+	}
+
+	err = r.write(r.stagingPath(), media)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Stage][write] error: %w | key: %s", err, media.Key)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Promote(ctx context.Context, key string) error {
+	stagedPath, err := r.find(r.stagingPath(), key)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Promote][find] error: %w | key: %s", err, key)
+	}
+	if stagedPath == "" {
+		return nil
+	}
+
+	// Remove the previous version first, it may have been stored with another extension
+	err = r.remove(r.storagePath, key)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Promote][remove] error: %w | key: %s", err, key)
+	}
+
+	err = os.Rename(stagedPath, r.storagePath+filepath.Base(stagedPath))
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Promote][os.Rename] error: %w | key: %s", err, key)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Discard(ctx context.Context, key string) error {
+	err := r.remove(r.stagingPath(), key)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Discard][remove] error: %w | key: %s", err, key)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Delete(ctx context.Context, key string) error {
+	err := r.remove(r.storagePath, key)
+	if err != nil {
+		return fmt.Errorf("[local_media_repository][Delete][remove] error: %w | key: %s", err, key)
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryLocal) Get(ctx context.Context, key string) (*MediaOpt, error) {
+	fullPath, err := r.find(r.storagePath, key)
+	if err != nil {
+		return nil, fmt.Errorf("[local_media_repository][Get][find] error: %w | key: %s", err, key)
+	}
+	if fullPath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("[local_media_repository][Get][os.ReadFile] error: %w | key: %s", err, key)
+	}
+
+	return &MediaOpt{
+		Key:       key,
+		Extension: filepath.Ext(fullPath),
+		Bytes:     data,
+	}, nil
+}
+
+func (r *mediaRepositoryLocal) List(ctx context.Context, staged bool) ([]MediaStat, error) {
+	dir := r.storagePath
+	if staged {
+		dir = r.stagingPath()
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[local_media_repository][List][os.ReadDir] error: %w", err)
+	}
+
+	var stats []MediaStat
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, fmt.Errorf("[local_media_repository][List][Info] error: %w | name: %s", err, e.Name())
+		}
+
+		stats = append(stats, MediaStat{
+			Key:       strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())),
+			UpdatedAt: info.ModTime().UnixMilli(),
+		})
+	}
+
+	return stats, nil
 }
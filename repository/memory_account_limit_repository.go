@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountLimitRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewAccountLimitRepositoryMemory(store *MemoryStore) *accountLimitRepositoryMemory {
+	return &accountLimitRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *accountLimitRepositoryMemory) GetAccountLimitByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.AccountLimit, error) {
+	var found *entity.AccountLimit
+
+	r.store.read(func() {
+		for _, limit := range r.store.accountLimits {
+			if limit.AccountId == accountId && limit.DeletedAt == nil {
+				found = &limit
+				return
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (r *accountLimitRepositoryMemory) UpdateLimit(ctx context.Context, accountLimit entity.AccountLimit) error {
+	var isFound bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.accountLimits[accountLimit.Id]
+		if !ok {
+			return nil
+		}
+
+		isFound = true
+
+		limit := prev
+		limit.Limit1M = accountLimit.Limit1M
+		limit.Limit2M = accountLimit.Limit2M
+		limit.Limit3M = accountLimit.Limit3M
+		limit.Limit4M = accountLimit.Limit4M
+		limit.UpdatedAt = nowUnixMilli()
+
+		r.store.accountLimits[limit.Id] = limit
+
+		return func() {
+			r.store.accountLimits[prev.Id] = prev
+		}
+	})
+
+	if !isFound {
+		return fmt.Errorf("[memory_account_limit_repository][UpdateLimit] account limit not found | account_limit_id: %v", accountLimit.Id)
+	}
+
+	return nil
+}
+
+func (r *accountLimitRepositoryMemory) InsertLimit(ctx context.Context, accountLimit entity.AccountLimit) error {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		accountLimit.Id = r.store.nextId("account_limits")
+		accountLimit.CreatedAt = now
+		accountLimit.UpdatedAt = now
+		accountLimit.DeletedAt = nil
+
+		r.store.accountLimits[accountLimit.Id] = accountLimit
+
+		return func() {
+			delete(r.store.accountLimits, accountLimit.Id)
+		}
+	})
+
+	return nil
+}
+
+func (r *accountLimitRepositoryMemory) DeleteLimit(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := maps.Clone(r.store.accountLimits)
+
+		now := nowUnixMilli()
+
+		for id, limit := range r.store.accountLimits {
+			if limit.AccountId == accountId && limit.DeletedAt == nil {
+				limit.DeletedAt = &now
+				limit.UpdatedAt = now
+
+				r.store.accountLimits[id] = limit
+			}
+		}
+
+		return func() {
+			r.store.accountLimits = prev
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountMfaRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewAccountMfaRepositoryMemory(store *MemoryStore) *accountMfaRepositoryMemory {
+	return &accountMfaRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *accountMfaRepositoryMemory) GetMfa(ctx context.Context, accountId int64) (*entity.AccountMfa, error) {
+	var found *entity.AccountMfa
+
+	r.store.read(func() {
+		if mfa, ok := r.store.accountMfa[accountId]; ok {
+			found = &mfa
+		}
+	})
+
+	return found, nil
+}
+
+// updateIf updates the enrolment only when it exists and cond holds, reports whether it was updated
+func (r *accountMfaRepositoryMemory) updateIf(accountId int64, cond func(mfa entity.AccountMfa) bool, fn func(mfa *entity.AccountMfa)) bool {
+	var isUpdated bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.accountMfa[accountId]
+		if !ok || !cond(prev) {
+			return nil
+		}
+
+		isUpdated = true
+
+		mfa := prev
+		fn(&mfa)
+		mfa.UpdatedAt = nowUnixMilli()
+
+		r.store.accountMfa[accountId] = mfa
+
+		return func() {
+			r.store.accountMfa[accountId] = prev
+		}
+	})
+
+	return isUpdated
+}
+
+func (r *accountMfaRepositoryMemory) SavePendingMfa(ctx context.Context, accountId int64, secret string) error {
+	r.store.write(r.tx, func() func() {
+		prev, existed := r.store.accountMfa[accountId]
+		if existed && prev.ConfirmedAt != nil {
+			return nil
+		}
+
+		now := nowUnixMilli()
+
+		r.store.accountMfa[accountId] = entity.AccountMfa{
+			AccountId: accountId,
+			Secret:    secret,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		return func() {
+			if existed {
+				r.store.accountMfa[accountId] = prev
+				return
+			}
+
+			delete(r.store.accountMfa, accountId)
+		}
+	})
+
+	return nil
+}
+
+func (r *accountMfaRepositoryMemory) ConfirmMfa(ctx context.Context, accountId int64) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(mfa entity.AccountMfa) bool {
+		return mfa.ConfirmedAt == nil
+	}, func(mfa *entity.AccountMfa) {
+		now := nowUnixMilli()
+		mfa.ConfirmedAt = &now
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountMfaRepositoryMemory) UseTotpStep(ctx context.Context, accountId, step int64) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(mfa entity.AccountMfa) bool {
+		return mfa.LastUsedStep < step
+	}, func(mfa *entity.AccountMfa) {
+		mfa.LastUsedStep = step
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountMfaRepositoryMemory) ReplaceRecoveryCodes(ctx context.Context, accountId int64, codeHashes []string) error {
+	r.store.write(r.tx, func() func() {
+		prev := slices.Clone(r.store.recoveryCodes)
+
+		r.store.recoveryCodes = slices.DeleteFunc(r.store.recoveryCodes, func(code memoryRecoveryCode) bool {
+			return code.AccountId == accountId
+		})
+
+		for _, codeHash := range codeHashes {
+			r.store.recoveryCodes = append(r.store.recoveryCodes, memoryRecoveryCode{
+				AccountId: accountId,
+				CodeHash:  codeHash,
+			})
+		}
+
+		return func() {
+			r.store.recoveryCodes = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *accountMfaRepositoryMemory) UseRecoveryCode(ctx context.Context, accountId int64, codeHash string) (bool, error) {
+	var isUsed bool
+
+	r.store.write(r.tx, func() func() {
+		for i, code := range r.store.recoveryCodes {
+			if code.AccountId != accountId || code.CodeHash != codeHash || code.UsedAt != nil {
+				continue
+			}
+
+			now := nowUnixMilli()
+			r.store.recoveryCodes[i].UsedAt = &now
+			isUsed = true
+
+			return func() {
+				r.store.recoveryCodes[i].UsedAt = nil
+			}
+		}
+
+		return nil
+	})
+
+	return isUsed, nil
+}
+
+func (r *accountMfaRepositoryMemory) DeleteMfa(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prevMfa := maps.Clone(r.store.accountMfa)
+		prevCodes := slices.Clone(r.store.recoveryCodes)
+
+		r.store.recoveryCodes = slices.DeleteFunc(r.store.recoveryCodes, func(code memoryRecoveryCode) bool {
+			return code.AccountId == accountId
+		})
+
+		delete(r.store.accountMfa, accountId)
+
+		return func() {
+			r.store.accountMfa = prevMfa
+			r.store.recoveryCodes = prevCodes
+		}
+	})
+
+	return nil
+}
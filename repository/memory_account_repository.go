@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewAccountRepositoryMemory(store *MemoryStore) *accountRepositoryMemory {
+	return &accountRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *accountRepositoryMemory) GetAccountByEmail(ctx context.Context, email string, forUpdate bool) (*entity.Account, error) {
+	var found *entity.Account
+
+	r.store.read(func() {
+		for _, account := range r.store.accounts {
+			if account.Email == email && account.DeletedAt == nil {
+				found = &account
+				return
+			}
+		}
+	})
+
+	return found, nil
+}
+
+func (r *accountRepositoryMemory) GetAccountById(ctx context.Context, accountId int64, forUpdate bool) (*entity.Account, error) {
+	var found *entity.Account
+
+	r.store.read(func() {
+		account, ok := r.store.accounts[accountId]
+		if ok && account.DeletedAt == nil {
+			found = &account
+		}
+	})
+
+	return found, nil
+}
+
+func (r *accountRepositoryMemory) InsertAccount(ctx context.Context, account entity.Account) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		account.Id = r.store.nextId("accounts")
+		account.CreatedAt = now
+		account.UpdatedAt = now
+
+		r.store.accounts[account.Id] = account
+
+		return func() {
+			delete(r.store.accounts, account.Id)
+		}
+	})
+
+	return account.Id, nil
+}
+
+func (r *accountRepositoryMemory) update(accountId int64, fn func(account *entity.Account)) error {
+	var isFound bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.accounts[accountId]
+		if !ok {
+			return nil
+		}
+
+		isFound = true
+
+		account := prev
+		fn(&account)
+		account.UpdatedAt = nowUnixMilli()
+
+		r.store.accounts[accountId] = account
+
+		return func() {
+			r.store.accounts[accountId] = prev
+		}
+	})
+
+	if !isFound {
+		return fmt.Errorf("account not found")
+	}
+
+	return nil
+}
+
+func (r *accountRepositoryMemory) DisableAccount(ctx context.Context, accountId int64) error {
+	err := r.update(accountId, func(account *entity.Account) {
+		now := nowUnixMilli()
+		account.DisabledAt = &now
+	})
+	if err != nil {
+		return fmt.Errorf("[memory_account_repository][DisableAccount][update] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+func (r *accountRepositoryMemory) UpdatePassword(ctx context.Context, accountId int64, password string) error {
+	err := r.update(accountId, func(account *entity.Account) {
+		account.Password = password
+	})
+	if err != nil {
+		return fmt.Errorf("[memory_account_repository][UpdatePassword][update] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// updateIf updates the account only when cond holds, reports whether it was updated
+func (r *accountRepositoryMemory) updateIf(accountId int64, cond func(account entity.Account) bool, fn func(account *entity.Account)) bool {
+	var isUpdated bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.accounts[accountId]
+		if !ok || !cond(prev) {
+			return nil
+		}
+
+		isUpdated = true
+
+		account := prev
+		fn(&account)
+		account.UpdatedAt = nowUnixMilli()
+
+		r.store.accounts[accountId] = account
+
+		return func() {
+			r.store.accounts[accountId] = prev
+		}
+	})
+
+	return isUpdated
+}
+
+func (r *accountRepositoryMemory) SetVerificationSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(account entity.Account) bool {
+		return account.VerifiedAt == nil && (account.VerificationSentAt == nil || *account.VerificationSentAt <= notAfter)
+	}, func(account *entity.Account) {
+		account.VerificationSentAt = &sentAt
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountRepositoryMemory) VerifyEmail(ctx context.Context, accountId, sentAt int64) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(account entity.Account) bool {
+		return account.VerifiedAt == nil && account.VerificationSentAt != nil && *account.VerificationSentAt == sentAt
+	}, func(account *entity.Account) {
+		now := nowUnixMilli()
+		account.VerifiedAt = &now
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountRepositoryMemory) SetPasswordResetSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(account entity.Account) bool {
+		return account.PasswordResetSentAt == nil || *account.PasswordResetSentAt <= notAfter
+	}, func(account *entity.Account) {
+		account.PasswordResetSentAt = &sentAt
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountRepositoryMemory) ResetPassword(ctx context.Context, accountId, sentAt int64, password string) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(account entity.Account) bool {
+		return account.PasswordResetSentAt != nil && *account.PasswordResetSentAt == sentAt
+	}, func(account *entity.Account) {
+		account.Password = password
+		account.PasswordResetSentAt = nil
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountRepositoryMemory) CloseAccount(ctx context.Context, accountId int64, emailHash *string) (bool, error) {
+	isUpdated := r.updateIf(accountId, func(account entity.Account) bool {
+		return account.DeletedAt == nil
+	}, func(account *entity.Account) {
+		now := nowUnixMilli()
+		account.DeletedAt = &now
+		account.EmailHash = emailHash
+	})
+
+	return isUpdated, nil
+}
+
+func (r *accountRepositoryMemory) IsEmailHeld(ctx context.Context, emailHash string) (bool, error) {
+	var isHeld bool
+
+	r.store.read(func() {
+		for _, account := range r.store.accounts {
+			if account.DeletedAt != nil && account.EmailHash != nil && *account.EmailHash == emailHash {
+				isHeld = true
+				return
+			}
+		}
+	})
+
+	return isHeld, nil
+}
+
+func (r *accountRepositoryMemory) GetAccountIdsToAnonymise(ctx context.Context, closedBefore int64, limit int) ([]int64, error) {
+	var due []entity.Account
+
+	r.store.read(func() {
+		for _, account := range r.store.accounts {
+			if account.DeletedAt != nil && *account.DeletedAt <= closedBefore && account.AnonymisedAt == nil {
+				due = append(due, account)
+			}
+		}
+	})
+
+	sort.Slice(due, func(i, j int) bool {
+		return *due[i].DeletedAt < *due[j].DeletedAt
+	})
+
+	var accountIds []int64
+
+	for i := 0; i < len(due) && i < limit; i++ {
+		accountIds = append(accountIds, due[i].Id)
+	}
+
+	return accountIds, nil
+}
+
+func (r *accountRepositoryMemory) AnonymiseAccount(ctx context.Context, accountId int64, isEmailHashKept bool) error {
+	r.updateIf(accountId, func(account entity.Account) bool {
+		return account.DeletedAt != nil
+	}, func(account *entity.Account) {
+		now := nowUnixMilli()
+		account.Email = ""
+		account.Password = ""
+		account.AnonymisedAt = &now
+
+		if !isEmailHashKept {
+			account.EmailHash = nil
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type auditEventRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewAuditEventRepositoryMemory(store *MemoryStore) *auditEventRepositoryMemory {
+	return &auditEventRepositoryMemory{
+		store: store,
+	}
+}
+
+// The running transaction holds the store, the head is therefore locked until it ends
+func (r *auditEventRepositoryMemory) LockChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	var head entity.AuditChainHead
+
+	r.store.write(r.tx, func() func() {
+		head = r.store.auditChain
+
+		r.store.auditChain.LastEventId++
+
+		return func() {
+			r.store.auditChain = head
+		}
+	})
+
+	return &head, nil
+}
+
+func (r *auditEventRepositoryMemory) InsertEvent(ctx context.Context, event entity.AuditEvent) error {
+	var err error
+
+	r.store.write(r.tx, func() func() {
+		if r.store.auditChain.LastEventId != event.Id {
+			err = fmt.Errorf("[memory_audit_event_repository][InsertEvent] event is not the reserved head of the chain | audit_event_id: %v", event.Id)
+			return nil
+		}
+
+		head := r.store.auditChain
+
+		r.store.auditEvents = append(r.store.auditEvents, event)
+		r.store.auditChain.LastHash = event.Hash
+
+		return func() {
+			r.store.auditEvents = r.store.auditEvents[:len(r.store.auditEvents)-1]
+			r.store.auditChain = head
+		}
+	})
+
+	return err
+}
+
+func isAuditEventMatching(event entity.AuditEvent, filter entity.AuditEventFilter) bool {
+	return (filter.ActorType == "" || event.ActorType == filter.ActorType) &&
+		(filter.ActorId == 0 || (event.ActorId != nil && *event.ActorId == filter.ActorId)) &&
+		(filter.Action == "" || event.Action == filter.Action) &&
+		(filter.EntityType == "" || event.EntityType == filter.EntityType) &&
+		(filter.EntityId == 0 || (event.EntityId != nil && *event.EntityId == filter.EntityId)) &&
+		(filter.CreatedFrom == 0 || event.CreatedAt >= filter.CreatedFrom) &&
+		(filter.CreatedTo == 0 || event.CreatedAt < filter.CreatedTo) &&
+		(filter.BeforeId == 0 || event.Id < filter.BeforeId)
+}
+
+// Events are appended in id order, they are listed newest first
+func (r *auditEventRepositoryMemory) GetEvents(ctx context.Context, filter entity.AuditEventFilter) ([]entity.AuditEvent, error) {
+	events := []entity.AuditEvent{}
+
+	r.store.read(func() {
+		for i := len(r.store.auditEvents) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+			if isAuditEventMatching(r.store.auditEvents[i], filter) {
+				events = append(events, r.store.auditEvents[i])
+			}
+		}
+	})
+
+	return events, nil
+}
+
+func (r *auditEventRepositoryMemory) GetEventsAfter(ctx context.Context, afterId int64, limit int) ([]entity.AuditEvent, error) {
+	events := []entity.AuditEvent{}
+
+	r.store.read(func() {
+		for _, event := range r.store.auditEvents {
+			if len(events) == limit {
+				break
+			}
+
+			if event.Id > afterId {
+				events = append(events, event)
+			}
+		}
+	})
+
+	return events, nil
+}
+
+func (r *auditEventRepositoryMemory) GetChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	var head entity.AuditChainHead
+
+	r.store.read(func() {
+		head = r.store.auditChain
+	})
+
+	return &head, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type consumerHistoryRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewConsumerHistoryRepositoryMemory(store *MemoryStore) *consumerHistoryRepositoryMemory {
+	return &consumerHistoryRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *consumerHistoryRepositoryMemory) InsertHistory(ctx context.Context, history entity.ConsumerHistory) error {
+	r.store.write(r.tx, func() func() {
+		history.Id = r.store.nextId("consumer_histories")
+		history.CreatedAt = nowUnixMilli()
+
+		r.store.consumerHistories = append(r.store.consumerHistories, history)
+
+		return func() {
+			r.store.consumerHistories = r.store.consumerHistories[:len(r.store.consumerHistories)-1]
+		}
+	})
+
+	return nil
+}
+
+func (r *consumerHistoryRepositoryMemory) AnonymiseHistories(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := slices.Clone(r.store.consumerHistories)
+
+		for i, history := range r.store.consumerHistories {
+			if history.AccountId != accountId {
+				continue
+			}
+
+			history.IdentityNumber = ""
+			history.FullName = ""
+			history.LegalName = ""
+			history.PlaceOfBirth = ""
+			history.DateOfBirth = ""
+			history.Salary = 0
+			history.IdentityCardPhotoKey = ""
+			history.SelfiePhotoKey = ""
+
+			r.store.consumerHistories[i] = history
+		}
+
+		return func() {
+			r.store.consumerHistories = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *consumerHistoryRepositoryMemory) GetHistoriesByAccountId(ctx context.Context, accountId int64) ([]entity.ConsumerHistory, error) {
+	var histories []entity.ConsumerHistory
+
+	r.store.read(func() {
+		for _, history := range r.store.consumerHistories {
+			if history.AccountId == accountId {
+				histories = append(histories, history)
+			}
+		}
+	})
+
+	sort.Slice(histories, func(i, j int) bool {
+		return histories[i].Version < histories[j].Version
+	})
+
+	return histories, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type consumerRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewConsumerRepositoryMemory(store *MemoryStore) *consumerRepositoryMemory {
+	return &consumerRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *consumerRepositoryMemory) GetConsumerByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.Consumer, error) {
+	var found *entity.Consumer
+
+	r.store.read(func() {
+		for _, consumer := range r.store.consumers {
+			if consumer.AccountId == accountId && consumer.DeletedAt == nil {
+				found = &consumer
+				return
+			}
+		}
+	})
+
+	return found, nil
+}
+
+// Only the columns stored by the SQL repositories are kept, photo content is not
+func (r *consumerRepositoryMemory) toStored(consumerData entity.Consumer) entity.Consumer {
+	consumerData.IdentityCardPhoto = entity.Media{Key: consumerData.IdentityCardPhoto.Key}
+	consumerData.SelfiePhoto = entity.Media{Key: consumerData.SelfiePhoto.Key}
+
+	return consumerData
+}
+
+func (r *consumerRepositoryMemory) InsertConsumer(ctx context.Context, consumerData entity.Consumer) error {
+	consumer := r.toStored(consumerData)
+
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		consumer.Id = r.store.nextId("consumers")
+		consumer.CreatedAt = now
+		consumer.UpdatedAt = now
+
+		r.store.consumers[consumer.Id] = consumer
+
+		return func() {
+			delete(r.store.consumers, consumer.Id)
+		}
+	})
+
+	return nil
+}
+
+func (r *consumerRepositoryMemory) UpdateConsumer(ctx context.Context, consumerData entity.Consumer) error {
+	var isFound bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.consumers[consumerData.Id]
+		if !ok {
+			return nil
+		}
+
+		isFound = true
+
+		consumer := r.toStored(consumerData)
+		consumer.AccountId = prev.AccountId
+		consumer.CreatedAt = prev.CreatedAt
+		consumer.DeletedAt = prev.DeletedAt
+		consumer.UpdatedAt = nowUnixMilli()
+
+		r.store.consumers[consumer.Id] = consumer
+
+		return func() {
+			r.store.consumers[prev.Id] = prev
+		}
+	})
+
+	if !isFound {
+		return fmt.Errorf("[memory_consumer_repository][UpdateConsumer] consumer not found | account_id: %v", consumerData.AccountId)
+	}
+
+	return nil
+}
+
+func (r *consumerRepositoryMemory) IsMediaKeyReferenced(ctx context.Context, key string) (bool, error) {
+	var isReferenced bool
+
+	r.store.read(func() {
+		for _, consumer := range r.store.consumers {
+			if consumer.IdentityCardPhoto.Key == key || consumer.SelfiePhoto.Key == key {
+				isReferenced = true
+				return
+			}
+		}
+
+		for _, history := range r.store.consumerHistories {
+			if history.IdentityCardPhotoKey == key || history.SelfiePhotoKey == key {
+				isReferenced = true
+				return
+			}
+		}
+	})
+
+	return isReferenced, nil
+}
+
+func (r *consumerRepositoryMemory) DeleteConsumer(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := maps.Clone(r.store.consumers)
+
+		now := nowUnixMilli()
+
+		for id, consumer := range r.store.consumers {
+			if consumer.AccountId == accountId && consumer.DeletedAt == nil {
+				consumer.DeletedAt = &now
+				consumer.UpdatedAt = now
+
+				r.store.consumers[id] = consumer
+			}
+		}
+
+		return func() {
+			r.store.consumers = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *consumerRepositoryMemory) AnonymiseConsumer(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := maps.Clone(r.store.consumers)
+
+		for id, consumer := range r.store.consumers {
+			if consumer.AccountId != accountId {
+				continue
+			}
+
+			consumer.IdentityNumber = ""
+			consumer.FullName = ""
+			consumer.LegalName = ""
+			consumer.PlaceOfBirth = ""
+			consumer.DateOfBirth = ""
+			consumer.Salary = 0
+			consumer.IdentityCardPhoto = entity.Media{}
+			consumer.SelfiePhoto = entity.Media{}
+			consumer.UpdatedAt = nowUnixMilli()
+
+			r.store.consumers[id] = consumer
+		}
+
+		return func() {
+			r.store.consumers = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *consumerRepositoryMemory) GetRejectedPhotoKeys(ctx context.Context, rejectedBefore int64, limit int) ([]string, error) {
+	found := map[string]bool{}
+
+	r.store.read(func() {
+		for _, consumer := range r.store.consumers {
+			if consumer.KycStatus == appconstant.KycStatusRejected && consumer.UpdatedAt <= rejectedBefore {
+				found[consumer.IdentityCardPhoto.Key] = true
+				found[consumer.SelfiePhoto.Key] = true
+			}
+		}
+
+		for _, history := range r.store.consumerHistories {
+			if history.KycStatus == appconstant.KycStatusRejected && history.CreatedAt <= rejectedBefore {
+				found[history.IdentityCardPhotoKey] = true
+				found[history.SelfiePhotoKey] = true
+			}
+		}
+	})
+
+	delete(found, "")
+
+	keys := slices.Collect(maps.Keys(found))
+	sort.Strings(keys)
+
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	return keys, nil
+}
+
+func (r *consumerRepositoryMemory) ClearRejectedPhotoKey(ctx context.Context, key string, rejectedBefore int64) error {
+	r.store.write(r.tx, func() func() {
+		prevConsumers := maps.Clone(r.store.consumers)
+		prevHistories := slices.Clone(r.store.consumerHistories)
+
+		for id, consumer := range r.store.consumers {
+			if consumer.KycStatus != appconstant.KycStatusRejected || consumer.UpdatedAt > rejectedBefore {
+				continue
+			}
+			if consumer.IdentityCardPhoto.Key != key && consumer.SelfiePhoto.Key != key {
+				continue
+			}
+
+			if consumer.IdentityCardPhoto.Key == key {
+				consumer.IdentityCardPhoto = entity.Media{}
+			}
+			if consumer.SelfiePhoto.Key == key {
+				consumer.SelfiePhoto = entity.Media{}
+			}
+
+			r.store.consumers[id] = consumer
+		}
+
+		for i, history := range r.store.consumerHistories {
+			if history.KycStatus != appconstant.KycStatusRejected || history.CreatedAt > rejectedBefore {
+				continue
+			}
+
+			if history.IdentityCardPhotoKey == key {
+				history.IdentityCardPhotoKey = ""
+			}
+			if history.SelfiePhotoKey == key {
+				history.SelfiePhotoKey = ""
+			}
+
+			r.store.consumerHistories[i] = history
+		}
+
+		return func() {
+			r.store.consumers = prevConsumers
+			r.store.consumerHistories = prevHistories
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type dataExportRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewDataExportRepositoryMemory(store *MemoryStore) *dataExportRepositoryMemory {
+	return &dataExportRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *dataExportRepositoryMemory) InsertExport(ctx context.Context, export entity.DataExport) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		export = entity.DataExport{
+			Id:          r.store.nextId("data_exports"),
+			AccountId:   export.AccountId,
+			RequestedBy: export.RequestedBy,
+			Status:      appconstant.DataExportStatusPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		r.store.dataExports[export.Id] = export
+
+		return func() {
+			delete(r.store.dataExports, export.Id)
+		}
+	})
+
+	return export.Id, nil
+}
+
+func (r *dataExportRepositoryMemory) GetExportById(ctx context.Context, exportId int64) (*entity.DataExport, error) {
+	var found *entity.DataExport
+
+	r.store.read(func() {
+		if export, ok := r.store.dataExports[exportId]; ok {
+			found = &export
+		}
+	})
+
+	return found, nil
+}
+
+// filter returns the exports cond holds for, sorted by id
+func (r *dataExportRepositoryMemory) filter(cond func(export entity.DataExport) bool) []entity.DataExport {
+	exports := []entity.DataExport{}
+
+	r.store.read(func() {
+		for _, export := range r.store.dataExports {
+			if cond(export) {
+				exports = append(exports, export)
+			}
+		}
+	})
+
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].Id < exports[j].Id
+	})
+
+	return exports
+}
+
+func (r *dataExportRepositoryMemory) GetExportsByAccountId(ctx context.Context, accountId int64) ([]entity.DataExport, error) {
+	exports := r.filter(func(export entity.DataExport) bool {
+		return export.AccountId == accountId
+	})
+
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].Id > exports[j].Id
+	})
+
+	return exports, nil
+}
+
+func isExportToProcess(export entity.DataExport, staleBefore int64) bool {
+	return export.Status == appconstant.DataExportStatusPending ||
+		(export.Status == appconstant.DataExportStatusProcessing && export.UpdatedAt <= staleBefore)
+}
+
+func (r *dataExportRepositoryMemory) GetExportIdsToProcess(ctx context.Context, staleBefore int64, limit int) ([]int64, error) {
+	exports := r.filter(func(export entity.DataExport) bool {
+		return isExportToProcess(export, staleBefore)
+	})
+
+	var exportIds []int64
+
+	for i := 0; i < len(exports) && i < limit; i++ {
+		exportIds = append(exportIds, exports[i].Id)
+	}
+
+	return exportIds, nil
+}
+
+// update changes the export when cond holds, reports whether it was changed
+func (r *dataExportRepositoryMemory) update(exportId int64, cond func(export entity.DataExport) bool, fn func(export *entity.DataExport)) bool {
+	var isUpdated bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.dataExports[exportId]
+		if !ok || !cond(prev) {
+			return nil
+		}
+
+		isUpdated = true
+
+		export := prev
+		fn(&export)
+		export.UpdatedAt = nowUnixMilli()
+
+		r.store.dataExports[exportId] = export
+
+		return func() {
+			r.store.dataExports[exportId] = prev
+		}
+	})
+
+	return isUpdated
+}
+
+func anyExport(entity.DataExport) bool {
+	return true
+}
+
+func (r *dataExportRepositoryMemory) ClaimExport(ctx context.Context, exportId, staleBefore int64) (bool, error) {
+	isClaimed := r.update(exportId, func(export entity.DataExport) bool {
+		return isExportToProcess(export, staleBefore)
+	}, func(export *entity.DataExport) {
+		export.Status = appconstant.DataExportStatusProcessing
+	})
+
+	return isClaimed, nil
+}
+
+func (r *dataExportRepositoryMemory) CompleteExport(ctx context.Context, exportId int64, archiveKey string, expiredAt int64) error {
+	r.update(exportId, anyExport, func(export *entity.DataExport) {
+		now := nowUnixMilli()
+
+		export.Status = appconstant.DataExportStatusCompleted
+		export.ArchiveKey = archiveKey
+		export.CompletedAt = &now
+		export.ExpiredAt = &expiredAt
+	})
+
+	return nil
+}
+
+func (r *dataExportRepositoryMemory) FailExport(ctx context.Context, exportId int64, failure string) error {
+	r.update(exportId, anyExport, func(export *entity.DataExport) {
+		export.Status = appconstant.DataExportStatusFailed
+		export.Failure = failure
+	})
+
+	return nil
+}
+
+func (r *dataExportRepositoryMemory) RecordDownload(ctx context.Context, exportId int64) error {
+	r.update(exportId, anyExport, func(export *entity.DataExport) {
+		now := nowUnixMilli()
+
+		export.DownloadedAt = &now
+		export.DownloadCount++
+	})
+
+	return nil
+}
+
+func (r *dataExportRepositoryMemory) GetExpiredExports(ctx context.Context, now int64, limit int) ([]entity.DataExport, error) {
+	exports := r.filter(func(export entity.DataExport) bool {
+		return export.Status == appconstant.DataExportStatusCompleted && export.ExpiredAt != nil && *export.ExpiredAt <= now
+	})
+
+	if len(exports) > limit {
+		exports = exports[:limit]
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepositoryMemory) ExpireExport(ctx context.Context, exportId int64) error {
+	r.update(exportId, anyExport, func(export *entity.DataExport) {
+		export.Status = appconstant.DataExportStatusExpired
+		export.ArchiveKey = ""
+	})
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type loginAttemptRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewLoginAttemptRepositoryMemory(store *MemoryStore) *loginAttemptRepositoryMemory {
+	return &loginAttemptRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *loginAttemptRepositoryMemory) GetLoginAttempt(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	var attempt *entity.LoginAttempt
+
+	r.store.read(func() {
+		if a, ok := r.store.loginAttempts[key]; ok {
+			attempt = &a
+		}
+	})
+
+	return attempt, nil
+}
+
+// restore puts back the attempt as it was before a change
+func (r *loginAttemptRepositoryMemory) restore(key string, previous entity.LoginAttempt, existed bool) func() {
+	return func() {
+		if existed {
+			r.store.loginAttempts[key] = previous
+			return
+		}
+
+		delete(r.store.loginAttempts, key)
+	}
+}
+
+func (r *loginAttemptRepositoryMemory) IncrementFailedAttempt(ctx context.Context, key string, windowStart int64) (*entity.LoginAttempt, error) {
+	var attempt entity.LoginAttempt
+
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+		previous, existed := r.store.loginAttempts[key]
+
+		attempt = previous
+
+		switch {
+		case !existed:
+			attempt = entity.LoginAttempt{Key: key, FailedCount: 1, CreatedAt: now}
+
+		case previous.LastFailedAt < windowStart:
+			attempt.FailedCount = 1
+			attempt.LockedUntil = nil
+
+		default:
+			attempt.FailedCount++
+		}
+
+		attempt.LastFailedAt = now
+		attempt.UpdatedAt = now
+
+		r.store.loginAttempts[key] = attempt
+
+		return r.restore(key, previous, existed)
+	})
+
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepositoryMemory) LockLoginAttempt(ctx context.Context, key string, lockedUntil int64) error {
+	r.store.write(r.tx, func() func() {
+		previous, existed := r.store.loginAttempts[key]
+		if !existed {
+			return nil
+		}
+
+		attempt := previous
+		attempt.LockedUntil = &lockedUntil
+		attempt.UpdatedAt = nowUnixMilli()
+
+		r.store.loginAttempts[key] = attempt
+
+		return r.restore(key, previous, existed)
+	})
+
+	return nil
+}
+
+func (r *loginAttemptRepositoryMemory) DeleteLoginAttempt(ctx context.Context, key string) error {
+	r.store.write(r.tx, func() func() {
+		previous, existed := r.store.loginAttempts[key]
+		if !existed {
+			return nil
+		}
+
+		delete(r.store.loginAttempts, key)
+
+		return r.restore(key, previous, existed)
+	})
+
+	return nil
+}
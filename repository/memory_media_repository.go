@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+type memoryMedia struct {
+	opt       MediaOpt
+	updatedAt int64
+}
+
+type mediaRepositoryMemory struct {
+	mu     sync.Mutex
+	stored map[string]memoryMedia
+	staged map[string]memoryMedia
+}
+
+func NewMediaRepositoryMemory() *mediaRepositoryMemory {
+	return &mediaRepositoryMemory{
+		stored: map[string]memoryMedia{},
+		staged: map[string]memoryMedia{},
+	}
+}
+
+// Media are kept as bytes, so multipart files are read right away
+func (r *mediaRepositoryMemory) toMemory(media MediaOpt) (memoryMedia, error) {
+	if media.Key == "" || media.Extension == "" {
+		return memoryMedia{}, fmt.Errorf("media key and extension is required")
+	}
+
+	data := media.Bytes
+
+	if len(data) == 0 {
+		if media.File == nil || media.File.File == nil {
+			return memoryMedia{}, fmt.Errorf("no file data provided")
+		}
+
+		var err error
+
+		data, err = io.ReadAll(media.File.File)
+		if err != nil {
+			return memoryMedia{}, fmt.Errorf("[io.ReadAll] error: %w", err)
+		}
+	}
+
+	return memoryMedia{
+		opt: MediaOpt{
+			Key:       media.Key,
+			Extension: media.Extension,
+			Bytes:     data,
+		},
+		updatedAt: nowUnixMilli(),
+	}, nil
+}
+
+func (r *mediaRepositoryMemory) Store(ctx context.Context, media MediaOpt) error {
+	m, err := r.toMemory(media)
+	if err != nil {
+		return fmt.Errorf("[memory_media_repository][Store][toMemory] error: %w | key: %s", err, media.Key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stored[m.opt.Key] = m
+
+	return nil
+}
+
+func (r *mediaRepositoryMemory) Stage(ctx context.Context, media MediaOpt) error {
+	m, err := r.toMemory(media)
+	if err != nil {
+		return fmt.Errorf("[memory_media_repository][Stage][toMemory] error: %w | key: %s", err, media.Key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.staged[m.opt.Key] = m
+
+	return nil
+}
+
+func (r *mediaRepositoryMemory) Promote(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.staged[key]
+	if !ok {
+		return nil
+	}
+
+	r.stored[key] = m
+	delete(r.staged, key)
+
+	return nil
+}
+
+func (r *mediaRepositoryMemory) Discard(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.staged, key)
+
+	return nil
+}
+
+func (r *mediaRepositoryMemory) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.stored, key)
+
+	return nil
+}
+
+func (r *mediaRepositoryMemory) Get(ctx context.Context, key string) (*MediaOpt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.stored[key]
+	if !ok {
+		return nil, nil
+	}
+
+	opt := m.opt
+
+	return &opt, nil
+}
+
+func (r *mediaRepositoryMemory) List(ctx context.Context, staged bool) ([]MediaStat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	media := r.stored
+	if staged {
+		media = r.staged
+	}
+
+	var stats []MediaStat
+
+	for key, m := range media {
+		stats = append(stats, MediaStat{
+			Key:       key,
+			UpdatedAt: m.updatedAt,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+
+	return stats, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type outboxRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewOutboxRepositoryMemory(store *MemoryStore) *outboxRepositoryMemory {
+	return &outboxRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *outboxRepositoryMemory) InsertEvent(ctx context.Context, event entity.OutboxEvent) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		event = entity.OutboxEvent{
+			Id:            r.store.nextId("outbox_events"),
+			EventType:     event.EventType,
+			AggregateType: event.AggregateType,
+			AggregateId:   event.AggregateId,
+			Payload:       event.Payload,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		r.store.outboxEvents[event.Id] = event
+
+		return func() {
+			delete(r.store.outboxEvents, event.Id)
+		}
+	})
+
+	return event.Id, nil
+}
+
+func (r *outboxRepositoryMemory) GetDueEvents(ctx context.Context, now int64, limit int) ([]entity.OutboxEvent, error) {
+	unpublished := []entity.OutboxEvent{}
+
+	r.store.read(func() {
+		for _, event := range r.store.outboxEvents {
+			if event.PublishedAt == nil {
+				unpublished = append(unpublished, event)
+			}
+		}
+	})
+
+	sort.Slice(unpublished, func(i, j int) bool {
+		return unpublished[i].Id < unpublished[j].Id
+	})
+
+	type aggregate struct {
+		Type string
+		Id   int64
+	}
+
+	// An event waits while an earlier event of its aggregate is unpublished
+	isWaiting := map[aggregate]bool{}
+
+	events := []entity.OutboxEvent{}
+
+	for _, event := range unpublished {
+		key := aggregate{Type: event.AggregateType, Id: event.AggregateId}
+
+		if !isWaiting[key] && event.NextAttemptAt <= now && len(events) < limit {
+			events = append(events, event)
+		}
+
+		isWaiting[key] = true
+	}
+
+	return events, nil
+}
+
+// update changes the event when cond holds, reports whether it was changed
+func (r *outboxRepositoryMemory) update(eventId int64, cond func(event entity.OutboxEvent) bool, fn func(event *entity.OutboxEvent)) bool {
+	var isUpdated bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.outboxEvents[eventId]
+		if !ok || !cond(prev) {
+			return nil
+		}
+
+		isUpdated = true
+
+		event := prev
+		fn(&event)
+		event.UpdatedAt = nowUnixMilli()
+
+		r.store.outboxEvents[eventId] = event
+
+		return func() {
+			r.store.outboxEvents[eventId] = prev
+		}
+	})
+
+	return isUpdated
+}
+
+func anyOutboxEvent(entity.OutboxEvent) bool {
+	return true
+}
+
+func (r *outboxRepositoryMemory) ClaimEvent(ctx context.Context, eventId, now, leaseUntil int64) (bool, error) {
+	isClaimed := r.update(eventId, func(event entity.OutboxEvent) bool {
+		return event.PublishedAt == nil && event.NextAttemptAt <= now
+	}, func(event *entity.OutboxEvent) {
+		event.NextAttemptAt = leaseUntil
+	})
+
+	return isClaimed, nil
+}
+
+func (r *outboxRepositoryMemory) MarkPublished(ctx context.Context, eventId int64) error {
+	r.update(eventId, anyOutboxEvent, func(event *entity.OutboxEvent) {
+		now := nowUnixMilli()
+
+		event.Attempts++
+		event.LastError = ""
+		event.PublishedAt = &now
+	})
+
+	return nil
+}
+
+func (r *outboxRepositoryMemory) MarkFailed(ctx context.Context, eventId int64, lastError string, nextAttemptAt int64) error {
+	r.update(eventId, anyOutboxEvent, func(event *entity.OutboxEvent) {
+		event.Attempts++
+		event.LastError = lastError
+		event.NextAttemptAt = nextAttemptAt
+	})
+
+	return nil
+}
+
+func (r *outboxRepositoryMemory) DeletePublishedEvents(ctx context.Context, publishedBefore int64, limit int) (int64, error) {
+	var deleted int64
+
+	r.store.write(r.tx, func() func() {
+		removed := map[int64]entity.OutboxEvent{}
+
+		for id, event := range r.store.outboxEvents {
+			if deleted >= int64(limit) {
+				break
+			}
+
+			if event.PublishedAt == nil || *event.PublishedAt > publishedBefore {
+				continue
+			}
+
+			removed[id] = event
+			delete(r.store.outboxEvents, id)
+
+			deleted++
+		}
+
+		return func() {
+			for id, event := range removed {
+				r.store.outboxEvents[id] = event
+			}
+		}
+	})
+
+	return deleted, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewPartnerRepositoryMemory(store *MemoryStore) *partnerRepositoryMemory {
+	return &partnerRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *partnerRepositoryMemory) InsertPartner(ctx context.Context, partner entity.Partner) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		partner.Id = r.store.nextId("partners")
+		partner.CreatedAt = now
+		partner.UpdatedAt = now
+
+		r.store.partners[partner.Id] = partner
+
+		return func() {
+			delete(r.store.partners, partner.Id)
+		}
+	})
+
+	return partner.Id, nil
+}
+
+func (r *partnerRepositoryMemory) GetPartnerById(ctx context.Context, partnerId int64) (*entity.Partner, error) {
+	var partner *entity.Partner
+
+	r.store.read(func() {
+		if p, ok := r.store.partners[partnerId]; ok {
+			partner = &p
+		}
+	})
+
+	return partner, nil
+}
+
+func (r *partnerRepositoryMemory) GetPartnerByApiKeyHash(ctx context.Context, apiKeyHash string) (*entity.Partner, error) {
+	var partner *entity.Partner
+
+	r.store.read(func() {
+		for _, p := range r.store.partners {
+			if p.ApiKeyHash == apiKeyHash {
+				partner = &p
+				return
+			}
+		}
+	})
+
+	return partner, nil
+}
+
+func (r *partnerRepositoryMemory) GetPartners(ctx context.Context) ([]entity.Partner, error) {
+	partners := []entity.Partner{}
+
+	r.store.read(func() {
+		for _, partner := range r.store.partners {
+			partners = append(partners, partner)
+		}
+	})
+
+	sort.Slice(partners, func(i, j int) bool {
+		return partners[i].Id < partners[j].Id
+	})
+
+	return partners, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerWebhookRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewPartnerWebhookRepositoryMemory(store *MemoryStore) *partnerWebhookRepositoryMemory {
+	return &partnerWebhookRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *partnerWebhookRepositoryMemory) InsertWebhook(ctx context.Context, webhook entity.PartnerWebhook) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		webhook.Id = r.store.nextId("partner_webhooks")
+		webhook.EventTypes = slices.Clone(webhook.EventTypes)
+		webhook.CreatedAt = now
+		webhook.UpdatedAt = now
+		webhook.DeletedAt = nil
+
+		r.store.partnerWebhooks[webhook.Id] = webhook
+
+		return func() {
+			delete(r.store.partnerWebhooks, webhook.Id)
+		}
+	})
+
+	return webhook.Id, nil
+}
+
+func (r *partnerWebhookRepositoryMemory) GetWebhookById(ctx context.Context, webhookId int64) (*entity.PartnerWebhook, error) {
+	var webhook *entity.PartnerWebhook
+
+	r.store.read(func() {
+		if w, ok := r.store.partnerWebhooks[webhookId]; ok {
+			webhook = &w
+		}
+	})
+
+	return webhook, nil
+}
+
+func (r *partnerWebhookRepositoryMemory) GetWebhooksByPartnerId(ctx context.Context, partnerId int64) ([]entity.PartnerWebhook, error) {
+	webhooks := []entity.PartnerWebhook{}
+
+	r.store.read(func() {
+		for _, webhook := range r.store.partnerWebhooks {
+			if webhook.PartnerId == partnerId && webhook.DeletedAt == nil {
+				webhooks = append(webhooks, webhook)
+			}
+		}
+	})
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].Id < webhooks[j].Id
+	})
+
+	return webhooks, nil
+}
+
+func (r *partnerWebhookRepositoryMemory) DeleteWebhook(ctx context.Context, partnerId, webhookId int64) (bool, error) {
+	var isDeleted bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.partnerWebhooks[webhookId]
+		if !ok || prev.PartnerId != partnerId || prev.DeletedAt != nil {
+			return nil
+		}
+
+		isDeleted = true
+
+		now := nowUnixMilli()
+
+		webhook := prev
+		webhook.DeletedAt = &now
+		webhook.UpdatedAt = now
+
+		r.store.partnerWebhooks[webhookId] = webhook
+
+		return func() {
+			r.store.partnerWebhooks[webhookId] = prev
+		}
+	})
+
+	return isDeleted, nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type refreshTokenRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewRefreshTokenRepositoryMemory(store *MemoryStore) *refreshTokenRepositoryMemory {
+	return &refreshTokenRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *refreshTokenRepositoryMemory) InsertToken(ctx context.Context, token string, session entity.Session) error {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		r.store.refreshTokens = append(r.store.refreshTokens, memoryRefreshToken{
+			Id:         r.store.nextId("refresh_tokens"),
+			Token:      token,
+			AccountId:  session.AccountId,
+			ExpiredAt:  session.ExpiredAt,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IpAddress,
+			DeviceHash: session.DeviceHash,
+			SessionKey: session.Key,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+
+		return func() {
+			r.store.refreshTokens = r.store.refreshTokens[:len(r.store.refreshTokens)-1]
+		}
+	})
+
+	return nil
+}
+
+func (r *refreshTokenRepositoryMemory) RevokeAccountTokens(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := append([]memoryRefreshToken(nil), r.store.refreshTokens...)
+
+		now := nowUnixMilli()
+
+		for i, token := range r.store.refreshTokens {
+			if token.AccountId == accountId && token.RevokedAt == nil {
+				r.store.refreshTokens[i].RevokedAt = &now
+				r.store.refreshTokens[i].UpdatedAt = now
+			}
+		}
+
+		return func() {
+			r.store.refreshTokens = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *refreshTokenRepositoryMemory) GetActiveSessions(ctx context.Context, accountId int64) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+
+	r.store.read(func() {
+		now := nowUnixMilli()
+
+		for i := len(r.store.refreshTokens) - 1; i >= 0; i-- {
+			token := r.store.refreshTokens[i]
+			if token.AccountId != accountId || token.RevokedAt != nil || token.ExpiredAt <= now {
+				continue
+			}
+
+			sessions = append(sessions, entity.Session{
+				Id:         token.Id,
+				AccountId:  token.AccountId,
+				UserAgent:  token.UserAgent,
+				IpAddress:  token.IpAddress,
+				DeviceHash: token.DeviceHash,
+				ExpiredAt:  token.ExpiredAt,
+				CreatedAt:  token.CreatedAt,
+			})
+		}
+	})
+
+	return sessions, nil
+}
+
+func (r *refreshTokenRepositoryMemory) RevokeSession(ctx context.Context, accountId, sessionId int64) (bool, error) {
+	var isRevoked bool
+
+	r.store.write(r.tx, func() func() {
+		for i, token := range r.store.refreshTokens {
+			if token.Id != sessionId || token.AccountId != accountId || token.RevokedAt != nil {
+				continue
+			}
+
+			isRevoked = true
+
+			now := nowUnixMilli()
+			r.store.refreshTokens[i].RevokedAt = &now
+			r.store.refreshTokens[i].UpdatedAt = now
+
+			return func() {
+				r.store.refreshTokens[i] = token
+			}
+		}
+
+		return nil
+	})
+
+	return isRevoked, nil
+}
+
+func (r *refreshTokenRepositoryMemory) IsNewDevice(ctx context.Context, accountId int64, deviceHash string) (bool, error) {
+	var isKnown, isMatching bool
+
+	r.store.read(func() {
+		for _, token := range r.store.refreshTokens {
+			if token.AccountId != accountId || token.DeviceHash == "" {
+				continue
+			}
+
+			isKnown = true
+
+			if token.DeviceHash == deviceHash {
+				isMatching = true
+				return
+			}
+		}
+	})
+
+	return isKnown && !isMatching, nil
+}
+
+func (r *refreshTokenRepositoryMemory) IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error) {
+	var isActive bool
+
+	r.store.read(func() {
+		now := nowUnixMilli()
+
+		for _, token := range r.store.refreshTokens {
+			if token.AccountId == accountId && token.SessionKey == sessionKey && token.RevokedAt == nil && token.ExpiredAt > now {
+				isActive = true
+				return
+			}
+		}
+	})
+
+	return isActive, nil
+}
+
+func (r *refreshTokenRepositoryMemory) DeleteAccountTokens(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := slices.Clone(r.store.refreshTokens)
+
+		r.store.refreshTokens = slices.DeleteFunc(r.store.refreshTokens, func(token memoryRefreshToken) bool {
+			return token.AccountId == accountId
+		})
+
+		return func() {
+			r.store.refreshTokens = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *refreshTokenRepositoryMemory) DeleteExpiredTokens(ctx context.Context, expiredBefore int64, limit int) (int64, error) {
+	var deleted int64
+
+	r.store.write(r.tx, func() func() {
+		prev := slices.Clone(r.store.refreshTokens)
+
+		r.store.refreshTokens = slices.DeleteFunc(r.store.refreshTokens, func(token memoryRefreshToken) bool {
+			if deleted >= int64(limit) || token.ExpiredAt > expiredBefore {
+				return false
+			}
+
+			deleted++
+
+			return true
+		})
+
+		return func() {
+			r.store.refreshTokens = prev
+		}
+	})
+
+	return deleted, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type memoryRecoveryCode struct {
+	AccountId int64
+	CodeHash  string
+	UsedAt    *int64
+}
+
+type memoryRefreshToken struct {
+	Id         int64
+	Token      string
+	AccountId  int64
+	ExpiredAt  int64
+	RevokedAt  *int64
+	UserAgent  string
+	IpAddress  string
+	DeviceHash string
+	SessionKey string
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+// MemoryStore holds the data of the in-memory repositories. Data is lost when the process exits,
+// it is meant for tests and local runs without a database.
+type MemoryStore struct {
+	// mu guards the data, txMu is held by the running transaction for its whole lifetime
+	mu   sync.Mutex
+	txMu sync.Mutex
+
+	lastId            map[string]int64
+	accounts          map[int64]entity.Account
+	consumers         map[int64]entity.Consumer
+	consumerHistories []entity.ConsumerHistory
+	refreshTokens     []memoryRefreshToken
+	accountLimits     map[int64]entity.AccountLimit
+	transactions      map[int64]entity.Transaction
+	loginAttempts     map[string]entity.LoginAttempt
+	accountMfa        map[int64]entity.AccountMfa
+	recoveryCodes     []memoryRecoveryCode
+	dataExports       map[int64]entity.DataExport
+	auditEvents       []entity.AuditEvent
+	auditChain        entity.AuditChainHead
+	outboxEvents      map[int64]entity.OutboxEvent
+	partners          map[int64]entity.Partner
+	partnerWebhooks   map[int64]entity.PartnerWebhook
+	webhookDeliveries map[int64]entity.WebhookDelivery
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lastId:            map[string]int64{},
+		accounts:          map[int64]entity.Account{},
+		consumers:         map[int64]entity.Consumer{},
+		accountLimits:     map[int64]entity.AccountLimit{},
+		transactions:      map[int64]entity.Transaction{},
+		loginAttempts:     map[string]entity.LoginAttempt{},
+		accountMfa:        map[int64]entity.AccountMfa{},
+		dataExports:       map[int64]entity.DataExport{},
+		outboxEvents:      map[int64]entity.OutboxEvent{},
+		partners:          map[int64]entity.Partner{},
+		partnerWebhooks:   map[int64]entity.PartnerWebhook{},
+		webhookDeliveries: map[int64]entity.WebhookDelivery{},
+	}
+}
+
+// nextId works like an auto increment column, ids are not reused after a rollback
+func (s *MemoryStore) nextId(table string) int64 {
+	s.lastId[table]++
+
+	return s.lastId[table]
+}
+
+// read runs fn holding the data lock
+func (s *MemoryStore) read(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn()
+}
+
+// write runs fn holding the data lock. fn returns how to undo its change, which is kept when tx is not nil
+// so the change can be rolled back.
+func (s *MemoryStore) write(tx *memoryTx, fn func() (undo func())) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	undo := fn()
+
+	if tx != nil && undo != nil {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// memoryTransaction serializes transactions, a transaction holds the store until it is committed or rolled back.
+// Reads for update are therefore always consistent, like SELECT ... FOR UPDATE on every row.
+type memoryTransaction struct {
+	store *MemoryStore
+}
+
+func NewMemoryTransaction(store *MemoryStore) *memoryTransaction {
+	return &memoryTransaction{
+		store: store,
+	}
+}
+
+func (t *memoryTransaction) Begin() (Tx, error) {
+	t.store.txMu.Lock()
+
+	return &memoryTx{
+		store:  t.store,
+		active: true,
+	}, nil
+}
+
+type memoryTx struct {
+	store  *MemoryStore
+	undo   []func()
+	active bool
+}
+
+func (t *memoryTx) Rollback() error {
+	if !t.active {
+		return sql.ErrTxDone
+	}
+
+	t.store.read(func() {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+	})
+
+	t.undo = nil
+	t.active = false
+	t.store.txMu.Unlock()
+
+	return nil
+}
+
+func (t *memoryTx) Commit() error {
+	if !t.active {
+		return sql.ErrTxDone
+	}
+
+	t.undo = nil
+	t.active = false
+	t.store.txMu.Unlock()
+
+	return nil
+}
+
+func (t *memoryTx) AccountTx() AccountRepository {
+	return &accountRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) ConsumerTx() ConsumerRepository {
+	return &consumerRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) ConsumerHistoryTx() ConsumerHistoryRepository {
+	return &consumerHistoryRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) RefreshTokenTx() RefreshTokenRepository {
+	return &refreshTokenRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) AccountLimitTx() AccountLimitRepository {
+	return &accountLimitRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) TransactionTx() TransactionRepository {
+	return &transactionRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) AccountMfaTx() AccountMfaRepository {
+	return &accountMfaRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) AuditEventTx() AuditEventRepository {
+	return &auditEventRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) OutboxTx() OutboxRepository {
+	return &outboxRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) PartnerTx() PartnerRepository {
+	return &partnerRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) PartnerWebhookTx() PartnerWebhookRepository {
+	return &partnerWebhookRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) WebhookDeliveryTx() WebhookDeliveryRepository {
+	return &webhookDeliveryRepositoryMemory{store: t.store, tx: t}
+}
+
+func (t *memoryTx) DataExportTx() DataExportRepository {
+	return &dataExportRepositoryMemory{store: t.store, tx: t}
+}
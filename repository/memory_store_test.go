@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func TestMemoryTransaction_Rollback(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	transaction := NewMemoryTransaction(store)
+
+	accountId, _ := NewAccountRepositoryMemory(store).InsertAccount(ctx, entity.Account{Email: "kept@example.com", Password: "old"})
+	NewRefreshTokenRepositoryMemory(store).InsertToken(ctx, "kept-token", entity.Session{AccountId: accountId, ExpiredAt: time.Now().Add(time.Hour).UnixMilli()})
+	NewAccountMfaRepositoryMemory(store).SavePendingMfa(ctx, accountId, "secret")
+
+	tx, err := transaction.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+
+	tx.AccountTx().InsertAccount(ctx, entity.Account{Email: "rolled-back@example.com"})
+	tx.AccountTx().UpdatePassword(ctx, accountId, "new")
+	tx.AccountLimitTx().InsertLimit(ctx, entity.AccountLimit{AccountId: accountId, Limit1M: 1})
+	tx.ConsumerHistoryTx().InsertHistory(ctx, entity.ConsumerHistory{AccountId: accountId})
+	tx.RefreshTokenTx().RevokeAccountTokens(ctx, accountId)
+	tx.AccountMfaTx().ConfirmMfa(ctx, accountId)
+	tx.AccountMfaTx().ReplaceRecoveryCodes(ctx, accountId, []string{"hash"})
+
+	err = tx.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	accountRepo := NewAccountRepositoryMemory(store)
+
+	if account, _ := accountRepo.GetAccountByEmail(ctx, "rolled-back@example.com", false); account != nil {
+		t.Error("inserted account kept after rollback")
+	}
+
+	if account, _ := accountRepo.GetAccountByEmail(ctx, "kept@example.com", false); account == nil || account.Password != "old" {
+		t.Errorf("account = %+v, want password restored after rollback", account)
+	}
+
+	if limit, _ := NewAccountLimitRepositoryMemory(store).GetAccountLimitByAccountId(ctx, accountId, false); limit != nil {
+		t.Error("inserted limit kept after rollback")
+	}
+
+	if len(store.consumerHistories) != 0 {
+		t.Error("inserted history kept after rollback")
+	}
+
+	if store.refreshTokens[0].RevokedAt != nil {
+		t.Error("token revocation kept after rollback")
+	}
+
+	if mfa, _ := NewAccountMfaRepositoryMemory(store).GetMfa(ctx, accountId); mfa == nil || mfa.ConfirmedAt != nil {
+		t.Errorf("mfa = %+v, want pending after rollback", mfa)
+	}
+
+	if len(store.recoveryCodes) != 0 {
+		t.Error("inserted recovery codes kept after rollback")
+	}
+
+	if err = tx.Commit(); err == nil {
+		t.Error("Commit() after Rollback() error = nil, want error")
+	}
+}
+
+func TestMemoryTransaction_Commit(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	tx, _ := NewMemoryTransaction(store).Begin()
+	tx.AccountTx().InsertAccount(ctx, entity.Account{Email: "user@example.com"})
+
+	err := tx.Commit()
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if err = tx.Rollback(); err == nil {
+		t.Error("Rollback() after Commit() error = nil, want error")
+	}
+
+	if account, _ := NewAccountRepositoryMemory(store).GetAccountByEmail(ctx, "user@example.com", false); account == nil {
+		t.Error("committed account not found")
+	}
+}
+
+func TestMemoryTransaction_Begin_ShouldWaitForRunningTransaction(t *testing.T) {
+	store := NewMemoryStore()
+	first, _ := NewMemoryTransaction(store).Begin()
+
+	began := make(chan struct{})
+
+	go func() {
+		second, _ := NewMemoryTransaction(store).Begin()
+		close(began)
+		second.Commit()
+	}()
+
+	select {
+	case <-began:
+		t.Fatal("second transaction began while the first is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.Commit()
+
+	select {
+	case <-began:
+	case <-time.After(time.Second):
+		t.Fatal("second transaction did not begin after the first committed")
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type transactionRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewTransactionRepositoryMemory(store *MemoryStore) *transactionRepositoryMemory {
+	return &transactionRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *transactionRepositoryMemory) InsertTransaction(ctx context.Context, transaction entity.Transaction) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		transaction.Id = r.store.nextId("transactions")
+		transaction.CreatedAt = now
+		transaction.UpdatedAt = now
+		transaction.DeletedAt = nil
+
+		r.store.transactions[transaction.Id] = transaction
+
+		return func() {
+			delete(r.store.transactions, transaction.Id)
+		}
+	})
+
+	return transaction.Id, nil
+}
+
+func (r *transactionRepositoryMemory) GetTransactionsByAccountId(ctx context.Context, accountId int64) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+
+	r.store.read(func() {
+		for _, transaction := range r.store.transactions {
+			if transaction.AccountId == accountId && transaction.DeletedAt == nil {
+				transactions = append(transactions, transaction)
+			}
+		}
+	})
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Id < transactions[j].Id
+	})
+
+	return transactions, nil
+}
+
+func (r *transactionRepositoryMemory) HasOutstandingTransactions(ctx context.Context, accountId int64) (bool, error) {
+	var isOutstanding bool
+
+	r.store.read(func() {
+		for _, transaction := range r.store.transactions {
+			if transaction.AccountId == accountId && transaction.DeletedAt == nil && transaction.Status == appconstant.TransactionStatusApproved {
+				isOutstanding = true
+				return
+			}
+		}
+	})
+
+	return isOutstanding, nil
+}
+
+func (r *transactionRepositoryMemory) AnonymiseTransactions(ctx context.Context, accountId int64) error {
+	r.store.write(r.tx, func() func() {
+		prev := maps.Clone(r.store.transactions)
+
+		for id, transaction := range r.store.transactions {
+			if transaction.AccountId == accountId {
+				transaction.ContactNumber = ""
+				transaction.UpdatedAt = nowUnixMilli()
+
+				r.store.transactions[id] = transaction
+			}
+		}
+
+		return func() {
+			r.store.transactions = prev
+		}
+	})
+
+	return nil
+}
+
+func (r *transactionRepositoryMemory) GetTransactionById(ctx context.Context, transactionId int64, forUpdate bool) (*entity.Transaction, error) {
+	var transaction *entity.Transaction
+
+	r.store.read(func() {
+		t, ok := r.store.transactions[transactionId]
+		if ok && t.DeletedAt == nil {
+			transaction = &t
+		}
+	})
+
+	return transaction, nil
+}
+
+func (r *transactionRepositoryMemory) UpdateStatus(ctx context.Context, transactionId int64, status string) error {
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.transactions[transactionId]
+		if !ok {
+			return nil
+		}
+
+		transaction := prev
+		transaction.Status = status
+		transaction.UpdatedAt = nowUnixMilli()
+
+		r.store.transactions[transactionId] = transaction
+
+		return func() {
+			r.store.transactions[transactionId] = prev
+		}
+	})
+
+	return nil
+}
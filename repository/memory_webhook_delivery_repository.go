@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type webhookDeliveryRepositoryMemory struct {
+	store *MemoryStore
+	tx    *memoryTx
+}
+
+func NewWebhookDeliveryRepositoryMemory(store *MemoryStore) *webhookDeliveryRepositoryMemory {
+	return &webhookDeliveryRepositoryMemory{
+		store: store,
+	}
+}
+
+func (r *webhookDeliveryRepositoryMemory) InsertDelivery(ctx context.Context, delivery entity.WebhookDelivery) (int64, error) {
+	r.store.write(r.tx, func() func() {
+		now := nowUnixMilli()
+
+		delivery = entity.WebhookDelivery{
+			Id:            r.store.nextId("webhook_deliveries"),
+			WebhookId:     delivery.WebhookId,
+			PartnerId:     delivery.PartnerId,
+			EventType:     delivery.EventType,
+			Payload:       delivery.Payload,
+			Status:        appconstant.WebhookDeliveryStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		r.store.webhookDeliveries[delivery.Id] = delivery
+
+		return func() {
+			delete(r.store.webhookDeliveries, delivery.Id)
+		}
+	})
+
+	return delivery.Id, nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) GetDeliveryById(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error) {
+	var delivery *entity.WebhookDelivery
+
+	r.store.read(func() {
+		if d, ok := r.store.webhookDeliveries[deliveryId]; ok {
+			delivery = &d
+		}
+	})
+
+	return delivery, nil
+}
+
+// filter lists the deliveries for which match holds, sorted by id
+func (r *webhookDeliveryRepositoryMemory) filter(match func(delivery entity.WebhookDelivery) bool, isDescending bool) []entity.WebhookDelivery {
+	deliveries := []entity.WebhookDelivery{}
+
+	r.store.read(func() {
+		for _, delivery := range r.store.webhookDeliveries {
+			if match(delivery) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+	})
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if isDescending {
+			return deliveries[i].Id > deliveries[j].Id
+		}
+
+		return deliveries[i].Id < deliveries[j].Id
+	})
+
+	return deliveries
+}
+
+func (r *webhookDeliveryRepositoryMemory) GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error) {
+	deliveries := r.filter(func(delivery entity.WebhookDelivery) bool {
+		return (filter.Status == "" || delivery.Status == filter.Status) &&
+			(filter.PartnerId == 0 || delivery.PartnerId == filter.PartnerId) &&
+			(filter.BeforeId == 0 || delivery.Id < filter.BeforeId)
+	}, true)
+
+	if len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) GetDueDeliveries(ctx context.Context, now int64, limit int) ([]entity.WebhookDelivery, error) {
+	deliveries := r.filter(func(delivery entity.WebhookDelivery) bool {
+		return delivery.Status == appconstant.WebhookDeliveryStatusPending && delivery.NextAttemptAt <= now
+	}, false)
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+// update changes the delivery when cond holds, reports whether it was changed
+func (r *webhookDeliveryRepositoryMemory) update(deliveryId int64, cond func(delivery entity.WebhookDelivery) bool, fn func(delivery *entity.WebhookDelivery)) bool {
+	var isUpdated bool
+
+	r.store.write(r.tx, func() func() {
+		prev, ok := r.store.webhookDeliveries[deliveryId]
+		if !ok || !cond(prev) {
+			return nil
+		}
+
+		isUpdated = true
+
+		delivery := prev
+		fn(&delivery)
+		delivery.UpdatedAt = nowUnixMilli()
+
+		r.store.webhookDeliveries[deliveryId] = delivery
+
+		return func() {
+			r.store.webhookDeliveries[deliveryId] = prev
+		}
+	})
+
+	return isUpdated
+}
+
+func anyWebhookDelivery(entity.WebhookDelivery) bool {
+	return true
+}
+
+func (r *webhookDeliveryRepositoryMemory) ClaimDelivery(ctx context.Context, deliveryId, now, leaseUntil int64) (bool, error) {
+	isClaimed := r.update(deliveryId, func(delivery entity.WebhookDelivery) bool {
+		return delivery.Status == appconstant.WebhookDeliveryStatusPending && delivery.NextAttemptAt <= now
+	}, func(delivery *entity.WebhookDelivery) {
+		delivery.NextAttemptAt = leaseUntil
+	})
+
+	return isClaimed, nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) MarkDelivered(ctx context.Context, deliveryId int64, statusCode int) error {
+	r.update(deliveryId, anyWebhookDelivery, func(delivery *entity.WebhookDelivery) {
+		now := nowUnixMilli()
+
+		delivery.Status = appconstant.WebhookDeliveryStatusDelivered
+		delivery.Attempts++
+		delivery.LastError = ""
+		delivery.LastStatusCode = &statusCode
+		delivery.DeliveredAt = &now
+	})
+
+	return nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) MarkFailed(ctx context.Context, deliveryId int64, lastError string, statusCode *int, nextAttemptAt int64) error {
+	r.update(deliveryId, anyWebhookDelivery, func(delivery *entity.WebhookDelivery) {
+		delivery.Attempts++
+		delivery.LastError = lastError
+		delivery.LastStatusCode = statusCode
+		delivery.NextAttemptAt = nextAttemptAt
+	})
+
+	return nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) MarkDead(ctx context.Context, deliveryId int64, lastError string, statusCode *int) error {
+	r.update(deliveryId, anyWebhookDelivery, func(delivery *entity.WebhookDelivery) {
+		delivery.Status = appconstant.WebhookDeliveryStatusDead
+		delivery.Attempts++
+		delivery.LastError = lastError
+		delivery.LastStatusCode = statusCode
+	})
+
+	return nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) Redeliver(ctx context.Context, deliveryId, now int64) (bool, error) {
+	isRedelivered := r.update(deliveryId, func(delivery entity.WebhookDelivery) bool {
+		return delivery.Status != appconstant.WebhookDeliveryStatusPending
+	}, func(delivery *entity.WebhookDelivery) {
+		delivery.Status = appconstant.WebhookDeliveryStatusPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = now
+		delivery.DeliveredAt = nil
+	})
+
+	return isRedelivered, nil
+}
+
+func (r *webhookDeliveryRepositoryMemory) DeleteDeliveredDeliveries(ctx context.Context, deliveredBefore int64, limit int) (int64, error) {
+	var deleted int64
+
+	r.store.write(r.tx, func() func() {
+		removed := map[int64]entity.WebhookDelivery{}
+
+		for id, delivery := range r.store.webhookDeliveries {
+			if deleted >= int64(limit) {
+				break
+			}
+
+			if delivery.Status != appconstant.WebhookDeliveryStatusDelivered || delivery.DeliveredAt == nil || *delivery.DeliveredAt > deliveredBefore {
+				continue
+			}
+
+			removed[id] = delivery
+			delete(r.store.webhookDeliveries, id)
+
+			deleted++
+		}
+
+		return func() {
+			for id, delivery := range removed {
+				r.store.webhookDeliveries[id] = delivery
+			}
+		}
+	})
+
+	return deleted, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
@@ -136,3 +137,25 @@ func (r *accountLimitRepositoryMysql) InsertLimit(ctx context.Context, accountLi
 
 	return nil
 }
+
+func (r *accountLimitRepositoryMysql) DeleteLimit(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_limits
+		SET deleted_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_limit_repository][DeleteLimit][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
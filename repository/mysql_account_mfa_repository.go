@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountMfaRepositoryMysql struct {
+	dbtx DBTX
+}
+
+func NewAccountMfaRepositoryMysql(dbtx DBTX) *accountMfaRepositoryMysql {
+	return &accountMfaRepositoryMysql{
+		dbtx: dbtx,
+	}
+}
+
+func (r *accountMfaRepositoryMysql) GetMfa(ctx context.Context, accountId int64) (*entity.AccountMfa, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT account_id, secret, confirmed_at, last_used_step, created_at, updated_at
+		FROM account_mfa
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	var mfa entity.AccountMfa
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId).Scan(
+		&mfa.AccountId,
+		&mfa.Secret,
+		&mfa.ConfirmedAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[mysql_account_mfa_repository][GetMfa][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return &mfa, nil
+}
+
+// SavePendingMfa stores the secret of an enrolment, replacing a pending one. A confirmed enrolment is kept.
+func (r *accountMfaRepositoryMysql) SavePendingMfa(ctx context.Context, accountId int64, secret string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO account_mfa (account_id, secret, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			secret = IF(confirmed_at IS NULL, VALUES(secret), secret),
+			last_used_step = IF(confirmed_at IS NULL, 0, last_used_step),
+			updated_at = IF(confirmed_at IS NULL, VALUES(updated_at), updated_at)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId, secret, now, now)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_mfa_repository][SavePendingMfa][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// ConfirmMfa enables a pending enrolment, reports whether it was pending
+func (r *accountMfaRepositoryMysql) ConfirmMfa(ctx context.Context, accountId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa
+		SET confirmed_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND confirmed_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][ConfirmMfa][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][ConfirmMfa][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// UseTotpStep records the time step of an accepted code, reports false when that step or a later one was already used
+func (r *accountMfaRepositoryMysql) UseTotpStep(ctx context.Context, accountId, step int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa
+		SET last_used_step = ?, updated_at = ?
+		WHERE account_id = ?
+			AND last_used_step < ?
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, step, nowUnixMilli(), accountId, step)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][UseTotpStep][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][UseTotpStep][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *accountMfaRepositoryMysql) ReplaceRecoveryCodes(ctx context.Context, accountId int64, codeHashes []string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM account_mfa_recovery_codes
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_mfa_repository][ReplaceRecoveryCodes][ExecContext][delete] error: %w | account_id: %v", err, accountId)
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		INSERT INTO account_mfa_recovery_codes (account_id, code_hash, created_at, updated_at)
+		VALUES `)
+
+	now := nowUnixMilli()
+
+	var args []any
+
+	for i, codeHash := range codeHashes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString("(?, ?, ?, ?)")
+
+		args = append(args, accountId, codeHash, now, now)
+	}
+
+	q = sb.String()
+
+	_, err = r.dbtx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_mfa_repository][ReplaceRecoveryCodes][ExecContext][insert] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, reports whether there was one
+func (r *accountMfaRepositoryMysql) UseRecoveryCode(ctx context.Context, accountId int64, codeHash string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa_recovery_codes
+		SET used_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND code_hash = ?
+			AND used_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, accountId, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][UseRecoveryCode][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_mfa_repository][UseRecoveryCode][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteMfa deletes the authenticator and the recovery codes of the account
+func (r *accountMfaRepositoryMysql) DeleteMfa(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM account_mfa_recovery_codes
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_mfa_repository][DeleteMfa][ExecContext][recovery_codes] error: %w | account_id: %v", err, accountId)
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		DELETE FROM account_mfa
+		WHERE account_id = ?
+	`)
+
+	q = sb.String()
+
+	_, err = r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_mfa_repository][DeleteMfa][ExecContext][mfa] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
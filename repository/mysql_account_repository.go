@@ -24,7 +24,7 @@ func (r *accountRepositoryMysql) GetAccountByEmail(ctx context.Context, email st
 	var sb strings.Builder
 
 	sb.WriteString(`
-		SELECT account_id, email, password, created_at, updated_at, deleted_at
+		SELECT account_id, email, password, disabled_at, verified_at, verification_sent_at, password_reset_sent_at, created_at, updated_at, deleted_at
 		FROM accounts
 		WHERE email = ?
 			AND deleted_at IS NULL
@@ -42,6 +42,10 @@ func (r *accountRepositoryMysql) GetAccountByEmail(ctx context.Context, email st
 		&account.Id,
 		&account.Email,
 		&account.Password,
+		&account.DisabledAt,
+		&account.VerifiedAt,
+		&account.VerificationSentAt,
+		&account.PasswordResetSentAt,
 		&account.CreatedAt,
 		&account.UpdatedAt,
 		&account.DeletedAt,
@@ -57,19 +61,60 @@ func (r *accountRepositoryMysql) GetAccountByEmail(ctx context.Context, email st
 	return &account, nil
 }
 
+func (r *accountRepositoryMysql) GetAccountById(ctx context.Context, accountId int64, forUpdate bool) (*entity.Account, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT account_id, email, password, disabled_at, verified_at, verification_sent_at, password_reset_sent_at, created_at, updated_at, deleted_at
+		FROM accounts
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	if forUpdate {
+		sb.WriteString(`FOR UPDATE`)
+	}
+
+	q := sb.String()
+
+	var account entity.Account
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId).Scan(
+		&account.Id,
+		&account.Email,
+		&account.Password,
+		&account.DisabledAt,
+		&account.VerifiedAt,
+		&account.VerificationSentAt,
+		&account.PasswordResetSentAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[mysql_account_repository][GetAccountById][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return &account, nil
+}
+
 func (r *accountRepositoryMysql) InsertAccount(ctx context.Context, account entity.Account) (int64, error) {
 	var sb strings.Builder
-	
+
 	sb.WriteString(`
-		INSERT INTO accounts (email, password, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO accounts (email, password, verified_at, verification_sent_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`)
 
 	q := sb.String()
-	
+
 	now := nowUnixMilli()
 
-	res, err := r.dbtx.ExecContext(ctx, q, account.Email, account.Password, now, now)
+	res, err := r.dbtx.ExecContext(ctx, q, account.Email, account.Password, account.VerifiedAt, account.VerificationSentAt, now, now)
 	if err != nil {
 		return 0, fmt.Errorf("[mysql_account_repository][InsertAccount][ExecContext] error: %w | email: %s", err, account.Email)
 	}
@@ -81,3 +126,276 @@ func (r *accountRepositoryMysql) InsertAccount(ctx context.Context, account enti
 
 	return accountId, nil
 }
+
+func (r *accountRepositoryMysql) DisableAccount(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET disabled_at = ?, updated_at = ?
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_repository][DisableAccount][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+func (r *accountRepositoryMysql) UpdatePassword(ctx context.Context, accountId int64, password string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET password = ?, updated_at = ?
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, password, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_repository][UpdatePassword][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// SetVerificationSentAt records a new verification email unless the previous one was sent after notAfter, reports whether it was recorded
+func (r *accountRepositoryMysql) SetVerificationSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET verification_sent_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND verified_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at <= ?)
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, sentAt, nowUnixMilli(), accountId, notAfter)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][SetVerificationSentAt][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][SetVerificationSentAt][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// VerifyEmail verifies the email if the verification sent at sentAt is still the latest one, reports whether it was verified
+func (r *accountRepositoryMysql) VerifyEmail(ctx context.Context, accountId, sentAt int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET verified_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND verified_at IS NULL
+			AND verification_sent_at = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, accountId, sentAt)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][VerifyEmail][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][VerifyEmail][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// SetPasswordResetSentAt records a new reset email unless the previous one was sent after notAfter, reports whether it was recorded
+func (r *accountRepositoryMysql) SetPasswordResetSentAt(ctx context.Context, accountId, sentAt, notAfter int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET password_reset_sent_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND (password_reset_sent_at IS NULL OR password_reset_sent_at <= ?)
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, sentAt, nowUnixMilli(), accountId, notAfter)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][SetPasswordResetSentAt][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][SetPasswordResetSentAt][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// ResetPassword sets the password if the reset sent at sentAt is still the latest one and unused, reports whether it was set
+func (r *accountRepositoryMysql) ResetPassword(ctx context.Context, accountId, sentAt int64, password string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET password = ?, password_reset_sent_at = NULL, updated_at = ?
+		WHERE account_id = ?
+			AND password_reset_sent_at = ?
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, password, nowUnixMilli(), accountId, sentAt)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][ResetPassword][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][ResetPassword][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// CloseAccount soft-deletes the account, keeping the hash of its email when the email is not free for reuse yet.
+// Reports false when the account was already closed.
+func (r *accountRepositoryMysql) CloseAccount(ctx context.Context, accountId int64, emailHash *string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET deleted_at = ?, email_hash = ?, updated_at = ?
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, emailHash, now, accountId)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][CloseAccount][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][CloseAccount][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// IsEmailHeld reports whether a closed account keeps the email from being registered again
+func (r *accountRepositoryMysql) IsEmailHeld(ctx context.Context, emailHash string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT COUNT(*)
+		FROM accounts
+		WHERE email_hash = ?
+			AND deleted_at IS NOT NULL
+	`)
+
+	q := sb.String()
+
+	var count int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, emailHash).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_account_repository][IsEmailHeld][QueryRowContext] error: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetAccountIdsToAnonymise lists up to limit accounts closed before closedBefore and not anonymised yet, closed first first
+func (r *accountRepositoryMysql) GetAccountIdsToAnonymise(ctx context.Context, closedBefore int64, limit int) ([]int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT account_id
+		FROM accounts
+		WHERE deleted_at <= ?
+			AND anonymised_at IS NULL
+		ORDER BY deleted_at
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, closedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_account_repository][GetAccountIdsToAnonymise][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	var accountIds []int64
+
+	for rows.Next() {
+		var accountId int64
+
+		err = rows.Scan(&accountId)
+		if err != nil {
+			return nil, fmt.Errorf("[mysql_account_repository][GetAccountIdsToAnonymise][rows.Scan] error: %w", err)
+		}
+
+		accountIds = append(accountIds, accountId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[mysql_account_repository][GetAccountIdsToAnonymise][rows.Err] error: %w", err)
+	}
+
+	return accountIds, nil
+}
+
+// AnonymiseAccount clears the email and password of a closed account, and the hash of its email unless it is kept
+func (r *accountRepositoryMysql) AnonymiseAccount(ctx context.Context, accountId int64, isEmailHashKept bool) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE accounts
+		SET email = '', password = '', anonymised_at = ?, updated_at = ?
+	`)
+
+	if !isEmailHashKept {
+		sb.WriteString(`, email_hash = NULL`)
+	}
+
+	sb.WriteString(`
+		WHERE account_id = ?
+			AND deleted_at IS NOT NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_account_repository][AnonymiseAccount][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type consumerHistoryRepositoryMysql struct {
+	dbtx DBTX
+}
+
+func NewConsumerHistoryRepositoryMysql(dbtx DBTX) *consumerHistoryRepositoryMysql {
+	return &consumerHistoryRepositoryMysql{
+		dbtx: dbtx,
+	}
+}
+
+func (r *consumerHistoryRepositoryMysql) InsertHistory(ctx context.Context, history entity.ConsumerHistory) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO consumer_histories (
+			consumer_id,
+			account_id,
+			version,
+			identity_number,
+			full_name,
+			legal_name,
+			place_of_birth,
+			date_of_birth,
+			salary,
+			identity_card_photo_key,
+			selfie_photo_key,
+			kyc_status,
+			created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		history.ConsumerId,
+		history.AccountId,
+		history.Version,
+		history.IdentityNumber,
+		history.FullName,
+		history.LegalName,
+		history.PlaceOfBirth,
+		history.DateOfBirth,
+		history.Salary,
+		history.IdentityCardPhotoKey,
+		history.SelfiePhotoKey,
+		history.KycStatus,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("[mysql_consumer_history_repository][InsertHistory][ExecContext] error: %w | account_id: %v", err, history.AccountId)
+	}
+
+	return nil
+}
+
+func (r *consumerHistoryRepositoryMysql) AnonymiseHistories(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE consumer_histories
+		SET
+			identity_number = '',
+			full_name = '',
+			legal_name = '',
+			place_of_birth = '',
+			date_of_birth = '',
+			salary = 0,
+			identity_card_photo_key = '',
+			selfie_photo_key = ''
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[mysql_consumer_history_repository][AnonymiseHistories][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+func (r *consumerHistoryRepositoryMysql) GetHistoriesByAccountId(ctx context.Context, accountId int64) ([]entity.ConsumerHistory, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			consumer_history_id,
+			consumer_id,
+			account_id,
+			version,
+			identity_number,
+			full_name,
+			legal_name,
+			place_of_birth,
+			date_of_birth,
+			salary,
+			identity_card_photo_key,
+			selfie_photo_key,
+			kyc_status,
+			created_at
+		FROM consumer_histories
+		WHERE account_id = ?
+		ORDER BY version
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_consumer_history_repository][GetHistoriesByAccountId][QueryContext] error: %w | account_id: %v", err, accountId)
+	}
+	defer rows.Close()
+
+	var histories []entity.ConsumerHistory
+
+	for rows.Next() {
+		var history entity.ConsumerHistory
+
+		err = rows.Scan(
+			&history.Id,
+			&history.ConsumerId,
+			&history.AccountId,
+			&history.Version,
+			&history.IdentityNumber,
+			&history.FullName,
+			&history.LegalName,
+			&history.PlaceOfBirth,
+			&history.DateOfBirth,
+			&history.Salary,
+			&history.IdentityCardPhotoKey,
+			&history.SelfiePhotoKey,
+			&history.KycStatus,
+			&history.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[mysql_consumer_history_repository][GetHistoriesByAccountId][rows.Scan] error: %w | account_id: %v", err, accountId)
+		}
+
+		histories = append(histories, history)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[mysql_consumer_history_repository][GetHistoriesByAccountId][rows.Err] error: %w | account_id: %v", err, accountId)
+	}
+
+	return histories, nil
+}
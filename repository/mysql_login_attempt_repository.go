@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type loginAttemptRepositoryMysql struct {
+	dbtx DBTX
+}
+
+func NewLoginAttemptRepositoryMysql(dbtx DBTX) *loginAttemptRepositoryMysql {
+	return &loginAttemptRepositoryMysql{
+		dbtx: dbtx,
+	}
+}
+
+func (r *loginAttemptRepositoryMysql) GetLoginAttempt(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT attempt_key, failed_count, last_failed_at, locked_until, created_at, updated_at
+		FROM login_attempts
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	var attempt entity.LoginAttempt
+
+	err := r.dbtx.QueryRowContext(ctx, q, key).Scan(
+		&attempt.Key,
+		&attempt.FailedCount,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+		&attempt.CreatedAt,
+		&attempt.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[mysql_login_attempt_repository][GetLoginAttempt][QueryRowContext] error: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+// The count restarts when the last failure is older than windowStart. MySQL assigns left to right using updated values,
+// so last_failed_at is assigned last.
+func (r *loginAttemptRepositoryMysql) IncrementFailedAttempt(ctx context.Context, key string, windowStart int64) (*entity.LoginAttempt, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO login_attempts (attempt_key, failed_count, last_failed_at, created_at, updated_at)
+		VALUES (?, 1, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			failed_count = IF(last_failed_at < ?, 1, failed_count + 1),
+			locked_until = IF(last_failed_at < ?, NULL, locked_until),
+			updated_at = VALUES(updated_at),
+			last_failed_at = VALUES(last_failed_at)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, key, now, now, now, windowStart, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_login_attempt_repository][IncrementFailedAttempt][ExecContext] error: %w", err)
+	}
+
+	attempt, err := r.GetLoginAttempt(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_login_attempt_repository][IncrementFailedAttempt][GetLoginAttempt] error: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (r *loginAttemptRepositoryMysql) LockLoginAttempt(ctx context.Context, key string, lockedUntil int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE login_attempts
+		SET locked_until = ?, updated_at = ?
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, lockedUntil, nowUnixMilli(), key)
+	if err != nil {
+		return fmt.Errorf("[mysql_login_attempt_repository][LockLoginAttempt][ExecContext] error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginAttemptRepositoryMysql) DeleteLoginAttempt(ctx context.Context, key string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM login_attempts
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, key)
+	if err != nil {
+		return fmt.Errorf("[mysql_login_attempt_repository][DeleteLoginAttempt][ExecContext] error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerWebhookRepositoryMysql struct {
+	dbtx DBTX
+}
+
+func NewPartnerWebhookRepositoryMysql(dbtx DBTX) *partnerWebhookRepositoryMysql {
+	return &partnerWebhookRepositoryMysql{
+		dbtx: dbtx,
+	}
+}
+
+// The event types are stored comma separated
+func (r *partnerWebhookRepositoryMysql) InsertWebhook(ctx context.Context, webhook entity.PartnerWebhook) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO partner_webhooks (partner_id, url, secret, event_types, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, webhook.PartnerId, webhook.Url, webhook.Secret, strings.Join(webhook.EventTypes, ","), now, now)
+	if err != nil {
+		return 0, fmt.Errorf("[mysql_partner_webhook_repository][InsertWebhook][ExecContext] error: %w | partner_id: %v", err, webhook.PartnerId)
+	}
+
+	webhookId, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("[mysql_partner_webhook_repository][InsertWebhook][LastInsertId] error: %w | partner_id: %v", err, webhook.PartnerId)
+	}
+
+	return webhookId, nil
+}
+
+func (r *partnerWebhookRepositoryMysql) queryWebhooks(ctx context.Context, q string, args ...any) ([]entity.PartnerWebhook, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_partner_webhook_repository][queryWebhooks][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []entity.PartnerWebhook{}
+
+	for rows.Next() {
+		var webhook entity.PartnerWebhook
+		var eventTypes string
+
+		err = rows.Scan(
+			&webhook.Id,
+			&webhook.PartnerId,
+			&webhook.Url,
+			&webhook.Secret,
+			&eventTypes,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+			&webhook.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[mysql_partner_webhook_repository][queryWebhooks][rows.Scan] error: %w", err)
+		}
+
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[mysql_partner_webhook_repository][queryWebhooks][rows.Err] error: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *partnerWebhookRepositoryMysql) GetWebhookById(ctx context.Context, webhookId int64) (*entity.PartnerWebhook, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			partner_webhook_id,
+			partner_id,
+			url,
+			secret,
+			event_types,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM partner_webhooks
+		WHERE partner_webhook_id = ?
+	`)
+
+	q := sb.String()
+
+	webhooks, err := r.queryWebhooks(ctx, q, webhookId)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_partner_webhook_repository][GetWebhookById][queryWebhooks] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+
+	return &webhooks[0], nil
+}
+
+func (r *partnerWebhookRepositoryMysql) GetWebhooksByPartnerId(ctx context.Context, partnerId int64) ([]entity.PartnerWebhook, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			partner_webhook_id,
+			partner_id,
+			url,
+			secret,
+			event_types,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM partner_webhooks
+		WHERE partner_id = ?
+			AND deleted_at IS NULL
+		ORDER BY partner_webhook_id
+	`)
+
+	q := sb.String()
+
+	webhooks, err := r.queryWebhooks(ctx, q, partnerId)
+	if err != nil {
+		return nil, fmt.Errorf("[mysql_partner_webhook_repository][GetWebhooksByPartnerId][queryWebhooks] error: %w | partner_id: %v", err, partnerId)
+	}
+
+	return webhooks, nil
+}
+
+func (r *partnerWebhookRepositoryMysql) DeleteWebhook(ctx context.Context, partnerId, webhookId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE partner_webhooks
+		SET deleted_at = ?, updated_at = ?
+		WHERE partner_webhook_id = ?
+			AND partner_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, webhookId, partnerId)
+	if err != nil {
+		return false, fmt.Errorf("[mysql_partner_webhook_repository][DeleteWebhook][ExecContext] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[mysql_partner_webhook_repository][DeleteWebhook][RowsAffected] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountMfaRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewAccountMfaRepositoryPostgres(dbtx DBTX) *accountMfaRepositoryPostgres {
+	return &accountMfaRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+func (r *accountMfaRepositoryPostgres) GetMfa(ctx context.Context, accountId int64) (*entity.AccountMfa, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT account_id, secret, confirmed_at, last_used_step, created_at, updated_at
+		FROM account_mfa
+		WHERE account_id = $1
+	`)
+
+	q := sb.String()
+
+	var mfa entity.AccountMfa
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId).Scan(
+		&mfa.AccountId,
+		&mfa.Secret,
+		&mfa.ConfirmedAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[postgres_account_mfa_repository][GetMfa][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return &mfa, nil
+}
+
+// SavePendingMfa stores the secret of an enrolment, replacing a pending one. A confirmed enrolment is kept.
+func (r *accountMfaRepositoryPostgres) SavePendingMfa(ctx context.Context, accountId int64, secret string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO account_mfa (account_id, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id) DO UPDATE SET
+			secret = excluded.secret,
+			last_used_step = 0,
+			updated_at = excluded.updated_at
+		WHERE account_mfa.confirmed_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId, secret, now, now)
+	if err != nil {
+		return fmt.Errorf("[postgres_account_mfa_repository][SavePendingMfa][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// ConfirmMfa enables a pending enrolment, reports whether it was pending
+func (r *accountMfaRepositoryPostgres) ConfirmMfa(ctx context.Context, accountId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa
+		SET confirmed_at = $1, updated_at = $2
+		WHERE account_id = $3
+			AND confirmed_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][ConfirmMfa][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][ConfirmMfa][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// UseTotpStep records the time step of an accepted code, reports false when that step or a later one was already used
+func (r *accountMfaRepositoryPostgres) UseTotpStep(ctx context.Context, accountId, step int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa
+		SET last_used_step = $1, updated_at = $2
+		WHERE account_id = $3
+			AND last_used_step < $4
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, step, nowUnixMilli(), accountId, step)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][UseTotpStep][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][UseTotpStep][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *accountMfaRepositoryPostgres) ReplaceRecoveryCodes(ctx context.Context, accountId int64, codeHashes []string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM account_mfa_recovery_codes
+		WHERE account_id = $1
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[postgres_account_mfa_repository][ReplaceRecoveryCodes][ExecContext][delete] error: %w | account_id: %v", err, accountId)
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		INSERT INTO account_mfa_recovery_codes (account_id, code_hash, created_at, updated_at)
+		VALUES `)
+
+	now := nowUnixMilli()
+
+	var args []any
+
+	for i, codeHash := range codeHashes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		n := len(args)
+		fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4)
+
+		args = append(args, accountId, codeHash, now, now)
+	}
+
+	q = sb.String()
+
+	_, err = r.dbtx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("[postgres_account_mfa_repository][ReplaceRecoveryCodes][ExecContext][insert] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, reports whether there was one
+func (r *accountMfaRepositoryPostgres) UseRecoveryCode(ctx context.Context, accountId int64, codeHash string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_mfa_recovery_codes
+		SET used_at = $1, updated_at = $2
+		WHERE account_id = $3
+			AND code_hash = $4
+			AND used_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, accountId, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][UseRecoveryCode][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_account_mfa_repository][UseRecoveryCode][RowsAffected] error: %w | account_id: %v", err, accountId)
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteMfa deletes the authenticator and the recovery codes of the account
+func (r *accountMfaRepositoryPostgres) DeleteMfa(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM account_mfa_recovery_codes
+		WHERE account_id = $1
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[postgres_account_mfa_repository][DeleteMfa][ExecContext][recovery_codes] error: %w | account_id: %v", err, accountId)
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		DELETE FROM account_mfa
+		WHERE account_id = $1
+	`)
+
+	q = sb.String()
+
+	_, err = r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[postgres_account_mfa_repository][DeleteMfa][ExecContext][mfa] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
@@ -0,0 +1,377 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type dataExportRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewDataExportRepositoryPostgres(dbtx DBTX) *dataExportRepositoryPostgres {
+	return &dataExportRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+func (r *dataExportRepositoryPostgres) InsertExport(ctx context.Context, export entity.DataExport) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO data_exports (account_id, requested_by, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING data_export_id
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	var exportId int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, export.AccountId, export.RequestedBy, appconstant.DataExportStatusPending, now, now).Scan(&exportId)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_data_export_repository][InsertExport][QueryRowContext] error: %w | account_id: %v", err, export.AccountId)
+	}
+
+	return exportId, nil
+}
+
+func (r *dataExportRepositoryPostgres) GetExportById(ctx context.Context, exportId int64) (*entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE data_export_id = $1
+	`)
+
+	q := sb.String()
+
+	var export entity.DataExport
+
+	err := r.dbtx.QueryRowContext(ctx, q, exportId).Scan(
+		&export.Id,
+		&export.AccountId,
+		&export.RequestedBy,
+		&export.Status,
+		&export.ArchiveKey,
+		&export.Failure,
+		&export.CompletedAt,
+		&export.ExpiredAt,
+		&export.DownloadedAt,
+		&export.DownloadCount,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExportById][QueryRowContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return &export, nil
+}
+
+// GetExportsByAccountId lists the exports of the account, newest first
+func (r *dataExportRepositoryPostgres) GetExportsByAccountId(ctx context.Context, accountId int64) ([]entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE account_id = $1
+		ORDER BY data_export_id DESC
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExportsByAccountId][QueryContext] error: %w | account_id: %v", err, accountId)
+	}
+	defer rows.Close()
+
+	exports := []entity.DataExport{}
+
+	for rows.Next() {
+		var export entity.DataExport
+
+		err = rows.Scan(
+			&export.Id,
+			&export.AccountId,
+			&export.RequestedBy,
+			&export.Status,
+			&export.ArchiveKey,
+			&export.Failure,
+			&export.CompletedAt,
+			&export.ExpiredAt,
+			&export.DownloadedAt,
+			&export.DownloadCount,
+			&export.CreatedAt,
+			&export.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_data_export_repository][GetExportsByAccountId][rows.Scan] error: %w | account_id: %v", err, accountId)
+		}
+
+		exports = append(exports, export)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExportsByAccountId][rows.Err] error: %w | account_id: %v", err, accountId)
+	}
+
+	return exports, nil
+}
+
+// GetExportIdsToProcess lists pending exports, and exports left processing since staleBefore by an instance that stopped
+func (r *dataExportRepositoryPostgres) GetExportIdsToProcess(ctx context.Context, staleBefore int64, limit int) ([]int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT data_export_id
+		FROM data_exports
+		WHERE status = $1
+			OR (status = $2 AND updated_at <= $3)
+		ORDER BY data_export_id
+		LIMIT $4
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, appconstant.DataExportStatusPending, appconstant.DataExportStatusProcessing, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExportIdsToProcess][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	var exportIds []int64
+
+	for rows.Next() {
+		var exportId int64
+
+		err = rows.Scan(&exportId)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_data_export_repository][GetExportIdsToProcess][rows.Scan] error: %w", err)
+		}
+
+		exportIds = append(exportIds, exportId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExportIdsToProcess][rows.Err] error: %w", err)
+	}
+
+	return exportIds, nil
+}
+
+// ClaimExport marks the export processing, reports false when another instance claimed it first
+func (r *dataExportRepositoryPostgres) ClaimExport(ctx context.Context, exportId, staleBefore int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = $1, updated_at = $2
+		WHERE data_export_id = $3
+			AND (status = $4 OR (status = $5 AND updated_at <= $6))
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusProcessing, nowUnixMilli(), exportId, appconstant.DataExportStatusPending, appconstant.DataExportStatusProcessing, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_data_export_repository][ClaimExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_data_export_repository][ClaimExport][RowsAffected] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *dataExportRepositoryPostgres) CompleteExport(ctx context.Context, exportId int64, archiveKey string, expiredAt int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = $1, archive_key = $2, completed_at = $3, expired_at = $4, updated_at = $5
+		WHERE data_export_id = $6
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusCompleted, archiveKey, now, expiredAt, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[postgres_data_export_repository][CompleteExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryPostgres) FailExport(ctx context.Context, exportId int64, failure string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = $1, failure = $2, updated_at = $3
+		WHERE data_export_id = $4
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusFailed, failure, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[postgres_data_export_repository][FailExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositoryPostgres) RecordDownload(ctx context.Context, exportId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET downloaded_at = $1, download_count = download_count + 1, updated_at = $2
+		WHERE data_export_id = $3
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[postgres_data_export_repository][RecordDownload][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+// GetExpiredExports lists completed exports whose archive can no longer be downloaded
+func (r *dataExportRepositoryPostgres) GetExpiredExports(ctx context.Context, now int64, limit int) ([]entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE status = $1
+			AND expired_at <= $2
+		ORDER BY data_export_id
+		LIMIT $3
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, appconstant.DataExportStatusCompleted, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExpiredExports][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []entity.DataExport{}
+
+	for rows.Next() {
+		var export entity.DataExport
+
+		err = rows.Scan(
+			&export.Id,
+			&export.AccountId,
+			&export.RequestedBy,
+			&export.Status,
+			&export.ArchiveKey,
+			&export.Failure,
+			&export.CompletedAt,
+			&export.ExpiredAt,
+			&export.DownloadedAt,
+			&export.DownloadCount,
+			&export.CreatedAt,
+			&export.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_data_export_repository][GetExpiredExports][rows.Scan] error: %w", err)
+		}
+
+		exports = append(exports, export)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_data_export_repository][GetExpiredExports][rows.Err] error: %w", err)
+	}
+
+	return exports, nil
+}
+
+// ExpireExport marks the export expired once its archive was deleted
+func (r *dataExportRepositoryPostgres) ExpireExport(ctx context.Context, exportId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = $1, archive_key = '', updated_at = $2
+		WHERE data_export_id = $3
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusExpired, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[postgres_data_export_repository][ExpireExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
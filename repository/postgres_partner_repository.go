@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewPartnerRepositoryPostgres(dbtx DBTX) *partnerRepositoryPostgres {
+	return &partnerRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+func (r *partnerRepositoryPostgres) InsertPartner(ctx context.Context, partner entity.Partner) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO partners (name, api_key_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING partner_id
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	var partnerId int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, partner.Name, partner.ApiKeyHash, now, now).Scan(&partnerId)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_partner_repository][InsertPartner][QueryRowContext] error: %w | name: %v", err, partner.Name)
+	}
+
+	return partnerId, nil
+}
+
+func (r *partnerRepositoryPostgres) queryPartners(ctx context.Context, q string, args ...any) ([]entity.Partner, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_repository][queryPartners][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	partners := []entity.Partner{}
+
+	for rows.Next() {
+		var partner entity.Partner
+
+		err = rows.Scan(
+			&partner.Id,
+			&partner.Name,
+			&partner.ApiKeyHash,
+			&partner.CreatedAt,
+			&partner.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_partner_repository][queryPartners][rows.Scan] error: %w", err)
+		}
+
+		partners = append(partners, partner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_partner_repository][queryPartners][rows.Err] error: %w", err)
+	}
+
+	return partners, nil
+}
+
+func (r *partnerRepositoryPostgres) GetPartnerById(ctx context.Context, partnerId int64) (*entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		WHERE partner_id = $1
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q, partnerId)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_repository][GetPartnerById][queryPartners] error: %w | partner_id: %v", err, partnerId)
+	}
+
+	if len(partners) == 0 {
+		return nil, nil
+	}
+
+	return &partners[0], nil
+}
+
+func (r *partnerRepositoryPostgres) GetPartnerByApiKeyHash(ctx context.Context, apiKeyHash string) (*entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		WHERE api_key_hash = $1
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q, apiKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_repository][GetPartnerByApiKeyHash][queryPartners] error: %w", err)
+	}
+
+	if len(partners) == 0 {
+		return nil, nil
+	}
+
+	return &partners[0], nil
+}
+
+func (r *partnerRepositoryPostgres) GetPartners(ctx context.Context) ([]entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		ORDER BY partner_id
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_repository][GetPartners][queryPartners] error: %w", err)
+	}
+
+	return partners, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerWebhookRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewPartnerWebhookRepositoryPostgres(dbtx DBTX) *partnerWebhookRepositoryPostgres {
+	return &partnerWebhookRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+// The event types are stored comma separated
+func (r *partnerWebhookRepositoryPostgres) InsertWebhook(ctx context.Context, webhook entity.PartnerWebhook) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO partner_webhooks (partner_id, url, secret, event_types, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING partner_webhook_id
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	var webhookId int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, webhook.PartnerId, webhook.Url, webhook.Secret, strings.Join(webhook.EventTypes, ","), now, now).Scan(&webhookId)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_partner_webhook_repository][InsertWebhook][QueryRowContext] error: %w | partner_id: %v", err, webhook.PartnerId)
+	}
+
+	return webhookId, nil
+}
+
+func (r *partnerWebhookRepositoryPostgres) queryWebhooks(ctx context.Context, q string, args ...any) ([]entity.PartnerWebhook, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_webhook_repository][queryWebhooks][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []entity.PartnerWebhook{}
+
+	for rows.Next() {
+		var webhook entity.PartnerWebhook
+		var eventTypes string
+
+		err = rows.Scan(
+			&webhook.Id,
+			&webhook.PartnerId,
+			&webhook.Url,
+			&webhook.Secret,
+			&eventTypes,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+			&webhook.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_partner_webhook_repository][queryWebhooks][rows.Scan] error: %w", err)
+		}
+
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_partner_webhook_repository][queryWebhooks][rows.Err] error: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *partnerWebhookRepositoryPostgres) GetWebhookById(ctx context.Context, webhookId int64) (*entity.PartnerWebhook, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			partner_webhook_id,
+			partner_id,
+			url,
+			secret,
+			event_types,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM partner_webhooks
+		WHERE partner_webhook_id = $1
+	`)
+
+	q := sb.String()
+
+	webhooks, err := r.queryWebhooks(ctx, q, webhookId)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_webhook_repository][GetWebhookById][queryWebhooks] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+
+	return &webhooks[0], nil
+}
+
+func (r *partnerWebhookRepositoryPostgres) GetWebhooksByPartnerId(ctx context.Context, partnerId int64) ([]entity.PartnerWebhook, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			partner_webhook_id,
+			partner_id,
+			url,
+			secret,
+			event_types,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM partner_webhooks
+		WHERE partner_id = $1
+			AND deleted_at IS NULL
+		ORDER BY partner_webhook_id
+	`)
+
+	q := sb.String()
+
+	webhooks, err := r.queryWebhooks(ctx, q, partnerId)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_partner_webhook_repository][GetWebhooksByPartnerId][queryWebhooks] error: %w | partner_id: %v", err, partnerId)
+	}
+
+	return webhooks, nil
+}
+
+func (r *partnerWebhookRepositoryPostgres) DeleteWebhook(ctx context.Context, partnerId, webhookId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE partner_webhooks
+		SET deleted_at = $1, updated_at = $2
+		WHERE partner_webhook_id = $3
+			AND partner_id = $4
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, webhookId, partnerId)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_partner_webhook_repository][DeleteWebhook][ExecContext] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_partner_webhook_repository][DeleteWebhook][RowsAffected] error: %w | partner_webhook_id: %v", err, webhookId)
+	}
+
+	return affected > 0, nil
+}
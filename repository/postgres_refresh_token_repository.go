@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type refreshTokenRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewRefreshTokenRepositoryPostgres(dbtx DBTX) *refreshTokenRepositoryPostgres {
+	return &refreshTokenRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+func (r *refreshTokenRepositoryPostgres) InsertToken(ctx context.Context, token string, session entity.Session) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO refresh_tokens (refresh_token, account_id, expired_at, user_agent, ip_address, device_hash, session_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, token, session.AccountId, session.ExpiredAt, session.UserAgent, session.IpAddress, session.DeviceHash, session.Key, now, now)
+	if err != nil {
+		return fmt.Errorf("[postgres_refresh_token_repository][InsertToken][ExecContext] error: %w | account_id: %v", err, session.AccountId)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepositoryPostgres) RevokeAccountTokens(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE refresh_tokens
+		SET revoked_at = $1, updated_at = $2
+		WHERE account_id = $3
+			AND revoked_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[postgres_refresh_token_repository][RevokeAccountTokens][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// GetActiveSessions lists the sessions not revoked nor expired, newest first
+func (r *refreshTokenRepositoryPostgres) GetActiveSessions(ctx context.Context, accountId int64) ([]entity.Session, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			refresh_token_id,
+			account_id,
+			user_agent,
+			ip_address,
+			device_hash,
+			expired_at,
+			created_at
+		FROM refresh_tokens
+		WHERE account_id = $1
+			AND revoked_at IS NULL
+			AND expired_at > $2
+		ORDER BY refresh_token_id DESC
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, accountId, nowUnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_refresh_token_repository][GetActiveSessions][QueryContext] error: %w | account_id: %v", err, accountId)
+	}
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+
+	for rows.Next() {
+		var session entity.Session
+
+		err = rows.Scan(
+			&session.Id,
+			&session.AccountId,
+			&session.UserAgent,
+			&session.IpAddress,
+			&session.DeviceHash,
+			&session.ExpiredAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_refresh_token_repository][GetActiveSessions][rows.Scan] error: %w | account_id: %v", err, accountId)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_refresh_token_repository][GetActiveSessions][rows.Err] error: %w | account_id: %v", err, accountId)
+	}
+
+	return sessions, nil
+}
+
+func (r *refreshTokenRepositoryPostgres) RevokeSession(ctx context.Context, accountId, sessionId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE refresh_tokens
+		SET revoked_at = $1, updated_at = $2
+		WHERE refresh_token_id = $3
+			AND account_id = $4
+			AND revoked_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, now, now, sessionId, accountId)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_refresh_token_repository][RevokeSession][ExecContext] error: %w | account_id: %v | session_id: %v", err, accountId, sessionId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_refresh_token_repository][RevokeSession][RowsAffected] error: %w | account_id: %v | session_id: %v", err, accountId, sessionId)
+	}
+
+	return affected > 0, nil
+}
+
+// IsNewDevice reports whether the account signed in from other devices but never from this one. Without any known device,
+// e.g. for sessions issued before devices were stored, there is nothing to compare with and it reports false.
+func (r *refreshTokenRepositoryPostgres) IsNewDevice(ctx context.Context, accountId int64, deviceHash string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			COUNT(*),
+			COUNT(CASE WHEN device_hash = $1 THEN 1 END)
+		FROM refresh_tokens
+		WHERE account_id = $2
+			AND device_hash <> ''
+	`)
+
+	q := sb.String()
+
+	var known, matching int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, deviceHash, accountId).Scan(&known, &matching)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_refresh_token_repository][IsNewDevice][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return known > 0 && matching == 0, nil
+}
+
+// IsSessionActive reports whether the session with the key is neither revoked nor expired
+func (r *refreshTokenRepositoryPostgres) IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM refresh_tokens
+			WHERE session_key = $1
+				AND account_id = $2
+				AND revoked_at IS NULL
+				AND expired_at > $3
+		)
+	`)
+
+	q := sb.String()
+
+	var isActive bool
+
+	err := r.dbtx.QueryRowContext(ctx, q, sessionKey, accountId, nowUnixMilli()).Scan(&isActive)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_refresh_token_repository][IsSessionActive][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return isActive, nil
+}
+
+func (r *refreshTokenRepositoryPostgres) DeleteAccountTokens(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM refresh_tokens
+		WHERE account_id = $1
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, accountId)
+	if err != nil {
+		return fmt.Errorf("[postgres_refresh_token_repository][DeleteAccountTokens][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// DeleteExpiredTokens deletes up to limit tokens expired before expiredBefore, returns how many were deleted
+func (r *refreshTokenRepositoryPostgres) DeleteExpiredTokens(ctx context.Context, expiredBefore int64, limit int) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM refresh_tokens
+		WHERE refresh_token_id IN (
+			SELECT refresh_token_id
+			FROM refresh_tokens
+			WHERE expired_at <= $1
+			ORDER BY expired_at
+			LIMIT $2
+		)
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, expiredBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_refresh_token_repository][DeleteExpiredTokens][ExecContext] error: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_refresh_token_repository][DeleteExpiredTokens][RowsAffected] error: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type webhookDeliveryRepositoryPostgres struct {
+	dbtx DBTX
+}
+
+func NewWebhookDeliveryRepositoryPostgres(dbtx DBTX) *webhookDeliveryRepositoryPostgres {
+	return &webhookDeliveryRepositoryPostgres{
+		dbtx: dbtx,
+	}
+}
+
+// InsertDelivery adds a pending delivery, due right away
+func (r *webhookDeliveryRepositoryPostgres) InsertDelivery(ctx context.Context, delivery entity.WebhookDelivery) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO webhook_deliveries (partner_webhook_id, partner_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING webhook_delivery_id
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	var deliveryId int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, delivery.WebhookId, delivery.PartnerId, delivery.EventType, string(delivery.Payload), appconstant.WebhookDeliveryStatusPending, now, now, now).Scan(&deliveryId)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_webhook_delivery_repository][InsertDelivery][QueryRowContext] error: %w | partner_webhook_id: %v", err, delivery.WebhookId)
+	}
+
+	return deliveryId, nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) queryDeliveries(ctx context.Context, q string, args ...any) ([]entity.WebhookDelivery, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_webhook_delivery_repository][queryDeliveries][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []entity.WebhookDelivery{}
+
+	for rows.Next() {
+		var delivery entity.WebhookDelivery
+		var payload string
+
+		err = rows.Scan(
+			&delivery.Id,
+			&delivery.WebhookId,
+			&delivery.PartnerId,
+			&delivery.EventType,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.LastStatusCode,
+			&delivery.DeliveredAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[postgres_webhook_delivery_repository][queryDeliveries][rows.Scan] error: %w", err)
+		}
+
+		delivery.Payload = json.RawMessage(payload)
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[postgres_webhook_delivery_repository][queryDeliveries][rows.Err] error: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) GetDeliveryById(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			webhook_delivery_id,
+			partner_webhook_id,
+			partner_id,
+			event_type,
+			payload,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error,
+			last_status_code,
+			delivered_at,
+			created_at,
+			updated_at
+		FROM webhook_deliveries
+		WHERE webhook_delivery_id = $1
+	`)
+
+	q := sb.String()
+
+	deliveries, err := r.queryDeliveries(ctx, q, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_webhook_delivery_repository][GetDeliveryById][queryDeliveries] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	return &deliveries[0], nil
+}
+
+// GetDeliveries lists the deliveries matching the filter, newest first
+func (r *webhookDeliveryRepositoryPostgres) GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			webhook_delivery_id,
+			partner_webhook_id,
+			partner_id,
+			event_type,
+			payload,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error,
+			last_status_code,
+			delivered_at,
+			created_at,
+			updated_at
+		FROM webhook_deliveries
+		WHERE 1 = 1`)
+
+	var args []any
+
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		fmt.Fprintf(&sb, "\n\t\t\tAND %s $%d", cond, len(args))
+	}
+
+	if filter.Status != "" {
+		where("status =", filter.Status)
+	}
+	if filter.PartnerId != 0 {
+		where("partner_id =", filter.PartnerId)
+	}
+	if filter.BeforeId != 0 {
+		where("webhook_delivery_id <", filter.BeforeId)
+	}
+
+	args = append(args, filter.Limit)
+
+	fmt.Fprintf(&sb, `
+		ORDER BY webhook_delivery_id DESC
+		LIMIT $%d
+	`, len(args))
+
+	q := sb.String()
+
+	deliveries, err := r.queryDeliveries(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_webhook_delivery_repository][GetDeliveries][queryDeliveries] error: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) GetDueDeliveries(ctx context.Context, now int64, limit int) ([]entity.WebhookDelivery, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			webhook_delivery_id,
+			partner_webhook_id,
+			partner_id,
+			event_type,
+			payload,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error,
+			last_status_code,
+			delivered_at,
+			created_at,
+			updated_at
+		FROM webhook_deliveries
+		WHERE status = $1
+			AND next_attempt_at <= $2
+		ORDER BY webhook_delivery_id
+		LIMIT $3
+	`)
+
+	q := sb.String()
+
+	deliveries, err := r.queryDeliveries(ctx, q, appconstant.WebhookDeliveryStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[postgres_webhook_delivery_repository][GetDueDeliveries][queryDeliveries] error: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery pushes the next attempt of a due delivery to leaseUntil, reports false when another dispatcher claimed
+// it first. A delivery left by a dispatcher that stopped is due again once the lease ends.
+func (r *webhookDeliveryRepositoryPostgres) ClaimDelivery(ctx context.Context, deliveryId, now, leaseUntil int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1, updated_at = $2
+		WHERE webhook_delivery_id = $3
+			AND status = $4
+			AND next_attempt_at <= $5
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, leaseUntil, nowUnixMilli(), deliveryId, appconstant.WebhookDeliveryStatusPending, now)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_webhook_delivery_repository][ClaimDelivery][ExecContext] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_webhook_delivery_repository][ClaimDelivery][RowsAffected] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) MarkDelivered(ctx context.Context, deliveryId int64, statusCode int) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_error = '', last_status_code = $2, delivered_at = $3, updated_at = $4
+		WHERE webhook_delivery_id = $5
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.WebhookDeliveryStatusDelivered, statusCode, now, now, deliveryId)
+	if err != nil {
+		return fmt.Errorf("[postgres_webhook_delivery_repository][MarkDelivered][ExecContext] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	return nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) MarkFailed(ctx context.Context, deliveryId int64, lastError string, statusCode *int, nextAttemptAt int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_error = $1, last_status_code = $2, next_attempt_at = $3, updated_at = $4
+		WHERE webhook_delivery_id = $5
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, lastError, statusCode, nextAttemptAt, nowUnixMilli(), deliveryId)
+	if err != nil {
+		return fmt.Errorf("[postgres_webhook_delivery_repository][MarkFailed][ExecContext] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	return nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) MarkDead(ctx context.Context, deliveryId int64, lastError string, statusCode *int) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_error = $2, last_status_code = $3, updated_at = $4
+		WHERE webhook_delivery_id = $5
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.WebhookDeliveryStatusDead, lastError, statusCode, nowUnixMilli(), deliveryId)
+	if err != nil {
+		return fmt.Errorf("[postgres_webhook_delivery_repository][MarkDead][ExecContext] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	return nil
+}
+
+// Redeliver makes a delivered or dead delivery pending again with no attempts, the error of its last attempt is kept
+func (r *webhookDeliveryRepositoryPostgres) Redeliver(ctx context.Context, deliveryId, now int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = $2, delivered_at = NULL, updated_at = $3
+		WHERE webhook_delivery_id = $4
+			AND status <> $5
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, appconstant.WebhookDeliveryStatusPending, now, nowUnixMilli(), deliveryId, appconstant.WebhookDeliveryStatusPending)
+	if err != nil {
+		return false, fmt.Errorf("[postgres_webhook_delivery_repository][Redeliver][ExecContext] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[postgres_webhook_delivery_repository][Redeliver][RowsAffected] error: %w | webhook_delivery_id: %v", err, deliveryId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *webhookDeliveryRepositoryPostgres) DeleteDeliveredDeliveries(ctx context.Context, deliveredBefore int64, limit int) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM webhook_deliveries
+		WHERE webhook_delivery_id IN (
+			SELECT webhook_delivery_id
+			FROM webhook_deliveries
+			WHERE status = $1
+				AND delivered_at <= $2
+			ORDER BY delivered_at
+			LIMIT $3
+		)
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, appconstant.WebhookDeliveryStatusDelivered, deliveredBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_webhook_delivery_repository][DeleteDeliveredDeliveries][ExecContext] error: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("[postgres_webhook_delivery_repository][DeleteDeliveredDeliveries][RowsAffected] error: %w", err)
+	}
+
+	return deleted, nil
+}
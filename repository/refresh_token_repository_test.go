@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// A session is active until it is revoked or expires
+func TestRefreshTokenRepository_IsSessionActive(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	refreshTokenRepo := repository.NewRefreshTokenRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+	expiredAt := time.Now().Add(time.Hour).UnixMilli()
+
+	err := refreshTokenRepo.InsertToken(ctx, "active-token", entity.Session{AccountId: accountId, UserAgent: "curl/8.0", Key: "active", ExpiredAt: expiredAt})
+	if err != nil {
+		t.Fatalf("InsertToken() error = %v", err)
+	}
+
+	err = refreshTokenRepo.InsertToken(ctx, "expired-token", entity.Session{AccountId: accountId, Key: "expired", ExpiredAt: time.Now().Add(-time.Hour).UnixMilli()})
+	if err != nil {
+		t.Fatalf("InsertToken() error = %v", err)
+	}
+
+	sessions, err := refreshTokenRepo.GetActiveSessions(ctx, accountId)
+	if err != nil || len(sessions) != 1 || sessions[0].UserAgent != "curl/8.0" {
+		t.Fatalf("GetActiveSessions() = %+v, %v, want the active session", sessions, err)
+	}
+
+	for key, want := range map[string]bool{"active": true, "expired": false, "unknown": false} {
+		isActive, err := refreshTokenRepo.IsSessionActive(ctx, accountId, key)
+		if err != nil || isActive != want {
+			t.Errorf("IsSessionActive(%q) = %v, %v, want %v", key, isActive, err, want)
+		}
+	}
+
+	isRevoked, err := refreshTokenRepo.RevokeSession(ctx, accountId, sessions[0].Id)
+	if err != nil || !isRevoked {
+		t.Fatalf("RevokeSession() = %v, %v, want true", isRevoked, err)
+	}
+
+	isActive, err := refreshTokenRepo.IsSessionActive(ctx, accountId, "active")
+	if err != nil || isActive {
+		t.Errorf("IsSessionActive() of a revoked session = %v, %v, want false", isActive, err)
+	}
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/helper"
+	"golang.org/x/image/draw"
+)
+
+type MediaVariant struct {
+	Name         string
+	MaxDimension int
+}
+
+// mediaRepositoryResized stores the original media through the underlying repository
+// and additionally stores a resized copy of images for every configured variant.
+type mediaRepositoryResized struct {
+	media    MediaRepository
+	variants []MediaVariant
+}
+
+func NewMediaRepositoryResized(media MediaRepository, variants []MediaVariant) *mediaRepositoryResized {
+	return &mediaRepositoryResized{
+		media:    media,
+		variants: variants,
+	}
+}
+
+// VariantKey derives the storage key of a media variant from the original media key.
+func VariantKey(key, variant string) string {
+	return key + "_" + variant
+}
+
+// OriginalKey returns the original media key of a variant key. Original keys are returned as is.
+func OriginalKey(key string) string {
+	original, _, _ := strings.Cut(key, "_")
+
+	return original
+}
+
+// resizeImage scales the image down so that its longest side is at most maxDimension,
+// keeping the aspect ratio. Images already smaller than maxDimension are re-encoded as is, and images
+// larger than helper.MaxImagePixels are rejected before they are decoded.
+// Takes image bytes and its extension, returns the resized image bytes encoded in the same format.
+func resizeImage(data []byte, ext string, maxDimension int) ([]byte, error) {
+	if maxDimension <= 0 {
+		return nil, fmt.Errorf("invalid max dimension: %v", maxDimension)
+	}
+
+	err := helper.ValidateImageDimensions(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > maxDimension || height > maxDimension {
+		if width >= height {
+			height = height * maxDimension / width
+			width = maxDimension
+		} else {
+			width = width * maxDimension / height
+			height = maxDimension
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+
+	switch ext {
+	case ".png":
+		err = png.Encode(&buf, dst)
+	case ".jpg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+	default:
+		return nil, fmt.Errorf("unsupported image type: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// withVariants returns the original media followed by a resized copy for every configured variant.
+func (r *mediaRepositoryResized) withVariants(media MediaOpt) ([]MediaOpt, error) {
+	if len(media.Bytes) == 0 && media.File != nil && media.File.File != nil {
+		data, err := io.ReadAll(media.File.File)
+		if err != nil {
+			return nil, fmt.Errorf("[io.ReadAll] error: %w", err)
+		}
+
+		media.Bytes = data
+		media.File = nil
+	}
+
+	medias := []MediaOpt{media}
+
+	if !slices.Contains([]string{".png", ".jpg"}, media.Extension) {
+		return medias, nil
+	}
+
+	for _, variant := range r.variants {
+		resized, err := resizeImage(media.Bytes, media.Extension, variant.MaxDimension)
+		if err != nil {
+			return nil, fmt.Errorf("[resizeImage] error: %w | variant: %s", err, variant.Name)
+		}
+
+		medias = append(medias, MediaOpt{
+			Key:       VariantKey(media.Key, variant.Name),
+			Extension: media.Extension,
+			Bytes:     resized,
+		})
+	}
+
+	return medias, nil
+}
+
+// keys returns the original key followed by the key of every configured variant.
+func (r *mediaRepositoryResized) keys(key string) []string {
+	keys := []string{key}
+
+	for _, variant := range r.variants {
+		keys = append(keys, VariantKey(key, variant.Name))
+	}
+
+	return keys
+}
+
+func (r *mediaRepositoryResized) Store(ctx context.Context, media MediaOpt) error {
+	medias, err := r.withVariants(media)
+	if err != nil {
+		return fmt.Errorf("[resized_media_repository][Store][withVariants] error: %w | key: %s", err, media.Key)
+	}
+
+	for _, m := range medias {
+		err = r.media.Store(ctx, m)
+		if err != nil {
+			return fmt.Errorf("[resized_media_repository][Store][media.Store] error: %w | key: %s", err, m.Key)
+		}
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryResized) Stage(ctx context.Context, media MediaOpt) error {
+	medias, err := r.withVariants(media)
+	if err != nil {
+		return fmt.Errorf("[resized_media_repository][Stage][withVariants] error: %w | key: %s", err, media.Key)
+	}
+
+	for _, m := range medias {
+		err = r.media.Stage(ctx, m)
+		if err != nil {
+			return fmt.Errorf("[resized_media_repository][Stage][media.Stage] error: %w | key: %s", err, m.Key)
+		}
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryResized) Promote(ctx context.Context, key string) error {
+	for _, k := range r.keys(key) {
+		err := r.media.Promote(ctx, k)
+		if err != nil {
+			return fmt.Errorf("[resized_media_repository][Promote][media.Promote] error: %w | key: %s", err, k)
+		}
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryResized) Discard(ctx context.Context, key string) error {
+	for _, k := range r.keys(key) {
+		err := r.media.Discard(ctx, k)
+		if err != nil {
+			return fmt.Errorf("[resized_media_repository][Discard][media.Discard] error: %w | key: %s", err, k)
+		}
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryResized) Delete(ctx context.Context, key string) error {
+	for _, k := range r.keys(key) {
+		err := r.media.Delete(ctx, k)
+		if err != nil {
+			return fmt.Errorf("[resized_media_repository][Delete][media.Delete] error: %w | key: %s", err, k)
+		}
+	}
+
+	return nil
+}
+
+func (r *mediaRepositoryResized) Get(ctx context.Context, key string) (*MediaOpt, error) {
+	return r.media.Get(ctx, key)
+}
+
+func (r *mediaRepositoryResized) List(ctx context.Context, staged bool) ([]MediaStat, error) {
+	return r.media.List(ctx, staged)
+}
@@ -0,0 +1,123 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// encodePng encodes a blank image of the given size
+func encodePng(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// pngHeader returns only the signature and header chunk of a png claiming the given size, as a small upload
+// claiming a huge image would
+func pngHeader(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	chunk = append(chunk, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
+
+func TestVariantKey(t *testing.T) {
+	key := repository.VariantKey("abc123", "thumbnail")
+
+	if key != "abc123_thumbnail" {
+		t.Errorf("VariantKey() = %q, want %q", key, "abc123_thumbnail")
+	}
+
+	if original := repository.OriginalKey(key); original != "abc123" {
+		t.Errorf("OriginalKey() of a variant = %q, want %q", original, "abc123")
+	}
+
+	if original := repository.OriginalKey("abc123"); original != "abc123" {
+		t.Errorf("OriginalKey() of an original = %q, want %q", original, "abc123")
+	}
+}
+
+// Every variant is scaled down to its longest side keeping the aspect ratio, a smaller image keeps its size
+func TestMediaRepositoryResized_Store(t *testing.T) {
+	ctx := context.Background()
+
+	media := repository.NewMediaRepositoryMemory()
+	resized := repository.NewMediaRepositoryResized(media, []repository.MediaVariant{
+		{Name: "thumbnail", MaxDimension: 40},
+		{Name: "preview", MaxDimension: 400},
+	})
+
+	err := resized.Store(ctx, repository.MediaOpt{Key: "photo", Extension: ".png", Bytes: encodePng(t, 200, 100)})
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	for key, want := range map[string]image.Point{
+		"photo":           {200, 100},
+		"photo_thumbnail": {40, 20},
+		"photo_preview":   {200, 100},
+	} {
+		stored, err := media.Get(ctx, key)
+		if err != nil || stored == nil {
+			t.Fatalf("Get(%q) = %v, %v, want the media", key, stored, err)
+		}
+
+		config, err := png.DecodeConfig(bytes.NewReader(stored.Bytes))
+		if err != nil {
+			t.Fatalf("DecodeConfig(%q) error = %v", key, err)
+		}
+
+		if config.Width != want.X || config.Height != want.Y {
+			t.Errorf("size of %q = %vx%v, want %vx%v", key, config.Width, config.Height, want.X, want.Y)
+		}
+	}
+
+	err = resized.Delete(ctx, "photo")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	stored, _ := media.List(ctx, false)
+	if len(stored) != 0 {
+		t.Errorf("media left after Delete() = %v", stored)
+	}
+}
+
+// An image claiming more pixels than the limit is rejected from its header, before it is decoded
+func TestMediaRepositoryResized_Store_RejectsHugeImage(t *testing.T) {
+	ctx := context.Background()
+
+	media := repository.NewMediaRepositoryMemory()
+	resized := repository.NewMediaRepositoryResized(media, []repository.MediaVariant{{Name: "thumbnail", MaxDimension: 40}})
+
+	err := resized.Store(ctx, repository.MediaOpt{Key: "bomb", Extension: ".png", Bytes: pngHeader(50000, 50000)})
+	if err == nil || !strings.Contains(err.Error(), "exceeded limit") {
+		t.Fatalf("Store() error = %v, want the image rejected for its dimensions", err)
+	}
+
+	stored, _ := media.List(ctx, false)
+	if len(stored) != 0 {
+		t.Errorf("media stored for a rejected image = %v", stored)
+	}
+}
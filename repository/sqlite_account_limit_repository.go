@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type accountLimitRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewAccountLimitRepositorySqlite(dbtx DBTX) *accountLimitRepositorySqlite {
+	return &accountLimitRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+// SQLite has no row locks, forUpdate is ignored since transactions begin immediate and hold the write lock of the database
+func (r *accountLimitRepositorySqlite) GetAccountLimitByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.AccountLimit, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT 
+			account_limit_id,
+			account_id,
+			account_limit_1_m,
+			account_limit_2_m,
+			account_limit_3_m,
+			account_limit_4_m,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM account_limits
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	var accountLimit entity.AccountLimit
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId).Scan(
+		&accountLimit.Id,
+		&accountLimit.AccountId,
+		&accountLimit.Limit1M,
+		&accountLimit.Limit2M,
+		&accountLimit.Limit3M,
+		&accountLimit.Limit4M,
+		&accountLimit.CreatedAt,
+		&accountLimit.UpdatedAt,
+		&accountLimit.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &accountLimit, nil
+}
+
+func (r *accountLimitRepositorySqlite) UpdateLimit(ctx context.Context, accountLimit entity.AccountLimit) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_limits
+		SET
+			account_limit_1_m = ?,
+			account_limit_2_m = ?,
+			account_limit_3_m = ?,
+			account_limit_4_m = ?,
+			updated_at = ?
+		WHERE account_limit_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		accountLimit.Limit1M,
+		accountLimit.Limit2M,
+		accountLimit.Limit3M,
+		accountLimit.Limit4M,
+		now,
+		accountLimit.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *accountLimitRepositorySqlite) InsertLimit(ctx context.Context, accountLimit entity.AccountLimit) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO account_limits (
+			account_id,
+			account_limit_1_m,
+			account_limit_2_m,
+			account_limit_3_m,
+			account_limit_4_m,
+			created_at,
+			updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		accountLimit.AccountId,
+		accountLimit.Limit1M,
+		accountLimit.Limit2M,
+		accountLimit.Limit3M,
+		accountLimit.Limit4M,
+		now,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *accountLimitRepositorySqlite) DeleteLimit(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE account_limits
+		SET deleted_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_account_limit_repository][DeleteLimit][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
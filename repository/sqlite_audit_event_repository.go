@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type auditEventRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewAuditEventRepositorySqlite(dbtx DBTX) *auditEventRepositorySqlite {
+	return &auditEventRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+// SQLite has no SELECT ... FOR UPDATE, the update takes the write lock of the database until the transaction ends
+func (r *auditEventRepositorySqlite) LockChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE audit_chain
+		SET last_event_id = last_event_id + 1
+		WHERE audit_chain_id = 1
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][LockChainHead][ExecContext] error: %w", err)
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		SELECT last_event_id - 1, last_hash
+		FROM audit_chain
+		WHERE audit_chain_id = 1
+	`)
+
+	q = sb.String()
+
+	var head entity.AuditChainHead
+
+	err = r.dbtx.QueryRowContext(ctx, q).Scan(&head.LastEventId, &head.LastHash)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][LockChainHead][QueryRowContext] error: %w", err)
+	}
+
+	return &head, nil
+}
+
+func (r *auditEventRepositorySqlite) InsertEvent(ctx context.Context, event entity.AuditEvent) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO audit_events (audit_event_id, actor_type, actor_id, action, entity_type, entity_id, diff, request_id, ip_address, prev_hash, hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		event.Id,
+		event.ActorType,
+		event.ActorId,
+		event.Action,
+		event.EntityType,
+		event.EntityId,
+		string(event.Diff),
+		event.RequestId,
+		event.IpAddress,
+		event.PrevHash,
+		event.Hash,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("[sqlite_audit_event_repository][InsertEvent][ExecContext][insert] error: %w | audit_event_id: %v", err, event.Id)
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		UPDATE audit_chain
+		SET last_hash = ?
+		WHERE audit_chain_id = 1
+			AND last_event_id = ?
+	`)
+
+	q = sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, event.Hash, event.Id)
+	if err != nil {
+		return fmt.Errorf("[sqlite_audit_event_repository][InsertEvent][ExecContext][head] error: %w | audit_event_id: %v", err, event.Id)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("[sqlite_audit_event_repository][InsertEvent][RowsAffected] error: %w | audit_event_id: %v", err, event.Id)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("[sqlite_audit_event_repository][InsertEvent] event is not the reserved head of the chain | audit_event_id: %v", event.Id)
+	}
+
+	return nil
+}
+
+func (r *auditEventRepositorySqlite) queryEvents(ctx context.Context, q string, args ...any) ([]entity.AuditEvent, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][queryEvents][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entity.AuditEvent{}
+
+	for rows.Next() {
+		var event entity.AuditEvent
+		var diff string
+
+		err = rows.Scan(
+			&event.Id,
+			&event.ActorType,
+			&event.ActorId,
+			&event.Action,
+			&event.EntityType,
+			&event.EntityId,
+			&diff,
+			&event.RequestId,
+			&event.IpAddress,
+			&event.PrevHash,
+			&event.Hash,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_audit_event_repository][queryEvents][rows.Scan] error: %w", err)
+		}
+
+		event.Diff = json.RawMessage(diff)
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][queryEvents][rows.Err] error: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetEvents lists the events matching the filter, newest first
+func (r *auditEventRepositorySqlite) GetEvents(ctx context.Context, filter entity.AuditEventFilter) ([]entity.AuditEvent, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			audit_event_id,
+			actor_type,
+			actor_id,
+			action,
+			entity_type,
+			entity_id,
+			diff,
+			request_id,
+			ip_address,
+			prev_hash,
+			hash,
+			created_at
+		FROM audit_events
+		WHERE 1 = 1`)
+
+	var args []any
+
+	where := func(cond string, arg any) {
+		sb.WriteString("\n\t\t\tAND " + cond + " ?")
+		args = append(args, arg)
+	}
+
+	if filter.ActorType != "" {
+		where("actor_type =", filter.ActorType)
+	}
+	if filter.ActorId != 0 {
+		where("actor_id =", filter.ActorId)
+	}
+	if filter.Action != "" {
+		where("action =", filter.Action)
+	}
+	if filter.EntityType != "" {
+		where("entity_type =", filter.EntityType)
+	}
+	if filter.EntityId != 0 {
+		where("entity_id =", filter.EntityId)
+	}
+	if filter.CreatedFrom != 0 {
+		where("created_at >=", filter.CreatedFrom)
+	}
+	if filter.CreatedTo != 0 {
+		where("created_at <", filter.CreatedTo)
+	}
+	if filter.BeforeId != 0 {
+		where("audit_event_id <", filter.BeforeId)
+	}
+
+	sb.WriteString(`
+		ORDER BY audit_event_id DESC
+		LIMIT ?
+	`)
+
+	args = append(args, filter.Limit)
+
+	q := sb.String()
+
+	events, err := r.queryEvents(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][GetEvents][queryEvents] error: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *auditEventRepositorySqlite) GetEventsAfter(ctx context.Context, afterId int64, limit int) ([]entity.AuditEvent, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			audit_event_id,
+			actor_type,
+			actor_id,
+			action,
+			entity_type,
+			entity_id,
+			diff,
+			request_id,
+			ip_address,
+			prev_hash,
+			hash,
+			created_at
+		FROM audit_events
+		WHERE audit_event_id > ?
+		ORDER BY audit_event_id
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	events, err := r.queryEvents(ctx, q, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][GetEventsAfter][queryEvents] error: %w | after_id: %v", err, afterId)
+	}
+
+	return events, nil
+}
+
+func (r *auditEventRepositorySqlite) GetChainHead(ctx context.Context) (*entity.AuditChainHead, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT last_event_id, last_hash
+		FROM audit_chain
+		WHERE audit_chain_id = 1
+	`)
+
+	q := sb.String()
+
+	var head entity.AuditChainHead
+
+	err := r.dbtx.QueryRowContext(ctx, q).Scan(&head.LastEventId, &head.LastHash)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_audit_event_repository][GetChainHead][QueryRowContext] error: %w", err)
+	}
+
+	return &head, nil
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type consumerRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewConsumerRepositorySqlite(dbtx DBTX) *consumerRepositorySqlite {
+	return &consumerRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+// SQLite has no row locks, forUpdate is ignored since transactions begin immediate and hold the write lock of the database
+func (r *consumerRepositorySqlite) GetConsumerByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.Consumer, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT 
+			consumer_id, 
+			account_id, 
+			identity_number, 
+			full_name, 
+			legal_name, 
+			place_of_birth, 
+			date_of_birth, 
+			salary, 
+			identity_card_photo_key, 
+			selfie_photo_key, 
+			kyc_status, 
+			version, 
+			created_at, 
+			updated_at, 
+			deleted_at
+		FROM consumers
+		WHERE account_id = ?
+		 	AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	var consumer entity.Consumer
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId).Scan(
+		&consumer.Id,
+		&consumer.AccountId,
+		&consumer.IdentityNumber,
+		&consumer.FullName,
+		&consumer.LegalName,
+		&consumer.PlaceOfBirth,
+		&consumer.DateOfBirth,
+		&consumer.Salary,
+		&consumer.IdentityCardPhoto.Key,
+		&consumer.SelfiePhoto.Key,
+		&consumer.KycStatus,
+		&consumer.Version,
+		&consumer.CreatedAt,
+		&consumer.UpdatedAt,
+		&consumer.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[sqlite_consumer_repository][GetConsumetByAccountId][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return &consumer, nil
+}
+
+func (r *consumerRepositorySqlite) InsertConsumer(ctx context.Context, consumerData entity.Consumer) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO consumers (account_id, identity_number, full_name, legal_name, place_of_birth, date_of_birth, salary, identity_card_photo_key, selfie_photo_key, kyc_status, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		consumerData.AccountId,
+		consumerData.IdentityNumber,
+		consumerData.FullName,
+		consumerData.LegalName,
+		consumerData.PlaceOfBirth,
+		consumerData.DateOfBirth,
+		consumerData.Salary,
+		consumerData.IdentityCardPhoto.Key,
+		consumerData.SelfiePhoto.Key,
+		consumerData.KycStatus,
+		consumerData.Version,
+		now,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *consumerRepositorySqlite) UpdateConsumer(ctx context.Context, consumerData entity.Consumer) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE consumers
+		SET
+			identity_number = ?,
+			full_name = ?,
+			legal_name = ?,
+			place_of_birth = ?,
+			date_of_birth = ?,
+			salary = ?,
+			identity_card_photo_key = ?,
+			selfie_photo_key = ?,
+			kyc_status = ?,
+			version = ?,
+			updated_at = ?
+		WHERE consumer_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q,
+		consumerData.IdentityNumber,
+		consumerData.FullName,
+		consumerData.LegalName,
+		consumerData.PlaceOfBirth,
+		consumerData.DateOfBirth,
+		consumerData.Salary,
+		consumerData.IdentityCardPhoto.Key,
+		consumerData.SelfiePhoto.Key,
+		consumerData.KycStatus,
+		consumerData.Version,
+		now,
+		consumerData.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("[sqlite_consumer_repository][UpdateConsumer][ExecContext] error: %w | account_id: %v", err, consumerData.AccountId)
+	}
+
+	return nil
+}
+
+func (r *consumerRepositorySqlite) IsMediaKeyReferenced(ctx context.Context, key string) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM consumers
+			WHERE identity_card_photo_key = ?
+				OR selfie_photo_key = ?
+		) OR EXISTS (
+			SELECT 1
+			FROM consumer_histories
+			WHERE identity_card_photo_key = ?
+				OR selfie_photo_key = ?
+		)
+	`)
+
+	q := sb.String()
+
+	var isReferenced bool
+
+	err := r.dbtx.QueryRowContext(ctx, q, key, key, key, key).Scan(&isReferenced)
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_consumer_repository][IsMediaKeyReferenced][QueryRowContext] error: %w | key: %s", err, key)
+	}
+
+	return isReferenced, nil
+}
+
+func (r *consumerRepositorySqlite) DeleteConsumer(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE consumers
+		SET deleted_at = ?, updated_at = ?
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, accountId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_consumer_repository][DeleteConsumer][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// AnonymiseConsumer clears the personal data of the consumer of the account, deleted or not. Its photos are no longer
+// referenced, so the media sweeper deletes them.
+func (r *consumerRepositorySqlite) AnonymiseConsumer(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE consumers
+		SET
+			identity_number = '',
+			full_name = '',
+			legal_name = '',
+			place_of_birth = '',
+			date_of_birth = '',
+			salary = 0,
+			identity_card_photo_key = '',
+			selfie_photo_key = '',
+			updated_at = ?
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, nowUnixMilli(), accountId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_consumer_repository][AnonymiseConsumer][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// GetRejectedPhotoKeys lists up to limit KYC photos of consumers and consumer histories rejected before rejectedBefore
+func (r *consumerRepositorySqlite) GetRejectedPhotoKeys(ctx context.Context, rejectedBefore int64, limit int) ([]string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT photo_key
+		FROM (
+			SELECT identity_card_photo_key AS photo_key FROM consumers WHERE kyc_status = ? AND updated_at <= ?
+			UNION
+			SELECT selfie_photo_key FROM consumers WHERE kyc_status = ? AND updated_at <= ?
+			UNION
+			SELECT identity_card_photo_key FROM consumer_histories WHERE kyc_status = ? AND created_at <= ?
+			UNION
+			SELECT selfie_photo_key FROM consumer_histories WHERE kyc_status = ? AND created_at <= ?
+		) AS rejected
+		WHERE photo_key <> ''
+		ORDER BY photo_key
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q,
+		appconstant.KycStatusRejected, rejectedBefore,
+		appconstant.KycStatusRejected, rejectedBefore,
+		appconstant.KycStatusRejected, rejectedBefore,
+		appconstant.KycStatusRejected, rejectedBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_consumer_repository][GetRejectedPhotoKeys][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+
+	for rows.Next() {
+		var key string
+
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_consumer_repository][GetRejectedPhotoKeys][rows.Scan] error: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_consumer_repository][GetRejectedPhotoKeys][rows.Err] error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// ClearRejectedPhotoKey removes a KYC photo from the consumers and consumer histories rejected before rejectedBefore.
+// updated_at is kept as the time of the rejection, so the other photo of the consumer is removed as well.
+func (r *consumerRepositorySqlite) ClearRejectedPhotoKey(ctx context.Context, key string, rejectedBefore int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE consumers
+		SET
+			identity_card_photo_key = CASE WHEN identity_card_photo_key = ? THEN '' ELSE identity_card_photo_key END,
+			selfie_photo_key = CASE WHEN selfie_photo_key = ? THEN '' ELSE selfie_photo_key END
+		WHERE (identity_card_photo_key = ? OR selfie_photo_key = ?)
+			AND kyc_status = ?
+			AND updated_at <= ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, key, key, key, key, appconstant.KycStatusRejected, rejectedBefore)
+	if err != nil {
+		return fmt.Errorf("[sqlite_consumer_repository][ClearRejectedPhotoKey][ExecContext][consumers] error: %w | key: %s", err, key)
+	}
+
+	sb.Reset()
+
+	sb.WriteString(`
+		UPDATE consumer_histories
+		SET
+			identity_card_photo_key = CASE WHEN identity_card_photo_key = ? THEN '' ELSE identity_card_photo_key END,
+			selfie_photo_key = CASE WHEN selfie_photo_key = ? THEN '' ELSE selfie_photo_key END
+		WHERE (identity_card_photo_key = ? OR selfie_photo_key = ?)
+			AND kyc_status = ?
+			AND created_at <= ?
+	`)
+
+	q = sb.String()
+
+	_, err = r.dbtx.ExecContext(ctx, q, key, key, key, key, appconstant.KycStatusRejected, rejectedBefore)
+	if err != nil {
+		return fmt.Errorf("[sqlite_consumer_repository][ClearRejectedPhotoKey][ExecContext][consumer_histories] error: %w | key: %s", err, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type dataExportRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewDataExportRepositorySqlite(dbtx DBTX) *dataExportRepositorySqlite {
+	return &dataExportRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+func (r *dataExportRepositorySqlite) InsertExport(ctx context.Context, export entity.DataExport) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO data_exports (account_id, requested_by, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, export.AccountId, export.RequestedBy, appconstant.DataExportStatusPending, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_data_export_repository][InsertExport][ExecContext] error: %w | account_id: %v", err, export.AccountId)
+	}
+
+	exportId, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_data_export_repository][InsertExport][LastInsertId] error: %w | account_id: %v", err, export.AccountId)
+	}
+
+	return exportId, nil
+}
+
+func (r *dataExportRepositorySqlite) GetExportById(ctx context.Context, exportId int64) (*entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE data_export_id = ?
+	`)
+
+	q := sb.String()
+
+	var export entity.DataExport
+
+	err := r.dbtx.QueryRowContext(ctx, q, exportId).Scan(
+		&export.Id,
+		&export.AccountId,
+		&export.RequestedBy,
+		&export.Status,
+		&export.ArchiveKey,
+		&export.Failure,
+		&export.CompletedAt,
+		&export.ExpiredAt,
+		&export.DownloadedAt,
+		&export.DownloadCount,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportById][QueryRowContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return &export, nil
+}
+
+// GetExportsByAccountId lists the exports of the account, newest first
+func (r *dataExportRepositorySqlite) GetExportsByAccountId(ctx context.Context, accountId int64) ([]entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE account_id = ?
+		ORDER BY data_export_id DESC
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportsByAccountId][QueryContext] error: %w | account_id: %v", err, accountId)
+	}
+	defer rows.Close()
+
+	exports := []entity.DataExport{}
+
+	for rows.Next() {
+		var export entity.DataExport
+
+		err = rows.Scan(
+			&export.Id,
+			&export.AccountId,
+			&export.RequestedBy,
+			&export.Status,
+			&export.ArchiveKey,
+			&export.Failure,
+			&export.CompletedAt,
+			&export.ExpiredAt,
+			&export.DownloadedAt,
+			&export.DownloadCount,
+			&export.CreatedAt,
+			&export.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportsByAccountId][rows.Scan] error: %w | account_id: %v", err, accountId)
+		}
+
+		exports = append(exports, export)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportsByAccountId][rows.Err] error: %w | account_id: %v", err, accountId)
+	}
+
+	return exports, nil
+}
+
+// GetExportIdsToProcess lists pending exports, and exports left processing since staleBefore by an instance that stopped
+func (r *dataExportRepositorySqlite) GetExportIdsToProcess(ctx context.Context, staleBefore int64, limit int) ([]int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT data_export_id
+		FROM data_exports
+		WHERE status = ?
+			OR (status = ? AND updated_at <= ?)
+		ORDER BY data_export_id
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, appconstant.DataExportStatusPending, appconstant.DataExportStatusProcessing, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportIdsToProcess][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	var exportIds []int64
+
+	for rows.Next() {
+		var exportId int64
+
+		err = rows.Scan(&exportId)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportIdsToProcess][rows.Scan] error: %w", err)
+		}
+
+		exportIds = append(exportIds, exportId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExportIdsToProcess][rows.Err] error: %w", err)
+	}
+
+	return exportIds, nil
+}
+
+// ClaimExport marks the export processing, reports false when another instance claimed it first
+func (r *dataExportRepositorySqlite) ClaimExport(ctx context.Context, exportId, staleBefore int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = ?, updated_at = ?
+		WHERE data_export_id = ?
+			AND (status = ? OR (status = ? AND updated_at <= ?))
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusProcessing, nowUnixMilli(), exportId, appconstant.DataExportStatusPending, appconstant.DataExportStatusProcessing, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_data_export_repository][ClaimExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_data_export_repository][ClaimExport][RowsAffected] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *dataExportRepositorySqlite) CompleteExport(ctx context.Context, exportId int64, archiveKey string, expiredAt int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = ?, archive_key = ?, completed_at = ?, expired_at = ?, updated_at = ?
+		WHERE data_export_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusCompleted, archiveKey, now, expiredAt, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_data_export_repository][CompleteExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositorySqlite) FailExport(ctx context.Context, exportId int64, failure string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = ?, failure = ?, updated_at = ?
+		WHERE data_export_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusFailed, failure, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_data_export_repository][FailExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepositorySqlite) RecordDownload(ctx context.Context, exportId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET downloaded_at = ?, download_count = download_count + 1, updated_at = ?
+		WHERE data_export_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_data_export_repository][RecordDownload][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
+
+// GetExpiredExports lists completed exports whose archive can no longer be downloaded
+func (r *dataExportRepositorySqlite) GetExpiredExports(ctx context.Context, now int64, limit int) ([]entity.DataExport, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			data_export_id,
+			account_id,
+			requested_by,
+			status,
+			archive_key,
+			failure,
+			completed_at,
+			expired_at,
+			downloaded_at,
+			download_count,
+			created_at,
+			updated_at
+		FROM data_exports
+		WHERE status = ?
+			AND expired_at <= ?
+		ORDER BY data_export_id
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, appconstant.DataExportStatusCompleted, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExpiredExports][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []entity.DataExport{}
+
+	for rows.Next() {
+		var export entity.DataExport
+
+		err = rows.Scan(
+			&export.Id,
+			&export.AccountId,
+			&export.RequestedBy,
+			&export.Status,
+			&export.ArchiveKey,
+			&export.Failure,
+			&export.CompletedAt,
+			&export.ExpiredAt,
+			&export.DownloadedAt,
+			&export.DownloadCount,
+			&export.CreatedAt,
+			&export.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_data_export_repository][GetExpiredExports][rows.Scan] error: %w", err)
+		}
+
+		exports = append(exports, export)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_data_export_repository][GetExpiredExports][rows.Err] error: %w", err)
+	}
+
+	return exports, nil
+}
+
+// ExpireExport marks the export expired once its archive was deleted
+func (r *dataExportRepositorySqlite) ExpireExport(ctx context.Context, exportId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE data_exports
+		SET status = ?, archive_key = '', updated_at = ?
+		WHERE data_export_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, appconstant.DataExportStatusExpired, now, exportId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_data_export_repository][ExpireExport][ExecContext] error: %w | data_export_id: %v", err, exportId)
+	}
+
+	return nil
+}
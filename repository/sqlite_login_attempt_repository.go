@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type loginAttemptRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewLoginAttemptRepositorySqlite(dbtx DBTX) *loginAttemptRepositorySqlite {
+	return &loginAttemptRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+func (r *loginAttemptRepositorySqlite) GetLoginAttempt(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT attempt_key, failed_count, last_failed_at, locked_until, created_at, updated_at
+		FROM login_attempts
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	var attempt entity.LoginAttempt
+
+	err := r.dbtx.QueryRowContext(ctx, q, key).Scan(
+		&attempt.Key,
+		&attempt.FailedCount,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+		&attempt.CreatedAt,
+		&attempt.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[sqlite_login_attempt_repository][GetLoginAttempt][QueryRowContext] error: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+// The count restarts when the last failure is older than windowStart. Assignments read the values before the update.
+func (r *loginAttemptRepositorySqlite) IncrementFailedAttempt(ctx context.Context, key string, windowStart int64) (*entity.LoginAttempt, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO login_attempts (attempt_key, failed_count, last_failed_at, created_at, updated_at)
+		VALUES (?, 1, ?, ?, ?)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failed_count = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failed_count + 1 END,
+			locked_until = CASE WHEN login_attempts.last_failed_at < ? THEN NULL ELSE login_attempts.locked_until END,
+			last_failed_at = excluded.last_failed_at,
+			updated_at = excluded.updated_at
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, key, now, now, now, windowStart, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_login_attempt_repository][IncrementFailedAttempt][ExecContext] error: %w", err)
+	}
+
+	attempt, err := r.GetLoginAttempt(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_login_attempt_repository][IncrementFailedAttempt][GetLoginAttempt] error: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (r *loginAttemptRepositorySqlite) LockLoginAttempt(ctx context.Context, key string, lockedUntil int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE login_attempts
+		SET locked_until = ?, updated_at = ?
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, lockedUntil, nowUnixMilli(), key)
+	if err != nil {
+		return fmt.Errorf("[sqlite_login_attempt_repository][LockLoginAttempt][ExecContext] error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginAttemptRepositorySqlite) DeleteLoginAttempt(ctx context.Context, key string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM login_attempts
+		WHERE attempt_key = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, key)
+	if err != nil {
+		return fmt.Errorf("[sqlite_login_attempt_repository][DeleteLoginAttempt][ExecContext] error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type outboxRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewOutboxRepositorySqlite(dbtx DBTX) *outboxRepositorySqlite {
+	return &outboxRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+func (r *outboxRepositorySqlite) InsertEvent(ctx context.Context, event entity.OutboxEvent) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, payload, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, event.EventType, event.AggregateType, event.AggregateId, string(event.Payload), now, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_outbox_repository][InsertEvent][ExecContext] error: %w | event_type: %s", err, event.EventType)
+	}
+
+	eventId, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_outbox_repository][InsertEvent][LastInsertId] error: %w | event_type: %s", err, event.EventType)
+	}
+
+	return eventId, nil
+}
+
+// GetDueEvents lists the unpublished events due at now, oldest first. An event waits while an earlier event of its
+// aggregate is unpublished, so the events of an aggregate are published in order.
+func (r *outboxRepositorySqlite) GetDueEvents(ctx context.Context, now int64, limit int) ([]entity.OutboxEvent, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			e.outbox_event_id,
+			e.event_type,
+			e.aggregate_type,
+			e.aggregate_id,
+			e.payload,
+			e.attempts,
+			e.next_attempt_at,
+			e.last_error,
+			e.published_at,
+			e.created_at,
+			e.updated_at
+		FROM outbox_events e
+		WHERE e.published_at IS NULL
+			AND e.next_attempt_at <= ?
+			AND NOT EXISTS (
+				SELECT 1
+				FROM outbox_events p
+				WHERE p.aggregate_type = e.aggregate_type
+					AND p.aggregate_id = e.aggregate_id
+					AND p.published_at IS NULL
+					AND p.outbox_event_id < e.outbox_event_id
+			)
+		ORDER BY e.outbox_event_id
+		LIMIT ?
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_outbox_repository][GetDueEvents][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entity.OutboxEvent{}
+
+	for rows.Next() {
+		var event entity.OutboxEvent
+		var payload string
+
+		err = rows.Scan(
+			&event.Id,
+			&event.EventType,
+			&event.AggregateType,
+			&event.AggregateId,
+			&payload,
+			&event.Attempts,
+			&event.NextAttemptAt,
+			&event.LastError,
+			&event.PublishedAt,
+			&event.CreatedAt,
+			&event.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_outbox_repository][GetDueEvents][rows.Scan] error: %w", err)
+		}
+
+		event.Payload = json.RawMessage(payload)
+
+		events = append(events, event)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_outbox_repository][GetDueEvents][rows.Err] error: %w", err)
+	}
+
+	return events, nil
+}
+
+// ClaimEvent pushes the next attempt of a due event to leaseUntil, reports false when another relay claimed it first.
+// An event left by a relay that stopped is due again once the lease ends.
+func (r *outboxRepositorySqlite) ClaimEvent(ctx context.Context, eventId, now, leaseUntil int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE outbox_events
+		SET next_attempt_at = ?, updated_at = ?
+		WHERE outbox_event_id = ?
+			AND published_at IS NULL
+			AND next_attempt_at <= ?
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, leaseUntil, nowUnixMilli(), eventId, now)
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_outbox_repository][ClaimEvent][ExecContext] error: %w | outbox_event_id: %v", err, eventId)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_outbox_repository][ClaimEvent][RowsAffected] error: %w | outbox_event_id: %v", err, eventId)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *outboxRepositorySqlite) MarkPublished(ctx context.Context, eventId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = '', published_at = ?, updated_at = ?
+		WHERE outbox_event_id = ?
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	_, err := r.dbtx.ExecContext(ctx, q, now, now, eventId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_outbox_repository][MarkPublished][ExecContext] error: %w | outbox_event_id: %v", err, eventId)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositorySqlite) MarkFailed(ctx context.Context, eventId int64, lastError string, nextAttemptAt int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE outbox_event_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, lastError, nextAttemptAt, nowUnixMilli(), eventId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_outbox_repository][MarkFailed][ExecContext] error: %w | outbox_event_id: %v", err, eventId)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositorySqlite) DeletePublishedEvents(ctx context.Context, publishedBefore int64, limit int) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		DELETE FROM outbox_events
+		WHERE outbox_event_id IN (
+			SELECT outbox_event_id
+			FROM outbox_events
+			WHERE published_at <= ?
+			ORDER BY published_at
+			LIMIT ?
+		)
+	`)
+
+	q := sb.String()
+
+	res, err := r.dbtx.ExecContext(ctx, q, publishedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_outbox_repository][DeletePublishedEvents][ExecContext] error: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_outbox_repository][DeletePublishedEvents][RowsAffected] error: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type partnerRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewPartnerRepositorySqlite(dbtx DBTX) *partnerRepositorySqlite {
+	return &partnerRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+func (r *partnerRepositorySqlite) InsertPartner(ctx context.Context, partner entity.Partner) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO partners (name, api_key_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q, partner.Name, partner.ApiKeyHash, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_partner_repository][InsertPartner][ExecContext] error: %w | name: %v", err, partner.Name)
+	}
+
+	partnerId, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_partner_repository][InsertPartner][LastInsertId] error: %w | name: %v", err, partner.Name)
+	}
+
+	return partnerId, nil
+}
+
+func (r *partnerRepositorySqlite) queryPartners(ctx context.Context, q string, args ...any) ([]entity.Partner, error) {
+	rows, err := r.dbtx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_partner_repository][queryPartners][QueryContext] error: %w", err)
+	}
+	defer rows.Close()
+
+	partners := []entity.Partner{}
+
+	for rows.Next() {
+		var partner entity.Partner
+
+		err = rows.Scan(
+			&partner.Id,
+			&partner.Name,
+			&partner.ApiKeyHash,
+			&partner.CreatedAt,
+			&partner.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_partner_repository][queryPartners][rows.Scan] error: %w", err)
+		}
+
+		partners = append(partners, partner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_partner_repository][queryPartners][rows.Err] error: %w", err)
+	}
+
+	return partners, nil
+}
+
+func (r *partnerRepositorySqlite) GetPartnerById(ctx context.Context, partnerId int64) (*entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		WHERE partner_id = ?
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q, partnerId)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_partner_repository][GetPartnerById][queryPartners] error: %w | partner_id: %v", err, partnerId)
+	}
+
+	if len(partners) == 0 {
+		return nil, nil
+	}
+
+	return &partners[0], nil
+}
+
+func (r *partnerRepositorySqlite) GetPartnerByApiKeyHash(ctx context.Context, apiKeyHash string) (*entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		WHERE api_key_hash = ?
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q, apiKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_partner_repository][GetPartnerByApiKeyHash][queryPartners] error: %w", err)
+	}
+
+	if len(partners) == 0 {
+		return nil, nil
+	}
+
+	return &partners[0], nil
+}
+
+func (r *partnerRepositorySqlite) GetPartners(ctx context.Context) ([]entity.Partner, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT partner_id, name, api_key_hash, created_at, updated_at
+		FROM partners
+		ORDER BY partner_id
+	`)
+
+	q := sb.String()
+
+	partners, err := r.queryPartners(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_partner_repository][GetPartners][queryPartners] error: %w", err)
+	}
+
+	return partners, nil
+}
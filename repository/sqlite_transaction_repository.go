@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type transactionRepositorySqlite struct {
+	dbtx DBTX
+}
+
+func NewTransactionRepositorySqlite(dbtx DBTX) *transactionRepositorySqlite {
+	return &transactionRepositorySqlite{
+		dbtx: dbtx,
+	}
+}
+
+func (r *transactionRepositorySqlite) InsertTransaction(ctx context.Context, transaction entity.Transaction) (int64, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		INSERT INTO transactions (
+    		account_id,
+    		contact_number,
+    		otr,
+    		installment_months,
+    		admin_fee,
+    		total_installment,
+    		total_interest,
+    		asset_name,
+    		due_at,
+    		status,
+    		partner_id,
+    		partner_reference,
+    		created_at,
+    		updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+
+	q := sb.String()
+
+	now := nowUnixMilli()
+
+	res, err := r.dbtx.ExecContext(ctx, q,
+		transaction.AccountId,
+		transaction.ContactNumber,
+		transaction.OTR,
+		transaction.InstallmentMonths,
+		transaction.AdminFee,
+		transaction.TotalInstallemnt,
+		transaction.TotalInterest,
+		transaction.AssetName,
+		transaction.DueAt,
+		transaction.Status,
+		transaction.PartnerId,
+		transaction.PartnerReference,
+		now,
+		now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_transaction_repository][InsertTransaction][ExecContext] error: %w | account_id: %v", err, transaction.AccountId)
+	}
+
+	transactionId, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("[sqlite_transaction_repository][InsertTransaction][LastInsertId] error: %w | account_id: %v", err, transaction.AccountId)
+	}
+
+	return transactionId, nil
+}
+
+func (r *transactionRepositorySqlite) GetTransactionsByAccountId(ctx context.Context, accountId int64) ([]entity.Transaction, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			transaction_id,
+			account_id,
+			contact_number,
+			otr,
+			installment_months,
+			admin_fee,
+			total_installment,
+			total_interest,
+			asset_name,
+			due_at,
+			status,
+			partner_id,
+			partner_reference,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM transactions
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+		ORDER BY transaction_id
+	`)
+
+	q := sb.String()
+
+	rows, err := r.dbtx.QueryContext(ctx, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("[sqlite_transaction_repository][GetTransactionsByAccountId][QueryContext] error: %w | account_id: %v", err, accountId)
+	}
+	defer rows.Close()
+
+	var transactions []entity.Transaction
+
+	for rows.Next() {
+		var transaction entity.Transaction
+
+		err = rows.Scan(
+			&transaction.Id,
+			&transaction.AccountId,
+			&transaction.ContactNumber,
+			&transaction.OTR,
+			&transaction.InstallmentMonths,
+			&transaction.AdminFee,
+			&transaction.TotalInstallemnt,
+			&transaction.TotalInterest,
+			&transaction.AssetName,
+			&transaction.DueAt,
+			&transaction.Status,
+			&transaction.PartnerId,
+			&transaction.PartnerReference,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+			&transaction.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[sqlite_transaction_repository][GetTransactionsByAccountId][rows.Scan] error: %w | account_id: %v", err, accountId)
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("[sqlite_transaction_repository][GetTransactionsByAccountId][rows.Err] error: %w | account_id: %v", err, accountId)
+	}
+
+	return transactions, nil
+}
+
+// HasOutstandingTransactions reports whether the account has an approved transaction, it is outstanding until paid off
+// or cancelled, even past its due date
+func (r *transactionRepositorySqlite) HasOutstandingTransactions(ctx context.Context, accountId int64) (bool, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE account_id = ?
+			AND deleted_at IS NULL
+			AND status = ?
+	`)
+
+	q := sb.String()
+
+	var count int64
+
+	err := r.dbtx.QueryRowContext(ctx, q, accountId, appconstant.TransactionStatusApproved).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("[sqlite_transaction_repository][HasOutstandingTransactions][QueryRowContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return count > 0, nil
+}
+
+// AnonymiseTransactions clears the personal data of the transactions of the account, the amounts are kept
+func (r *transactionRepositorySqlite) AnonymiseTransactions(ctx context.Context, accountId int64) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE transactions
+		SET contact_number = '', updated_at = ?
+		WHERE account_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, nowUnixMilli(), accountId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_transaction_repository][AnonymiseTransactions][ExecContext] error: %w | account_id: %v", err, accountId)
+	}
+
+	return nil
+}
+
+// SQLite has no row locks, forUpdate is ignored since transactions begin immediate and hold the write lock of the database
+func (r *transactionRepositorySqlite) GetTransactionById(ctx context.Context, transactionId int64, forUpdate bool) (*entity.Transaction, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		SELECT
+			transaction_id,
+			account_id,
+			contact_number,
+			otr,
+			installment_months,
+			admin_fee,
+			total_installment,
+			total_interest,
+			asset_name,
+			due_at,
+			status,
+			partner_id,
+			partner_reference,
+			created_at,
+			updated_at,
+			deleted_at
+		FROM transactions
+		WHERE transaction_id = ?
+			AND deleted_at IS NULL
+	`)
+
+	q := sb.String()
+
+	var transaction entity.Transaction
+
+	err := r.dbtx.QueryRowContext(ctx, q, transactionId).Scan(
+		&transaction.Id,
+		&transaction.AccountId,
+		&transaction.ContactNumber,
+		&transaction.OTR,
+		&transaction.InstallmentMonths,
+		&transaction.AdminFee,
+		&transaction.TotalInstallemnt,
+		&transaction.TotalInterest,
+		&transaction.AssetName,
+		&transaction.DueAt,
+		&transaction.Status,
+		&transaction.PartnerId,
+		&transaction.PartnerReference,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+		&transaction.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("[sqlite_transaction_repository][GetTransactionById][QueryRowContext] error: %w | transaction_id: %v", err, transactionId)
+	}
+
+	return &transaction, nil
+}
+
+func (r *transactionRepositorySqlite) UpdateStatus(ctx context.Context, transactionId int64, status string) error {
+	var sb strings.Builder
+
+	sb.WriteString(`
+		UPDATE transactions
+		SET status = ?, updated_at = ?
+		WHERE transaction_id = ?
+	`)
+
+	q := sb.String()
+
+	_, err := r.dbtx.ExecContext(ctx, q, status, nowUnixMilli(), transactionId)
+	if err != nil {
+		return fmt.Errorf("[sqlite_transaction_repository][UpdateStatus][ExecContext] error: %w | transaction_id: %v", err, transactionId)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/michaelyusak/xyz-kredit-plus/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedDBTX starts a span for every query. Only the statement is recorded, never its arguments,
+// since arguments hold personal data such as NIK and email.
+type tracedDBTX struct {
+	dbtx   DBTX
+	system attribute.KeyValue
+	driver Driver
+}
+
+// traced wraps dbtx once, every repository factory passes its dbtx through it
+func traced(driver Driver, dbtx DBTX) DBTX {
+	if t, ok := dbtx.(*tracedDBTX); ok {
+		return t
+	}
+
+	system := semconv.DBSystemMySQL
+
+	switch driver {
+	case DriverPostgres:
+		system = semconv.DBSystemPostgreSQL
+
+	case DriverSqlite:
+		system = semconv.DBSystemSqlite
+	}
+
+	return &tracedDBTX{
+		dbtx:   dbtx,
+		system: system,
+		driver: driver,
+	}
+}
+
+func (t *tracedDBTX) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+
+	return tracing.Tracer().Start(ctx, string(t.driver)+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			t.system,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+
+	result, err := t.dbtx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (t *tracedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+
+	stmt, err := t.dbtx.PrepareContext(ctx, query)
+	tracing.End(span, err)
+
+	return stmt, err
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+
+	rows, err := t.dbtx.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+
+	return rows, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+
+	// The query runs here, scanning the row does not hit the database anymore
+	row := t.dbtx.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+
+	return row
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedDBTX(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	dbtx := traced(DriverSqlite, db)
+
+	if traced(DriverSqlite, dbtx) != dbtx {
+		t.Errorf("traced wraps an already traced dbtx again")
+	}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	_, err = dbtx.ExecContext(ctx, "CREATE TABLE accounts (email TEXT)")
+	if err != nil {
+		t.Fatalf("ExecContext: %v", err)
+	}
+
+	_, err = dbtx.ExecContext(ctx, "INSERT INTO accounts (email) VALUES (?)", "secret@example.com")
+	if err != nil {
+		t.Fatalf("ExecContext: %v", err)
+	}
+
+	var email string
+
+	err = dbtx.QueryRowContext(ctx, "SELECT email FROM accounts WHERE email = ?", "secret@example.com").Scan(&email)
+	if err != nil {
+		t.Fatalf("QueryRowContext: %v", err)
+	}
+
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("spans = %v, want 3 queries and the parent", len(spans))
+	}
+
+	for _, span := range spans[:3] {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the parent span", span.Name())
+		}
+
+		var statement string
+
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "secret@example.com") {
+				t.Errorf("span %s records a query argument in %s", span.Name(), attr.Key)
+			}
+
+			if attr.Key == "db.query.text" {
+				statement = attr.Value.AsString()
+			}
+		}
+
+		if statement == "" {
+			t.Errorf("span %s has no statement", span.Name())
+		}
+	}
+
+	if spans[2].Name() != "sqlite SELECT" {
+		t.Errorf("span name = %s, want sqlite SELECT", spans[2].Name())
+	}
+}
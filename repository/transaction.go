@@ -5,73 +5,115 @@ import (
 	"fmt"
 )
 
+// Transaction begins database transactions. It is shared by every request, so each Begin returns its own Tx.
 type Transaction interface {
-	Begin() error
+	Begin() (Tx, error)
+}
+
+// Tx is a running transaction, repositories taken from it run their queries in it
+type Tx interface {
 	Rollback() error
 	Commit() error
-	AccountMysqlTx() *accountRepositoryMysql
-	ConsumerMysqlTx() *consumerRepositoryMysql
-	RefreshTokenMysqlTx() *refreshTokenRepositoryMysql
-	AccountLimitMysqlTx() *accountLimitRepositoryMysql
-	TransactionMysqlTx() *transactionRepositoryMysql
+	AccountTx() AccountRepository
+	ConsumerTx() ConsumerRepository
+	ConsumerHistoryTx() ConsumerHistoryRepository
+	RefreshTokenTx() RefreshTokenRepository
+	AccountLimitTx() AccountLimitRepository
+	TransactionTx() TransactionRepository
+	AccountMfaTx() AccountMfaRepository
+	AuditEventTx() AuditEventRepository
+	OutboxTx() OutboxRepository
+	PartnerTx() PartnerRepository
+	PartnerWebhookTx() PartnerWebhookRepository
+	WebhookDeliveryTx() WebhookDeliveryRepository
+	DataExportTx() DataExportRepository
 }
 
 type sqlTransaction struct {
-	db *sql.DB
-	tx *sql.Tx
+	db     *sql.DB
+	driver Driver
 }
 
-func NewSqlTransaction(db *sql.DB) *sqlTransaction {
+func NewSqlTransaction(db *sql.DB, driver Driver) *sqlTransaction {
 	return &sqlTransaction{
-		db: db,
+		db:     db,
+		driver: driver,
 	}
 }
 
-func (s *sqlTransaction) Begin() error {
+func (s *sqlTransaction) Begin() (Tx, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("[transaction][Begin][db.Begin] Error: %w", err)
+		return nil, fmt.Errorf("[transaction][Begin][db.Begin] Error: %w", err)
 	}
 
-	s.tx = tx
+	return &sqlTx{
+		tx:     tx,
+		driver: s.driver,
+	}, nil
+}
 
-	return nil
+type sqlTx struct {
+	tx     *sql.Tx
+	driver Driver
 }
 
-func (s *sqlTransaction) Rollback() error {
+func (s *sqlTx) Rollback() error {
 	return s.tx.Rollback()
 }
 
-func (s *sqlTransaction) Commit() error {
+func (s *sqlTx) Commit() error {
 	return s.tx.Commit()
 }
 
-func (s *sqlTransaction) AccountMysqlTx() *accountRepositoryMysql {
-	return &accountRepositoryMysql{
-		dbtx: s.tx,
-	}
+func (s *sqlTx) AccountTx() AccountRepository {
+	return NewAccountRepository(s.driver, s.tx)
 }
 
-func (s *sqlTransaction) ConsumerMysqlTx() *consumerRepositoryMysql {
-	return &consumerRepositoryMysql{
-		dbtx: s.tx,
-	}
+func (s *sqlTx) ConsumerTx() ConsumerRepository {
+	return NewConsumerRepository(s.driver, s.tx)
 }
 
-func (s *sqlTransaction) RefreshTokenMysqlTx() *refreshTokenRepositoryMysql {
-	return &refreshTokenRepositoryMysql{
-		dbtx: s.tx,
-	}
+func (s *sqlTx) ConsumerHistoryTx() ConsumerHistoryRepository {
+	return NewConsumerHistoryRepository(s.driver, s.tx)
 }
 
-func (s *sqlTransaction) AccountLimitMysqlTx() *accountLimitRepositoryMysql {
-	return &accountLimitRepositoryMysql{
-		dbtx: s.tx,
-	}
+func (s *sqlTx) RefreshTokenTx() RefreshTokenRepository {
+	return NewRefreshTokenRepository(s.driver, s.tx)
 }
 
-func (s *sqlTransaction) TransactionMysqlTx() *transactionRepositoryMysql {
-	return &transactionRepositoryMysql{
-		dbtx: s.tx,
-	}
+func (s *sqlTx) AccountLimitTx() AccountLimitRepository {
+	return NewAccountLimitRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) TransactionTx() TransactionRepository {
+	return NewTransactionRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) AccountMfaTx() AccountMfaRepository {
+	return NewAccountMfaRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) AuditEventTx() AuditEventRepository {
+	return NewAuditEventRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) OutboxTx() OutboxRepository {
+	return NewOutboxRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) PartnerTx() PartnerRepository {
+	return NewPartnerRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) PartnerWebhookTx() PartnerWebhookRepository {
+	return NewPartnerWebhookRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) WebhookDeliveryTx() WebhookDeliveryRepository {
+	return NewWebhookDeliveryRepository(s.driver, s.tx)
+}
+
+func (s *sqlTx) DataExportTx() DataExportRepository {
+	return NewDataExportRepository(s.driver, s.tx)
 }
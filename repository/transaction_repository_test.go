@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// An approved transaction is outstanding until it is paid off, even once its due date passed
+func TestTransactionRepository_HasOutstandingTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	transactionRepo := repository.NewTransactionRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+
+	transactionId, err := transactionRepo.InsertTransaction(ctx, entity.Transaction{
+		AccountId:     accountId,
+		ContactNumber: "081312341234",
+		OTR:           200000,
+		AssetName:     "Motorcycle",
+		DueAt:         1,
+		Status:        appconstant.TransactionStatusApproved,
+	})
+	if err != nil {
+		t.Fatalf("InsertTransaction() error = %v", err)
+	}
+
+	isOutstanding, err := transactionRepo.HasOutstandingTransactions(ctx, accountId)
+	if err != nil || !isOutstanding {
+		t.Errorf("HasOutstandingTransactions() of a transaction past due = %v, %v, want true", isOutstanding, err)
+	}
+
+	err = transactionRepo.UpdateStatus(ctx, transactionId, appconstant.TransactionStatusPaidOff)
+	if err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	isOutstanding, err = transactionRepo.HasOutstandingTransactions(ctx, accountId)
+	if err != nil || isOutstanding {
+		t.Errorf("HasOutstandingTransactions() of a paid off transaction = %v, %v, want false", isOutstanding, err)
+	}
+}
+
+// A transaction reads back as it was booked, including its installment months
+func TestTransactionRepository_GetTransactionById(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSqlite(t, "immediate")
+	transactionRepo := repository.NewTransactionRepository(repository.DriverSqlite, db)
+
+	accountId := insertAccount(t, db, "user@example.com")
+
+	transactionId, err := transactionRepo.InsertTransaction(ctx, entity.Transaction{
+		AccountId:         accountId,
+		ContactNumber:     "081312341234",
+		OTR:               200000,
+		InstallmentMonths: 2,
+		AdminFee:          10000,
+		TotalInstallemnt:  220000,
+		TotalInterest:     10000,
+		AssetName:         "Motorcycle",
+		DueAt:             1,
+		Status:            appconstant.TransactionStatusApproved,
+		PartnerReference:  "order-1234",
+	})
+	if err != nil {
+		t.Fatalf("InsertTransaction() error = %v", err)
+	}
+
+	transaction, err := transactionRepo.GetTransactionById(ctx, transactionId, true)
+	if err != nil || transaction == nil {
+		t.Fatalf("GetTransactionById() = %+v, %v, want the transaction", transaction, err)
+	}
+
+	if transaction.AccountId != accountId || transaction.InstallmentMonths != 2 || transaction.OTR != 200000 || transaction.PartnerReference != "order-1234" {
+		t.Errorf("GetTransactionById() = %+v, want the transaction as booked", *transaction)
+	}
+
+	transactions, err := transactionRepo.GetTransactionsByAccountId(ctx, accountId)
+	if err != nil || len(transactions) != 1 || transactions[0].InstallmentMonths != 2 {
+		t.Errorf("GetTransactionsByAccountId() = %+v, %v, want the transaction", transactions, err)
+	}
+
+	transaction, err = transactionRepo.GetTransactionById(ctx, transactionId+1, false)
+	if err != nil || transaction != nil {
+		t.Errorf("GetTransactionById() of an unknown id = %+v, %v, want nil", transaction, err)
+	}
+}
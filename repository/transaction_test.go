@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// openSqlite opens a SQLite database in a temporary directory migrated like in production, with the given
+// transaction locking mode
+func openSqlite(t *testing.T, txlock string) *sql.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?_txlock=%s&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", filepath.Join(t.TempDir(), "test.db"), txlock)
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migration.NewMigrator(repository.DriverSqlite, db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	return db
+}
+
+// Transactions begun from the same Transaction are independent, rolling back one keeps the changes of the other
+func TestSqlTransaction_Begin_ReturnsIndependentTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	// Deferred transactions begin without waiting for each other
+	db := openSqlite(t, "deferred")
+	transaction := repository.NewSqlTransaction(db, repository.DriverSqlite)
+
+	first, err := transaction.Begin()
+	if err != nil {
+		t.Fatalf("Begin() first error = %v", err)
+	}
+
+	second, err := transaction.Begin()
+	if err != nil {
+		t.Fatalf("Begin() second error = %v", err)
+	}
+
+	_, err = first.AccountTx().InsertAccount(ctx, entity.Account{Email: "first@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatalf("InsertAccount() error = %v", err)
+	}
+
+	err = second.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() second error = %v", err)
+	}
+
+	err = first.Commit()
+	if err != nil {
+		t.Fatalf("Commit() first error = %v", err)
+	}
+
+	account, err := repository.NewAccountRepository(repository.DriverSqlite, db).GetAccountByEmail(ctx, "first@example.com", false)
+	if err != nil {
+		t.Fatalf("GetAccountByEmail() error = %v", err)
+	}
+
+	if account == nil {
+		t.Error("account of the first transaction rolled back with the second one")
+	}
+}
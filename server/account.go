@@ -0,0 +1,143 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/notifier"
+	"github.com/michaelyusak/xyz-kredit-plus/publisher"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier delivering account notifications, shared with the CLI
+func NewNotifier(config config.ServiceConfig, log *logrus.Logger) notifier.Notifier {
+	switch config.Notifier.Driver {
+	case "smtp":
+		return notifier.NewSmtpNotifier(notifier.SmtpOpt{
+			Host:     config.Notifier.Smtp.Host,
+			Port:     config.Notifier.Smtp.Port,
+			Username: config.Notifier.Smtp.Username,
+			Password: config.Notifier.Smtp.Password,
+			From:     config.Notifier.From,
+			Timeout:  time.Duration(config.Notifier.Smtp.Timeout),
+		}, log)
+
+	case "file":
+		return notifier.NewFileNotifier(config.Notifier.File.Path, config.Notifier.From, log)
+
+	default:
+		return notifier.NewLogNotifier(log)
+	}
+}
+
+// Publisher the outbox relay publishes the domain events to, shared with the CLI
+func NewPublisher(config config.OutboxConfig) publisher.Publisher {
+	switch config.Driver {
+	case "webhook":
+		return publisher.NewWebhookPublisher(publisher.WebhookOpt{
+			Url:     config.Webhook.Url,
+			Token:   config.Webhook.Token,
+			Timeout: time.Duration(config.Timeout),
+		})
+
+	case "kafka":
+		return publisher.NewKafkaPublisher(publisher.KafkaOpt{
+			RestProxyUrl: config.Kafka.RestProxyUrl,
+			Topic:        config.Kafka.Topic,
+			Username:     config.Kafka.Username,
+			Password:     config.Kafka.Password,
+			Timeout:      time.Duration(config.Timeout),
+		})
+
+	default:
+		return publisher.NewStdoutPublisher(os.Stdout)
+	}
+}
+
+func LoginProtectionOpt(config config.LoginProtectionConfig) service.LoginProtectionOpt {
+	return service.LoginProtectionOpt{
+		AccountMaxAttempts: config.AccountMaxAttempts,
+		IpMaxAttempts:      config.IpMaxAttempts,
+		DelayAfterAttempts: config.DelayAfterAttempts,
+		BaseDelay:          time.Duration(config.BaseDelay),
+		MaxDelay:           time.Duration(config.MaxDelay),
+		Lockout:            time.Duration(config.Lockout),
+		Window:             time.Duration(config.Window),
+	}
+}
+
+func EmailVerificationOpt(config config.EmailVerificationConfig) service.EmailVerificationOpt {
+	return service.EmailVerificationOpt{
+		Key:            []byte(config.Key),
+		TokenTtl:       time.Duration(config.TokenTtl),
+		ResendCooldown: time.Duration(config.ResendCooldown),
+		LinkUrl:        config.LinkUrl,
+	}
+}
+
+func PasswordResetOpt(config config.PasswordResetConfig) service.PasswordResetOpt {
+	return service.PasswordResetOpt{
+		Key:            []byte(config.Key),
+		TokenTtl:       time.Duration(config.TokenTtl),
+		ResendCooldown: time.Duration(config.ResendCooldown),
+		LinkUrl:        config.LinkUrl,
+	}
+}
+
+func MfaOpt(config config.MfaConfig) service.MfaOpt {
+	return service.MfaOpt{
+		Key:          []byte(config.Key),
+		Issuer:       config.Issuer,
+		ChallengeTtl: time.Duration(config.ChallengeTtl),
+	}
+}
+
+func AccountClosureOpt(config config.AccountClosureConfig, retention config.RetentionConfig) service.AccountClosureOpt {
+	return service.AccountClosureOpt{
+		AnonymiseAfter: time.Duration(retention.ClosedAccounts.RetainFor),
+		EmailReuse:     config.EmailReuse,
+	}
+}
+
+func DataExportOpt(config config.DataExportConfig) service.DataExportOpt {
+	return service.DataExportOpt{
+		Key:     []byte(config.Key),
+		LinkUrl: config.LinkUrl,
+		Ttl:     time.Duration(config.Ttl),
+	}
+}
+
+func RetentionOpt(config config.RetentionConfig) service.RetentionOpt {
+	return service.RetentionOpt{
+		RefreshTokensRetainFor:     time.Duration(config.RefreshTokens.RetainFor),
+		RejectedKycPhotosRetainFor: time.Duration(config.RejectedKycPhotos.RetainFor),
+		PublishedEventsRetainFor:   time.Duration(config.PublishedEvents.RetainFor),
+		DeliveredWebhooksRetainFor: time.Duration(config.DeliveredWebhooks.RetainFor),
+	}
+}
+
+// OutboxOpt leases an event for twice the timeout of the sink, so another relay only publishes it again once the
+// relay publishing it gave up
+func OutboxOpt(config config.OutboxConfig) service.OutboxOpt {
+	return service.OutboxOpt{
+		BatchSize:   config.BatchSize,
+		BaseBackoff: time.Duration(config.BaseBackoff),
+		MaxBackoff:  time.Duration(config.MaxBackoff),
+		Lease:       2 * time.Duration(config.Timeout),
+	}
+}
+
+// WebhookOpt leases a delivery for twice the timeout of the partner, so another dispatcher only sends it again once
+// the dispatcher sending it gave up
+func WebhookOpt(config config.WebhookConfig) service.WebhookOpt {
+	return service.WebhookOpt{
+		Key:         []byte(config.Key),
+		BatchSize:   config.BatchSize,
+		BaseBackoff: time.Duration(config.BaseBackoff),
+		MaxBackoff:  time.Duration(config.MaxBackoff),
+		MaxAttempts: config.MaxAttempts,
+		Lease:       2 * time.Duration(config.Timeout),
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+	hAdaptor "github.com/michaelyusak/go-helper/adaptor"
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// ConnectDB connects to the configured database, MySQL when none is configured. Shared with the CLI.
+func ConnectDB(config config.ServiceConfig) (*sql.DB, repository.Driver, error) {
+	driver := repository.Driver(config.Database)
+	if driver == "" {
+		driver = repository.DriverMysql
+	}
+
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	switch driver {
+	case repository.DriverMysql:
+		db, err = hAdaptor.ConnectDB(hAdaptor.MYSQL, config.MySQL)
+
+	case repository.DriverPostgres:
+		db, err = hAdaptor.ConnectDB(hAdaptor.PSQL, config.Postgres)
+
+	case repository.DriverSqlite:
+		db, err = connectSqlite(config.SQLite)
+
+	default:
+		return nil, "", fmt.Errorf("[server][ConnectDB] unsupported database: %s", config.Database)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("[server][ConnectDB] error: %w | database: %s", err, driver)
+	}
+
+	return db, driver, nil
+}
+
+// Transactions begin immediate so a transaction holds the write lock from its first read, the way
+// SELECT ... FOR UPDATE does on the other databases. Writers wait for each other instead of failing.
+func connectSqlite(config config.SQLiteConfig) (*sql.DB, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("sqlite path is required")
+	}
+
+	dsn := fmt.Sprintf("file:%s?_txlock=immediate&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", config.Path)
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("[sql.Open] error: %w", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		return nil, fmt.Errorf("[db.Ping] error: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runPeriodically runs job every interval until ctx is cancelled.
+func runPeriodically(ctx context.Context, log *logrus.Logger, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("[server][runPeriodically] %s stopped", name)
+			return
+
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				log.WithFields(logrus.Fields{
+					"error": err.Error(),
+				}).Errorf("[server][runPeriodically] %s failed", name)
+			}
+		}
+	}
+}
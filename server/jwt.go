@@ -0,0 +1,21 @@
+package server
+
+import (
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/keyring"
+)
+
+// Keyring signing and verifying access tokens, shared with the CLI
+func NewKeyring(config config.JwtConfig) (*keyring.Keyring, error) {
+	return keyring.New(keyring.Opt{
+		Issuer:           config.Issuer,
+		Algorithm:        config.Algorithm,
+		Secret:           []byte(config.Key),
+		Dir:              config.KeysDir,
+		RotationInterval: time.Duration(config.RotationInterval),
+		PublishAhead:     time.Duration(config.PublishAhead),
+		Retention:        time.Duration(config.Retention),
+	})
+}
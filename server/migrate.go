@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/sirupsen/logrus"
+)
+
+func migrateUp(ctx context.Context, db *sql.DB, driver repository.Driver, log *logrus.Logger) error {
+	migrator, err := migration.NewMigrator(driver, db)
+	if err != nil {
+		return fmt.Errorf("[server][migrateUp][migration.NewMigrator] error: %w", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		log.Infof("[server][migrateUp] applied migration %v_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("[server][migrateUp][migrator.Up] error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/middleware"
+	"github.com/michaelyusak/xyz-kredit-plus/ratelimit"
+	"github.com/michaelyusak/xyz-kredit-plus/service"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const rateLimitRedisPrefix = "kredit_plus:rate_limit:"
+
+// Rate limit store with its readiness check, redis is shared by every instance while memory limits each instance on its own
+func newRateLimitStore(config config.RateLimitConfig) (ratelimit.Store, []service.HealthCheck) {
+	if config.Store != "redis" {
+		return ratelimit.NewMemoryStore(), nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Redis.Addr,
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
+	})
+
+	check := service.HealthCheck{
+		Name: "rate_limit_store",
+		Check: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		},
+	}
+
+	return ratelimit.NewRedisStore(client, rateLimitRedisPrefix), []service.HealthCheck{check}
+}
+
+func rateLimitMiddleware(store ratelimit.Store, config config.RateLimitConfig, group string, rule config.RateLimitRuleConfig, log *logrus.Logger) gin.HandlerFunc {
+	if !config.IsEnabled {
+		return passThrough
+	}
+
+	limit := ratelimit.Limit{
+		Rate:  rule.Rate,
+		Burst: rule.Burst,
+	}
+
+	return middleware.RateLimit(store, group, limit, rule.KeyBy, log)
+}
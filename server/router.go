@@ -1,22 +1,25 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	hAdaptor "github.com/michaelyusak/go-helper/adaptor"
 	hHandler "github.com/michaelyusak/go-helper/handler"
 	hHelper "github.com/michaelyusak/go-helper/helper"
 	hMiddleware "github.com/michaelyusak/go-helper/middleware"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
 	"github.com/michaelyusak/xyz-kredit-plus/config"
 	"github.com/michaelyusak/xyz-kredit-plus/handler"
-	"github.com/michaelyusak/xyz-kredit-plus/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
 	"github.com/michaelyusak/xyz-kredit-plus/middleware"
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
 	"github.com/michaelyusak/xyz-kredit-plus/repository"
 	"github.com/michaelyusak/xyz-kredit-plus/service"
+	"github.com/michaelyusak/xyz-kredit-plus/webhook"
 	"github.com/sirupsen/logrus"
 
 	_ "github.com/michaelyusak/xyz-kredit-plus/docs"
@@ -26,60 +29,255 @@ import (
 
 type routerOpts struct {
 	common         *hHandler.CommonHandler
+	health         *handler.HealthHandler
+	jwks           *handler.JwksHandler
 	account        *handler.AccountHandler
 	consumer       *handler.ConsumerHandler
 	transaction    *handler.TransactionHandler
+	dataExport     *handler.DataExportHandler
+	audit          *handler.AuditHandler
+	webhook        *handler.WebhookHandler
 	jwt            hHelper.JWTHelper
+	sessions       middleware.SessionChecker
 	allowedOrigins []string
+	trustedProxies []string
+	adminApiKey    string
+
+	isEmailVerificationRequiredForKyc bool
+
+	accountRateLimit     gin.HandlerFunc
+	consumerRateLimit    gin.HandlerFunc
+	transactionRateLimit gin.HandlerFunc
 }
 
-func createRouter(config config.ServiceConfig, log *logrus.Logger) *gin.Engine {
-	mysql, err := hAdaptor.ConnectDB(hAdaptor.MYSQL, config.MySQL)
+func createRouter(ctx context.Context, config config.ServiceConfig, log *logrus.Logger) (*gin.Engine, service.HealthService) {
+	audit.SetKey(config.Audit.Key)
+
+	db, driver, err := ConnectDB(config)
 	if err != nil {
-		panic(fmt.Errorf("[server][createRouter][hAdaptor.ConnectDB] error: %w", err))
+		panic(fmt.Errorf("[server][createRouter][ConnectDB] error: %w", err))
+	}
+
+	if config.IsEnableMigration {
+		log.Info("[server][createRouter] migration is enabled")
+
+		err = migrateUp(context.Background(), db, driver, log)
+		if err != nil {
+			panic(fmt.Errorf("[server][createRouter][migrateUp] Error: %w", err))
+		}
+	}
+
+	transaction := repository.NewSqlTransaction(db, driver)
+	accountRepo := repository.NewAccountRepository(driver, db)
+	consumerRepo := repository.NewConsumerRepository(driver, db)
+	consumerHistoryRepo := repository.NewConsumerHistoryRepository(driver, db)
+	RefreshTokenRepo := repository.NewRefreshTokenRepository(driver, db)
+	mediaRepo := NewMediaRepository(config)
+	accountLimitRepo := repository.NewAccountLimitRepository(driver, db)
+	transactionRepo := repository.NewTransactionRepository(driver, db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(driver, db)
+	accountMfaRepo := repository.NewAccountMfaRepository(driver, db)
+	dataExportRepo := repository.NewDataExportRepository(driver, db)
+	auditEventRepo := repository.NewAuditEventRepository(driver, db)
+	outboxRepo := repository.NewOutboxRepository(driver, db)
+	partnerRepo := repository.NewPartnerRepository(driver, db)
+	partnerWebhookRepo := repository.NewPartnerWebhookRepository(driver, db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(driver, db)
+
+	hash := hHelper.NewHashHelper(config.Hash)
+	jwtKeyring, err := NewKeyring(config.Jwt)
+	if err != nil {
+		panic(fmt.Errorf("[server][createRouter][NewKeyring] Error: %w", err))
 	}
 
 	if config.IsEnableSeeding {
 		log.Info("[server][createRouter] seeding is enabled")
 
-		err = helper.Seed(mysql)
+		err = seed(context.Background(), db, driver, config.Seeding, hash, mediaRepo, log)
 		if err != nil {
-			panic(fmt.Errorf("[server][helper.Seed] Error: %w", err))
+			panic(fmt.Errorf("[server][createRouter][seed] Error: %w", err))
 		}
 	}
 
-	transaction := repository.NewSqlTransaction(mysql)
-	accountRepo := repository.NewAccountRepositoryMysql(mysql)
-	consumerRepo := repository.NewConsumerRepositoryMysql(mysql)
-	RefreshTokenRepo := repository.NewRefreshTokenRepositoryMysql(mysql)
-	mediaRepo := repository.NewMediaRepositoryLocal(config.LocalMediaStorage.Path) // save file into local storage for simplicity
-	accountLimitRepo := repository.NewAccountLimitRepositoryMysql(mysql)
-	transactionRepo := repository.NewTransactionRepositoryMysql(mysql)
+	notifier := NewNotifier(config, log)
 
-	hash := hHelper.NewHashHelper(config.Hash)
-	jwt := hHelper.NewJWTHelper(config.Jwt, jwt.SigningMethodHS512)
+	accountService := service.WithAccountTracing(service.NewAccountService(transaction, hash, jwtKeyring, accountRepo, consumerRepo, RefreshTokenRepo, loginAttemptRepo, accountMfaRepo, notifier, LoginProtectionOpt(config.LoginProtection), EmailVerificationOpt(config.EmailVerification), PasswordResetOpt(config.PasswordReset), MfaOpt(config.Mfa), AccountClosureOpt(config.AccountClosure, config.Retention)))
+	consumerService := service.WithConsumerTracing(service.NewConsumerService(transaction, consumerRepo, mediaRepo, accountLimitRepo))
+	transactionService := service.WithTransactionTracing(service.NewTransactionService(transaction, accountLimitRepo, transactionRepo, config.Mfa.RequiredAboveOtr))
+	mediaService := service.WithMediaTracing(service.NewMediaService(consumerRepo, mediaRepo, time.Duration(config.MediaSweeper.GracePeriod)))
+	retentionService := service.WithRetentionTracing(service.NewRetentionService(accountService, RefreshTokenRepo, consumerRepo, mediaRepo, outboxRepo, webhookDeliveryRepo, RetentionOpt(config.Retention)))
+	auditService := service.WithAuditTracing(service.NewAuditService(auditEventRepo))
+	outboxService := service.WithOutboxTracing(service.NewOutboxService(outboxRepo, NewPublisher(config.Outbox), OutboxOpt(config.Outbox)))
+	webhookService := service.WithWebhookTracing(service.NewWebhookService(transaction, partnerRepo, partnerWebhookRepo, webhookDeliveryRepo, webhook.NewHttpSender(time.Duration(config.Webhook.Timeout)), WebhookOpt(config.Webhook)))
+	dataExportService := service.WithDataExportTracing(service.NewDataExportService(accountRepo, consumerRepo, consumerHistoryRepo, accountLimitRepo, transactionRepo, RefreshTokenRepo, dataExportRepo, mediaRepo, NewArchiveRepository(config), notifier, transaction, DataExportOpt(config.DataExport)))
+
+	if jwtKeyring.IsAsymmetric() {
+		go runPeriodically(ctx, log, "jwt key rotation", time.Duration(config.Jwt.ReloadInterval), jwtKeyring.Rotate)
+	}
+
+	if config.MediaSweeper.IsEnabled {
+		go runPeriodically(ctx, log, "media sweeper", time.Duration(config.MediaSweeper.Interval), func(ctx context.Context) error {
+			result, err := mediaService.SweepOrphans(ctx)
+			if err != nil {
+				return err
+			}
+
+			log.WithFields(logrus.Fields{
+				"promoted":  result.Promoted,
+				"discarded": result.Discarded,
+				"deleted":   result.Deleted,
+			}).Info("[server][createRouter] media sweep completed")
+
+			return nil
+		})
+	}
+
+	go runPeriodically(ctx, log, "retention", time.Duration(config.Retention.Interval), func(ctx context.Context) error {
+		report, err := retentionService.Run(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(logrus.Fields{
+			"refresh_tokens_deleted":     report.RefreshTokensDeleted,
+			"accounts_anonymised":        report.AccountsAnonymised,
+			"kyc_photos_deleted":         report.KycPhotosDeleted,
+			"outbox_events_deleted":      report.OutboxEventsDeleted,
+			"webhook_deliveries_deleted": report.WebhookDeliveriesDeleted,
+		}).Info("[server][createRouter] retention applied")
+
+		return nil
+	})
+
+	go runPeriodically(ctx, log, "data export worker", time.Duration(config.DataExport.WorkerInterval), func(ctx context.Context) error {
+		result, err := dataExportService.ProcessExports(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(logrus.Fields{
+			"completed": result.Completed,
+			"failed":    result.Failed,
+			"expired":   result.Expired,
+		}).Info("[server][createRouter] data exports processed")
+
+		return nil
+	})
+
+	go runPeriodically(ctx, log, "outbox relay", time.Duration(config.Outbox.RelayInterval), func(ctx context.Context) error {
+		result, err := outboxService.Relay(ctx)
+		if err != nil {
+			return err
+		}
+
+		if result.Published > 0 || result.Failed > 0 {
+			log.WithFields(logrus.Fields{
+				"published": result.Published,
+				"failed":    result.Failed,
+			}).Info("[server][createRouter] outbox relayed")
+		}
+
+		return nil
+	})
+
+	go runPeriodically(ctx, log, "webhook dispatcher", time.Duration(config.Webhook.DispatchInterval), func(ctx context.Context) error {
+		result, err := webhookService.Dispatch(ctx)
+		if err != nil {
+			return err
+		}
+
+		if result.Delivered > 0 || result.Failed > 0 || result.Dead > 0 {
+			log.WithFields(logrus.Fields{
+				"delivered": result.Delivered,
+				"failed":    result.Failed,
+				"dead":      result.Dead,
+			}).Info("[server][createRouter] webhooks dispatched")
+		}
+
+		return nil
+	})
+
+	migrator, err := migration.NewMigrator(driver, db)
+	if err != nil {
+		panic(fmt.Errorf("[server][createRouter][migration.NewMigrator] Error: %w", err))
+	}
+
+	err = metrics.RegisterDB(db, config.Database)
+	if err != nil {
+		panic(fmt.Errorf("[server][createRouter][metrics.RegisterDB] Error: %w", err))
+	}
 
-	accountService := service.NewAccountService(transaction, hash, jwt, accountRepo, consumerRepo, RefreshTokenRepo)
-	consumerService := service.NewConsumerService(transaction, consumerRepo, mediaRepo, accountLimitRepo)
-	transactionService := service.NewTransactionService(transaction, accountLimitRepo, transactionRepo)
+	rateLimitStore, rateLimitChecks := newRateLimitStore(config.RateLimit)
+
+	healthChecks := []service.HealthCheck{
+		service.DatabaseHealthCheck(db),
+		service.MediaHealthCheck(mediaRepo),
+		service.MigrationHealthCheck(migrator),
+		service.ConfigHealthCheck(config.Validate),
+	}
+
+	if config.RateLimit.IsEnabled {
+		healthChecks = append(healthChecks, rateLimitChecks...)
+	}
+
+	healthService := service.NewHealthService(time.Duration(config.ContextTimeout), healthChecks...)
 
 	commonHandler := &hHandler.CommonHandler{}
+	healthHandler := handler.NewHealthHandler(healthService, log)
+	jwksHandler := handler.NewJwksHandler(jwtKeyring)
 	accountHandler := handler.NewAccountHandler(accountService, time.Duration(config.ContextTimeout))
 	consumerHandler := handler.NewConsumerHandler(consumerService, time.Duration(config.ContextTimeout))
 	transactionHandler := handler.NewTransactionHandler(transactionService, time.Duration(config.ContextTimeout))
+	dataExportHandler := handler.NewDataExportHandler(dataExportService, time.Duration(config.ContextTimeout))
+	auditHandler := handler.NewAuditHandler(auditService, time.Duration(config.ContextTimeout))
+	webhookHandler := handler.NewWebhookHandler(webhookService, time.Duration(config.ContextTimeout))
 
 	opt := routerOpts{
 		common:         commonHandler,
+		health:         healthHandler,
+		jwks:           jwksHandler,
 		account:        accountHandler,
 		consumer:       consumerHandler,
 		transaction:    transactionHandler,
-		jwt:            jwt,
+		dataExport:     dataExportHandler,
+		audit:          auditHandler,
+		webhook:        webhookHandler,
+		jwt:            jwtKeyring,
+		sessions:       accountService,
 		allowedOrigins: config.AllowedOrigins,
+		trustedProxies: config.TrustedProxies,
+		adminApiKey:    config.Admin.ApiKey,
+
+		isEmailVerificationRequiredForKyc: config.EmailVerification.IsRequiredForKyc,
+
+		accountRateLimit:     rateLimitMiddleware(rateLimitStore, config.RateLimit, "account", config.RateLimit.Account, log),
+		consumerRateLimit:    rateLimitMiddleware(rateLimitStore, config.RateLimit, "consumer", config.RateLimit.Consumer, log),
+		transactionRateLimit: rateLimitMiddleware(rateLimitStore, config.RateLimit, "transaction", config.RateLimit.Transaction, log),
 	}
 
 	router := newRouter(opt, log)
 
-	return router
+	return router, healthService
+}
+
+// Media repository storing every photo with its resized variants, shared with the CLI
+func NewMediaRepository(config config.ServiceConfig) repository.MediaRepository {
+	return repository.NewMediaRepositoryResized(
+		repository.NewMediaRepositoryLocal(config.LocalMediaStorage.Path), // save file into local storage for simplicity
+		mediaVariants(config.MediaVariant),
+	)
+}
+
+// Repository storing the data export archives, shared with the CLI
+func NewArchiveRepository(config config.ServiceConfig) repository.MediaRepository {
+	return repository.NewMediaRepositoryLocal(config.DataExport.Path)
+}
+
+func mediaVariants(config config.MediaVariantConfig) []repository.MediaVariant {
+	return []repository.MediaVariant{
+		{Name: appconstant.MediaSizeThumbnail, MaxDimension: config.ThumbnailSize},
+		{Name: appconstant.MediaSizePreview, MaxDimension: config.PreviewSize},
+	}
 }
 
 func newRouter(routerOpts routerOpts, log *logrus.Logger) *gin.Engine {
@@ -89,59 +287,126 @@ func newRouter(routerOpts routerOpts, log *logrus.Logger) *gin.Engine {
 
 	router.ContextWithFallback = true
 
+	// The client IP keys the rate limits and login lockouts, X-Forwarded-For is only taken from the proxies in front
+	err := router.SetTrustedProxies(routerOpts.trustedProxies)
+	if err != nil {
+		panic(fmt.Errorf("[server][newRouter][SetTrustedProxies] Error: %w", err))
+	}
+
 	router.Use(
+		middleware.Metrics(),
 		hMiddleware.Logger(log),
 		hMiddleware.RequestIdHandlerMiddleware,
+		middleware.Tracing(),
+		middleware.Audit(),
 		hMiddleware.ErrorHandlerMiddleware,
 		gin.Recovery(),
 	)
 
-	authMiddleware := middleware.AuthMiddleware(routerOpts.jwt)
+	authMiddleware := middleware.AuthMiddleware(routerOpts.jwt, routerOpts.sessions)
+	adminAuthMiddleware := middleware.AdminAuthMiddleware(routerOpts.adminApiKey)
 	kycFilter := middleware.KycFilter()
 
+	emailVerifiedFilter := passThrough
+	if routerOpts.isEmailVerificationRequiredForKyc {
+		emailVerifiedFilter = middleware.EmailVerifiedFilter()
+	}
+
 	corsRouting(router, corsConfig, routerOpts.allowedOrigins)
-	commonRouting(router, routerOpts.common)
+	commonRouting(router, adminAuthMiddleware, routerOpts.common, routerOpts.health)
+	wellKnownRouting(router, routerOpts.jwks)
 	swaggerRouting(router)
-	accountRouting(router, routerOpts.account)
-	consumerRouting(router, authMiddleware, routerOpts.consumer)
-	transactionRouting(router, authMiddleware, kycFilter, routerOpts.transaction)
+	accountRouting(router, authMiddleware, routerOpts.accountRateLimit, routerOpts.account, routerOpts.dataExport)
+	consumerRouting(router, authMiddleware, emailVerifiedFilter, routerOpts.consumerRateLimit, routerOpts.consumer)
+	transactionRouting(router, authMiddleware, kycFilter, routerOpts.transactionRateLimit, routerOpts.transaction)
+	adminRouting(router, adminAuthMiddleware, routerOpts.audit, routerOpts.webhook, routerOpts.transaction, routerOpts.consumer)
 
 	return router
 }
 
+// passThrough stands in for a middleware that is disabled
+func passThrough(c *gin.Context) {
+	c.Next()
+}
+
 func corsRouting(router *gin.Engine, configCors cors.Config, allowedOrigins []string) {
 	configCors.AllowOrigins = allowedOrigins
 	configCors.AllowMethods = []string{"POST", "GET", "PUT", "PATCH", "DELETE"}
-	configCors.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", "Accept", "User-Agent", "Cache-Control"}
-	configCors.ExposeHeaders = []string{"Content-Length"}
+	configCors.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", "Accept", "User-Agent", "Cache-Control", appconstant.PartnerKeyHeader}
+	configCors.ExposeHeaders = []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"}
 	configCors.AllowCredentials = true
 	router.Use(cors.New(configCors))
 }
 
-func commonRouting(router *gin.Engine, common *hHandler.CommonHandler) {
+func commonRouting(router *gin.Engine, adminAuthMiddleware gin.HandlerFunc, common *hHandler.CommonHandler, health *handler.HealthHandler) {
 	router.GET("/ping", common.Ping)
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz)
+	router.GET("/metrics", adminAuthMiddleware, gin.WrapH(metrics.Handler()))
 	router.NoRoute(common.NoRoute)
 }
 
+func wellKnownRouting(router *gin.Engine, jwks *handler.JwksHandler) {
+	router.GET("/.well-known/jwks.json", jwks.Jwks)
+}
+
 func swaggerRouting(router *gin.Engine) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 }
 
-func accountRouting(router *gin.Engine, account *handler.AccountHandler) {
-	accountRouter := router.Group("/v1/account")
+func accountRouting(router *gin.Engine, authMiddleware, rateLimit gin.HandlerFunc, account *handler.AccountHandler, dataExport *handler.DataExportHandler) {
+	accountRouter := router.Group("/v1/account", rateLimit)
 
 	accountRouter.POST("/register", account.Register)
 	accountRouter.POST("/login", account.Login)
+	accountRouter.POST("/login/mfa", account.CompleteMfaLogin)
+	accountRouter.POST("/verify-email", account.VerifyEmail)
+	accountRouter.POST("/resend-verification", authMiddleware, account.ResendVerificationEmail)
+	accountRouter.POST("/forgot-password", account.ForgotPassword)
+	accountRouter.POST("/reset-password", account.ResetPassword)
+	accountRouter.POST("/change-password", authMiddleware, account.ChangePassword)
+	accountRouter.POST("/mfa/enroll", authMiddleware, account.EnrollMfa)
+	accountRouter.POST("/mfa/confirm", authMiddleware, account.ConfirmMfa)
+	accountRouter.GET("/sessions", authMiddleware, account.GetSessions)
+	accountRouter.DELETE("/sessions/:id", authMiddleware, account.RevokeSession)
+	accountRouter.POST("/close", authMiddleware, account.CloseAccount)
+	accountRouter.POST("/data-exports", authMiddleware, dataExport.RequestExport)
+	accountRouter.GET("/data-exports", authMiddleware, dataExport.GetExports)
+	// Authorised by the token of the link, so it opens from the email
+	accountRouter.POST("/data-exports/download", dataExport.DownloadExport)
 }
 
-func consumerRouting(router *gin.Engine, authMiddleware gin.HandlerFunc, consumer *handler.ConsumerHandler) {
+// rateLimit runs after authMiddleware so it can key on the account_id
+func consumerRouting(router *gin.Engine, authMiddleware, emailVerifiedFilter, rateLimit gin.HandlerFunc, consumer *handler.ConsumerHandler) {
 	consumerRouter := router.Group("/v1/consumer")
 
-	consumerRouter.POST("/process-kyc", authMiddleware, consumer.ProcessKyc)
+	consumerRouter.POST("/process-kyc", authMiddleware, rateLimit, emailVerifiedFilter, consumer.ProcessKyc)
+	consumerRouter.POST("/resubmit-kyc", authMiddleware, rateLimit, emailVerifiedFilter, consumer.ResubmitKyc)
+	consumerRouter.PATCH("/profile", authMiddleware, rateLimit, consumer.UpdateProfile)
+	consumerRouter.GET("/kyc-photo/:photo", authMiddleware, rateLimit, consumer.GetKycPhoto)
 }
 
-func transactionRouting(router *gin.Engine, authMiddleware, kycFilter gin.HandlerFunc, transaction *handler.TransactionHandler) {
+func transactionRouting(router *gin.Engine, authMiddleware, kycFilter, rateLimit gin.HandlerFunc, transaction *handler.TransactionHandler) {
 	transactionRouter := router.Group("/v1/transaction")
 
-	transactionRouter.POST("/create", authMiddleware, kycFilter, transaction.CreateTransaction)
+	transactionRouter.POST("/create", authMiddleware, rateLimit, kycFilter, transaction.CreateTransaction)
+}
+
+func adminRouting(router *gin.Engine, adminAuthMiddleware gin.HandlerFunc, audit *handler.AuditHandler, webhook *handler.WebhookHandler, transaction *handler.TransactionHandler, consumer *handler.ConsumerHandler) {
+	adminRouter := router.Group("/v1/admin", adminAuthMiddleware)
+
+	adminRouter.GET("/audit-events", audit.GetEvents)
+	adminRouter.GET("/audit-events/verify", audit.VerifyChain)
+	adminRouter.POST("/transactions/:id/cancel", transaction.CancelTransaction)
+	adminRouter.POST("/transactions/:id/pay-off", transaction.PayOffTransaction)
+	adminRouter.POST("/consumers/:account_id/kyc/approve", consumer.ApproveKyc)
+	adminRouter.POST("/consumers/:account_id/kyc/reject", consumer.RejectKyc)
+	adminRouter.GET("/consumers/:account_id/kyc-photo/:photo", consumer.GetConsumerKycPhoto)
+	adminRouter.POST("/partners", webhook.CreatePartner)
+	adminRouter.GET("/partners", webhook.GetPartners)
+	adminRouter.POST("/partners/:id/webhooks", webhook.CreateWebhook)
+	adminRouter.GET("/partners/:id/webhooks", webhook.GetWebhooks)
+	adminRouter.DELETE("/partners/:id/webhooks/:webhook_id", webhook.DeleteWebhook)
+	adminRouter.GET("/webhook-deliveries", webhook.GetDeliveries)
+	adminRouter.POST("/webhook-deliveries/:id/redeliver", webhook.Redeliver)
 }
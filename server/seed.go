@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/fixture"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Load fixtures from the configured directory, or the bundled set of the configured environment
+func seed(ctx context.Context, db *sql.DB, driver repository.Driver, config config.SeedingConfig, hash hHelper.HashHelper, mediaRepo repository.MediaRepository, log *logrus.Logger) error {
+	var (
+		f   *fixture.Fixture
+		err error
+	)
+
+	if config.Dir != "" {
+		f, err = fixture.LoadDir(config.Dir)
+	} else {
+		environment := config.Environment
+		if environment == "" {
+			environment = "development"
+		}
+
+		f, err = fixture.LoadEnvironment(environment)
+	}
+	if err != nil {
+		return fmt.Errorf("[server][seed][fixture.Load] error: %w", err)
+	}
+
+	result, err := fixture.NewSeeder(db, driver, hash, mediaRepo).Seed(ctx, f)
+	if err != nil {
+		return fmt.Errorf("[server][seed][Seed] error: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"accounts_inserted":     result.AccountsInserted,
+		"accounts_updated":      result.AccountsUpdated,
+		"transactions_inserted": result.TransactionsInserted,
+	}).Info("[server][seed] fixtures loaded")
+
+	return nil
+}
@@ -10,14 +10,23 @@ import (
 
 	"github.com/michaelyusak/go-helper/helper"
 	"github.com/michaelyusak/xyz-kredit-plus/config"
+	"github.com/michaelyusak/xyz-kredit-plus/tracing"
 )
 
-func Init() {
+func Init(opt config.LoadOpt) {
 	log := helper.NewLogrus()
 
-	config := config.Init(log)
+	config := config.Init(log, opt)
 
-	router := createRouter(config, log)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, config.Tracing)
+	if err != nil {
+		log.Fatalf("[server][Init][tracing.Init] error: %s", err.Error())
+	}
+
+	router, healthService := createRouter(ctx, config, log)
 
 	srv := http.Server{
 		Handler: router,
@@ -39,15 +48,27 @@ func Init() {
 	<-quit
 	log.Info("Server shutdown gracefully ...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.GracefulPeriod)*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(config.GracefulPeriod))
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	// Readiness fails from now on, new requests are still accepted for the drain delay so the load balancer stops routing here before the listener closes
+	healthService.SetDraining()
+
+	log.Infof("Draining for %v", time.Duration(config.Health.DrainDelay))
+	time.Sleep(time.Duration(config.Health.DrainDelay))
+
+	stop()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Fatalf("Server shutdown: %s", err.Error())
 	}
 
-	<-ctx.Done()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Errorf("[server][Init][shutdownTracing] error: %s", err.Error())
+	}
+
+	<-shutdownCtx.Done()
 
-	log.Infof("Timeout of %v seconds", config.GracefulPeriod)
+	log.Infof("Timeout of %v", time.Duration(config.GracefulPeriod))
 	log.Info("Server exited")
 }
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+// Closed accounts anonymised per transaction by one run of the anonymiser
+const anonymiseBatchSize = 100
+
+type AccountClosureOpt struct {
+	// Personal data of a closed account is anonymised once it was closed this long, 0 anonymises it on the next run
+	AnonymiseAfter time.Duration
+	// When the email of a closed account can register again: on_close, on_anonymisation or never
+	EmailReuse string
+}
+
+func (o AccountClosureOpt) withDefaults() AccountClosureOpt {
+	if o.EmailReuse == "" {
+		o.EmailReuse = appconstant.EmailReuseOnAnonymisation
+	}
+
+	return o
+}
+
+// emailHash identifies the email of a closed account without keeping it, for as long as it is not free for reuse
+func (s *accountServiceImpl) emailHash(email string) string {
+	return s.hash.HashSHA512(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// CloseAccount soft-deletes the account with its consumer and limit, and signs out every session. It is refused while
+// a transaction still has installments due. Personal data is kept until AnonymiseAfter, then anonymised.
+func (s *accountServiceImpl) CloseAccount(ctx context.Context, accountId int64, req entity.CloseAccountReq) (*entity.AccountClosure, error) {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][CloseAccount][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	isValid, err := s.hash.Check(req.Password, []byte(account.Password))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][CloseAccount][hash.Check] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if !isValid {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_service][CloseAccount] wrong password | account_id: %v", accountId),
+			ResponseMessage: "invalid password",
+		})
+	}
+
+	var emailHash *string
+	if s.accountClosure.EmailReuse != appconstant.EmailReuseOnClose {
+		hashed := s.emailHash(account.Email)
+		emailHash = &hashed
+	}
+
+	err = s.closeAccount(ctx, accountId, emailHash)
+	if err != nil {
+		return nil, err
+	}
+
+	anonymiseAt := time.Now().Add(s.accountClosure.AnonymiseAfter)
+
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Your account was closed",
+		Body:      fmt.Sprintf("Your XYZ Kredit Plus account was closed and every session was signed out. Your personal data will be anonymised on %s.", anonymiseAt.UTC().Format(time.RFC1123)),
+	})
+
+	return &entity.AccountClosure{
+		AnonymiseAt: anonymiseAt.UnixMilli(),
+	}, nil
+}
+
+func (s *accountServiceImpl) closeAccount(ctx context.Context, accountId int64, emailHash *string) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	accountRepo := tx.AccountTx()
+	consumerRepo := tx.ConsumerTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	transactionRepo := tx.TransactionTx()
+	refreshTokenRepo := tx.RefreshTokenTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	account, err := accountRepo.GetAccountById(ctx, accountId, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		err = apperror.NotFoundError()
+		return err
+	}
+
+	// Transactions are created holding the limit, locking it keeps one from being created while the account closes
+	_, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][accountLimitRepo.GetAccountLimitByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	isOutstanding, err := transactionRepo.HasOutstandingTransactions(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][transactionRepo.HasOutstandingTransactions] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if isOutstanding {
+		err = apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusConflict,
+			Message:         fmt.Sprintf("[account_service][closeAccount] outstanding transactions | account_id: %v", accountId),
+			ResponseMessage: "account has outstanding transactions",
+		})
+		return err
+	}
+
+	isClosed, err := accountRepo.CloseAccount(ctx, accountId, emailHash)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][accountRepo.CloseAccount] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if !isClosed {
+		err = apperror.NotFoundError()
+		return err
+	}
+
+	err = consumerRepo.DeleteConsumer(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][consumerRepo.DeleteConsumer] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = accountLimitRepo.DeleteLimit(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][accountLimitRepo.DeleteLimit] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = refreshTokenRepo.RevokeAccountTokens(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][closeAccount][refreshTokenRepo.RevokeAccountTokens] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return nil
+}
+
+// AnonymiseClosedAccounts clears the personal data of every account closed for AnonymiseAfter: the email and
+// password, the consumer profile and its history, contact numbers of transactions, refresh tokens and second factors.
+// KYC photos are no longer referenced afterwards and are deleted by the media sweeper.
+func (s *accountServiceImpl) AnonymiseClosedAccounts(ctx context.Context) (*entity.AnonymiseResult, error) {
+	closedBefore := time.Now().Add(-s.accountClosure.AnonymiseAfter).UnixMilli()
+
+	result := &entity.AnonymiseResult{}
+
+	for {
+		accountIds, err := s.accountRepo.GetAccountIdsToAnonymise(ctx, closedBefore, anonymiseBatchSize)
+		if err != nil {
+			return result, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][AnonymiseClosedAccounts][accountRepo.GetAccountIdsToAnonymise] Error: %s", err.Error()),
+			})
+		}
+
+		for _, accountId := range accountIds {
+			err = s.anonymiseAccount(ctx, accountId)
+			if err != nil {
+				return result, err
+			}
+
+			result.Anonymised++
+		}
+
+		if len(accountIds) < anonymiseBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (s *accountServiceImpl) anonymiseAccount(ctx context.Context, accountId int64) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	accountRepo := tx.AccountTx()
+	consumerRepo := tx.ConsumerTx()
+	consumerHistoryRepo := tx.ConsumerHistoryTx()
+	transactionRepo := tx.TransactionTx()
+	refreshTokenRepo := tx.RefreshTokenTx()
+	accountMfaRepo := tx.AccountMfaTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	// With the never policy the hash of the email stays, so the email cannot register again
+	err = accountRepo.AnonymiseAccount(ctx, accountId, s.accountClosure.EmailReuse == appconstant.EmailReuseNever)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][accountRepo.AnonymiseAccount] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = consumerRepo.AnonymiseConsumer(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][consumerRepo.AnonymiseConsumer] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = consumerHistoryRepo.AnonymiseHistories(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][consumerHistoryRepo.AnonymiseHistories] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = transactionRepo.AnonymiseTransactions(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][transactionRepo.AnonymiseTransactions] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = refreshTokenRepo.DeleteAccountTokens(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][refreshTokenRepo.DeleteAccountTokens] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = accountMfaRepo.DeleteMfa(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][anonymiseAccount][accountMfaRepo.DeleteMfa] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func (e *testEnv) closeAccount(t *testing.T, accountId int64) {
+	t.Helper()
+
+	_, err := e.account.CloseAccount(context.Background(), accountId, entity.CloseAccountReq{Password: testPassword})
+	if err != nil {
+		t.Fatalf("CloseAccount() error = %v", err)
+	}
+}
+
+func (e *testEnv) anonymise(t *testing.T) int {
+	t.Helper()
+
+	result, err := e.account.AnonymiseClosedAccounts(context.Background())
+	if err != nil {
+		t.Fatalf("AnonymiseClosedAccounts() error = %v", err)
+	}
+
+	return result.Anonymised
+}
+
+func TestAccountClosure_CloseAccount(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	_, err := env.account.CloseAccount(context.Background(), accountId, entity.CloseAccountReq{Password: "Wrong-password1"})
+	assertAppError(t, err, http.StatusBadRequest)
+
+	closure, err := env.account.CloseAccount(context.Background(), accountId, entity.CloseAccountReq{Password: testPassword})
+	if err != nil {
+		t.Fatalf("CloseAccount() error = %v", err)
+	}
+
+	if closure.AnonymiseAt <= time.Now().UnixMilli() {
+		t.Errorf("anonymise at = %v, want after the retention period", closure.AnonymiseAt)
+	}
+
+	if account, _ := env.accountRepo.GetAccountById(context.Background(), accountId, false); account != nil {
+		t.Errorf("account = %+v, want closed", account)
+	}
+
+	if consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false); consumer != nil {
+		t.Errorf("consumer = %+v, want soft-deleted", consumer)
+	}
+
+	if limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false); limit != nil {
+		t.Errorf("limit = %+v, want soft-deleted", limit)
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want every session signed out", sessions)
+	}
+
+	if sent := env.notifier.sent(); len(sent) != 1 || sent[0].Recipient != "user@example.com" {
+		t.Errorf("notifications = %+v, want the closure confirmed", sent)
+	}
+
+	_, err = env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err == nil {
+		t.Error("Login() error = nil, want a closed account refused")
+	}
+
+	_, err = env.account.CloseAccount(context.Background(), accountId, entity.CloseAccountReq{Password: testPassword})
+	assertAppError(t, err, http.StatusNotFound)
+}
+
+func TestAccountClosure_OutstandingTransactions(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	_, err = env.account.CloseAccount(context.Background(), accountId, entity.CloseAccountReq{Password: testPassword})
+	assertAppError(t, err, http.StatusConflict)
+
+	if account, _ := env.accountRepo.GetAccountById(context.Background(), accountId, false); account == nil {
+		t.Error("account closed with an outstanding transaction")
+	}
+
+	if limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false); limit == nil {
+		t.Error("limit deleted with an outstanding transaction")
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) != 1 {
+		t.Errorf("sessions = %+v, want kept", sessions)
+	}
+}
+
+// A request authorised just before the account closed is still refused
+func TestAccountClosure_RefusesTransactions(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	env.closeAccount(t, accountId)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	assertAppError(t, err, http.StatusForbidden)
+
+	transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+	if len(transactions) != 0 {
+		t.Errorf("transactions = %d, want none", len(transactions))
+	}
+}
+
+func TestAccountClosure_AnonymiseClosedAccounts(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+	otherId := env.registerWithKyc(t, "other@example.com", 600000)
+
+	consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+
+	env.closeAccount(t, accountId)
+
+	if anonymised := env.anonymise(t); anonymised != 0 {
+		t.Errorf("anonymised = %d, want none before the retention period", anonymised)
+	}
+
+	env.account.accountClosure.AnonymiseAfter = 0
+
+	if anonymised := env.anonymise(t); anonymised != 1 {
+		t.Fatalf("anonymised = %d, want the closed account", anonymised)
+	}
+
+	for _, key := range []string{consumer.IdentityCardPhoto.Key, consumer.SelfiePhoto.Key} {
+		if isReferenced, _ := env.consumerRepo.IsMediaKeyReferenced(context.Background(), key); isReferenced {
+			t.Errorf("photo %s still referenced, want it left to the media sweeper", key)
+		}
+	}
+
+	if anonymised := env.anonymise(t); anonymised != 0 {
+		t.Errorf("anonymised = %d, want an account anonymised once", anonymised)
+	}
+
+	if other, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), otherId, false); other == nil || other.FullName == "" {
+		t.Errorf("consumer of the open account = %+v, want kept", other)
+	}
+}
+
+func TestAccountClosure_EmailReuse(t *testing.T) {
+	tests := []struct {
+		policy              string
+		wantReusedOnClose   bool
+		wantReusedAfterward bool
+	}{
+		{policy: appconstant.EmailReuseOnClose, wantReusedOnClose: true, wantReusedAfterward: true},
+		{policy: appconstant.EmailReuseOnAnonymisation, wantReusedOnClose: false, wantReusedAfterward: true},
+		{policy: appconstant.EmailReuseNever, wantReusedOnClose: false, wantReusedAfterward: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.account.accountClosure.EmailReuse = tt.policy
+
+			env.closeAccount(t, env.register(t, "user@example.com"))
+
+			register := func() error {
+				_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "User@Example.com", Password: testPassword}, testClient)
+				return err
+			}
+
+			if err := register(); (err == nil) != tt.wantReusedOnClose {
+				t.Fatalf("RegisterAccount() after closure error = %v, want reused %v", err, tt.wantReusedOnClose)
+			}
+			if tt.wantReusedOnClose {
+				return
+			}
+
+			env.account.accountClosure.AnonymiseAfter = 0
+			env.anonymise(t)
+
+			if err := register(); (err == nil) != tt.wantReusedAfterward {
+				t.Errorf("RegisterAccount() after anonymisation error = %v, want reused %v", err, tt.wantReusedAfterward)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+type accountLimitServiceImpl struct {
+	transaction  repository.Transaction
+	consumerRepo repository.ConsumerRepository
+}
+
+func NewAccountLimitService(transaction repository.Transaction, consumerRepo repository.ConsumerRepository) *accountLimitServiceImpl {
+	return &accountLimitServiceImpl{
+		transaction:  transaction,
+		consumerRepo: consumerRepo,
+	}
+}
+
+// Set limit of an account, overriding the calculated limit. Only the limits given are overridden, they are merged
+// with the current ones holding the lock on the limit, so a concurrent change is not lost.
+func (s *accountLimitServiceImpl) SetLimit(ctx context.Context, req entity.SetAccountLimitReq) (*entity.AccountLimit, error) {
+	given := []*float64{req.Limit1M, req.Limit2M, req.Limit3M, req.Limit4M}
+
+	isAnyGiven := false
+	isAllGiven := true
+
+	for _, value := range given {
+		if value == nil {
+			isAllGiven = false
+			continue
+		}
+
+		if *value < 0 {
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+				ResponseMessage: "limit cannot be negative",
+			})
+		}
+
+		isAnyGiven = true
+	}
+
+	if !isAnyGiven {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "nothing to update",
+		})
+	}
+
+	consumer, err := s.consumerRepo.GetConsumerByAccountId(ctx, req.AccountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_limit_service][SetLimit][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), req.AccountId),
+		})
+	}
+	if consumer == nil {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_limit_service][SetLimit] KYC not submitted | account_id: %v", req.AccountId),
+			ResponseMessage: "kyc not submitted",
+		})
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_limit_service][SetLimit][transaction.Begin] Error: %s | account_id: %v", err.Error(), req.AccountId),
+		})
+	}
+
+	accountLimitRepo := tx.AccountLimitTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	existing, err := accountLimitRepo.GetAccountLimitByAccountId(ctx, req.AccountId, true)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_limit_service][SetLimit][accountLimitRepo.GetAccountLimitByAccountId] Error: %s | account_id: %v", err.Error(), req.AccountId),
+		})
+	}
+
+	limit := entity.AccountLimit{
+		AccountId: req.AccountId,
+	}
+
+	if existing != nil {
+		limit.Limit1M = existing.Limit1M
+		limit.Limit2M = existing.Limit2M
+		limit.Limit3M = existing.Limit3M
+		limit.Limit4M = existing.Limit4M
+	} else if !isAllGiven {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_limit_service][SetLimit] no limit yet, every limit is required | account_id: %v", req.AccountId),
+			ResponseMessage: "account has no limit yet, every limit is required",
+		})
+		return nil, err
+	}
+
+	if req.Limit1M != nil {
+		limit.Limit1M = *req.Limit1M
+	}
+	if req.Limit2M != nil {
+		limit.Limit2M = *req.Limit2M
+	}
+	if req.Limit3M != nil {
+		limit.Limit3M = *req.Limit3M
+	}
+	if req.Limit4M != nil {
+		limit.Limit4M = *req.Limit4M
+	}
+
+	if existing == nil {
+		err = accountLimitRepo.InsertLimit(ctx, limit)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_limit_service][SetLimit][accountLimitRepo.InsertLimit] Error: %s | account_id: %v", err.Error(), limit.AccountId),
+			})
+		}
+
+		err = appendAuditEvent(ctx, auditEventRepo, limit.AccountId, appconstant.AuditActionLimitCreated, appconstant.AuditEntityAccountLimit, limit.AccountId, limitAuditDiff(nil, limit))
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_limit_service][SetLimit][appendAuditEvent] Error: %s | account_id: %v", err.Error(), limit.AccountId),
+			})
+		}
+
+		return &limit, nil
+	}
+
+	limit.Id = existing.Id
+
+	err = accountLimitRepo.UpdateLimit(ctx, limit)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_limit_service][SetLimit][accountLimitRepo.UpdateLimit] Error: %s | account_id: %v", err.Error(), limit.AccountId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, limit.AccountId, appconstant.AuditActionLimitUpdated, appconstant.AuditEntityAccountLimit, limit.AccountId, limitAuditDiff(existing, limit))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_limit_service][SetLimit][appendAuditEvent] Error: %s | account_id: %v", err.Error(), limit.AccountId),
+		})
+	}
+
+	return &limit, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func TestAccountLimit_SetLimit_KeepsTheLimitsNotGiven(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	current, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+
+	s := NewAccountLimitService(env.account.transaction, env.consumerRepo)
+
+	limit3M := float64(2000000)
+
+	updated, err := s.SetLimit(context.Background(), entity.SetAccountLimitReq{AccountId: accountId, Limit3M: &limit3M})
+	if err != nil {
+		t.Fatalf("SetLimit() error = %v", err)
+	}
+
+	stored, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+
+	for _, limit := range []*entity.AccountLimit{updated, stored} {
+		if limit.Limit1M != current.Limit1M || limit.Limit2M != current.Limit2M || limit.Limit3M != limit3M || limit.Limit4M != current.Limit4M {
+			t.Errorf("limit = %+v, want only the 3 months limit of %+v changed", limit, current)
+		}
+	}
+}
+
+func TestAccountLimit_SetLimit_Invalid(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	// A consumer approved without a limit yet
+	withoutLimitId := env.register(t, "other@example.com")
+
+	consumer := testConsumer(t, withoutLimitId, 600000)
+	consumer.KycStatus = appconstant.KycStatusApproved
+
+	err := env.consumerRepo.InsertConsumer(context.Background(), consumer)
+	if err != nil {
+		t.Fatalf("InsertConsumer() error = %v", err)
+	}
+
+	s := NewAccountLimitService(env.account.transaction, env.consumerRepo)
+
+	negative := float64(-1)
+	positive := float64(100)
+
+	tests := []struct {
+		name string
+		req  entity.SetAccountLimitReq
+	}{
+		{name: "nothing given", req: entity.SetAccountLimitReq{AccountId: accountId}},
+		{name: "negative", req: entity.SetAccountLimitReq{AccountId: accountId, Limit1M: &positive, Limit2M: &negative}},
+		{name: "partial without a limit yet", req: entity.SetAccountLimitReq{AccountId: withoutLimitId, Limit1M: &positive}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SetLimit(context.Background(), tt.req)
+			assertAppError(t, err, http.StatusBadRequest)
+		})
+	}
+
+	if limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), withoutLimitId, false); limit != nil {
+		t.Errorf("limit = %+v, want none inserted from a partial limit", limit)
+	}
+}
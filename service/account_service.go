@@ -9,36 +9,63 @@ import (
 
 	"github.com/michaelyusak/go-helper/apperror"
 	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
 	"github.com/michaelyusak/xyz-kredit-plus/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
+	"github.com/michaelyusak/xyz-kredit-plus/notifier"
 	"github.com/michaelyusak/xyz-kredit-plus/repository"
 )
 
 type accountServiceImpl struct {
-	transaction      repository.Transaction
-	hash             hHelper.HashHelper
-	jwt              hHelper.JWTHelper
-	accountRepo      repository.AccountRepository
-	consumerRepo     repository.ConsumerRepository
-	refreshTokenRepo repository.RefreshTokenRepository
+	transaction       repository.Transaction
+	hash              hHelper.HashHelper
+	jwt               hHelper.JWTHelper
+	accountRepo       repository.AccountRepository
+	consumerRepo      repository.ConsumerRepository
+	refreshTokenRepo  repository.RefreshTokenRepository
+	loginAttemptRepo  repository.LoginAttemptRepository
+	accountMfaRepo    repository.AccountMfaRepository
+	notifier          notifier.Notifier
+	loginProtection   LoginProtectionOpt
+	emailVerification EmailVerificationOpt
+	passwordReset     PasswordResetOpt
+	mfa               MfaOpt
+	accountClosure    AccountClosureOpt
+	// Checked against when the email is not registered, so both cases take as long as a wrong password
+	dummyHash []byte
 }
 
-func NewAccountService(transaction repository.Transaction, hash hHelper.HashHelper, jwt hHelper.JWTHelper, accountRepo repository.AccountRepository, consumerRepo repository.ConsumerRepository, refreshTokenRepo repository.RefreshTokenRepository) *accountServiceImpl {
+func NewAccountService(transaction repository.Transaction, hash hHelper.HashHelper, jwt hHelper.JWTHelper, accountRepo repository.AccountRepository, consumerRepo repository.ConsumerRepository, refreshTokenRepo repository.RefreshTokenRepository, loginAttemptRepo repository.LoginAttemptRepository, accountMfaRepo repository.AccountMfaRepository, notifier notifier.Notifier, loginProtection LoginProtectionOpt, emailVerification EmailVerificationOpt, passwordReset PasswordResetOpt, mfa MfaOpt, accountClosure AccountClosureOpt) *accountServiceImpl {
+	dummyHash, _ := hash.Hash("dummy password of an unregistered email")
+
 	return &accountServiceImpl{
-		transaction:      transaction,
-		hash:             hash,
-		jwt:              jwt,
-		accountRepo:      accountRepo,
-		consumerRepo:     consumerRepo,
-		refreshTokenRepo: refreshTokenRepo,
+		transaction:       transaction,
+		hash:              hash,
+		jwt:               jwt,
+		accountRepo:       accountRepo,
+		consumerRepo:      consumerRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		loginAttemptRepo:  loginAttemptRepo,
+		accountMfaRepo:    accountMfaRepo,
+		notifier:          notifier,
+		loginProtection:   loginProtection.withDefaults(),
+		emailVerification: emailVerification.withDefaults(),
+		passwordReset:     passwordReset.withDefaults(),
+		mfa:               mfa.withDefaults(),
+		accountClosure:    accountClosure.withDefaults(),
+		dummyHash:         []byte(dummyHash),
 	}
 }
 
-func (s *accountServiceImpl) generateJwt(account entity.Account, isKycCompleted bool) (*entity.TokenData, error) {
+func (s *accountServiceImpl) generateJwt(account entity.Account, sessionKey string, isKycCompleted, isMfaAuthenticated bool) (*entity.TokenData, error) {
 	customClaims := entity.JwtClaims{
-		AccountId:      account.Id,
-		Email:          account.Email,
-		IsKycCompleted: isKycCompleted,
+		AccountId:          account.Id,
+		Email:              account.Email,
+		IsKycCompleted:     isKycCompleted,
+		IsEmailVerified:    account.VerifiedAt != nil,
+		IsMfaAuthenticated: isMfaAuthenticated,
+		SessionKey:         sessionKey,
 	}
 
 	claimsBytes, err := json.Marshal(customClaims)
@@ -72,30 +99,59 @@ func (s *accountServiceImpl) generateJwt(account entity.Account, isKycCompleted
 	}, nil
 }
 
-func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount entity.Account) (*entity.TokenData, error) {
+// issueToken generates tokens reflecting the current KYC status of the account and stores the refresh token as a session
+// of the client
+func (s *accountServiceImpl) issueToken(ctx context.Context, account entity.Account, client entity.ClientInfo, isMfaAuthenticated bool) (*entity.TokenData, error) {
+	existingConsumer, err := s.consumerRepo.GetConsumerByAccountId(ctx, account.Id, false)
+	if err != nil {
+		return nil, fmt.Errorf("[account_service][issueToken][consumerRepo.GetConsumerByAccountId] Error: %w", err)
+	}
+
+	isKycCompleted := existingConsumer != nil && existingConsumer.KycStatus == appconstant.KycStatusApproved
+
+	sessionKey, err := newSessionKey()
+	if err != nil {
+		return nil, fmt.Errorf("[account_service][issueToken][newSessionKey] Error: %w", err)
+	}
+
+	token, err := s.generateJwt(account, sessionKey, isKycCompleted, isMfaAuthenticated)
+	if err != nil {
+		return nil, fmt.Errorf("[account_service][issueToken][generateJwt] Error: %w", err)
+	}
+
+	session := s.newSession(account.Id, token.RefreshToken.ExpiredAt, client)
+	session.Key = sessionKey
+
+	err = s.refreshTokenRepo.InsertToken(ctx, token.RefreshToken.Token, session)
+	if err != nil {
+		return nil, fmt.Errorf("[account_service][issueToken][refreshTokenRepo.InsertToken] Error: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount entity.Account, client entity.ClientInfo) (*entity.TokenData, error) {
 	if !helper.ValidatePassword(newAccount.Password) {
-		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
-			ResponseMessage: "invalid password",
-		})
+		return nil, invalidPasswordError()
 	}
 
-	err := s.transaction.Begin()
+	tx, err := s.transaction.Begin()
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[account_service][RegisterAccount][transaction.Begin] Error: %s", err.Error()),
 		})
 	}
 
-	accountRepo := s.transaction.AccountMysqlTx()
-	consumerRepo := s.transaction.ConsumerMysqlTx()
-	refreshTokenRepo := s.transaction.RefreshTokenMysqlTx()
+	accountRepo := tx.AccountTx()
+	consumerRepo := tx.ConsumerTx()
+	refreshTokenRepo := tx.RefreshTokenTx()
+	auditEventRepo := tx.AuditEventTx()
+	outboxRepo := tx.OutboxTx()
 
 	defer func() {
 		if err != nil {
-			s.transaction.Rollback()
+			tx.Rollback()
 		}
-
-		s.transaction.Commit()
 	}()
 
 	existing, err := accountRepo.GetAccountByEmail(ctx, newAccount.Email, true)
@@ -105,10 +161,25 @@ func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount ent
 		})
 	}
 	if existing != nil {
-		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
 			Message:         "[account_service][Register] email already registered",
 			ResponseMessage: "email already registered",
 		})
+		return nil, err
+	}
+
+	isHeld, err := accountRepo.IsEmailHeld(ctx, s.emailHash(newAccount.Email))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][accountRepo.IsEmailHeld] Error: %s", err.Error()),
+		})
+	}
+	if isHeld {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         "[account_service][Register] email of a closed account",
+			ResponseMessage: "email belongs to a closed account and cannot be registered yet",
+		})
+		return nil, err
 	}
 
 	hashed, err := s.hash.Hash(newAccount.Password)
@@ -120,6 +191,9 @@ func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount ent
 
 	newAccount.Password = hashed
 
+	verificationSentAt := time.Now().UnixMilli()
+	newAccount.VerificationSentAt = &verificationSentAt
+
 	accountId, err := accountRepo.InsertAccount(ctx, newAccount)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
@@ -129,6 +203,23 @@ func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount ent
 
 	newAccount.Id = accountId
 
+	err = appendAuditEvent(ctx, auditEventRepo, accountId, appconstant.AuditActionAccountRegistered, appconstant.AuditEntityAccount, accountId, nil)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][appendAuditEvent] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = appendOutboxEvent(ctx, outboxRepo, appconstant.EventTypeAccountRegistered, appconstant.EventAggregateAccount, accountId, entity.AccountRegisteredEvent{
+		AccountId: accountId,
+		Email:     newAccount.Email,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][appendOutboxEvent] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
 	existingConsumer, err := consumerRepo.GetConsumerByAccountId(ctx, newAccount.Id, false)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
@@ -138,28 +229,66 @@ func (s *accountServiceImpl) RegisterAccount(ctx context.Context, newAccount ent
 
 	isKycCompleted := false
 
-	if existingConsumer != nil {
+	if existingConsumer != nil && existingConsumer.KycStatus == appconstant.KycStatusApproved {
 		isKycCompleted = true
 	}
 
-	token, err := s.generateJwt(newAccount, isKycCompleted)
+	sessionKey, err := newSessionKey()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][newSessionKey] Error: %s", err.Error()),
+		})
+	}
+
+	token, err := s.generateJwt(newAccount, sessionKey, isKycCompleted, false)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[account_service][RegisterAccount][generateJwt] Error: %s", err.Error()),
 		})
 	}
 
-	err = refreshTokenRepo.InsertToken(ctx, token.RefreshToken.Token, newAccount.Id, token.RefreshToken.ExpiredAt)
+	session := s.newSession(newAccount.Id, token.RefreshToken.ExpiredAt, client)
+	session.Key = sessionKey
+
+	err = refreshTokenRepo.InsertToken(ctx, token.RefreshToken.Token, session)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[account_service][RegisterAccount][refreshTokenRepo.InsertToken] Error: %s", err.Error()),
 		})
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][transaction.Commit] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	// Sent once the account is committed, so a slow mail server does not hold the transaction and a rolled back
+	// registration is never emailed
+	err = s.sendVerificationEmail(ctx, newAccount, verificationSentAt)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RegisterAccount][sendVerificationEmail] Error: %s", err.Error()),
+		})
+	}
+
+	metrics.RecordRegistration()
+
 	return token, nil
 }
 
-func (s *accountServiceImpl) Login(ctx context.Context, account entity.Account) (*entity.TokenData, error) {
+// An unregistered email and a wrong password get the same response, and failed logins are throttled per email and per IP
+// With two-factor authentication enabled, a correct password only gets a challenge to complete with CompleteMfaLogin.
+func (s *accountServiceImpl) Login(ctx context.Context, account entity.Account, client entity.ClientInfo) (*entity.LoginResult, error) {
+	emailKey := s.emailAttemptKey(account.Email)
+	ipKey := s.ipAttemptKey(client.IpAddress)
+
+	err := s.checkLoginAllowed(ctx, emailKey, ipKey)
+	if err != nil {
+		return nil, err
+	}
+
 	existing, err := s.accountRepo.GetAccountByEmail(ctx, account.Email, false)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
@@ -167,12 +296,16 @@ func (s *accountServiceImpl) Login(ctx context.Context, account entity.Account)
 		})
 	}
 	if existing == nil {
-		return nil, apperror.NewAppError(apperror.AppErrorOpt{
-			Code:            http.StatusUnauthorized,
-			Message:         "[account_service][Login] email not registered",
-			ResponseMessage: "email not registered",
-		})
+		s.hash.Check(account.Password, s.dummyHash)
+
+		err = s.recordLoginFailure(ctx, emailKey, ipKey, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, invalidCredentialsError()
 	}
+
 	isValid, err := s.hash.Check(account.Password, []byte(existing.Password))
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
@@ -180,34 +313,144 @@ func (s *accountServiceImpl) Login(ctx context.Context, account entity.Account)
 		})
 	}
 	if !isValid {
+		err = s.recordLoginFailure(ctx, emailKey, ipKey, existing)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, invalidCredentialsError()
+	}
+
+	// Only revealed to whoever knows the password
+	if existing.DisabledAt != nil {
 		return nil, apperror.NewAppError(apperror.AppErrorOpt{
-			Code:            http.StatusUnauthorized,
-			Message:         "[account_service][Login] invalid credentials",
-			ResponseMessage: "invalid credentials",
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[account_service][Login] account disabled | account_id: %v", existing.Id),
+			ResponseMessage: "account disabled",
 		})
 	}
 
-	account = *existing
+	mfa, err := s.accountMfaRepo.GetMfa(ctx, existing.Id)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][Login][accountMfaRepo.GetMfa] Error: %s | account_id: %v", err.Error(), existing.Id),
+		})
+	}
 
-	existingConsumer, err := s.consumerRepo.GetConsumerByAccountId(ctx, account.Id, false)
+	// Failed attempts are only cleared once the second factor is checked too
+	if mfa != nil && mfa.ConfirmedAt != nil {
+		challenge, err := s.mfaChallenge(existing.Id)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][Login][mfaChallenge] Error: %s | account_id: %v", err.Error(), existing.Id),
+			})
+		}
+
+		return &entity.LoginResult{MfaChallenge: challenge}, nil
+	}
+
+	token, err := s.completeLogin(ctx, *existing, emailKey, client, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.LoginResult{TokenData: token}, nil
+}
+
+// completeLogin clears the failed attempts of the email and issues tokens once every factor is checked. The owner is
+// alerted when the client signs in from a device the account never used.
+func (s *accountServiceImpl) completeLogin(ctx context.Context, account entity.Account, emailKey string, client entity.ClientInfo, isMfaAuthenticated bool) (*entity.TokenData, error) {
+	err := s.loginAttemptRepo.DeleteLoginAttempt(ctx, emailKey)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
-			Message: fmt.Sprintf("[account_service][Login][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), existing.Id),
+			Message: fmt.Sprintf("[account_service][completeLogin][loginAttemptRepo.DeleteLoginAttempt] Error: %s | account_id: %v", err.Error(), account.Id),
 		})
 	}
 
-	isKycCompleted := false
+	isNewDevice := false
 
-	if existingConsumer != nil {
-		isKycCompleted = true
+	// Checked before the session is stored, which makes the device known
+	if session := s.newSession(account.Id, 0, client); session.DeviceHash != "" {
+		isNewDevice, err = s.refreshTokenRepo.IsNewDevice(ctx, account.Id, session.DeviceHash)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][completeLogin][refreshTokenRepo.IsNewDevice] Error: %s | account_id: %v", err.Error(), account.Id),
+			})
+		}
+	}
+
+	token, err := s.issueToken(ctx, account, client, isMfaAuthenticated)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][completeLogin][issueToken] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
 	}
 
-	token, err := s.generateJwt(account, isKycCompleted)
+	err = recordAuditEvent(ctx, s.transaction, account.Id, appconstant.AuditActionLoginSucceeded, appconstant.AuditEntityAccount, account.Id, nil)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
-			Message: fmt.Sprintf("[account_service][Login][generateJwt] Error: %s | account_id: %v", err.Error(), existing.Id),
+			Message: fmt.Sprintf("[account_service][completeLogin][recordAuditEvent] Error: %s | account_id: %v", err.Error(), account.Id),
 		})
 	}
 
+	if isNewDevice {
+		s.notifyNewDevice(ctx, account, client)
+	}
+
 	return token, nil
 }
+
+// DisableAccount keeps the account from signing in, and signs out every session it has
+func (s *accountServiceImpl) DisableAccount(ctx context.Context, email string) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][DisableAccount][transaction.Begin] Error: %s | email: %s", err.Error(), email),
+		})
+	}
+
+	accountRepo := tx.AccountTx()
+	refreshTokenRepo := tx.RefreshTokenTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	existing, err := accountRepo.GetAccountByEmail(ctx, email, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][DisableAccount][accountRepo.GetAccountByEmail] Error: %s | email: %s", err.Error(), email),
+		})
+	}
+	if existing == nil {
+		err = apperror.NotFoundError()
+		return err
+	}
+	if existing.DisabledAt != nil {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_service][DisableAccount] account already disabled | account_id: %v", existing.Id),
+			ResponseMessage: "account already disabled",
+		})
+		return err
+	}
+
+	err = accountRepo.DisableAccount(ctx, existing.Id)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][DisableAccount][accountRepo.DisableAccount] Error: %s | account_id: %v", err.Error(), existing.Id),
+		})
+	}
+
+	err = refreshTokenRepo.RevokeAccountTokens(ctx, existing.Id)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][DisableAccount][refreshTokenRepo.RevokeAccountTokens] Error: %s | account_id: %v", err.Error(), existing.Id),
+		})
+	}
+
+	return nil
+}
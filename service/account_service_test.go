@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func TestAccountService_RegisterAccount(t *testing.T) {
+	t.Run("should register account and return tokens", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		token, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+		if err != nil {
+			t.Fatalf("RegisterAccount() error = %v", err)
+		}
+
+		if token.AccessToken.Token == "" || token.RefreshToken.Token == "" {
+			t.Errorf("RegisterAccount() token = %+v, want access and refresh token", token)
+		}
+
+		account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+		if account == nil {
+			t.Fatal("account not stored")
+		}
+
+		if account.Password == testPassword {
+			t.Error("password stored in plain text")
+		}
+	})
+
+	t.Run("should reject weak password", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: "password"}, testClient)
+		assertAppError(t, err, http.StatusBadRequest)
+
+		account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+		if account != nil {
+			t.Error("account stored for rejected registration")
+		}
+	})
+
+	t.Run("should reject duplicate email", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		env.register(t, "user@example.com")
+
+		_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+		appErr := assertAppError(t, err, http.StatusBadRequest)
+
+		if appErr.ResponseMessage != "email already registered" {
+			t.Errorf("response message = %q, want %q", appErr.ResponseMessage, "email already registered")
+		}
+	})
+}
+
+func TestAccountService_Login(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		disable  bool
+		wantCode int
+	}{
+		{
+			name:     "should login with valid credentials",
+			email:    "user@example.com",
+			password: testPassword,
+		},
+		{
+			name:     "should reject wrong password",
+			email:    "user@example.com",
+			password: "@abcD12345",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "should reject unknown email",
+			email:    "unknown@example.com",
+			password: testPassword,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "should reject disabled account",
+			email:    "user@example.com",
+			password: testPassword,
+			disable:  true,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			env.register(t, "user@example.com")
+
+			if tt.disable {
+				err := env.account.DisableAccount(context.Background(), "user@example.com")
+				if err != nil {
+					t.Fatalf("DisableAccount() error = %v", err)
+				}
+			}
+
+			token, err := env.account.Login(context.Background(), entity.Account{Email: tt.email, Password: tt.password}, testClient)
+
+			if tt.wantCode != 0 {
+				assertAppError(t, err, tt.wantCode)
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			if token.AccessToken.Token == "" {
+				t.Error("Login() returned no access token")
+			}
+		})
+	}
+}
+
+func TestAccountService_DisableAccount(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	err := env.login("user@example.com", testPassword, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) == 0 {
+		t.Fatal("sessions = none, want the session of the login")
+	}
+
+	err = env.account.DisableAccount(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("DisableAccount() error = %v", err)
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want every session signed out", sessions)
+	}
+
+	// A request authorised just before the account was disabled is still refused
+	_, err = env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	assertAppError(t, err, http.StatusForbidden)
+
+	err = env.account.DisableAccount(context.Background(), "user@example.com")
+	assertAppError(t, err, http.StatusBadRequest)
+}
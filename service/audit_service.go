@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+const (
+	defaultAuditEventLimit = 50
+	maxAuditEventLimit     = 200
+	// Events checked per query while verifying the chain
+	auditVerifyBatchSize = 500
+)
+
+// auditDiff holds the changed fields of an audited entity. Personal data is left out, the events are kept when the
+// account is anonymised.
+type auditDiff map[string]entity.AuditChange
+
+// add records the field when its value changed, before is nil for a created entity
+func (d auditDiff) add(field string, before, after any) auditDiff {
+	if before != after {
+		d[field] = entity.AuditChange{Before: before, After: after}
+	}
+
+	return d
+}
+
+func limitAuditDiff(before *entity.AccountLimit, after entity.AccountLimit) auditDiff {
+	diff := auditDiff{}
+
+	if before == nil {
+		return diff.
+			add("limit_1_m", nil, after.Limit1M).
+			add("limit_2_m", nil, after.Limit2M).
+			add("limit_3_m", nil, after.Limit3M).
+			add("limit_4_m", nil, after.Limit4M)
+	}
+
+	return diff.
+		add("limit_1_m", before.Limit1M, after.Limit1M).
+		add("limit_2_m", before.Limit2M, after.Limit2M).
+		add("limit_3_m", before.Limit3M, after.Limit3M).
+		add("limit_4_m", before.Limit4M, after.Limit4M)
+}
+
+// appendAuditEvent chains an event about the account to the audit log. It runs in the transaction of the change, so the
+// event is only kept when the change is committed. The account is the actor unless the context carries another one,
+// an accountId or entityId of 0 is unknown.
+func appendAuditEvent(ctx context.Context, auditEventRepo repository.AuditEventRepository, accountId int64, action, entityType string, entityId int64, diff auditDiff) error {
+	actor, ok := audit.ActorFrom(ctx)
+	if !ok {
+		actor = audit.Actor{Type: appconstant.AuditActorAnonymous}
+
+		if accountId != 0 {
+			actor = audit.Actor{Type: appconstant.AuditActorAccount, Id: &accountId}
+		}
+	}
+
+	if diff == nil {
+		diff = auditDiff{}
+	}
+
+	diffBytes, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("[audit_service][appendAuditEvent][json.Marshal] Error: %w | action: %s", err, action)
+	}
+
+	head, err := auditEventRepo.LockChainHead(ctx)
+	if err != nil {
+		return fmt.Errorf("[audit_service][appendAuditEvent][auditEventRepo.LockChainHead] Error: %w | action: %s", err, action)
+	}
+
+	request := audit.RequestFrom(ctx)
+
+	event := entity.AuditEvent{
+		Id:         head.LastEventId + 1,
+		ActorType:  actor.Type,
+		ActorId:    actor.Id,
+		Action:     action,
+		EntityType: entityType,
+		Diff:       diffBytes,
+		RequestId:  request.Id,
+		IpAddress:  request.IpAddress,
+		PrevHash:   head.LastHash,
+		CreatedAt:  time.Now().UnixMilli(),
+	}
+
+	if entityId != 0 {
+		event.EntityId = &entityId
+	}
+
+	event.Hash, err = audit.Hash(event)
+	if err != nil {
+		return fmt.Errorf("[audit_service][appendAuditEvent][audit.Hash] Error: %w | action: %s", err, action)
+	}
+
+	err = auditEventRepo.InsertEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("[audit_service][appendAuditEvent][auditEventRepo.InsertEvent] Error: %w | action: %s", err, action)
+	}
+
+	return nil
+}
+
+// recordAuditEvent appends the event in a transaction of its own, for events not recording a change such as logins
+func recordAuditEvent(ctx context.Context, transaction repository.Transaction, accountId int64, action, entityType string, entityId int64, diff auditDiff) error {
+	tx, err := transaction.Begin()
+	if err != nil {
+		return fmt.Errorf("[audit_service][recordAuditEvent][transaction.Begin] Error: %w | action: %s", err, action)
+	}
+
+	err = appendAuditEvent(ctx, tx.AuditEventTx(), accountId, action, entityType, entityId, diff)
+	if err != nil {
+		tx.Rollback()
+
+		return fmt.Errorf("[audit_service][recordAuditEvent][appendAuditEvent] Error: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("[audit_service][recordAuditEvent][transaction.Commit] Error: %w | action: %s", err, action)
+	}
+
+	return nil
+}
+
+type auditServiceImpl struct {
+	auditEventRepo repository.AuditEventRepository
+}
+
+func NewAuditService(auditEventRepo repository.AuditEventRepository) *auditServiceImpl {
+	return &auditServiceImpl{
+		auditEventRepo: auditEventRepo,
+	}
+}
+
+// GetEvents lists the events matching the filter newest first, a page at a time
+func (s *auditServiceImpl) GetEvents(ctx context.Context, filter entity.AuditEventFilter) (*entity.AuditEvents, error) {
+	if filter.Limit < 0 || filter.Limit > maxAuditEventLimit {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: fmt.Sprintf("limit must be between 1 and %v", maxAuditEventLimit),
+		})
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditEventLimit
+	}
+
+	events, err := s.auditEventRepo.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[audit_service][GetEvents][auditEventRepo.GetEvents] Error: %s", err.Error()),
+		})
+	}
+
+	result := &entity.AuditEvents{Events: events}
+
+	if len(events) == filter.Limit {
+		result.NextBeforeId = events[len(events)-1].Id
+	}
+
+	return result, nil
+}
+
+// VerifyChain recomputes the hash of every event up to the head of the chain. Events appended while verifying are
+// left for the next verification.
+func (s *auditServiceImpl) VerifyChain(ctx context.Context) (*entity.AuditVerification, error) {
+	head, err := s.auditEventRepo.GetChainHead(ctx)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[audit_service][VerifyChain][auditEventRepo.GetChainHead] Error: %s", err.Error()),
+		})
+	}
+
+	result := &entity.AuditVerification{}
+
+	broken := func(eventId int64, reason string) (*entity.AuditVerification, error) {
+		result.BrokenAtId = &eventId
+		result.Reason = reason
+
+		return result, nil
+	}
+
+	var lastId int64
+	var lastHash string
+
+	for lastId < head.LastEventId {
+		events, err := s.auditEventRepo.GetEventsAfter(ctx, lastId, auditVerifyBatchSize)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[audit_service][VerifyChain][auditEventRepo.GetEventsAfter] Error: %s | after_id: %v", err.Error(), lastId),
+			})
+		}
+
+		if len(events) == 0 {
+			return broken(lastId+1, "event is missing")
+		}
+
+		for _, event := range events {
+			if event.Id > head.LastEventId {
+				break
+			}
+
+			if event.Id != lastId+1 {
+				return broken(lastId+1, "event is missing")
+			}
+
+			if event.PrevHash != lastHash {
+				return broken(event.Id, "prev_hash does not match the hash of the previous event")
+			}
+
+			hash, err := audit.Hash(event)
+			if err != nil {
+				return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[audit_service][VerifyChain][audit.Hash] Error: %s | audit_event_id: %v", err.Error(), event.Id),
+				})
+			}
+
+			if hash != event.Hash {
+				return broken(event.Id, "event was modified after it was recorded")
+			}
+
+			lastId = event.Id
+			lastHash = event.Hash
+			result.EventsChecked++
+		}
+	}
+
+	if lastHash != head.LastHash {
+		return broken(lastId, "hash does not match the head of the chain")
+	}
+
+	result.IsValid = true
+
+	return result, nil
+}
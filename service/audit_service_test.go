@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+func (e *testEnv) auditService() *auditServiceImpl {
+	if e.db != nil {
+		return NewAuditService(repository.NewAuditEventRepository(repository.DriverSqlite, e.db))
+	}
+
+	return NewAuditService(repository.NewAuditEventRepositoryMemory(e.store))
+}
+
+// auditActions lists the actions of the events matching the filter, oldest first
+func (e *testEnv) auditActions(t *testing.T, filter entity.AuditEventFilter) []string {
+	t.Helper()
+
+	events, err := e.auditService().GetEvents(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+
+	var actions []string
+
+	for i := len(events.Events) - 1; i >= 0; i-- {
+		actions = append(actions, events.Events[i].Action)
+	}
+
+	return actions
+}
+
+func assertActions(t *testing.T, actions []string, want ...string) {
+	t.Helper()
+
+	if len(actions) != len(want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("actions = %v, want %v", actions, want)
+		}
+	}
+}
+
+// tamperedAuditEventRepository stands in for a database edited behind the service
+type tamperedAuditEventRepository struct {
+	repository.AuditEventRepository
+	tamper func(events []entity.AuditEvent) []entity.AuditEvent
+}
+
+func (r tamperedAuditEventRepository) GetEventsAfter(ctx context.Context, afterId int64, limit int) ([]entity.AuditEvent, error) {
+	events, err := r.AuditEventRepository.GetEventsAfter(ctx, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.tamper(events), nil
+}
+
+func TestAudit_RecordsEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	ctx := audit.WithRequest(context.Background(), audit.Request{Id: "request-1", IpAddress: "10.0.0.1"})
+
+	_, err := env.account.RegisterAccount(ctx, entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+
+	env.login("user@example.com", "@abcD12345", "10.0.0.1")
+	env.login("unknown@example.com", testPassword, "10.0.0.1")
+
+	if err := env.login("user@example.com", testPassword, "10.0.0.1"); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	err = env.consumer.ProcessKyc(context.Background(), testConsumer(t, account.Id, 600000))
+	if err != nil {
+		t.Fatalf("ProcessKyc() error = %v", err)
+	}
+
+	transaction, err := env.transaction.CreateTransaction(context.Background(), testTransaction(account.Id, 200000, 2))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	assertActions(t, env.auditActions(t, entity.AuditEventFilter{}),
+		appconstant.AuditActionAccountRegistered,
+		appconstant.AuditActionLoginFailed,
+		appconstant.AuditActionLoginFailed,
+		appconstant.AuditActionLoginSucceeded,
+		appconstant.AuditActionKycApproved,
+		appconstant.AuditActionLimitCreated,
+		appconstant.AuditActionLimitUpdated,
+		appconstant.AuditActionTransactionCreated,
+	)
+
+	events, _ := env.auditService().GetEvents(context.Background(), entity.AuditEventFilter{Action: appconstant.AuditActionAccountRegistered})
+
+	registered := events.Events[0]
+	if registered.ActorType != appconstant.AuditActorAccount || *registered.ActorId != account.Id || *registered.EntityId != account.Id ||
+		registered.RequestId != "request-1" || registered.IpAddress != "10.0.0.1" {
+		t.Errorf("event = %+v, want the registration by the account with its request", registered)
+	}
+
+	events, _ = env.auditService().GetEvents(context.Background(), entity.AuditEventFilter{ActorType: appconstant.AuditActorAnonymous})
+	if len(events.Events) != 2 || events.Events[0].EntityId != nil || *events.Events[1].EntityId != account.Id {
+		t.Errorf("events = %+v, want the failed logins of the unknown email and of the account", events.Events)
+	}
+
+	events, _ = env.auditService().GetEvents(context.Background(), entity.AuditEventFilter{EntityType: appconstant.AuditEntityAccountLimit, Action: appconstant.AuditActionLimitUpdated})
+
+	var diff map[string]entity.AuditChange
+
+	err = json.Unmarshal(events.Events[0].Diff, &diff)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if diff["limit_2_m"].Before != float64(800000) || diff["limit_2_m"].After != float64(600000) {
+		t.Errorf("diff = %+v, want the limit reduced by the transaction", diff)
+	}
+
+	events, _ = env.auditService().GetEvents(context.Background(), entity.AuditEventFilter{EntityType: appconstant.AuditEntityTransaction, EntityId: transaction.Id})
+	if len(events.Events) != 1 {
+		t.Errorf("events = %+v, want the transaction created", events.Events)
+	}
+}
+
+func TestAudit_ActorFromContext(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	s := NewAccountLimitService(env.account.transaction, env.consumerRepo)
+
+	limit1M := float64(100)
+
+	_, err := s.SetLimit(audit.WithActor(context.Background(), audit.Actor{Type: appconstant.AuditActorCli}), entity.SetAccountLimitReq{
+		AccountId: accountId,
+		Limit1M:   &limit1M,
+	})
+	if err != nil {
+		t.Fatalf("SetLimit() error = %v", err)
+	}
+
+	events, _ := env.auditService().GetEvents(context.Background(), entity.AuditEventFilter{Action: appconstant.AuditActionLimitUpdated})
+	if len(events.Events) != 1 || events.Events[0].ActorType != appconstant.AuditActorCli || events.Events[0].ActorId != nil {
+		t.Fatalf("events = %+v, want the limit updated by the cli", events.Events)
+	}
+
+	var diff map[string]entity.AuditChange
+
+	json.Unmarshal(events.Events[0].Diff, &diff)
+
+	if len(diff) != 1 || diff["limit_1_m"].Before != float64(600000) || diff["limit_1_m"].After != float64(100) {
+		t.Errorf("diff = %+v, want only the changed limit", diff)
+	}
+}
+
+// failingTransaction fails to insert transactions, after the limit was deducted and audited
+type failingTransaction struct {
+	repository.Transaction
+}
+
+type failingTx struct {
+	repository.Tx
+}
+
+type failingTransactionRepository struct {
+	repository.TransactionRepository
+}
+
+func (t failingTransaction) Begin() (repository.Tx, error) {
+	tx, err := t.Transaction.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return failingTx{tx}, nil
+}
+
+func (t failingTx) TransactionTx() repository.TransactionRepository {
+	return failingTransactionRepository{t.Tx.TransactionTx()}
+}
+
+func (r failingTransactionRepository) InsertTransaction(ctx context.Context, transaction entity.Transaction) (int64, error) {
+	return 0, errors.New("insert failed")
+}
+
+func TestAudit_RolledBackChange(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	s := NewTransactionService(failingTransaction{env.account.transaction}, env.accountLimitRepo, env.transactionRepo, testMfaRequiredAboveOtr)
+
+	_, err := s.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	assertAppError(t, err, http.StatusInternalServerError)
+
+	_, err = env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	assertActions(t, env.auditActions(t, entity.AuditEventFilter{}),
+		appconstant.AuditActionAccountRegistered,
+		appconstant.AuditActionKycApproved,
+		appconstant.AuditActionLimitCreated,
+		appconstant.AuditActionLimitUpdated,
+		appconstant.AuditActionTransactionCreated,
+	)
+
+	verification, err := env.auditService().VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+
+	if !verification.IsValid || verification.EventsChecked != 5 {
+		t.Errorf("verification = %+v, want the chain intact without the rolled back event", verification)
+	}
+}
+
+// Logins audit in transactions of their own, concurrent ones must not commit or roll back each other's
+func TestAudit_ConcurrentLogins(t *testing.T) {
+	env := newSqliteTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	const logins = 20
+
+	errs := make(chan error, logins)
+
+	for i := 0; i < logins; i++ {
+		go func() {
+			errs <- env.login("user@example.com", testPassword, "10.0.0.1")
+		}()
+	}
+
+	for i := 0; i < logins; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Login() error = %v", err)
+		}
+	}
+
+	actions := env.auditActions(t, entity.AuditEventFilter{Action: appconstant.AuditActionLoginSucceeded})
+	if len(actions) != logins {
+		t.Errorf("login events = %d, want %d", len(actions), logins)
+	}
+
+	verification, err := env.auditService().VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+
+	if !verification.IsValid {
+		t.Errorf("verification = %+v, want the chain intact", verification)
+	}
+}
+
+func TestAudit_GetEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		env.register(t, email)
+	}
+
+	s := env.auditService()
+
+	first, err := s.GetEvents(context.Background(), entity.AuditEventFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+
+	if len(first.Events) != 2 || first.Events[0].Id != 3 || first.NextBeforeId != 2 {
+		t.Fatalf("page = %+v, want the 2 newest events", first)
+	}
+
+	last, _ := s.GetEvents(context.Background(), entity.AuditEventFilter{Limit: 2, BeforeId: first.NextBeforeId})
+	if len(last.Events) != 1 || last.Events[0].Id != 1 || last.NextBeforeId != 0 {
+		t.Errorf("page = %+v, want the oldest event on the last page", last)
+	}
+
+	_, err = s.GetEvents(context.Background(), entity.AuditEventFilter{Limit: maxAuditEventLimit + 1})
+	assertAppError(t, err, http.StatusBadRequest)
+}
+
+func TestAudit_VerifyChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(events []entity.AuditEvent) []entity.AuditEvent
+		brokenAtId int64
+	}{
+		{
+			name: "modified diff",
+			tamper: func(events []entity.AuditEvent) []entity.AuditEvent {
+				events[1].Diff = json.RawMessage(`{"limit_1_m":{"before":null,"after":99999999}}`)
+				return events
+			},
+			brokenAtId: 2,
+		},
+		{
+			name: "modified hash",
+			tamper: func(events []entity.AuditEvent) []entity.AuditEvent {
+				events[0].Hash = strings.Repeat("0", 64)
+				return events
+			},
+			brokenAtId: 1,
+		},
+		{
+			name: "modified and rehashed without the key",
+			tamper: func(events []entity.AuditEvent) []entity.AuditEvent {
+				events[1].Diff = json.RawMessage(`{"limit_1_m":{"before":null,"after":99999999}}`)
+				events[1].Hash = ""
+
+				b, _ := json.Marshal(events[1])
+				sum := sha256.Sum256(b)
+
+				events[1].Hash = hex.EncodeToString(sum[:])
+				events[2].PrevHash = events[1].Hash
+				return events
+			},
+			brokenAtId: 2,
+		},
+		{
+			name: "deleted event",
+			tamper: func(events []entity.AuditEvent) []entity.AuditEvent {
+				return append(events[:1], events[2:]...)
+			},
+			brokenAtId: 2,
+		},
+		{
+			name: "deleted latest event",
+			tamper: func(events []entity.AuditEvent) []entity.AuditEvent {
+				return events[:len(events)-1]
+			},
+			brokenAtId: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			env.registerWithKyc(t, "user@example.com", 600000)
+
+			s := NewAuditService(tamperedAuditEventRepository{repository.NewAuditEventRepositoryMemory(env.store), tt.tamper})
+
+			verification, err := s.VerifyChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v", err)
+			}
+
+			if verification.IsValid || verification.BrokenAtId == nil || *verification.BrokenAtId != tt.brokenAtId {
+				t.Errorf("verification = %+v, want broken at %d", verification, tt.brokenAtId)
+			}
+		})
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 
 	"github.com/michaelyusak/go-helper/apperror"
 	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
 	"github.com/michaelyusak/xyz-kredit-plus/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
 	"github.com/michaelyusak/xyz-kredit-plus/repository"
 )
 
@@ -28,6 +30,20 @@ func NewConsumerService(transaction repository.Transaction, consumerRepo reposit
 	}
 }
 
+// Limits of a consumer earning limitReferenceSalary, limits scale linearly with the salary up to limitMaxSalary
+const (
+	limitReferenceSalary = 600000
+	limitMaxSalary       = 50000000
+)
+
+// Limits of 1 to 4 months installment of a consumer earning limitReferenceSalary
+var limitReferences = [4]float64{600000, 800000, 1000000, 1200000}
+
+// A greater salary earns the limits of limitMaxSalary, so a mistyped salary cannot grant an unbounded limit
+func limitSalary(salary int64) float64 {
+	return float64(min(salary, limitMaxSalary))
+}
+
 // Validate consumer data for KYC and calculate account limit
 func (s *consumerServiceImpl) validateData(consumerData entity.Consumer) (*entity.AccountLimit, error) {
 	// Validate data e.g. liveness, check Dukcapil, etc
@@ -38,14 +54,48 @@ func (s *consumerServiceImpl) validateData(consumerData entity.Consumer) (*entit
 
 	// Calculate limit based on consumer risks
 
-	limit.Limit1M = 600000
-	limit.Limit2M = 800000
-	limit.Limit3M = 1000000
-	limit.Limit4M = 1200000
+	ratio := limitSalary(consumerData.Salary) / limitReferenceSalary
+
+	limit.Limit1M = limitReferences[0] * ratio
+	limit.Limit2M = limitReferences[1] * ratio
+	limit.Limit3M = limitReferences[2] * ratio
+	limit.Limit4M = limitReferences[3] * ratio
 
 	return &limit, nil
 }
 
+// Recalculate limit after salary changed. Limits scale linearly with salary up to limitMaxSalary,
+// so the portion already used by transactions is kept by scaling the remaining limit.
+func (s *consumerServiceImpl) recalculateLimit(limit entity.AccountLimit, oldSalary, newSalary int64) entity.AccountLimit {
+	ratio := limitSalary(newSalary) / limitSalary(oldSalary)
+
+	newLimit := limit
+
+	newLimit.Limit1M = limit.Limit1M * ratio
+	newLimit.Limit2M = limit.Limit2M * ratio
+	newLimit.Limit3M = limit.Limit3M * ratio
+	newLimit.Limit4M = limit.Limit4M * ratio
+
+	return newLimit
+}
+
+func (s *consumerServiceImpl) toHistory(consumer entity.Consumer) entity.ConsumerHistory {
+	return entity.ConsumerHistory{
+		ConsumerId:           consumer.Id,
+		AccountId:            consumer.AccountId,
+		Version:              consumer.Version,
+		IdentityNumber:       consumer.IdentityNumber,
+		FullName:             consumer.FullName,
+		LegalName:            consumer.LegalName,
+		PlaceOfBirth:         consumer.PlaceOfBirth,
+		DateOfBirth:          consumer.DateOfBirth,
+		Salary:               consumer.Salary,
+		IdentityCardPhotoKey: consumer.IdentityCardPhoto.Key,
+		SelfiePhotoKey:       consumer.SelfiePhoto.Key,
+		KycStatus:            consumer.KycStatus,
+	}
+}
+
 // Validate consumer data
 func (s *consumerServiceImpl) validateFile(media entity.Media) (repository.MediaOpt, error) {
 	allowedPhotoExts := []string{".png", ".jpg"}
@@ -111,7 +161,7 @@ func (s *consumerServiceImpl) ProcessKyc(ctx context.Context, consumerData entit
 		})
 	}
 
-	consumerData.IdentityCardPhoto.Key = helper.HashSHA256(fmt.Sprintf("%v%s", consumerData.AccountId, appconstant.KYCIdentityCardPhotoTag))
+	consumerData.IdentityCardPhoto.Key = helper.KycPhotoKey(consumerData.AccountId, appconstant.KYCIdentityCardPhotoTag, 1)
 
 	selfiePhotoOpt, err := s.validateFile(consumerData.SelfiePhoto)
 	if err != nil {
@@ -121,24 +171,53 @@ func (s *consumerServiceImpl) ProcessKyc(ctx context.Context, consumerData entit
 		})
 	}
 
-	consumerData.SelfiePhoto.Key = helper.HashSHA256(fmt.Sprintf("%v%s", consumerData.AccountId, appconstant.KYCSelfiePhotoTag))
+	consumerData.SelfiePhoto.Key = helper.KycPhotoKey(consumerData.AccountId, appconstant.KYCSelfiePhotoTag, 1)
+
+	consumerData.KycStatus = appconstant.KycStatusApproved
+	consumerData.Version = 1
 
-	err = s.transaction.Begin()
+	identityCardOpt.Key = consumerData.IdentityCardPhoto.Key
+	selfiePhotoOpt.Key = consumerData.SelfiePhoto.Key
+
+	// Photos are staged first and only promoted once the consumer is committed,
+	// so a failed KYC does not leave any photo behind.
+	defer func() {
+		if err != nil {
+			s.mediaRepo.Discard(ctx, identityCardOpt.Key)
+			s.mediaRepo.Discard(ctx, selfiePhotoOpt.Key)
+		}
+	}()
+
+	err = s.mediaRepo.Stage(ctx, identityCardOpt)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Stage][identityCard] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = s.mediaRepo.Stage(ctx, selfiePhotoOpt)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Stage][selfie] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	tx, err := s.transaction.Begin()
 	if err != nil {
 		return apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[consumer_service][ProcessKyc][transaction.Begin] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
 		})
 	}
 
-	consumerRepo := s.transaction.ConsumerMysqlTx()
-	accountLimitRepo := s.transaction.AccountLimitMysqlTx()
+	consumerRepo := tx.ConsumerTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	auditEventRepo := tx.AuditEventTx()
+	outboxRepo := tx.OutboxTx()
 
 	defer func() {
 		if err != nil {
-			s.transaction.Rollback()
+			tx.Rollback()
 		}
-
-		s.transaction.Commit()
 	}()
 
 	_, err = consumerRepo.GetConsumerByAccountId(ctx, consumerData.AccountId, true)
@@ -162,21 +241,500 @@ func (s *consumerServiceImpl) ProcessKyc(ctx context.Context, consumerData entit
 		})
 	}
 
-	identityCardOpt.Key = consumerData.IdentityCardPhoto.Key
-	err = s.mediaRepo.Store(ctx, identityCardOpt)
+	err = appendAuditEvent(ctx, auditEventRepo, consumerData.AccountId, appconstant.AuditActionKycApproved, appconstant.AuditEntityConsumer, consumerData.AccountId, auditDiff{}.
+		add("kyc_status", nil, consumerData.KycStatus).
+		add("version", nil, consumerData.Version))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][appendAuditEvent][kyc] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, consumerData.AccountId, appconstant.AuditActionLimitCreated, appconstant.AuditEntityAccountLimit, consumerData.AccountId, limitAuditDiff(nil, *accountLimit))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][appendAuditEvent][limit] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = appendOutboxEvent(ctx, outboxRepo, appconstant.EventTypeKycCompleted, appconstant.EventAggregateAccount, consumerData.AccountId, entity.KycCompletedEvent{
+		AccountId: consumerData.AccountId,
+		KycStatus: consumerData.KycStatus,
+		Version:   consumerData.Version,
+		Limit:     eventLimit(*accountLimit),
+	})
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][appendOutboxEvent] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][transaction.Commit] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	metrics.RecordKycOutcome(consumerData.KycStatus)
+
+	// The consumer is committed at this point, staged photos must not be discarded.
+	// Photos left staged are promoted later by the media sweeper.
+	if promoteErr := s.mediaRepo.Promote(ctx, identityCardOpt.Key); promoteErr != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Promote][identityCard] Error: %s | account_id: %v", promoteErr.Error(), consumerData.AccountId),
+		})
+	}
+
+	if promoteErr := s.mediaRepo.Promote(ctx, selfiePhotoOpt.Key); promoteErr != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Promote][selfie] Error: %s | account_id: %v", promoteErr.Error(), consumerData.AccountId),
+		})
+	}
+
+	return nil
+}
+
+func (s *consumerServiceImpl) GetKycPhoto(ctx context.Context, accountId int64, photoTag, size string) (*entity.MediaContent, error) {
+	consumer, err := s.consumerRepo.GetConsumerByAccountId(ctx, accountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][GetKycPhoto][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if consumer == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	var key string
+
+	switch photoTag {
+	case appconstant.KYCIdentityCardPhotoTag:
+		key = consumer.IdentityCardPhoto.Key
+
+	case appconstant.KYCSelfiePhotoTag:
+		key = consumer.SelfiePhoto.Key
+
+	default:
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][GetKycPhoto] unknown photo tag: %s | account_id: %v", photoTag, accountId),
+			ResponseMessage: "unknown photo type",
+		})
+	}
+
+	// Consumers seeded without photos have no key
+	if key == "" {
+		return nil, apperror.NotFoundError()
+	}
+
+	switch size {
+	case "", appconstant.MediaSizeOriginal:
+
+	case appconstant.MediaSizeThumbnail, appconstant.MediaSizePreview:
+		key = repository.VariantKey(key, size)
+
+	default:
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][GetKycPhoto] unknown size: %s | account_id: %v", size, accountId),
+			ResponseMessage: "unknown photo size",
+		})
+	}
+
+	media, err := s.mediaRepo.Get(ctx, key)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][GetKycPhoto][mediaRepo.Get] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if media == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	return &entity.MediaContent{
+		Key:         media.Key,
+		ContentType: http.DetectContentType(media.Bytes),
+		Bytes:       media.Bytes,
+	}, nil
+}
+
+func (s *consumerServiceImpl) UpdateProfile(ctx context.Context, accountId int64, req entity.UpdateConsumerProfileReq) error {
+	if req.FullName == "" && req.Salary == 0 {
+		return apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "nothing to update",
+		})
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][UpdateProfile][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	consumerRepo := tx.ConsumerTx()
+	consumerHistoryRepo := tx.ConsumerHistoryTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	existing, err := consumerRepo.GetConsumerByAccountId(ctx, accountId, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][UpdateProfile][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if existing == nil {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][UpdateProfile] KYC not submitted | account_id: %v", accountId),
+			ResponseMessage: "kyc not submitted",
+		})
+		return err
+	}
+
+	err = consumerHistoryRepo.InsertHistory(ctx, s.toHistory(*existing))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][UpdateProfile][consumerHistoryRepo.InsertHistory] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	updated := *existing
+	updated.Version++
+
+	if req.FullName != "" {
+		updated.FullName = req.FullName
+	}
+
+	if req.Salary != 0 {
+		updated.Salary = req.Salary
+	}
+
+	err = consumerRepo.UpdateConsumer(ctx, updated)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][UpdateProfile][consumerRepo.UpdateConsumer] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	if updated.Salary != existing.Salary {
+		err = s.recalculateAccountLimit(ctx, accountLimitRepo, auditEventRepo, accountId, existing.Salary, updated.Salary)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[consumer_service][UpdateProfile][recalculateAccountLimit] Error: %s | account_id: %v", err.Error(), accountId),
+			})
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][UpdateProfile][transaction.Commit] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return nil
+}
+
+// recalculateAccountLimit scales the limit of the account to its new salary, an account without a limit yet is left
+// without one
+func (s *consumerServiceImpl) recalculateAccountLimit(ctx context.Context, accountLimitRepo repository.AccountLimitRepository, auditEventRepo repository.AuditEventRepository, accountId, oldSalary, newSalary int64) error {
+	limit, err := accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, true)
+	if err != nil {
+		return fmt.Errorf("[consumer_service][recalculateAccountLimit][accountLimitRepo.GetAccountLimitByAccountId] Error: %w", err)
+	}
+	if limit == nil {
+		return nil
+	}
+
+	newLimit := s.recalculateLimit(*limit, oldSalary, newSalary)
+
+	err = accountLimitRepo.UpdateLimit(ctx, newLimit)
+	if err != nil {
+		return fmt.Errorf("[consumer_service][recalculateAccountLimit][accountLimitRepo.UpdateLimit] Error: %w", err)
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, accountId, appconstant.AuditActionLimitUpdated, appconstant.AuditEntityAccountLimit, accountId, limitAuditDiff(limit, newLimit))
+	if err != nil {
+		return fmt.Errorf("[consumer_service][recalculateAccountLimit][appendAuditEvent] Error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *consumerServiceImpl) ResubmitKyc(ctx context.Context, consumerData entity.Consumer) error {
+	existing, err := s.consumerRepo.GetConsumerByAccountId(ctx, consumerData.AccountId, false)
 	if err != nil {
 		return apperror.InternalServerError(apperror.AppErrorOpt{
-			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Store][identityCard] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
 		})
 	}
+	if existing == nil {
+		return apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][ResubmitKyc] KYC not submitted | account_id: %v", consumerData.AccountId),
+			ResponseMessage: "kyc not submitted",
+		})
+	}
+
+	identityCardOpt, err := s.validateFile(consumerData.IdentityCardPhoto)
+	if err != nil {
+		return apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][ResubmitKyc][validateFile][identityCard] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+			ResponseMessage: "invalid or corrupted indentity card photo",
+		})
+	}
+
+	selfiePhotoOpt, err := s.validateFile(consumerData.SelfiePhoto)
+	if err != nil {
+		return apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][ResubmitKyc][validateFile][selfie] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+			ResponseMessage: "invalid or corrupted selfie photo",
+		})
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][transaction.Begin] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	consumerRepo := tx.ConsumerTx()
+	consumerHistoryRepo := tx.ConsumerHistoryTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	// Photos are staged and only promoted once the resubmission is committed
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+
+			if identityCardOpt.Key != "" {
+				s.mediaRepo.Discard(ctx, identityCardOpt.Key)
+			}
 
+			if selfiePhotoOpt.Key != "" {
+				s.mediaRepo.Discard(ctx, selfiePhotoOpt.Key)
+			}
+		}
+	}()
+
+	existing, err = consumerRepo.GetConsumerByAccountId(ctx, consumerData.AccountId, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][consumerRepo.GetConsumerByAccountId][ForUpdate] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+	if existing == nil {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][ResubmitKyc] KYC not submitted | account_id: %v", consumerData.AccountId),
+			ResponseMessage: "kyc not submitted",
+		})
+		return err
+	}
+
+	consumerData.Id = existing.Id
+	consumerData.Version = existing.Version + 1
+	consumerData.KycStatus = appconstant.KycStatusPendingReview
+
+	consumerData.IdentityCardPhoto.Key = helper.KycPhotoKey(consumerData.AccountId, appconstant.KYCIdentityCardPhotoTag, consumerData.Version)
+	identityCardOpt.Key = consumerData.IdentityCardPhoto.Key
+
+	consumerData.SelfiePhoto.Key = helper.KycPhotoKey(consumerData.AccountId, appconstant.KYCSelfiePhotoTag, consumerData.Version)
 	selfiePhotoOpt.Key = consumerData.SelfiePhoto.Key
-	err = s.mediaRepo.Store(ctx, selfiePhotoOpt)
+
+	err = s.mediaRepo.Stage(ctx, identityCardOpt)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][mediaRepo.Stage][identityCard] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = s.mediaRepo.Stage(ctx, selfiePhotoOpt)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][mediaRepo.Stage][selfie] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = consumerHistoryRepo.InsertHistory(ctx, s.toHistory(*existing))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][consumerHistoryRepo.InsertHistory] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = consumerRepo.UpdateConsumer(ctx, consumerData)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][consumerRepo.UpdateConsumer] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, consumerData.AccountId, appconstant.AuditActionKycSubmitted, appconstant.AuditEntityConsumer, consumerData.AccountId, auditDiff{}.
+		add("kyc_status", existing.KycStatus, consumerData.KycStatus).
+		add("version", existing.Version, consumerData.Version))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][appendAuditEvent][kyc] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	if consumerData.Salary != existing.Salary {
+		var limit *entity.AccountLimit
+
+		limit, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, consumerData.AccountId, true)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[consumer_service][ResubmitKyc][accountLimitRepo.GetAccountLimitByAccountId] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+			})
+		}
+
+		if limit != nil {
+			newLimit := s.recalculateLimit(*limit, existing.Salary, consumerData.Salary)
+
+			err = accountLimitRepo.UpdateLimit(ctx, newLimit)
+			if err != nil {
+				return apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[consumer_service][ResubmitKyc][accountLimitRepo.UpdateLimit] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+				})
+			}
+
+			err = appendAuditEvent(ctx, auditEventRepo, consumerData.AccountId, appconstant.AuditActionLimitUpdated, appconstant.AuditEntityAccountLimit, consumerData.AccountId, limitAuditDiff(limit, newLimit))
+			if err != nil {
+				return apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[consumer_service][ResubmitKyc][appendAuditEvent][limit] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+				})
+			}
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return apperror.InternalServerError(apperror.AppErrorOpt{
-			Message: fmt.Sprintf("[consumer_service][ProcessKyc][mediaRepo.Store][selfie] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][transaction.Commit] Error: %s | account_id: %v", err.Error(), consumerData.AccountId),
+		})
+	}
+
+	metrics.RecordKycOutcome(consumerData.KycStatus)
+
+	// The resubmission is committed at this point, staged photos must not be discarded.
+	// Photos left staged are promoted later by the media sweeper.
+	if promoteErr := s.mediaRepo.Promote(ctx, identityCardOpt.Key); promoteErr != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][mediaRepo.Promote][identityCard] Error: %s | account_id: %v", promoteErr.Error(), consumerData.AccountId),
+		})
+	}
+
+	if promoteErr := s.mediaRepo.Promote(ctx, selfiePhotoOpt.Key); promoteErr != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ResubmitKyc][mediaRepo.Promote][selfie] Error: %s | account_id: %v", promoteErr.Error(), consumerData.AccountId),
 		})
 	}
 
 	return nil
 }
+
+func (s *consumerServiceImpl) ReviewKyc(ctx context.Context, accountId int64, isApproved bool) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	consumerRepo := tx.ConsumerTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	auditEventRepo := tx.AuditEventTx()
+	outboxRepo := tx.OutboxTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	consumer, err := consumerRepo.GetConsumerByAccountId(ctx, accountId, true)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if consumer == nil {
+		err = apperror.NotFoundError()
+		return err
+	}
+	if consumer.KycStatus != appconstant.KycStatusPendingReview {
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[consumer_service][ReviewKyc] KYC is not pending review | account_id: %v | kyc_status: %s", accountId, consumer.KycStatus),
+			ResponseMessage: "kyc is not pending review",
+		})
+		return err
+	}
+
+	previousStatus := consumer.KycStatus
+
+	action := appconstant.AuditActionKycRejected
+	consumer.KycStatus = appconstant.KycStatusRejected
+	if isApproved {
+		action = appconstant.AuditActionKycApproved
+		consumer.KycStatus = appconstant.KycStatusApproved
+	}
+
+	err = consumerRepo.UpdateConsumer(ctx, *consumer)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][consumerRepo.UpdateConsumer] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, accountId, action, appconstant.AuditEntityConsumer, accountId, auditDiff{}.
+		add("kyc_status", previousStatus, consumer.KycStatus))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][appendAuditEvent] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	event := entity.KycCompletedEvent{
+		AccountId: accountId,
+		KycStatus: consumer.KycStatus,
+		Version:   consumer.Version,
+	}
+
+	if isApproved {
+		var limit *entity.AccountLimit
+
+		limit, err = accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, false)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[consumer_service][ReviewKyc][accountLimitRepo.GetAccountLimitByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+			})
+		}
+		if limit != nil {
+			event.Limit = eventLimit(*limit)
+		}
+	}
+
+	err = appendOutboxEvent(ctx, outboxRepo, appconstant.EventTypeKycCompleted, appconstant.EventAggregateAccount, accountId, event)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][appendOutboxEvent] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[consumer_service][ReviewKyc][transaction.Commit] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	metrics.RecordKycOutcome(consumer.KycStatus)
+
+	return nil
+}
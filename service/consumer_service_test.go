@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func TestConsumerService_ProcessKyc(t *testing.T) {
+	t.Run("should approve KYC, store photos and calculate limit", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 1200000)
+
+		consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+		if consumer == nil {
+			t.Fatal("consumer not stored")
+		}
+
+		if consumer.KycStatus != appconstant.KycStatusApproved {
+			t.Errorf("kyc status = %s, want %s", consumer.KycStatus, appconstant.KycStatusApproved)
+		}
+
+		for _, key := range []string{consumer.IdentityCardPhoto.Key, consumer.SelfiePhoto.Key} {
+			media, _ := env.mediaRepo.Get(context.Background(), key)
+			if media == nil {
+				t.Errorf("photo %s not promoted", key)
+			}
+		}
+
+		staged, _ := env.mediaRepo.List(context.Background(), true)
+		if len(staged) != 0 {
+			t.Errorf("staged photos = %v, want none", staged)
+		}
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit == nil {
+			t.Fatal("limit not stored")
+		}
+
+		want := entity.AccountLimit{Limit1M: 1200000, Limit2M: 1600000, Limit3M: 2000000, Limit4M: 2400000}
+		if limit.Limit1M != want.Limit1M || limit.Limit2M != want.Limit2M || limit.Limit3M != want.Limit3M || limit.Limit4M != want.Limit4M {
+			t.Errorf("limit = %+v, want %+v", *limit, want)
+		}
+	})
+
+	t.Run("should cap the limit of a salary above the maximum", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 900000000000)
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit == nil {
+			t.Fatal("limit not stored")
+		}
+
+		want := entity.AccountLimit{Limit1M: 50000000, Limit2M: 800000 * 50000000 / 600000.0, Limit3M: 1000000 * 50000000 / 600000.0, Limit4M: 100000000}
+		if limit.Limit1M != want.Limit1M || limit.Limit2M != want.Limit2M || limit.Limit3M != want.Limit3M || limit.Limit4M != want.Limit4M {
+			t.Errorf("limit = %+v, want %+v", *limit, want)
+		}
+	})
+
+	t.Run("should reject duplicate KYC", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 1200000)
+
+		err := env.consumer.ProcessKyc(context.Background(), testConsumer(t, accountId, 5000000))
+		assertAppError(t, err, http.StatusBadRequest)
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit.Limit1M != 1200000 {
+			t.Errorf("limit 1 month = %v, want unchanged 1200000", limit.Limit1M)
+		}
+	})
+
+	t.Run("should reject invalid photo without storing anything", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+
+		consumer := testConsumer(t, accountId, 1200000)
+		consumer.SelfiePhoto.Base64 = "bm90IGFuIGltYWdl"
+
+		err := env.consumer.ProcessKyc(context.Background(), consumer)
+		assertAppError(t, err, http.StatusBadRequest)
+
+		existing, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+		if existing != nil {
+			t.Error("consumer stored for rejected KYC")
+		}
+
+		stored, _ := env.mediaRepo.List(context.Background(), false)
+		staged, _ := env.mediaRepo.List(context.Background(), true)
+		if len(stored) != 0 || len(staged) != 0 {
+			t.Errorf("photos left behind, stored = %v, staged = %v", stored, staged)
+		}
+	})
+
+	t.Run("should mark KYC completed on login", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		env.registerWithKyc(t, "user@example.com", 1200000)
+
+		_, err := env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+	})
+}
+
+// A refused review or update leaves nothing half done, the next change goes through
+func TestConsumerService_ReviewKycAndUpdateProfile(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	err := env.consumer.ReviewKyc(context.Background(), accountId, true)
+	assertAppError(t, err, http.StatusBadRequest)
+
+	err = env.consumer.ReviewKyc(context.Background(), accountId+1, true)
+	assertAppError(t, err, http.StatusNotFound)
+
+	err = env.consumer.UpdateProfile(context.Background(), accountId+1, entity.UpdateConsumerProfileReq{FullName: "other"})
+	assertAppError(t, err, http.StatusBadRequest)
+
+	err = env.consumer.UpdateProfile(context.Background(), accountId, entity.UpdateConsumerProfileReq{FullName: "user renamed"})
+	if err != nil {
+		t.Fatalf("UpdateProfile() full name error = %v", err)
+	}
+
+	err = env.consumer.UpdateProfile(context.Background(), accountId, entity.UpdateConsumerProfileReq{Salary: 1200000})
+	if err != nil {
+		t.Fatalf("UpdateProfile() salary error = %v", err)
+	}
+
+	consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+	if consumer.FullName != "user renamed" || consumer.Salary != 1200000 || consumer.Version != 3 {
+		t.Errorf("consumer = %+v, want both updates committed", consumer)
+	}
+
+	limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+	if limit.Limit1M != 1200000 {
+		t.Errorf("limit 1 month = %v, want recalculated to 1200000", limit.Limit1M)
+	}
+}
@@ -0,0 +1,626 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/notifier"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+const (
+	// Exports generated by one run of the worker
+	exportBatchSize = 10
+	// An export processing for this long was left by an instance that stopped, it is generated again
+	exportStaleAfter = 10 * time.Minute
+	archiveExtension = ".zip"
+)
+
+type DataExportOpt struct {
+	// Signs the download links
+	Key []byte
+	// Page emailed once the archive is ready, with the token appended as ?token=. It posts the token to the download
+	// endpoint, so the token stays out of the access logs of the API.
+	LinkUrl string
+	// Archives can be downloaded this long, then they are deleted
+	Ttl time.Duration
+}
+
+func (o DataExportOpt) withDefaults() DataExportOpt {
+	if o.Ttl <= 0 {
+		o.Ttl = 7 * 24 * time.Hour
+	}
+
+	return o
+}
+
+type dataExportServiceImpl struct {
+	accountRepo         repository.AccountRepository
+	consumerRepo        repository.ConsumerRepository
+	consumerHistoryRepo repository.ConsumerHistoryRepository
+	accountLimitRepo    repository.AccountLimitRepository
+	transactionRepo     repository.TransactionRepository
+	refreshTokenRepo    repository.RefreshTokenRepository
+	dataExportRepo      repository.DataExportRepository
+	mediaRepo           repository.MediaRepository
+	// Stores the archives, apart from the media
+	archiveRepo repository.MediaRepository
+	notifier    notifier.Notifier
+	transaction repository.Transaction
+	opt         DataExportOpt
+}
+
+func NewDataExportService(accountRepo repository.AccountRepository, consumerRepo repository.ConsumerRepository, consumerHistoryRepo repository.ConsumerHistoryRepository, accountLimitRepo repository.AccountLimitRepository, transactionRepo repository.TransactionRepository, refreshTokenRepo repository.RefreshTokenRepository, dataExportRepo repository.DataExportRepository, mediaRepo repository.MediaRepository, archiveRepo repository.MediaRepository, notifier notifier.Notifier, transaction repository.Transaction, opt DataExportOpt) *dataExportServiceImpl {
+	return &dataExportServiceImpl{
+		accountRepo:         accountRepo,
+		consumerRepo:        consumerRepo,
+		consumerHistoryRepo: consumerHistoryRepo,
+		accountLimitRepo:    accountLimitRepo,
+		transactionRepo:     transactionRepo,
+		refreshTokenRepo:    refreshTokenRepo,
+		dataExportRepo:      dataExportRepo,
+		mediaRepo:           mediaRepo,
+		archiveRepo:         archiveRepo,
+		notifier:            notifier,
+		transaction:         transaction,
+		opt:                 opt.withDefaults(),
+	}
+}
+
+func invalidDownloadTokenError() error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		Message:         "[data_export_service][DownloadExport] invalid download token",
+		ResponseMessage: "invalid or expired download link",
+	})
+}
+
+// RequestExport queues an export of the personal data of the account. While one is still queued or generating, it is
+// returned instead of queueing another.
+func (s *dataExportServiceImpl) RequestExport(ctx context.Context, accountId int64, requestedBy string) (*entity.DataExport, error) {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	exports, err := s.dataExportRepo.GetExportsByAccountId(ctx, accountId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][dataExportRepo.GetExportsByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	for _, export := range exports {
+		if export.Status == appconstant.DataExportStatusPending || export.Status == appconstant.DataExportStatusProcessing {
+			return &export, nil
+		}
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	dataExportRepo := tx.DataExportTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	exportId, err := dataExportRepo.InsertExport(ctx, entity.DataExport{
+		AccountId:   accountId,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][dataExportRepo.InsertExport] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, accountId, appconstant.AuditActionDataExportRequested, appconstant.AuditEntityDataExport, exportId, auditDiff{}.
+		add("requested_by", nil, requestedBy))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][appendAuditEvent] Error: %s | account_id: %v | data_export_id: %v", err.Error(), accountId, exportId),
+		})
+	}
+
+	export, err := dataExportRepo.GetExportById(ctx, exportId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][RequestExport][dataExportRepo.GetExportById] Error: %s | account_id: %v | data_export_id: %v", err.Error(), accountId, exportId),
+		})
+	}
+
+	return export, nil
+}
+
+// GetExports lists the exports of the account, newest first
+func (s *dataExportServiceImpl) GetExports(ctx context.Context, accountId int64) (*entity.DataExports, error) {
+	exports, err := s.dataExportRepo.GetExportsByAccountId(ctx, accountId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GetExports][dataExportRepo.GetExportsByAccountId] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return &entity.DataExports{Exports: exports}, nil
+}
+
+// ProcessExports deletes the archives past their ttl, then generates a batch of the queued exports. An export that
+// cannot be generated is marked failed and the others are still generated.
+func (s *dataExportServiceImpl) ProcessExports(ctx context.Context) (*entity.DataExportResult, error) {
+	result := &entity.DataExportResult{}
+
+	expired, err := s.dataExportRepo.GetExpiredExports(ctx, time.Now().UnixMilli(), exportBatchSize)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][ProcessExports][dataExportRepo.GetExpiredExports] Error: %s", err.Error()),
+		})
+	}
+
+	for _, export := range expired {
+		err = s.archiveRepo.Delete(ctx, export.ArchiveKey)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[data_export_service][ProcessExports][archiveRepo.Delete] Error: %s | data_export_id: %v", err.Error(), export.Id),
+			})
+		}
+
+		err = s.dataExportRepo.ExpireExport(ctx, export.Id)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[data_export_service][ProcessExports][dataExportRepo.ExpireExport] Error: %s | data_export_id: %v", err.Error(), export.Id),
+			})
+		}
+
+		result.Expired++
+	}
+
+	exportIds, err := s.dataExportRepo.GetExportIdsToProcess(ctx, time.Now().Add(-exportStaleAfter).UnixMilli(), exportBatchSize)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][ProcessExports][dataExportRepo.GetExportIdsToProcess] Error: %s", err.Error()),
+		})
+	}
+
+	for _, exportId := range exportIds {
+		export, err := s.GenerateExport(ctx, exportId)
+		if err != nil {
+			// Claimed by another instance
+			var appErr *apperror.AppError
+			if errors.As(err, &appErr) && appErr.Code == http.StatusConflict {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if export.Status == appconstant.DataExportStatusFailed {
+			result.Failed++
+			continue
+		}
+
+		result.Completed++
+	}
+
+	return result, nil
+}
+
+// GenerateExport writes the archive of a queued export and emails its download link to the owner. When the archive
+// cannot be written, the export is marked failed and returned.
+func (s *dataExportServiceImpl) GenerateExport(ctx context.Context, exportId int64) (*entity.DataExport, error) {
+	isClaimed, err := s.dataExportRepo.ClaimExport(ctx, exportId, time.Now().Add(-exportStaleAfter).UnixMilli())
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][dataExportRepo.ClaimExport] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+	if !isClaimed {
+		return nil, apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusConflict,
+			Message:         fmt.Sprintf("[data_export_service][GenerateExport] export not queued | data_export_id: %v", exportId),
+			ResponseMessage: "export is not queued",
+		})
+	}
+
+	export, err := s.dataExportRepo.GetExportById(ctx, exportId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][dataExportRepo.GetExportById] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	account, archive, err := s.buildArchive(ctx, export.AccountId)
+	if err != nil {
+		failure := truncate(err.Error(), 255)
+
+		failErr := s.dataExportRepo.FailExport(ctx, exportId, failure)
+		if failErr != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[data_export_service][GenerateExport][dataExportRepo.FailExport] Error: %s | data_export_id: %v", failErr.Error(), exportId),
+			})
+		}
+
+		export.Status = appconstant.DataExportStatusFailed
+		export.Failure = failure
+
+		return export, nil
+	}
+
+	archiveKey, err := newArchiveKey()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][newArchiveKey] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	err = s.archiveRepo.Store(ctx, repository.MediaOpt{
+		Key:       archiveKey,
+		Extension: archiveExtension,
+		Bytes:     archive,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][archiveRepo.Store] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	expiredAt := time.Now().Add(s.opt.Ttl).UnixMilli()
+
+	err = s.dataExportRepo.CompleteExport(ctx, exportId, archiveKey, expiredAt)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][dataExportRepo.CompleteExport] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	export, err = s.dataExportRepo.GetExportById(ctx, exportId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][dataExportRepo.GetExportById] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	err = s.notifyExportReady(ctx, *account, *export)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][GenerateExport][notifyExportReady] Error: %s | data_export_id: %v", err.Error(), exportId),
+		})
+	}
+
+	return export, nil
+}
+
+// DownloadLink is the link emailed to the owner, valid until the archive expires
+func (s *dataExportServiceImpl) DownloadLink(export entity.DataExport) (string, error) {
+	if export.ExpiredAt == nil {
+		return "", fmt.Errorf("[data_export_service][DownloadLink] export not completed | data_export_id: %v", export.Id)
+	}
+
+	token, err := signToken(s.opt.Key, signedTokenClaims{
+		Purpose:   appconstant.TokenPurposeDataExport,
+		AccountId: export.AccountId,
+		IssuedAt:  export.CreatedAt,
+		ExpiredAt: *export.ExpiredAt,
+		SubjectId: export.Id,
+	})
+	if err != nil {
+		return "", fmt.Errorf("[data_export_service][DownloadLink][signToken] Error: %w", err)
+	}
+
+	link, err := url.Parse(s.opt.LinkUrl)
+	if err != nil {
+		return "", fmt.Errorf("[data_export_service][DownloadLink][url.Parse] Error: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+func (s *dataExportServiceImpl) notifyExportReady(ctx context.Context, account entity.Account, export entity.DataExport) error {
+	link, err := s.DownloadLink(export)
+	if err != nil {
+		return err
+	}
+
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Your personal data export is ready",
+		Body:      fmt.Sprintf("The archive of the personal data XYZ Kredit Plus holds about you is ready. Download it from %s before %s, the link works until then.", link, time.UnixMilli(*export.ExpiredAt).UTC().Format(time.RFC1123)),
+	})
+
+	return nil
+}
+
+// DownloadExport returns the archive of a download link, and records the download on the export
+func (s *dataExportServiceImpl) DownloadExport(ctx context.Context, token string) (*entity.MediaContent, error) {
+	claims := verifyToken(s.opt.Key, appconstant.TokenPurposeDataExport, token, time.Now().UnixMilli())
+	if claims == nil {
+		return nil, invalidDownloadTokenError()
+	}
+
+	export, err := s.dataExportRepo.GetExportById(ctx, claims.SubjectId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][DownloadExport][dataExportRepo.GetExportById] Error: %s | data_export_id: %v", err.Error(), claims.SubjectId),
+		})
+	}
+	if export == nil || export.AccountId != claims.AccountId || export.Status != appconstant.DataExportStatusCompleted {
+		return nil, invalidDownloadTokenError()
+	}
+
+	archive, err := s.archiveRepo.Get(ctx, export.ArchiveKey)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][DownloadExport][archiveRepo.Get] Error: %s | data_export_id: %v", err.Error(), export.Id),
+		})
+	}
+	if archive == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	err = s.recordDownload(ctx, *export)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[data_export_service][DownloadExport][recordDownload] Error: %s | data_export_id: %v", err.Error(), export.Id),
+		})
+	}
+
+	return &entity.MediaContent{
+		Key:         fmt.Sprintf("xyz-kredit-plus-data-%v%s", export.Id, archiveExtension),
+		ContentType: "application/zip",
+		Bytes:       archive.Bytes,
+	}, nil
+}
+
+// recordDownload records the download on the export, together with its audit event
+func (s *dataExportServiceImpl) recordDownload(ctx context.Context, export entity.DataExport) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return fmt.Errorf("[transaction.Begin] error: %w", err)
+	}
+
+	err = tx.DataExportTx().RecordDownload(ctx, export.Id)
+	if err != nil {
+		tx.Rollback()
+
+		return fmt.Errorf("[dataExportRepo.RecordDownload] error: %w", err)
+	}
+
+	err = appendAuditEvent(ctx, tx.AuditEventTx(), export.AccountId, appconstant.AuditActionDataExportDownloaded, appconstant.AuditEntityDataExport, export.Id, nil)
+	if err != nil {
+		tx.Rollback()
+
+		return fmt.Errorf("[appendAuditEvent] error: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("[transaction.Commit] error: %w", err)
+	}
+
+	return nil
+}
+
+// buildArchive zips the account, its consumer and KYC photos, the earlier KYC submissions, limit, transactions and
+// sessions
+func (s *dataExportServiceImpl) buildArchive(ctx context.Context, accountId int64) (*entity.Account, []byte, error) {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[accountRepo.GetAccountById] error: %w", err)
+	}
+	if account == nil {
+		return nil, nil, fmt.Errorf("account not found")
+	}
+
+	consumer, err := s.consumerRepo.GetConsumerByAccountId(ctx, accountId, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[consumerRepo.GetConsumerByAccountId] error: %w", err)
+	}
+
+	limit, err := s.accountLimitRepo.GetAccountLimitByAccountId(ctx, accountId, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[accountLimitRepo.GetAccountLimitByAccountId] error: %w", err)
+	}
+
+	transactions, err := s.transactionRepo.GetTransactionsByAccountId(ctx, accountId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[transactionRepo.GetTransactionsByAccountId] error: %w", err)
+	}
+	if transactions == nil {
+		transactions = []entity.Transaction{}
+	}
+
+	sessions, err := s.refreshTokenRepo.GetActiveSessions(ctx, accountId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[refreshTokenRepo.GetActiveSessions] error: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	archive := zip.NewWriter(&buf)
+
+	type file struct {
+		name    string
+		content any
+	}
+
+	files := []file{
+		{name: "account.json", content: entity.DataExportAccount{
+			AccountId:  account.Id,
+			Email:      account.Email,
+			VerifiedAt: account.VerifiedAt,
+			DisabledAt: account.DisabledAt,
+			CreatedAt:  account.CreatedAt,
+			UpdatedAt:  account.UpdatedAt,
+		}},
+	}
+
+	if consumer != nil {
+		exported := entity.DataExportConsumer{
+			IdentityNumber: consumer.IdentityNumber,
+			FullName:       consumer.FullName,
+			LegalName:      consumer.LegalName,
+			PlaceOfBirth:   consumer.PlaceOfBirth,
+			DateOfBirth:    consumer.DateOfBirth,
+			Salary:         consumer.Salary,
+			KycStatus:      consumer.KycStatus,
+			CreatedAt:      consumer.CreatedAt,
+			UpdatedAt:      consumer.UpdatedAt,
+		}
+
+		exported.IdentityCardPhotoFile, err = s.addPhoto(ctx, archive, consumer.IdentityCardPhoto.Key, "identity_card_photo")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exported.SelfiePhotoFile, err = s.addPhoto(ctx, archive, consumer.SelfiePhoto.Key, "selfie_photo")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files = append(files, file{name: "consumer.json", content: exported})
+	}
+
+	histories, err := s.consumerHistoryRepo.GetHistoriesByAccountId(ctx, accountId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[consumerHistoryRepo.GetHistoriesByAccountId] error: %w", err)
+	}
+
+	exportedHistories := []entity.DataExportConsumerHistory{}
+
+	for _, history := range histories {
+		exported := entity.DataExportConsumerHistory{
+			Version:        history.Version,
+			IdentityNumber: history.IdentityNumber,
+			FullName:       history.FullName,
+			LegalName:      history.LegalName,
+			PlaceOfBirth:   history.PlaceOfBirth,
+			DateOfBirth:    history.DateOfBirth,
+			Salary:         history.Salary,
+			KycStatus:      history.KycStatus,
+			CreatedAt:      history.CreatedAt,
+		}
+
+		prefix := fmt.Sprintf("v%v_", history.Version)
+
+		exported.IdentityCardPhotoFile, err = s.addPhoto(ctx, archive, history.IdentityCardPhotoKey, prefix+"identity_card_photo")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exported.SelfiePhotoFile, err = s.addPhoto(ctx, archive, history.SelfiePhotoKey, prefix+"selfie_photo")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exportedHistories = append(exportedHistories, exported)
+	}
+
+	files = append(files, file{name: "consumer_histories.json", content: exportedHistories})
+
+	files = append(files,
+		file{name: "limit.json", content: limit},
+		file{name: "transactions.json", content: transactions},
+		file{name: "sessions.json", content: sessions},
+	)
+
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.content, "", "  ")
+		if err != nil {
+			return nil, nil, fmt.Errorf("[json.MarshalIndent] error: %w | file: %s", err, f.name)
+		}
+
+		err = addFile(archive, f.name, data)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("[zip.Writer.Close] error: %w", err)
+	}
+
+	return account, buf.Bytes(), nil
+}
+
+// addPhoto adds the original of a KYC photo to the kyc directory, returns its path or an empty string without a photo
+func (s *dataExportServiceImpl) addPhoto(ctx context.Context, archive *zip.Writer, key, name string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+
+	media, err := s.mediaRepo.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("[mediaRepo.Get] error: %w | key: %s", err, key)
+	}
+	if media == nil {
+		return "", nil
+	}
+
+	path := "kyc/" + name + media.Extension
+
+	return path, addFile(archive, path, media.Bytes)
+}
+
+func addFile(archive *zip.Writer, name string, data []byte) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("[zip.Writer.Create] error: %w | file: %s", err, name)
+	}
+
+	_, err = file.Write(data)
+	if err != nil {
+		return fmt.Errorf("[zip.Writer.Write] error: %w | file: %s", err, name)
+	}
+
+	return nil
+}
+
+// newArchiveKey is random, so the key of an archive cannot be guessed from its export
+func newArchiveKey() (string, error) {
+	key := make([]byte, 16)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+func truncate(s string, length int) string {
+	if runes := []rune(s); len(runes) > length {
+		return string(runes[:length])
+	}
+
+	return s
+}
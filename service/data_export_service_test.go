@@ -0,0 +1,261 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+func (e *testEnv) dataExportService() *dataExportServiceImpl {
+	return NewDataExportService(e.accountRepo, e.consumerRepo, repository.NewConsumerHistoryRepositoryMemory(e.store), e.accountLimitRepo, e.transactionRepo,
+		repository.NewRefreshTokenRepositoryMemory(e.store), repository.NewDataExportRepositoryMemory(e.store), e.mediaRepo, repository.NewMediaRepositoryMemory(), e.notifier,
+		repository.NewMemoryTransaction(e.store), DataExportOpt{
+			Key:     []byte("test"),
+			LinkUrl: "http://localhost:5173/data-export",
+		})
+}
+
+// generateExport requests an export of the account and generates it right away
+func generateExport(t *testing.T, s *dataExportServiceImpl, accountId int64) *entity.DataExport {
+	t.Helper()
+
+	requested, err := s.RequestExport(context.Background(), accountId, appconstant.DataExportRequestedByAccount)
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+
+	generated, err := s.GenerateExport(context.Background(), requested.Id)
+	if err != nil {
+		t.Fatalf("GenerateExport() error = %v", err)
+	}
+
+	return generated
+}
+
+func unzip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	files := map[string][]byte{}
+
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", file.Name, err)
+		}
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		r.Close()
+
+		files[file.Name] = buf.Bytes()
+	}
+
+	return files
+}
+
+func TestDataExport_RequestExport(t *testing.T) {
+	env := newTestEnv(t)
+	s := env.dataExportService()
+
+	accountId := env.register(t, "user@example.com")
+
+	first, err := s.RequestExport(context.Background(), accountId, appconstant.DataExportRequestedByAccount)
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+
+	if first.Status != appconstant.DataExportStatusPending {
+		t.Errorf("status = %s, want pending", first.Status)
+	}
+
+	second, err := s.RequestExport(context.Background(), accountId, appconstant.DataExportRequestedByAccount)
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+
+	if second.Id != first.Id {
+		t.Errorf("export = %d, want the queued export %d returned", second.Id, first.Id)
+	}
+
+	_, err = s.RequestExport(context.Background(), 999, appconstant.DataExportRequestedByCli)
+	assertAppError(t, err, http.StatusNotFound)
+}
+
+func TestDataExport_GenerateExport(t *testing.T) {
+	env := newTestEnv(t)
+	s := env.dataExportService()
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	err = env.consumer.UpdateProfile(context.Background(), accountId, entity.UpdateConsumerProfileReq{Salary: 700000})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+
+	export := generateExport(t, s, accountId)
+
+	if export.Status != appconstant.DataExportStatusCompleted || export.CompletedAt == nil || export.ExpiredAt == nil {
+		t.Fatalf("export = %+v, want completed", export)
+	}
+
+	_, err = s.GenerateExport(context.Background(), export.Id)
+	assertAppError(t, err, http.StatusConflict)
+
+	sent := env.notifier.sent()
+	if len(sent) != 1 || sent[0].Recipient != "user@example.com" {
+		t.Fatalf("notifications = %+v, want the download link sent", sent)
+	}
+
+	archive, err := s.DownloadExport(context.Background(), env.linkToken(t))
+	if err != nil {
+		t.Fatalf("DownloadExport() error = %v", err)
+	}
+
+	files := unzip(t, archive.Bytes)
+
+	for _, name := range []string{"account.json", "consumer.json", "consumer_histories.json", "limit.json", "transactions.json", "sessions.json", "kyc/identity_card_photo.png", "kyc/selfie_photo.png", "kyc/v1_selfie_photo.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	var consumer entity.DataExportConsumer
+
+	err = json.Unmarshal(files["consumer.json"], &consumer)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if consumer.IdentityNumber != "3171011209900001" || consumer.SelfiePhotoFile != "kyc/selfie_photo.png" {
+		t.Errorf("consumer.json = %+v, want the consumer with its photos", consumer)
+	}
+
+	var histories []entity.DataExportConsumerHistory
+
+	err = json.Unmarshal(files["consumer_histories.json"], &histories)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if len(histories) != 1 || histories[0].Version != 1 || histories[0].Salary != 600000 || histories[0].SelfiePhotoFile != "kyc/v1_selfie_photo.png" {
+		t.Errorf("consumer_histories.json = %+v, want the submission before the profile update", histories)
+	}
+
+	var transactions []entity.Transaction
+
+	err = json.Unmarshal(files["transactions.json"], &transactions)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if len(transactions) != 1 || transactions[0].TotalInstallemnt == 0 || transactions[0].DueAt == 0 {
+		t.Errorf("transactions.json = %+v, want the transaction with its installments", transactions)
+	}
+
+	exports, err := s.GetExports(context.Background(), accountId)
+	if err != nil {
+		t.Fatalf("GetExports() error = %v", err)
+	}
+
+	if len(exports.Exports) != 1 || exports.Exports[0].DownloadCount != 1 || exports.Exports[0].DownloadedAt == nil {
+		t.Errorf("exports = %+v, want the download recorded", exports.Exports)
+	}
+
+	actions := env.auditActions(t, entity.AuditEventFilter{EntityType: appconstant.AuditEntityDataExport, EntityId: export.Id})
+	assertActions(t, actions, appconstant.AuditActionDataExportRequested, appconstant.AuditActionDataExportDownloaded)
+}
+
+func TestDataExport_DownloadExport(t *testing.T) {
+	env := newTestEnv(t)
+	s := env.dataExportService()
+
+	export := generateExport(t, s, env.register(t, "user@example.com"))
+
+	token := env.linkToken(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "tampered", token: token + "x"},
+		{name: "signed by another key", token: func() string {
+			other := env.dataExportService()
+			other.opt.Key = []byte("other")
+
+			link, _ := other.DownloadLink(*export)
+			return link[strings.Index(link, "token=")+len("token="):]
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.DownloadExport(context.Background(), tt.token)
+			assertAppError(t, err, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestDataExport_ProcessExports(t *testing.T) {
+	env := newTestEnv(t)
+	s := env.dataExportService()
+
+	accountId := env.register(t, "user@example.com")
+
+	_, err := s.RequestExport(context.Background(), accountId, appconstant.DataExportRequestedByAccount)
+	if err != nil {
+		t.Fatalf("RequestExport() error = %v", err)
+	}
+
+	result, err := s.ProcessExports(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessExports() error = %v", err)
+	}
+
+	if result.Completed != 1 || result.Expired != 0 {
+		t.Fatalf("result = %+v, want the queued export completed", result)
+	}
+
+	token := env.linkToken(t)
+
+	s.opt.Ttl = -time.Minute
+	generateExport(t, s, accountId)
+
+	result, err = s.ProcessExports(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessExports() error = %v", err)
+	}
+
+	if result.Expired != 1 {
+		t.Fatalf("result = %+v, want the archive past its ttl expired", result)
+	}
+
+	exports, _ := s.GetExports(context.Background(), accountId)
+	if exports.Exports[0].Status != appconstant.DataExportStatusExpired || exports.Exports[1].Status != appconstant.DataExportStatusCompleted {
+		t.Errorf("exports = %+v, want the latest expired and the first kept", exports.Exports)
+	}
+
+	_, err = s.DownloadExport(context.Background(), token)
+	if err != nil {
+		t.Errorf("DownloadExport() of the archive within its ttl error = %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type EmailVerificationOpt struct {
+	// HMAC key signing verification tokens, unrelated to the JWT key so a verification token is never a valid access token
+	Key      []byte
+	TokenTtl time.Duration
+	// Minimum time between two verification emails of an account
+	ResendCooldown time.Duration
+	// Page receiving the token as the token query parameter
+	LinkUrl string
+}
+
+func (o EmailVerificationOpt) withDefaults() EmailVerificationOpt {
+	if o.TokenTtl <= 0 {
+		o.TokenTtl = 24 * time.Hour
+	}
+	if o.ResendCooldown < 0 {
+		o.ResendCooldown = 0
+	}
+
+	return o
+}
+
+func invalidVerificationTokenError() error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		Message:         "[account_service][VerifyEmail] invalid verification token",
+		ResponseMessage: "invalid or expired verification token",
+	})
+}
+
+func emailAlreadyVerifiedError(accountId int64) error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		Message:         fmt.Sprintf("[account_service] email already verified | account_id: %v", accountId),
+		ResponseMessage: "email already verified",
+	})
+}
+
+// sendVerificationEmail sends a token bound to sentAt, so sending a new one invalidates the previous ones
+func (s *accountServiceImpl) sendVerificationEmail(ctx context.Context, account entity.Account, sentAt int64) error {
+	token, err := signToken(s.emailVerification.Key, signedTokenClaims{
+		Purpose:   appconstant.TokenPurposeEmailVerification,
+		AccountId: account.Id,
+		IssuedAt:  sentAt,
+		ExpiredAt: time.UnixMilli(sentAt).Add(s.emailVerification.TokenTtl).UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("[account_service][sendVerificationEmail][signToken] Error: %w", err)
+	}
+
+	link, err := url.Parse(s.emailVerification.LinkUrl)
+	if err != nil {
+		return fmt.Errorf("[account_service][sendVerificationEmail][url.Parse] Error: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	// Delivery failures are logged by the notifier, the owner can ask for another email
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Verify your email",
+		Body:      fmt.Sprintf("Welcome to XYZ Kredit Plus. Verify your email by opening %s, the link expires in %s.", link.String(), s.emailVerification.TokenTtl),
+	})
+
+	return nil
+}
+
+// VerifyEmail consumes a verification token and returns tokens carrying the verified email
+func (s *accountServiceImpl) VerifyEmail(ctx context.Context, token string, client entity.ClientInfo) (*entity.TokenData, error) {
+	claims := verifyToken(s.emailVerification.Key, appconstant.TokenPurposeEmailVerification, token, time.Now().UnixMilli())
+	if claims == nil {
+		return nil, invalidVerificationTokenError()
+	}
+
+	account, err := s.accountRepo.GetAccountById(ctx, claims.AccountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][VerifyEmail][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), claims.AccountId),
+		})
+	}
+	if account == nil {
+		return nil, invalidVerificationTokenError()
+	}
+	if account.VerifiedAt != nil {
+		return nil, emailAlreadyVerifiedError(account.Id)
+	}
+
+	// Only the latest token verifies, and only once
+	isVerified, err := s.accountRepo.VerifyEmail(ctx, account.Id, claims.IssuedAt)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][VerifyEmail][accountRepo.VerifyEmail] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+	if !isVerified {
+		return nil, invalidVerificationTokenError()
+	}
+
+	now := time.Now().UnixMilli()
+	account.VerifiedAt = &now
+
+	tokenData, err := s.issueToken(ctx, *account, client, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][VerifyEmail][issueToken] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+
+	return tokenData, nil
+}
+
+// ResendVerificationEmail sends a new verification email at most once per cooldown
+func (s *accountServiceImpl) ResendVerificationEmail(ctx context.Context, accountId int64) error {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResendVerificationEmail][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		return apperror.NotFoundError()
+	}
+	if account.VerifiedAt != nil {
+		return emailAlreadyVerifiedError(account.Id)
+	}
+
+	now := time.Now()
+
+	isRecorded, err := s.accountRepo.SetVerificationSentAt(ctx, account.Id, now.UnixMilli(), now.Add(-s.emailVerification.ResendCooldown).UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResendVerificationEmail][accountRepo.SetVerificationSentAt] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+	if !isRecorded {
+		retryAfter := s.emailVerification.ResendCooldown
+		if account.VerificationSentAt != nil {
+			retryAfter = time.UnixMilli(*account.VerificationSentAt).Add(s.emailVerification.ResendCooldown).Sub(now)
+		}
+
+		return apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusTooManyRequests,
+			Message:         fmt.Sprintf("[account_service][ResendVerificationEmail] resend cooldown | account_id: %v", account.Id),
+			ResponseMessage: fmt.Sprintf("verification email already sent, try again in %v seconds", max(1, int64(math.Ceil(retryAfter.Seconds())))),
+		})
+	}
+
+	err = s.sendVerificationEmail(ctx, *account, now.UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResendVerificationEmail][sendVerificationEmail] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+var verificationLinkPattern = regexp.MustCompile(`http\S+token=[A-Za-z0-9_.%-]+`)
+
+// linkToken takes the token out of the link of the latest email
+func (e *testEnv) linkToken(t *testing.T) string {
+	t.Helper()
+
+	sent := e.notifier.sent()
+	if len(sent) == 0 {
+		t.Fatal("no email sent")
+	}
+
+	link, err := url.Parse(verificationLinkPattern.FindString(sent[len(sent)-1].Body))
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	return link.Query().Get("token")
+}
+
+func (e *testEnv) isVerified(t *testing.T, accountId int64) bool {
+	t.Helper()
+
+	account, err := e.accountRepo.GetAccountById(context.Background(), accountId, false)
+	if err != nil || account == nil {
+		t.Fatalf("GetAccountById() = %v, %v", account, err)
+	}
+
+	return account.VerifiedAt != nil
+}
+
+func TestEmailVerification_RegisterSendsToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	sent := env.notifier.sent()
+	if len(sent) != 1 || sent[0].Recipient != "user@example.com" {
+		t.Fatalf("notifications = %+v, want one verification email to the account", sent)
+	}
+
+	token := env.linkToken(t)
+
+	account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+	if env.isVerified(t, account.Id) {
+		t.Fatal("account verified before the token is used")
+	}
+
+	tokenData, err := env.account.VerifyEmail(context.Background(), token, testClient)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if tokenData.AccessToken.Token == "" {
+		t.Error("VerifyEmail() returned no access token")
+	}
+
+	if !env.isVerified(t, account.Id) {
+		t.Fatal("account not verified")
+	}
+
+	// Single use
+	assertAppError(t, func() error { _, err := env.account.VerifyEmail(context.Background(), token, testClient); return err }(), http.StatusBadRequest)
+}
+
+// committedAccountNotifier records whether the account an email is sent to was committed, read outside the
+// transaction registering it
+type committedAccountNotifier struct {
+	accountRepo repository.AccountRepository
+	isCommitted []bool
+}
+
+func (n *committedAccountNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	account, err := n.accountRepo.GetAccountByEmail(ctx, notification.Recipient, false)
+
+	n.isCommitted = append(n.isCommitted, err == nil && account != nil)
+
+	return nil
+}
+
+func TestEmailVerification_SentAfterCommit(t *testing.T) {
+	env := newSqliteTestEnv(t)
+
+	notifier := &committedAccountNotifier{accountRepo: env.accountRepo}
+	env.account.notifier = notifier
+
+	_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	if len(notifier.isCommitted) != 1 || !notifier.isCommitted[0] {
+		t.Errorf("emails sent to a committed account = %v, want the verification email sent after the commit", notifier.isCommitted)
+	}
+}
+
+func TestEmailVerification_InvalidTokens(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.register(t, "user@example.com")
+
+	account, _ := env.accountRepo.GetAccountById(context.Background(), accountId, false)
+	sentAt := *account.VerificationSentAt
+
+	sign := func(key string, claims signedTokenClaims) string {
+		token, err := signToken([]byte(key), claims)
+		if err != nil {
+			t.Fatalf("signToken() error = %v", err)
+		}
+
+		return token
+	}
+
+	valid := signedTokenClaims{
+		Purpose:   appconstant.TokenPurposeEmailVerification,
+		AccountId: accountId,
+		IssuedAt:  sentAt,
+		ExpiredAt: time.Now().Add(time.Hour).UnixMilli(),
+	}
+
+	expired := valid
+	expired.ExpiredAt = time.Now().Add(-time.Second).UnixMilli()
+
+	otherPurpose := valid
+	otherPurpose.Purpose = "password_reset"
+
+	superseded := valid
+	superseded.IssuedAt = sentAt - 1
+
+	tests := map[string]string{
+		"malformed":     "not-a-token",
+		"wrong key":     sign("other", valid),
+		"tampered":      sign("test", valid) + "x",
+		"expired":       sign("test", expired),
+		"other purpose": sign("test", otherPurpose),
+		"superseded":    sign("test", superseded),
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := env.account.VerifyEmail(context.Background(), token, testClient)
+
+			appErr := assertAppError(t, err, http.StatusBadRequest)
+			if appErr.ResponseMessage != "invalid or expired verification token" {
+				t.Errorf("response = %q", appErr.ResponseMessage)
+			}
+		})
+	}
+
+	if env.isVerified(t, accountId) {
+		t.Fatal("account verified by an invalid token")
+	}
+
+	_, err := env.account.VerifyEmail(context.Background(), sign("test", valid), testClient)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+}
+
+func TestEmailVerification_ResendCooldown(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+	firstToken := env.linkToken(t)
+
+	assertAppError(t, env.account.ResendVerificationEmail(context.Background(), account.Id), http.StatusTooManyRequests)
+
+	if sent := env.notifier.sent(); len(sent) != 1 {
+		t.Fatalf("notifications = %d, want no email during the cooldown", len(sent))
+	}
+
+	env.account.emailVerification.ResendCooldown = 0
+	time.Sleep(2 * time.Millisecond)
+
+	err = env.account.ResendVerificationEmail(context.Background(), account.Id)
+	if err != nil {
+		t.Fatalf("ResendVerificationEmail() error = %v", err)
+	}
+
+	// The new email invalidates the previous one
+	_, err = env.account.VerifyEmail(context.Background(), firstToken, testClient)
+	assertAppError(t, err, http.StatusBadRequest)
+
+	_, err = env.account.VerifyEmail(context.Background(), env.linkToken(t), testClient)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+
+	assertAppError(t, env.account.ResendVerificationEmail(context.Background(), account.Id), http.StatusBadRequest)
+}
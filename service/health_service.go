@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// HealthCheck is a single dependency checked for readiness
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type healthServiceImpl struct {
+	checks   []HealthCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthService(timeout time.Duration, checks ...HealthCheck) *healthServiceImpl {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &healthServiceImpl{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Liveness only reports that the process is able to serve requests, dependencies are not checked
+func (s *healthServiceImpl) Liveness(ctx context.Context) *entity.HealthReport {
+	return &entity.HealthReport{
+		Status: appconstant.HealthStatusOk,
+	}
+}
+
+// Readiness runs every check concurrently, the service is ready only when all of them pass and it is not shutting down
+func (s *healthServiceImpl) Readiness(ctx context.Context) *entity.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	report := entity.HealthReport{
+		Status:       appconstant.HealthStatusOk,
+		Dependencies: make([]entity.DependencyHealth, len(s.checks)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, check := range s.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Check(ctx)
+
+			dependency := entity.DependencyHealth{
+				Name:      check.Name,
+				Status:    appconstant.HealthStatusOk,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			if err != nil {
+				dependency.Status = appconstant.HealthStatusUnavailable
+				dependency.Error = err.Error()
+			}
+
+			report.Dependencies[i] = dependency
+		}()
+	}
+
+	wg.Wait()
+
+	for _, dependency := range report.Dependencies {
+		if dependency.Status != appconstant.HealthStatusOk {
+			report.Status = appconstant.HealthStatusUnavailable
+		}
+	}
+
+	if s.draining.Load() {
+		report.Status = appconstant.HealthStatusDraining
+	}
+
+	return &report
+}
+
+// SetDraining makes readiness fail for the rest of the process lifetime, so the instance is taken out of rotation while shutting down
+func (s *healthServiceImpl) SetDraining() {
+	s.draining.Store(true)
+}
+
+func DatabaseHealthCheck(db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// MediaHealthCheck stages then discards a probe file, a staged file is never served and is swept if discarding fails
+func MediaHealthCheck(mediaRepo repository.MediaRepository) HealthCheck {
+	return HealthCheck{
+		Name: "media_storage",
+		Check: func(ctx context.Context) error {
+			key := fmt.Sprintf("healthcheck_%v", time.Now().UnixNano())
+
+			err := mediaRepo.Stage(ctx, repository.MediaOpt{
+				Key:       key,
+				Extension: ".txt",
+				Bytes:     []byte("ok"),
+			})
+			if err != nil {
+				return fmt.Errorf("storage is not writable: %w", err)
+			}
+
+			err = mediaRepo.Discard(ctx, key)
+			if err != nil {
+				return fmt.Errorf("probe file cannot be removed: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// MigrationHealthCheck fails when a migration bundled into the binary is not applied yet
+func MigrationHealthCheck(migrator migration.Migrator) HealthCheck {
+	return HealthCheck{
+		Name: "migration",
+		Check: func(ctx context.Context) error {
+			statuses, err := migrator.Status(ctx)
+			if err != nil {
+				return err
+			}
+
+			var pending int
+
+			for _, status := range statuses {
+				if status.AppliedAt == nil {
+					pending++
+				}
+			}
+
+			if pending > 0 {
+				return fmt.Errorf("%v migration(s) pending, latest version is %v", pending, statuses[len(statuses)-1].Version)
+			}
+
+			return nil
+		},
+	}
+}
+
+func ConfigHealthCheck(validate func() error) HealthCheck {
+	return HealthCheck{
+		Name: "config",
+		Check: func(ctx context.Context) error {
+			return validate()
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+)
+
+type fakeMigrator struct {
+	migration.Migrator
+	statuses []migration.MigrationStatus
+}
+
+func (m fakeMigrator) Status(ctx context.Context) ([]migration.MigrationStatus, error) {
+	return m.statuses, nil
+}
+
+func TestHealthService_Readiness(t *testing.T) {
+	env := newTestEnv(t)
+	appliedAt := int64(1)
+
+	health := NewHealthService(0,
+		MediaHealthCheck(env.mediaRepo),
+		MigrationHealthCheck(fakeMigrator{statuses: []migration.MigrationStatus{{Version: 1, AppliedAt: &appliedAt}}}),
+		ConfigHealthCheck(func() error { return nil }),
+	)
+
+	report := health.Readiness(context.Background())
+	if report.Status != appconstant.HealthStatusOk {
+		t.Fatalf("status = %s, want ok: %+v", report.Status, report.Dependencies)
+	}
+
+	if len(report.Dependencies) != 3 {
+		t.Fatalf("dependencies = %v, want 3", len(report.Dependencies))
+	}
+
+	staged, err := env.mediaRepo.List(context.Background(), true)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if len(staged) != 0 {
+		t.Errorf("media probe left %v staged file(s)", len(staged))
+	}
+
+	health.SetDraining()
+
+	report = health.Readiness(context.Background())
+	if report.Status != appconstant.HealthStatusDraining {
+		t.Errorf("status = %s, want draining", report.Status)
+	}
+
+	if health.Liveness(context.Background()).Status != appconstant.HealthStatusOk {
+		t.Errorf("liveness must not fail while draining")
+	}
+}
+
+func TestHealthService_ReadinessFailing(t *testing.T) {
+	health := NewHealthService(0,
+		MigrationHealthCheck(fakeMigrator{statuses: []migration.MigrationStatus{{Version: 1}}}),
+		ConfigHealthCheck(func() error { return errors.New("jwt.key: is required") }),
+	)
+
+	report := health.Readiness(context.Background())
+	if report.Status != appconstant.HealthStatusUnavailable {
+		t.Fatalf("status = %s, want unavailable", report.Status)
+	}
+
+	for _, dependency := range report.Dependencies {
+		if dependency.Status != appconstant.HealthStatusUnavailable || dependency.Error == "" {
+			t.Errorf("dependency %s = %+v, want unavailable with error", dependency.Name, dependency)
+		}
+	}
+}
@@ -7,14 +7,81 @@ import (
 )
 
 type AccountService interface {
-	RegisterAccount(ctx context.Context, newAccount entity.Account) (*entity.TokenData, error)
-	Login(ctx context.Context, account entity.Account) (*entity.TokenData, error)
+	RegisterAccount(ctx context.Context, newAccount entity.Account, client entity.ClientInfo) (*entity.TokenData, error)
+	Login(ctx context.Context, account entity.Account, client entity.ClientInfo) (*entity.LoginResult, error)
+	DisableAccount(ctx context.Context, email string) error
+	UnlockLogin(ctx context.Context, email, clientIp string) error
+	VerifyEmail(ctx context.Context, token string, client entity.ClientInfo) (*entity.TokenData, error)
+	ResendVerificationEmail(ctx context.Context, accountId int64) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ChangePassword(ctx context.Context, accountId int64, req entity.ChangePasswordReq, client entity.ClientInfo) (*entity.TokenData, error)
+	EnrollMfa(ctx context.Context, accountId int64) (*entity.MfaEnrollment, error)
+	ConfirmMfa(ctx context.Context, accountId int64, code string) (*entity.MfaRecoveryCodes, error)
+	CompleteMfaLogin(ctx context.Context, challengeToken, code string, client entity.ClientInfo) (*entity.TokenData, error)
+	GetSessions(ctx context.Context, accountId int64) (*entity.Sessions, error)
+	IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error)
+	RevokeSession(ctx context.Context, accountId, sessionId int64) error
+	CloseAccount(ctx context.Context, accountId int64, req entity.CloseAccountReq) (*entity.AccountClosure, error)
+	AnonymiseClosedAccounts(ctx context.Context) (*entity.AnonymiseResult, error)
 }
 
 type ConsumerService interface {
 	ProcessKyc(ctx context.Context, consumerData entity.Consumer) error
+	GetKycPhoto(ctx context.Context, accountId int64, photoTag, size string) (*entity.MediaContent, error)
+	UpdateProfile(ctx context.Context, accountId int64, req entity.UpdateConsumerProfileReq) error
+	ResubmitKyc(ctx context.Context, consumerData entity.Consumer) error
+	ReviewKyc(ctx context.Context, accountId int64, isApproved bool) error
+}
+
+type AccountLimitService interface {
+	SetLimit(ctx context.Context, req entity.SetAccountLimitReq) (*entity.AccountLimit, error)
 }
 
 type TransactionService interface {
 	CreateTransaction(ctx context.Context, transaction entity.Transaction) (*entity.Transaction, error)
+	CancelTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error)
+	PayOffTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error)
+}
+
+type DataExportService interface {
+	RequestExport(ctx context.Context, accountId int64, requestedBy string) (*entity.DataExport, error)
+	GetExports(ctx context.Context, accountId int64) (*entity.DataExports, error)
+	ProcessExports(ctx context.Context) (*entity.DataExportResult, error)
+	GenerateExport(ctx context.Context, exportId int64) (*entity.DataExport, error)
+	DownloadExport(ctx context.Context, token string) (*entity.MediaContent, error)
+}
+
+type RetentionService interface {
+	Run(ctx context.Context) (*entity.RetentionReport, error)
+}
+
+type AuditService interface {
+	GetEvents(ctx context.Context, filter entity.AuditEventFilter) (*entity.AuditEvents, error)
+	VerifyChain(ctx context.Context) (*entity.AuditVerification, error)
+}
+
+type OutboxService interface {
+	Relay(ctx context.Context) (*entity.OutboxRelayResult, error)
+}
+
+type WebhookService interface {
+	CreatePartner(ctx context.Context, req entity.CreatePartnerReq) (*entity.CreatedPartner, error)
+	GetPartners(ctx context.Context) (*entity.Partners, error)
+	CreateWebhook(ctx context.Context, partnerId int64, req entity.CreatePartnerWebhookReq) (*entity.CreatedPartnerWebhook, error)
+	GetWebhooks(ctx context.Context, partnerId int64) (*entity.PartnerWebhooks, error)
+	DeleteWebhook(ctx context.Context, partnerId, webhookId int64) error
+	GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) (*entity.WebhookDeliveries, error)
+	Redeliver(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error)
+	Dispatch(ctx context.Context) (*entity.WebhookDispatchResult, error)
+}
+
+type MediaService interface {
+	SweepOrphans(ctx context.Context) (*entity.MediaSweepResult, error)
+}
+
+type HealthService interface {
+	Liveness(ctx context.Context) *entity.HealthReport
+	Readiness(ctx context.Context) *entity.HealthReport
+	SetDraining()
 }
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+type LoginProtectionOpt struct {
+	// Failed logins of an email before it is locked out
+	AccountMaxAttempts int
+	// Failed logins from an IP address, of any email, before it is locked out
+	IpMaxAttempts int
+	// Failed logins of an email before every further attempt has to wait, the wait doubles on every failure
+	DelayAfterAttempts int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	Lockout            time.Duration
+	// Failures older than the window are forgotten
+	Window time.Duration
+}
+
+func (o LoginProtectionOpt) withDefaults() LoginProtectionOpt {
+	if o.AccountMaxAttempts <= 0 {
+		o.AccountMaxAttempts = 10
+	}
+	if o.IpMaxAttempts <= 0 {
+		o.IpMaxAttempts = 50
+	}
+	if o.DelayAfterAttempts <= 0 {
+		o.DelayAfterAttempts = 3
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = time.Second
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = time.Minute
+	}
+	if o.Lockout <= 0 {
+		o.Lockout = 15 * time.Minute
+	}
+	if o.Window <= 0 {
+		o.Window = 15 * time.Minute
+	}
+
+	return o
+}
+
+// Attempts are tracked by hash so emails and IP addresses are not stored in clear, unknown emails are tracked the same
+// way as registered ones so lockouts do not reveal whether an email is registered
+func (s *accountServiceImpl) emailAttemptKey(email string) string {
+	return "email:" + s.hash.HashSHA512(strings.ToLower(strings.TrimSpace(email)))
+}
+
+func (s *accountServiceImpl) ipAttemptKey(clientIp string) string {
+	if clientIp == "" {
+		return ""
+	}
+
+	return "ip:" + s.hash.HashSHA512(clientIp)
+}
+
+func invalidCredentialsError() error {
+	return apperror.NewAppError(apperror.AppErrorOpt{
+		Code:            http.StatusUnauthorized,
+		Message:         "[account_service][Login] invalid credentials",
+		ResponseMessage: "invalid credentials",
+	})
+}
+
+func tooManyLoginAttemptsError(retryAfter time.Duration) error {
+	return apperror.NewAppError(apperror.AppErrorOpt{
+		Code:            http.StatusTooManyRequests,
+		Message:         "[account_service][Login] too many login attempts",
+		ResponseMessage: fmt.Sprintf("too many login attempts, try again in %v seconds", int64(math.Ceil(retryAfter.Seconds()))),
+	})
+}
+
+// loginWait returns how long the key has to wait before the next attempt, zero when it may attempt now
+func (s *accountServiceImpl) loginWait(attempt *entity.LoginAttempt, isDelayed bool, now time.Time) time.Duration {
+	if attempt == nil || attempt.LastFailedAt < now.Add(-s.loginProtection.Window).UnixMilli() {
+		return 0
+	}
+
+	if attempt.LockedUntil != nil && *attempt.LockedUntil > now.UnixMilli() {
+		return time.UnixMilli(*attempt.LockedUntil).Sub(now)
+	}
+
+	if !isDelayed || attempt.FailedCount < s.loginProtection.DelayAfterAttempts {
+		return 0
+	}
+
+	delay := s.loginProtection.BaseDelay << min(attempt.FailedCount-s.loginProtection.DelayAfterAttempts, 30)
+	if delay <= 0 || delay > s.loginProtection.MaxDelay {
+		delay = s.loginProtection.MaxDelay
+	}
+
+	return max(time.UnixMilli(attempt.LastFailedAt).Add(delay).Sub(now), 0)
+}
+
+func (s *accountServiceImpl) checkLoginAllowed(ctx context.Context, emailKey, ipKey string) error {
+	now := time.Now()
+
+	attempt, err := s.loginAttemptRepo.GetLoginAttempt(ctx, emailKey)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][checkLoginAllowed][loginAttemptRepo.GetLoginAttempt][email] Error: %s", err.Error()),
+		})
+	}
+
+	wait := s.loginWait(attempt, true, now)
+
+	if ipKey != "" {
+		attempt, err = s.loginAttemptRepo.GetLoginAttempt(ctx, ipKey)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][checkLoginAllowed][loginAttemptRepo.GetLoginAttempt][ip] Error: %s", err.Error()),
+			})
+		}
+
+		wait = max(wait, s.loginWait(attempt, false, now))
+	}
+
+	if wait > 0 {
+		return tooManyLoginAttemptsError(wait)
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts the failure for the email and the IP, and locks them out once they reach their limit.
+// The owner of a registered account is notified when the account gets locked out.
+func (s *accountServiceImpl) recordLoginFailure(ctx context.Context, emailKey, ipKey string, existing *entity.Account) error {
+	now := time.Now()
+	windowStart := now.Add(-s.loginProtection.Window).UnixMilli()
+	lockedUntil := now.Add(s.loginProtection.Lockout)
+
+	var accountId int64
+	if existing != nil {
+		accountId = existing.Id
+	}
+
+	// Whoever failed to login is not known to be the owner of the account
+	err := recordAuditEvent(audit.WithActor(ctx, audit.Actor{Type: appconstant.AuditActorAnonymous}), s.transaction, accountId,
+		appconstant.AuditActionLoginFailed, appconstant.AuditEntityAccount, accountId, nil)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][recordLoginFailure][recordAuditEvent] Error: %s", err.Error()),
+		})
+	}
+
+	attempt, err := s.loginAttemptRepo.IncrementFailedAttempt(ctx, emailKey, windowStart)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][recordLoginFailure][loginAttemptRepo.IncrementFailedAttempt][email] Error: %s", err.Error()),
+		})
+	}
+
+	if attempt.FailedCount == s.loginProtection.AccountMaxAttempts ||
+		(attempt.FailedCount > s.loginProtection.AccountMaxAttempts && attempt.LockedUntil == nil) {
+		err = s.loginAttemptRepo.LockLoginAttempt(ctx, emailKey, lockedUntil.UnixMilli())
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][recordLoginFailure][loginAttemptRepo.LockLoginAttempt][email] Error: %s", err.Error()),
+			})
+		}
+
+		if existing != nil {
+			s.notifyLockout(ctx, *existing, lockedUntil)
+		}
+	}
+
+	if ipKey == "" {
+		return nil
+	}
+
+	attempt, err = s.loginAttemptRepo.IncrementFailedAttempt(ctx, ipKey, windowStart)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][recordLoginFailure][loginAttemptRepo.IncrementFailedAttempt][ip] Error: %s", err.Error()),
+		})
+	}
+
+	if attempt.FailedCount >= s.loginProtection.IpMaxAttempts && attempt.LockedUntil == nil {
+		err = s.loginAttemptRepo.LockLoginAttempt(ctx, ipKey, lockedUntil.UnixMilli())
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][recordLoginFailure][loginAttemptRepo.LockLoginAttempt][ip] Error: %s", err.Error()),
+			})
+		}
+	}
+
+	return nil
+}
+
+// A failed notification does not fail the login, the lockout is in place regardless and the notifier logs the failure
+func (s *accountServiceImpl) notifyLockout(ctx context.Context, account entity.Account, lockedUntil time.Time) {
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Your account has been temporarily locked",
+		Body: fmt.Sprintf("We detected %v failed login attempts on your account. Login is locked until %s. If this was not you, we recommend changing your password.",
+			s.loginProtection.AccountMaxAttempts, lockedUntil.UTC().Format(time.RFC1123)),
+	})
+}
+
+// UnlockLogin clears the failed logins of an email, and of an IP address when given
+func (s *accountServiceImpl) UnlockLogin(ctx context.Context, email, clientIp string) error {
+	err := s.loginAttemptRepo.DeleteLoginAttempt(ctx, s.emailAttemptKey(email))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][UnlockLogin][loginAttemptRepo.DeleteLoginAttempt][email] Error: %s", err.Error()),
+		})
+	}
+
+	if ipKey := s.ipAttemptKey(clientIp); ipKey != "" {
+		err = s.loginAttemptRepo.DeleteLoginAttempt(ctx, ipKey)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][UnlockLogin][loginAttemptRepo.DeleteLoginAttempt][ip] Error: %s", err.Error()),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func (e *testEnv) login(email, password, ip string) error {
+	_, err := e.account.Login(context.Background(), entity.Account{Email: email, Password: password}, entity.ClientInfo{IpAddress: ip})
+
+	return err
+}
+
+func TestLoginProtection_UniformError(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	wrongPassword := assertAppError(t, env.login("user@example.com", "@abcD12345", "10.0.0.1"), http.StatusUnauthorized)
+	unknownEmail := assertAppError(t, env.login("unknown@example.com", testPassword, "10.0.0.1"), http.StatusUnauthorized)
+
+	if wrongPassword.ResponseMessage != unknownEmail.ResponseMessage {
+		t.Errorf("response differs: wrong password %q, unknown email %q", wrongPassword.ResponseMessage, unknownEmail.ResponseMessage)
+	}
+}
+
+func TestLoginProtection_AccountLockout(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	// Spread over IPs so only the account limit is reached
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		assertAppError(t, env.login("user@example.com", "@abcD12345", fmt.Sprintf("10.0.0.%d", i+1)), http.StatusUnauthorized)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	// Locked even with the right password
+	assertAppError(t, env.login("user@example.com", testPassword, "10.0.1.1"), http.StatusTooManyRequests)
+
+	sent := env.notifier.sent()
+	if len(sent) != 1 || sent[0].Recipient != "user@example.com" {
+		t.Fatalf("notifications = %+v, want one lockout notification to the account", sent)
+	}
+
+	err := env.account.UnlockLogin(context.Background(), "USER@example.com", "")
+	if err != nil {
+		t.Fatalf("UnlockLogin() error = %v", err)
+	}
+
+	err = env.login("user@example.com", testPassword, "10.0.1.1")
+	if err != nil {
+		t.Fatalf("Login() after unlock error = %v", err)
+	}
+}
+
+func TestLoginProtection_UnknownEmailLockout(t *testing.T) {
+	env := newTestEnv(t)
+
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		assertAppError(t, env.login("unknown@example.com", testPassword, fmt.Sprintf("10.0.0.%d", i+1)), http.StatusUnauthorized)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	// Locked out like a registered email, but nobody to notify
+	assertAppError(t, env.login("unknown@example.com", testPassword, "10.0.1.1"), http.StatusTooManyRequests)
+
+	if sent := env.notifier.sent(); len(sent) != 0 {
+		t.Errorf("notifications = %+v, want none", sent)
+	}
+}
+
+func TestLoginProtection_IpLockout(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	// Different emails from the same IP, none reaches the account limit
+	for i := 0; i < 8; i++ {
+		assertAppError(t, env.login(fmt.Sprintf("unknown%d@example.com", i), testPassword, "10.0.0.1"), http.StatusUnauthorized)
+	}
+
+	assertAppError(t, env.login("user@example.com", testPassword, "10.0.0.1"), http.StatusTooManyRequests)
+
+	err := env.login("user@example.com", testPassword, "10.0.0.2")
+	if err != nil {
+		t.Fatalf("Login() from another IP error = %v", err)
+	}
+}
+
+func TestLoginProtection_ProgressiveDelay(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.account.loginProtection.DelayAfterAttempts = 2
+	env.account.loginProtection.BaseDelay = time.Hour
+	env.account.loginProtection.MaxDelay = time.Hour
+
+	env.register(t, "user@example.com")
+
+	assertAppError(t, env.login("user@example.com", "@abcD12345", "10.0.0.1"), http.StatusUnauthorized)
+	assertAppError(t, env.login("user@example.com", "@abcD12345", "10.0.0.1"), http.StatusUnauthorized)
+
+	appErr := assertAppError(t, env.login("user@example.com", testPassword, "10.0.0.1"), http.StatusTooManyRequests)
+	if appErr.ResponseMessage != "too many login attempts, try again in 3600 seconds" {
+		t.Errorf("response message = %q", appErr.ResponseMessage)
+	}
+}
+
+func TestLoginProtection_SuccessResetsAccount(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(2 * time.Millisecond)
+		assertAppError(t, env.login("user@example.com", "@abcD12345", fmt.Sprintf("10.0.0.%d", i+1)), http.StatusUnauthorized)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	err := env.login("user@example.com", testPassword, "10.0.1.1")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	// The count restarted, one more failure does not lock the account
+	assertAppError(t, env.login("user@example.com", "@abcD12345", "10.0.1.1"), http.StatusUnauthorized)
+
+	time.Sleep(2 * time.Millisecond)
+
+	err = env.login("user@example.com", testPassword, "10.0.1.1")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+type mediaServiceImpl struct {
+	consumerRepo repository.ConsumerRepository
+	mediaRepo    repository.MediaRepository
+	gracePeriod  time.Duration
+}
+
+func NewMediaService(consumerRepo repository.ConsumerRepository, mediaRepo repository.MediaRepository, gracePeriod time.Duration) *mediaServiceImpl {
+	if gracePeriod <= 0 {
+		gracePeriod = time.Hour
+	}
+
+	return &mediaServiceImpl{
+		consumerRepo: consumerRepo,
+		mediaRepo:    mediaRepo,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+// Group media by original key, variants are handled together with their original.
+// Returns the latest update time of every original key.
+func (s *mediaServiceImpl) groupByOriginalKey(stats []repository.MediaStat) map[string]int64 {
+	grouped := map[string]int64{}
+
+	for _, stat := range stats {
+		key := repository.OriginalKey(stat.Key)
+
+		if stat.UpdatedAt > grouped[key] {
+			grouped[key] = stat.UpdatedAt
+		}
+	}
+
+	return grouped
+}
+
+// Media younger than the grace period is left alone, it may belong to a KYC still in progress.
+func (s *mediaServiceImpl) SweepOrphans(ctx context.Context) (*entity.MediaSweepResult, error) {
+	var result entity.MediaSweepResult
+
+	cutoff := time.Now().Add(-s.gracePeriod).UnixMilli()
+
+	staged, err := s.mediaRepo.List(ctx, true)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[media_service][SweepOrphans][mediaRepo.List][staged] Error: %s", err.Error()),
+		})
+	}
+
+	for key, updatedAt := range s.groupByOriginalKey(staged) {
+		if updatedAt > cutoff {
+			continue
+		}
+
+		isReferenced, err := s.consumerRepo.IsMediaKeyReferenced(ctx, key)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[media_service][SweepOrphans][consumerRepo.IsMediaKeyReferenced][staged] Error: %s | key: %s", err.Error(), key),
+			})
+		}
+
+		// Consumer is committed but promoting its photos failed, finish the promotion
+		if isReferenced {
+			err = s.mediaRepo.Promote(ctx, key)
+			if err != nil {
+				return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[media_service][SweepOrphans][mediaRepo.Promote] Error: %s | key: %s", err.Error(), key),
+				})
+			}
+
+			result.Promoted++
+			continue
+		}
+
+		err = s.mediaRepo.Discard(ctx, key)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[media_service][SweepOrphans][mediaRepo.Discard] Error: %s | key: %s", err.Error(), key),
+			})
+		}
+
+		result.Discarded++
+	}
+
+	stored, err := s.mediaRepo.List(ctx, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[media_service][SweepOrphans][mediaRepo.List][stored] Error: %s", err.Error()),
+		})
+	}
+
+	for key, updatedAt := range s.groupByOriginalKey(stored) {
+		if updatedAt > cutoff {
+			continue
+		}
+
+		isReferenced, err := s.consumerRepo.IsMediaKeyReferenced(ctx, key)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[media_service][SweepOrphans][consumerRepo.IsMediaKeyReferenced][stored] Error: %s | key: %s", err.Error(), key),
+			})
+		}
+		if isReferenced {
+			continue
+		}
+
+		err = s.mediaRepo.Delete(ctx, key)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[media_service][SweepOrphans][mediaRepo.Delete] Error: %s | key: %s", err.Error(), key),
+			})
+		}
+
+		result.Deleted++
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = otp.DigitsSix
+	// Codes of the previous and next time step are accepted too, to allow for clock drift
+	totpSkew = 1
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+type MfaOpt struct {
+	// Encrypts the TOTP secrets and signs login challenges and recovery code hashes
+	Key []byte
+	// Shown by authenticator apps next to the account email
+	Issuer string
+	// Time given to enter the second factor after the password was checked
+	ChallengeTtl time.Duration
+}
+
+func (o MfaOpt) withDefaults() MfaOpt {
+	if o.Issuer == "" {
+		o.Issuer = "XYZ Kredit Plus"
+	}
+	if o.ChallengeTtl <= 0 {
+		o.ChallengeTtl = 5 * time.Minute
+	}
+
+	return o
+}
+
+// subKey derives a key per use from the configured key, so a challenge signature never doubles as an encryption key
+func (o MfaOpt) subKey(label string) []byte {
+	mac := hmac.New(sha256.New, o.Key)
+	mac.Write([]byte(label))
+
+	return mac.Sum(nil)
+}
+
+func invalidMfaChallengeError() error {
+	return apperror.NewAppError(apperror.AppErrorOpt{
+		Code:            http.StatusUnauthorized,
+		Message:         "[account_service][CompleteMfaLogin] invalid mfa challenge",
+		ResponseMessage: "invalid or expired mfa challenge",
+	})
+}
+
+func invalidMfaCodeError(accountId int64) error {
+	return apperror.NewAppError(apperror.AppErrorOpt{
+		Code:            http.StatusUnauthorized,
+		Message:         fmt.Sprintf("[account_service][CompleteMfaLogin] invalid two-factor code | account_id: %v", accountId),
+		ResponseMessage: "invalid two-factor code",
+	})
+}
+
+func (s *accountServiceImpl) encryptMfaSecret(secret string) (string, error) {
+	block, err := aes.NewCipher(s.mfa.subKey("totp_secret"))
+	if err != nil {
+		return "", fmt.Errorf("[account_service][encryptMfaSecret][aes.NewCipher] Error: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("[account_service][encryptMfaSecret][cipher.NewGCM] Error: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("[account_service][encryptMfaSecret][rand.Read] Error: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (s *accountServiceImpl) decryptMfaSecret(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("[account_service][decryptMfaSecret][base64.DecodeString] Error: %w", err)
+	}
+
+	block, err := aes.NewCipher(s.mfa.subKey("totp_secret"))
+	if err != nil {
+		return "", fmt.Errorf("[account_service][decryptMfaSecret][aes.NewCipher] Error: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("[account_service][decryptMfaSecret][cipher.NewGCM] Error: %w", err)
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("[account_service][decryptMfaSecret] ciphertext too short")
+	}
+
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("[account_service][decryptMfaSecret][gcm.Open] Error: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+// hashRecoveryCode is deterministic so a code can be looked up, and keyed so leaked hashes cannot be brute forced offline
+func (s *accountServiceImpl) hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	mac := hmac.New(sha256.New, s.mfa.subKey("recovery_code"))
+	mac.Write([]byte(normalized))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b := make([]byte, 8)
+
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, fmt.Errorf("[account_service][generateRecoveryCodes][rand.Read] Error: %w", err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:recoveryCodeLength]
+
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+	}
+
+	return codes, nil
+}
+
+// checkTotp accepts a code of the current time step, or a neighbouring one, that is later than the last accepted code
+func (s *accountServiceImpl) checkTotp(ctx context.Context, mfa entity.AccountMfa, code string) (bool, error) {
+	secret, err := s.decryptMfaSecret(mfa.Secret)
+	if err != nil {
+		return false, fmt.Errorf("[account_service][checkTotp][decryptMfaSecret] Error: %w", err)
+	}
+
+	now := time.Now()
+	currentStep := now.Unix() / totpPeriod
+
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		expected, err := totp.GenerateCodeCustom(secret, now.Add(time.Duration(offset*totpPeriod)*time.Second), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    totpDigits,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return false, fmt.Errorf("[account_service][checkTotp][totp.GenerateCodeCustom] Error: %w", err)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		isUnused, err := s.accountMfaRepo.UseTotpStep(ctx, mfa.AccountId, currentStep+offset)
+		if err != nil {
+			return false, fmt.Errorf("[account_service][checkTotp][accountMfaRepo.UseTotpStep] Error: %w", err)
+		}
+
+		return isUnused, nil
+	}
+
+	return false, nil
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code, reports whether a recovery code was used
+func (s *accountServiceImpl) checkSecondFactor(ctx context.Context, mfa entity.AccountMfa, code string) (isValid, isRecoveryCode bool, err error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totpDigits.Length() {
+		isValid, err = s.checkTotp(ctx, mfa, code)
+		if err != nil {
+			return false, false, fmt.Errorf("[account_service][checkSecondFactor][checkTotp] Error: %w", err)
+		}
+
+		return isValid, false, nil
+	}
+
+	isValid, err = s.accountMfaRepo.UseRecoveryCode(ctx, mfa.AccountId, s.hashRecoveryCode(code))
+	if err != nil {
+		return false, false, fmt.Errorf("[account_service][checkSecondFactor][accountMfaRepo.UseRecoveryCode] Error: %w", err)
+	}
+
+	return isValid, isValid, nil
+}
+
+// mfaChallenge hands out a token proving the password of the account was checked, to be completed with a second factor
+func (s *accountServiceImpl) mfaChallenge(accountId int64) (*entity.MfaChallenge, error) {
+	now := time.Now()
+	expiredAt := now.Add(s.mfa.ChallengeTtl).UnixMilli()
+
+	token, err := signToken(s.mfa.subKey("challenge"), signedTokenClaims{
+		Purpose:   appconstant.TokenPurposeMfaChallenge,
+		AccountId: accountId,
+		IssuedAt:  now.UnixMilli(),
+		ExpiredAt: expiredAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[account_service][mfaChallenge][signToken] Error: %w", err)
+	}
+
+	return &entity.MfaChallenge{
+		Token:     token,
+		ExpiredAt: expiredAt,
+	}, nil
+}
+
+// EnrollMfa starts enrolling an authenticator app, replacing an unconfirmed enrolment. Two-factor authentication is only
+// enabled once a code of the app is confirmed.
+func (s *accountServiceImpl) EnrollMfa(ctx context.Context, accountId int64) (*entity.MfaEnrollment, error) {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][EnrollMfa][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	// Losing the authenticator is only recoverable through an email the account owns
+	if account.VerifiedAt == nil {
+		return nil, apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[account_service][EnrollMfa] email not verified | account_id: %v", accountId),
+			ResponseMessage: "email not verified",
+		})
+	}
+
+	existing, err := s.accountMfaRepo.GetMfa(ctx, accountId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][EnrollMfa][accountMfaRepo.GetMfa] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if existing != nil && existing.ConfirmedAt != nil {
+		return nil, mfaAlreadyEnabledError(accountId)
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.mfa.Issuer,
+		AccountName: account.Email,
+		Period:      totpPeriod,
+		Digits:      totpDigits,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][EnrollMfa][totp.Generate] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	encrypted, err := s.encryptMfaSecret(key.Secret())
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][EnrollMfa][encryptMfaSecret] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = s.accountMfaRepo.SavePendingMfa(ctx, accountId, encrypted)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][EnrollMfa][accountMfaRepo.SavePendingMfa] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return &entity.MfaEnrollment{
+		Secret:          key.Secret(),
+		ProvisioningUri: key.URL(),
+	}, nil
+}
+
+func mfaAlreadyEnabledError(accountId int64) error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		Message:         fmt.Sprintf("[account_service] two-factor authentication already enabled | account_id: %v", accountId),
+		ResponseMessage: "two-factor authentication already enabled",
+	})
+}
+
+// ConfirmMfa enables two-factor authentication with a code of the enrolled app and returns the recovery codes, which
+// are only stored hashed and never shown again
+func (s *accountServiceImpl) ConfirmMfa(ctx context.Context, accountId int64, code string) (*entity.MfaRecoveryCodes, error) {
+	mfa, err := s.accountMfaRepo.GetMfa(ctx, accountId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ConfirmMfa][accountMfaRepo.GetMfa] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if mfa == nil {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_service][ConfirmMfa] not enrolled | account_id: %v", accountId),
+			ResponseMessage: "two-factor authentication not enrolled",
+		})
+	}
+	if mfa.ConfirmedAt != nil {
+		return nil, mfaAlreadyEnabledError(accountId)
+	}
+
+	isValid, err := s.checkTotp(ctx, *mfa, strings.TrimSpace(code))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ConfirmMfa][checkTotp] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if !isValid {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "invalid two-factor code",
+		})
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ConfirmMfa][generateRecoveryCodes] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	codeHashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		codeHashes = append(codeHashes, s.hashRecoveryCode(code))
+	}
+
+	err = s.confirmMfa(ctx, accountId, codeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err == nil && account != nil {
+		s.notifier.Notify(ctx, entity.Notification{
+			Recipient: account.Email,
+			Subject:   "Two-factor authentication enabled",
+			Body:      "Two-factor authentication was enabled on your XYZ Kredit Plus account. If you did not enable it, reset your password right away.",
+		})
+	}
+
+	return &entity.MfaRecoveryCodes{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// confirmMfa enables the enrolment and stores its recovery codes together
+func (s *accountServiceImpl) confirmMfa(ctx context.Context, accountId int64, codeHashes []string) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][confirmMfa][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	accountMfaRepo := tx.AccountMfaTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	isConfirmed, err := accountMfaRepo.ConfirmMfa(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][confirmMfa][accountMfaRepo.ConfirmMfa] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if !isConfirmed {
+		err = mfaAlreadyEnabledError(accountId)
+		return err
+	}
+
+	err = accountMfaRepo.ReplaceRecoveryCodes(ctx, accountId, codeHashes)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][confirmMfa][accountMfaRepo.ReplaceRecoveryCodes] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return nil
+}
+
+// CompleteMfaLogin finishes a login challenged for a second factor. Wrong codes count as failed logins, so guessing
+// codes is throttled like guessing passwords.
+func (s *accountServiceImpl) CompleteMfaLogin(ctx context.Context, challengeToken, code string, client entity.ClientInfo) (*entity.TokenData, error) {
+	claims := verifyToken(s.mfa.subKey("challenge"), appconstant.TokenPurposeMfaChallenge, challengeToken, time.Now().UnixMilli())
+	if claims == nil {
+		return nil, invalidMfaChallengeError()
+	}
+
+	account, err := s.accountRepo.GetAccountById(ctx, claims.AccountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][CompleteMfaLogin][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), claims.AccountId),
+		})
+	}
+	if account == nil || account.DisabledAt != nil {
+		return nil, invalidMfaChallengeError()
+	}
+
+	emailKey := s.emailAttemptKey(account.Email)
+	ipKey := s.ipAttemptKey(client.IpAddress)
+
+	err = s.checkLoginAllowed(ctx, emailKey, ipKey)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.accountMfaRepo.GetMfa(ctx, account.Id)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][CompleteMfaLogin][accountMfaRepo.GetMfa] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+	if mfa == nil || mfa.ConfirmedAt == nil {
+		return nil, invalidMfaChallengeError()
+	}
+
+	isValid, isRecoveryCode, err := s.checkSecondFactor(ctx, *mfa, code)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][CompleteMfaLogin][checkSecondFactor] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+	if !isValid {
+		err = s.recordLoginFailure(ctx, emailKey, ipKey, account)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, invalidMfaCodeError(account.Id)
+	}
+
+	token, err := s.completeLogin(ctx, *account, emailKey, client, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if isRecoveryCode {
+		s.notifier.Notify(ctx, entity.Notification{
+			Recipient: account.Email,
+			Subject:   "A recovery code was used to sign in",
+			Body:      "A recovery code was used to sign in to your XYZ Kredit Plus account, it cannot be used again. If it was not you, reset your password right away.",
+		})
+	}
+
+	return token, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/pquerna/otp/totp"
+)
+
+// enableMfa verifies the email of the account, enrolls and confirms an authenticator, and returns its secret and recovery codes
+func (e *testEnv) enableMfa(t *testing.T, accountId int64) (string, []string) {
+	t.Helper()
+
+	account, err := e.accountRepo.GetAccountById(context.Background(), accountId, false)
+	if err != nil || account == nil || account.VerificationSentAt == nil {
+		t.Fatalf("GetAccountById() = %v, %v", account, err)
+	}
+
+	_, err = e.accountRepo.VerifyEmail(context.Background(), accountId, *account.VerificationSentAt)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+
+	enrollment, err := e.account.EnrollMfa(context.Background(), accountId)
+	if err != nil {
+		t.Fatalf("EnrollMfa() error = %v", err)
+	}
+
+	recoveryCodes, err := e.account.ConfirmMfa(context.Background(), accountId, totpCode(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("ConfirmMfa() error = %v", err)
+	}
+
+	e.notifier.reset()
+
+	return enrollment.Secret, recoveryCodes.RecoveryCodes
+}
+
+// totpCode returns the code of the time step at offset steps from now
+func totpCode(t *testing.T, secret string, offset int) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, time.Now().Add(time.Duration(offset*totpPeriod)*time.Second))
+	if err != nil {
+		t.Fatalf("totp.GenerateCode() error = %v", err)
+	}
+
+	return code
+}
+
+// challenge logs in with the password and returns the mfa challenge token
+func (e *testEnv) challenge(t *testing.T, email string) string {
+	t.Helper()
+
+	result, err := e.account.Login(context.Background(), entity.Account{Email: email, Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if result.TokenData != nil || result.MfaChallenge == nil || result.MfaChallenge.Token == "" {
+		t.Fatalf("Login() = %+v, want only an mfa challenge", result)
+	}
+
+	return result.MfaChallenge.Token
+}
+
+func TestMfa_Enrollment(t *testing.T) {
+	t.Run("should require a verified email", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+
+		_, err := env.account.EnrollMfa(context.Background(), accountId)
+		assertAppError(t, err, http.StatusForbidden)
+	})
+
+	t.Run("should keep login single step until confirmed", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+
+		account, _ := env.accountRepo.GetAccountById(context.Background(), accountId, false)
+		env.accountRepo.VerifyEmail(context.Background(), accountId, *account.VerificationSentAt)
+
+		enrollment, err := env.account.EnrollMfa(context.Background(), accountId)
+		if err != nil {
+			t.Fatalf("EnrollMfa() error = %v", err)
+		}
+
+		if !strings.HasPrefix(enrollment.ProvisioningUri, "otpauth://totp/") || !strings.Contains(enrollment.ProvisioningUri, "secret="+enrollment.Secret) {
+			t.Errorf("provisioning uri = %q, want an otpauth uri with the secret", enrollment.ProvisioningUri)
+		}
+
+		mfa, _ := env.accountMfaRepo.GetMfa(context.Background(), accountId)
+		if mfa == nil || mfa.Secret == enrollment.Secret {
+			t.Errorf("stored secret = %+v, want the secret encrypted", mfa)
+		}
+
+		result, err := env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+		if err != nil || result.TokenData == nil {
+			t.Fatalf("Login() = %+v, %v, want tokens while unconfirmed", result, err)
+		}
+
+		_, err = env.account.ConfirmMfa(context.Background(), accountId, "000000")
+		assertAppError(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("should return hashed recovery codes once and reject enrolling again", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+		_, recoveryCodes := env.enableMfa(t, accountId)
+
+		if len(recoveryCodes) != recoveryCodeCount {
+			t.Fatalf("recovery codes = %d, want %d", len(recoveryCodes), recoveryCodeCount)
+		}
+
+		seen := map[string]bool{}
+		for _, code := range recoveryCodes {
+			if seen[code] {
+				t.Errorf("recovery code %q returned twice", code)
+			}
+			seen[code] = true
+		}
+
+		_, err := env.account.EnrollMfa(context.Background(), accountId)
+		assertAppError(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestMfa_Login(t *testing.T) {
+	t.Run("should issue tokens only after a valid code", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+		secret, _ := env.enableMfa(t, accountId)
+
+		challenge := env.challenge(t, "user@example.com")
+
+		_, err := env.account.CompleteMfaLogin(context.Background(), challenge, "000000", testClient)
+		assertAppError(t, err, http.StatusUnauthorized)
+
+		// The code of the current step was used to confirm, the next step is still accepted
+		code := totpCode(t, secret, 1)
+
+		token, err := env.account.CompleteMfaLogin(context.Background(), challenge, code, testClient)
+		if err != nil {
+			t.Fatalf("CompleteMfaLogin() error = %v", err)
+		}
+
+		if token.AccessToken.Token == "" {
+			t.Error("CompleteMfaLogin() returned no access token")
+		}
+
+		_, err = env.account.CompleteMfaLogin(context.Background(), env.challenge(t, "user@example.com"), code, testClient)
+		assertAppError(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("should reject a challenge signed for another purpose", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+		secret, _ := env.enableMfa(t, accountId)
+
+		forged, _ := signToken(env.account.mfa.subKey("challenge"), signedTokenClaims{
+			Purpose:   "password_reset",
+			AccountId: accountId,
+			ExpiredAt: time.Now().Add(time.Minute).UnixMilli(),
+		})
+
+		_, err := env.account.CompleteMfaLogin(context.Background(), forged, totpCode(t, secret, 1), testClient)
+		assertAppError(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("should accept a recovery code once and notify", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+		_, recoveryCodes := env.enableMfa(t, accountId)
+
+		_, err := env.account.CompleteMfaLogin(context.Background(), env.challenge(t, "user@example.com"), strings.ToUpper(recoveryCodes[0]), testClient)
+		if err != nil {
+			t.Fatalf("CompleteMfaLogin() error = %v", err)
+		}
+
+		if sent := env.notifier.sent(); len(sent) != 1 || sent[0].Recipient != "user@example.com" {
+			t.Errorf("notifications = %+v, want one to user@example.com", sent)
+		}
+
+		_, err = env.account.CompleteMfaLogin(context.Background(), env.challenge(t, "user@example.com"), recoveryCodes[0], testClient)
+		assertAppError(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("should count wrong codes as failed logins", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.register(t, "user@example.com")
+		secret, _ := env.enableMfa(t, accountId)
+
+		challenge := env.challenge(t, "user@example.com")
+
+		for i := 0; i < 5; i++ {
+			time.Sleep(2 * time.Millisecond)
+			assertAppError(t, func() error {
+				_, err := env.account.CompleteMfaLogin(context.Background(), challenge, "000000", testClient)
+				return err
+			}(), http.StatusUnauthorized)
+		}
+
+		_, err := env.account.CompleteMfaLogin(context.Background(), challenge, totpCode(t, secret, 1), testClient)
+		assertAppError(t, err, http.StatusTooManyRequests)
+	})
+}
+
+func TestMfa_TransactionAboveOtr(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Limit for 1 month is 3000000
+	accountId := env.registerWithKyc(t, "user@example.com", 3000000)
+
+	transaction := testTransaction(accountId, testMfaRequiredAboveOtr+500000, 1)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), transaction)
+	assertAppError(t, err, http.StatusForbidden)
+
+	transaction.IsMfaAuthenticated = true
+
+	_, err = env.transaction.CreateTransaction(context.Background(), transaction)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	_, err = env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 100000, 1))
+	if err != nil {
+		t.Fatalf("CreateTransaction() below the threshold error = %v", err)
+	}
+}
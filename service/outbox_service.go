@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
+	"github.com/michaelyusak/xyz-kredit-plus/publisher"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// appendOutboxEvent writes a domain event to the outbox. It runs in the transaction of the change, so the event is
+// only published when the change is committed.
+func appendOutboxEvent(ctx context.Context, outboxRepo repository.OutboxRepository, eventType, aggregateType string, aggregateId int64, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("[outbox_service][appendOutboxEvent][json.Marshal] Error: %w | event_type: %s", err, eventType)
+	}
+
+	_, err = outboxRepo.InsertEvent(ctx, entity.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Payload:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("[outbox_service][appendOutboxEvent][outboxRepo.InsertEvent] Error: %w | event_type: %s", err, eventType)
+	}
+
+	return nil
+}
+
+func eventLimit(limit entity.AccountLimit) *entity.EventLimit {
+	return &entity.EventLimit{
+		Limit1M: limit.Limit1M,
+		Limit2M: limit.Limit2M,
+		Limit3M: limit.Limit3M,
+		Limit4M: limit.Limit4M,
+	}
+}
+
+type OutboxOpt struct {
+	// Events loaded per query by the relay
+	BatchSize int
+	// Wait after the first failed attempt, doubled after every further failure up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// An event being published is skipped by other relays this long, it is published again when the relay
+	// publishing it stopped
+	Lease time.Duration
+}
+
+func (o OutboxOpt) withDefaults() OutboxOpt {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 5 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.Lease <= 0 {
+		o.Lease = time.Minute
+	}
+
+	return o
+}
+
+type outboxServiceImpl struct {
+	outboxRepo repository.OutboxRepository
+	publisher  publisher.Publisher
+	opt        OutboxOpt
+}
+
+func NewOutboxService(outboxRepo repository.OutboxRepository, publisher publisher.Publisher, opt OutboxOpt) *outboxServiceImpl {
+	return &outboxServiceImpl{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		opt:        opt.withDefaults(),
+	}
+}
+
+// exponentialBackoff is the wait before the next attempt of something that failed attempts times, base doubled after
+// every failure up to max
+func exponentialBackoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base << min(attempts-1, 30)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	return delay
+}
+
+// backoff is the wait before the next attempt of an event that failed attempts times
+func (s *outboxServiceImpl) backoff(attempts int) time.Duration {
+	return exponentialBackoff(s.opt.BaseBackoff, s.opt.MaxBackoff, attempts)
+}
+
+// Relay publishes the events due when it starts. An event the sink refuses is retried after a backoff until it is
+// accepted, the later events of its aggregate wait for it, so events are never dropped nor reordered.
+func (s *outboxServiceImpl) Relay(ctx context.Context) (*entity.OutboxRelayResult, error) {
+	result := &entity.OutboxRelayResult{}
+
+	now := time.Now().UnixMilli()
+
+	// A pass publishes the first due event of every aggregate, the next pass the events that followed them
+	for {
+		events, err := s.outboxRepo.GetDueEvents(ctx, now, s.opt.BatchSize)
+		if err != nil {
+			return result, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[outbox_service][Relay][outboxRepo.GetDueEvents] Error: %s", err.Error()),
+			})
+		}
+
+		published := result.Published
+
+		for _, event := range events {
+			err = s.publish(ctx, event, now, result)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		if result.Published == published {
+			return result, nil
+		}
+	}
+}
+
+// publish claims the event and hands it to the sink, then records the outcome
+func (s *outboxServiceImpl) publish(ctx context.Context, event entity.OutboxEvent, now int64, result *entity.OutboxRelayResult) error {
+	isClaimed, err := s.outboxRepo.ClaimEvent(ctx, event.Id, now, time.Now().Add(s.opt.Lease).UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[outbox_service][publish][outboxRepo.ClaimEvent] Error: %s | outbox_event_id: %v", err.Error(), event.Id),
+		})
+	}
+	if !isClaimed {
+		return nil
+	}
+
+	publishErr := s.publisher.Publish(ctx, entity.DomainEvent{
+		Id:            event.Id,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateId,
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	})
+	if publishErr != nil {
+		metrics.RecordOutboxAttempt(event.EventType, "failed")
+
+		err = s.outboxRepo.MarkFailed(ctx, event.Id, truncate(publishErr.Error(), 255), time.Now().Add(s.backoff(event.Attempts+1)).UnixMilli())
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[outbox_service][publish][outboxRepo.MarkFailed] Error: %s | outbox_event_id: %v", err.Error(), event.Id),
+			})
+		}
+
+		result.Failed++
+
+		return nil
+	}
+
+	metrics.RecordOutboxAttempt(event.EventType, "published")
+
+	err = s.outboxRepo.MarkPublished(ctx, event.Id)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[outbox_service][publish][outboxRepo.MarkPublished] Error: %s | outbox_event_id: %v", err.Error(), event.Id),
+		})
+	}
+
+	result.Published++
+
+	return nil
+}
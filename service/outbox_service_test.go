@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// recordingPublisher keeps every event it accepts, it refuses them while err is set
+type recordingPublisher struct {
+	mu     sync.Mutex
+	err    error
+	events []entity.DomainEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event entity.DomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.events = append(p.events, event)
+
+	return nil
+}
+
+func (p *recordingPublisher) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+func (p *recordingPublisher) types() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var types []string
+
+	for _, event := range p.events {
+		types = append(types, event.Type)
+	}
+
+	return types
+}
+
+func (e *testEnv) outboxService(publisher *recordingPublisher, opt OutboxOpt) *outboxServiceImpl {
+	return NewOutboxService(repository.NewOutboxRepositoryMemory(e.store), publisher, opt)
+}
+
+func relay(t *testing.T, s *outboxServiceImpl) *entity.OutboxRelayResult {
+	t.Helper()
+
+	result, err := s.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("Relay() error = %v", err)
+	}
+
+	return result
+}
+
+// pendingEvents lists the first unpublished event of every aggregate, whether due or not
+func (e *testEnv) pendingEvents(t *testing.T) []entity.OutboxEvent {
+	t.Helper()
+
+	events, err := repository.NewOutboxRepositoryMemory(e.store).GetDueEvents(context.Background(), math.MaxInt64, 100)
+	if err != nil {
+		t.Fatalf("GetDueEvents() error = %v", err)
+	}
+
+	return events
+}
+
+func TestOutbox_PublishesEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	publisher := &recordingPublisher{}
+	s := env.outboxService(publisher, OutboxOpt{})
+
+	result := relay(t, s)
+	if result.Published != 3 || result.Failed != 0 {
+		t.Fatalf("result = %+v, want 3 published", result)
+	}
+
+	assertActions(t, publisher.types(),
+		appconstant.EventTypeAccountRegistered,
+		appconstant.EventTypeTransactionCreated,
+		appconstant.EventTypeKycCompleted,
+	)
+
+	var kyc entity.KycCompletedEvent
+
+	err = json.Unmarshal(publisher.events[2].Data, &kyc)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if kyc.AccountId != accountId || kyc.KycStatus != appconstant.KycStatusApproved || kyc.Limit == nil || kyc.Limit.Limit1M == 0 {
+		t.Errorf("kyc.completed = %+v, want approved with the granted limit", kyc)
+	}
+
+	var transaction entity.TransactionCreatedEvent
+
+	err = json.Unmarshal(publisher.events[1].Data, &transaction)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if transaction.AccountId != accountId || transaction.OTR != 200000 || transaction.TransactionId != publisher.events[1].AggregateId {
+		t.Errorf("transaction.created = %+v, want the created transaction", transaction)
+	}
+
+	result = relay(t, s)
+	if result.Published != 0 {
+		t.Errorf("result = %+v, want nothing published again", result)
+	}
+}
+
+func TestOutbox_RolledBackChange(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	s := NewTransactionService(failingTransaction{env.account.transaction}, env.accountLimitRepo, env.transactionRepo, testMfaRequiredAboveOtr)
+
+	_, err := s.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+	assertAppError(t, err, http.StatusInternalServerError)
+
+	publisher := &recordingPublisher{}
+
+	relay(t, env.outboxService(publisher, OutboxOpt{}))
+
+	assertActions(t, publisher.types(),
+		appconstant.EventTypeAccountRegistered,
+		appconstant.EventTypeKycCompleted,
+	)
+}
+
+// failingLimitTransaction fails to read limits, after the KYC decision was written and audited
+type failingLimitTransaction struct {
+	repository.Transaction
+}
+
+type failingLimitTx struct {
+	repository.Tx
+}
+
+type failingAccountLimitRepository struct {
+	repository.AccountLimitRepository
+}
+
+func (t failingLimitTransaction) Begin() (repository.Tx, error) {
+	tx, err := t.Transaction.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return failingLimitTx{tx}, nil
+}
+
+func (t failingLimitTx) AccountLimitTx() repository.AccountLimitRepository {
+	return failingAccountLimitRepository{t.Tx.AccountLimitTx()}
+}
+
+func (r failingAccountLimitRepository) GetAccountLimitByAccountId(ctx context.Context, accountId int64, forUpdate bool) (*entity.AccountLimit, error) {
+	return nil, errors.New("read failed")
+}
+
+func TestOutbox_RolledBackReview(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	err := env.consumer.ResubmitKyc(context.Background(), testConsumer(t, accountId, 600000))
+	if err != nil {
+		t.Fatalf("ResubmitKyc() error = %v", err)
+	}
+
+	relay(t, env.outboxService(&recordingPublisher{}, OutboxOpt{}))
+
+	s := NewConsumerService(failingLimitTransaction{env.account.transaction}, env.consumerRepo, env.mediaRepo, env.accountLimitRepo)
+
+	err = s.ReviewKyc(context.Background(), accountId, true)
+	assertAppError(t, err, http.StatusInternalServerError)
+
+	consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+	if consumer.KycStatus != appconstant.KycStatusPendingReview {
+		t.Errorf("kyc status = %q, want the decision rolled back", consumer.KycStatus)
+	}
+
+	publisher := &recordingPublisher{}
+
+	relay(t, env.outboxService(publisher, OutboxOpt{}))
+
+	if types := publisher.types(); len(types) != 0 {
+		t.Errorf("events = %v, want none", types)
+	}
+}
+
+func TestOutbox_RetriesRefusedEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.registerWithKyc(t, "user@example.com", 600000)
+
+	publisher := &recordingPublisher{}
+	publisher.fail(errors.New("sink unavailable"))
+
+	s := env.outboxService(publisher, OutboxOpt{BaseBackoff: 50 * time.Millisecond, MaxBackoff: time.Hour})
+
+	result := relay(t, s)
+	if result.Published != 0 || result.Failed != 1 {
+		t.Fatalf("result = %+v, want the first event of the account failed", result)
+	}
+
+	pending := env.pendingEvents(t)
+	if len(pending) != 1 || pending[0].EventType != appconstant.EventTypeAccountRegistered || pending[0].Attempts != 1 || pending[0].LastError == "" {
+		t.Fatalf("pending = %+v, want account.registered failed once", pending)
+	}
+
+	publisher.fail(nil)
+
+	result = relay(t, s)
+	if result.Published != 0 || result.Failed != 0 {
+		t.Fatalf("result = %+v, want the event held back until its backoff passed", result)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	result = relay(t, s)
+	if result.Published != 2 {
+		t.Fatalf("result = %+v, want both events published in order", result)
+	}
+
+	assertActions(t, publisher.types(),
+		appconstant.EventTypeAccountRegistered,
+		appconstant.EventTypeKycCompleted,
+	)
+}
+
+func TestOutbox_Backoff(t *testing.T) {
+	s := NewOutboxService(nil, nil, OutboxOpt{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+
+	for _, tt := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	} {
+		if got := s.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
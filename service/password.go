@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/helper"
+)
+
+type PasswordResetOpt struct {
+	// HMAC key signing reset tokens
+	Key      []byte
+	TokenTtl time.Duration
+	// Minimum time between two reset emails of an account
+	ResendCooldown time.Duration
+	// Page receiving the token as the token query parameter
+	LinkUrl string
+}
+
+func (o PasswordResetOpt) withDefaults() PasswordResetOpt {
+	if o.TokenTtl <= 0 {
+		o.TokenTtl = time.Hour
+	}
+	if o.ResendCooldown < 0 {
+		o.ResendCooldown = 0
+	}
+
+	return o
+}
+
+func invalidPasswordError() error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		ResponseMessage: "invalid password",
+	})
+}
+
+func invalidResetTokenError() error {
+	return apperror.BadRequestError(apperror.AppErrorOpt{
+		Message:         "[account_service][ResetPassword] invalid reset token",
+		ResponseMessage: "invalid or expired reset token",
+	})
+}
+
+func (s *accountServiceImpl) notifyPasswordChanged(ctx context.Context, account entity.Account) {
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Your password was changed",
+		Body:      "The password of your XYZ Kredit Plus account was changed and every session was signed out. If you did not change it, reset your password right away.",
+	})
+}
+
+// ForgotPassword emails a reset link to a registered and enabled account. It succeeds for any email so it does not reveal
+// which emails are registered, and sends at most one email per cooldown.
+func (s *accountServiceImpl) ForgotPassword(ctx context.Context, email string) error {
+	account, err := s.accountRepo.GetAccountByEmail(ctx, email, false)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ForgotPassword][accountRepo.GetAccountByEmail] Error: %s | email: %s", err.Error(), email),
+		})
+	}
+	if account == nil || account.DisabledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+
+	isRecorded, err := s.accountRepo.SetPasswordResetSentAt(ctx, account.Id, now.UnixMilli(), now.Add(-s.passwordReset.ResendCooldown).UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ForgotPassword][accountRepo.SetPasswordResetSentAt] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+	if !isRecorded {
+		return nil
+	}
+
+	token, err := signToken(s.passwordReset.Key, signedTokenClaims{
+		Purpose:   appconstant.TokenPurposePasswordReset,
+		AccountId: account.Id,
+		IssuedAt:  now.UnixMilli(),
+		ExpiredAt: now.Add(s.passwordReset.TokenTtl).UnixMilli(),
+	})
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ForgotPassword][signToken] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+
+	link, err := url.Parse(s.passwordReset.LinkUrl)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ForgotPassword][url.Parse] Error: %s", err.Error()),
+		})
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "Reset your password",
+		Body:      fmt.Sprintf("Reset your XYZ Kredit Plus password by opening %s, the link expires in %s. If you did not ask for it, ignore this email.", link.String(), s.passwordReset.TokenTtl),
+	})
+
+	return nil
+}
+
+// ResetPassword sets a new password with the latest reset token, once, and signs out every session
+func (s *accountServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if !helper.ValidatePassword(newPassword) {
+		return invalidPasswordError()
+	}
+
+	claims := verifyToken(s.passwordReset.Key, appconstant.TokenPurposePasswordReset, token, time.Now().UnixMilli())
+	if claims == nil {
+		return invalidResetTokenError()
+	}
+
+	account, err := s.accountRepo.GetAccountById(ctx, claims.AccountId, false)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResetPassword][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), claims.AccountId),
+		})
+	}
+	if account == nil {
+		return invalidResetTokenError()
+	}
+
+	hashed, err := s.hash.Hash(newPassword)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResetPassword][hash.Hash] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+
+	// Only the latest token resets, and only once
+	err = s.changePassword(ctx, account.Id, hashed, &claims.IssuedAt)
+	if err != nil {
+		return err
+	}
+
+	// Whoever resets the password can log in again right away
+	err = s.loginAttemptRepo.DeleteLoginAttempt(ctx, s.emailAttemptKey(account.Email))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ResetPassword][loginAttemptRepo.DeleteLoginAttempt] Error: %s | account_id: %v", err.Error(), account.Id),
+		})
+	}
+
+	s.notifyPasswordChanged(ctx, *account)
+
+	return nil
+}
+
+// ChangePassword replaces the password of an authenticated account, signs out every other session and returns new tokens
+func (s *accountServiceImpl) ChangePassword(ctx context.Context, accountId int64, req entity.ChangePasswordReq, client entity.ClientInfo) (*entity.TokenData, error) {
+	account, err := s.accountRepo.GetAccountById(ctx, accountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ChangePassword][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if account == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	isValid, err := s.hash.Check(req.CurrentPassword, []byte(account.Password))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ChangePassword][hash.Check] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+	if !isValid {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[account_service][ChangePassword] wrong current password | account_id: %v", accountId),
+			ResponseMessage: "invalid current password",
+		})
+	}
+
+	if !helper.ValidatePassword(req.NewPassword) {
+		return nil, invalidPasswordError()
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "new password must differ from the current password",
+		})
+	}
+
+	hashed, err := s.hash.Hash(req.NewPassword)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ChangePassword][hash.Hash] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	err = s.changePassword(ctx, accountId, hashed, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyPasswordChanged(ctx, *account)
+
+	token, err := s.issueToken(ctx, *account, client, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][ChangePassword][issueToken] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return token, nil
+}
+
+// changePassword stores the hashed password and revokes every refresh token of the account together.
+// With resetSentAt the password is only stored if that reset is still the latest one and unused.
+func (s *accountServiceImpl) changePassword(ctx context.Context, accountId int64, hashed string, resetSentAt *int64) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][changePassword][transaction.Begin] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	accountRepo := tx.AccountTx()
+	refreshTokenRepo := tx.RefreshTokenTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	if resetSentAt != nil {
+		var isReset bool
+
+		isReset, err = accountRepo.ResetPassword(ctx, accountId, *resetSentAt, hashed)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][changePassword][accountRepo.ResetPassword] Error: %s | account_id: %v", err.Error(), accountId),
+			})
+		}
+		if !isReset {
+			return invalidResetTokenError()
+		}
+	} else {
+		err = accountRepo.UpdatePassword(ctx, accountId, hashed)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[account_service][changePassword][accountRepo.UpdatePassword] Error: %s | account_id: %v", err.Error(), accountId),
+			})
+		}
+	}
+
+	err = refreshTokenRepo.RevokeAccountTokens(ctx, accountId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][changePassword][refreshTokenRepo.RevokeAccountTokens] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	err := env.account.ForgotPassword(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+
+	sent := env.notifier.sent()
+	if len(sent) != 1 || sent[0].Subject != "Reset your password" {
+		t.Fatalf("notifications = %+v, want one reset email", sent)
+	}
+
+	token := env.linkToken(t)
+
+	assertAppError(t, env.account.ResetPassword(context.Background(), token, "weak"), http.StatusBadRequest)
+
+	err = env.account.ResetPassword(context.Background(), token, "@newPass1234")
+	if err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+
+	assertAppError(t, env.login("user@example.com", testPassword, "10.0.0.1"), http.StatusUnauthorized)
+
+	if err := env.login("user@example.com", "@newPass1234", "10.0.0.1"); err != nil {
+		t.Fatalf("login with the new password error = %v", err)
+	}
+
+	// Single use
+	appErr := assertAppError(t, env.account.ResetPassword(context.Background(), token, "@otherPass1234"), http.StatusBadRequest)
+	if appErr.ResponseMessage != "invalid or expired reset token" {
+		t.Errorf("response = %q", appErr.ResponseMessage)
+	}
+
+	if sent := env.notifier.sent(); sent[len(sent)-1].Subject != "Your password was changed" {
+		t.Errorf("last notification = %+v, want a password changed alert", sent[len(sent)-1])
+	}
+}
+
+func TestPasswordReset_UnknownEmailAndCooldown(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+
+	err := env.account.ForgotPassword(context.Background(), "unknown@example.com")
+	if err != nil {
+		t.Fatalf("ForgotPassword() unknown email error = %v, want the same response as a registered email", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err = env.account.ForgotPassword(context.Background(), "user@example.com")
+		if err != nil {
+			t.Fatalf("ForgotPassword() error = %v", err)
+		}
+	}
+
+	if sent := env.notifier.sent(); len(sent) != 1 {
+		t.Fatalf("notifications = %d, want a single email during the cooldown", len(sent))
+	}
+
+	firstToken := env.linkToken(t)
+
+	env.account.passwordReset.ResendCooldown = 0
+	time.Sleep(2 * time.Millisecond)
+
+	err = env.account.ForgotPassword(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+
+	// The new email invalidates the previous one
+	assertAppError(t, env.account.ResetPassword(context.Background(), firstToken, "@newPass1234"), http.StatusBadRequest)
+
+	// A token signed for another purpose is not a reset token, even with the same key
+	account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+
+	otherPurpose, err := signToken(env.account.passwordReset.Key, signedTokenClaims{
+		Purpose:   appconstant.TokenPurposeEmailVerification,
+		AccountId: account.Id,
+		IssuedAt:  *account.PasswordResetSentAt,
+		ExpiredAt: time.Now().Add(time.Hour).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("signToken() error = %v", err)
+	}
+
+	assertAppError(t, env.account.ResetPassword(context.Background(), otherPurpose, "@newPass1234"), http.StatusBadRequest)
+
+	err = env.account.ResetPassword(context.Background(), env.linkToken(t), "@newPass1234")
+	if err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.register(t, "user@example.com")
+
+	change := func(current, next string) error {
+		_, err := env.account.ChangePassword(context.Background(), accountId, entity.ChangePasswordReq{CurrentPassword: current, NewPassword: next}, testClient)
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		next    string
+		message string
+	}{
+		{"wrong current password", "@wrongPass1234", "@newPass1234", "invalid current password"},
+		{"invalid new password", testPassword, "weak", "invalid password"},
+		{"same password", testPassword, testPassword, "new password must differ from the current password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := assertAppError(t, change(tt.current, tt.next), http.StatusBadRequest)
+			if appErr.ResponseMessage != tt.message {
+				t.Errorf("response = %q, want %q", appErr.ResponseMessage, tt.message)
+			}
+		})
+	}
+
+	token, err := env.account.ChangePassword(context.Background(), accountId, entity.ChangePasswordReq{CurrentPassword: testPassword, NewPassword: "@newPass1234"}, testClient)
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	if token.AccessToken.Token == "" {
+		t.Error("ChangePassword() returned no access token")
+	}
+
+	assertAppError(t, env.login("user@example.com", testPassword, "10.0.0.1"), http.StatusUnauthorized)
+
+	if err := env.login("user@example.com", "@newPass1234", "10.0.0.1"); err != nil {
+		t.Fatalf("login with the new password error = %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+// Records removed per query by one run of the retention job
+const retentionBatchSize = 100
+
+type RetentionOpt struct {
+	// Refresh tokens are deleted once they were expired this long
+	RefreshTokensRetainFor time.Duration
+	// KYC photos are deleted once their submission was rejected this long
+	RejectedKycPhotosRetainFor time.Duration
+	// Outbox events are deleted once they were published this long
+	PublishedEventsRetainFor time.Duration
+	// Webhook deliveries are deleted once they were delivered this long
+	DeliveredWebhooksRetainFor time.Duration
+}
+
+type retentionServiceImpl struct {
+	// Anonymises closed accounts once they were closed for the AnonymiseAfter of the account closure
+	accountService   AccountService
+	refreshTokenRepo repository.RefreshTokenRepository
+	consumerRepo     repository.ConsumerRepository
+	mediaRepo        repository.MediaRepository
+	outboxRepo       repository.OutboxRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	opt              RetentionOpt
+}
+
+func NewRetentionService(accountService AccountService, refreshTokenRepo repository.RefreshTokenRepository, consumerRepo repository.ConsumerRepository, mediaRepo repository.MediaRepository, outboxRepo repository.OutboxRepository, deliveryRepo repository.WebhookDeliveryRepository, opt RetentionOpt) *retentionServiceImpl {
+	return &retentionServiceImpl{
+		accountService:   accountService,
+		refreshTokenRepo: refreshTokenRepo,
+		consumerRepo:     consumerRepo,
+		mediaRepo:        mediaRepo,
+		outboxRepo:       outboxRepo,
+		deliveryRepo:     deliveryRepo,
+		opt:              opt,
+	}
+}
+
+// Run applies every retention policy and reports what was removed. When a policy fails, the report of the policies
+// applied so far is returned with the error.
+func (s *retentionServiceImpl) Run(ctx context.Context) (*entity.RetentionReport, error) {
+	report := &entity.RetentionReport{
+		StartedAt: time.Now().UnixMilli(),
+	}
+
+	deleted, err := s.deleteExpiredTokens(ctx)
+	report.RefreshTokensDeleted = deleted
+	metrics.RecordRetentionRemoved("refresh_tokens", int(deleted))
+	if err != nil {
+		return report, err
+	}
+
+	anonymised, err := s.accountService.AnonymiseClosedAccounts(ctx)
+	if anonymised != nil {
+		report.AccountsAnonymised = anonymised.Anonymised
+		metrics.RecordRetentionRemoved("closed_accounts", anonymised.Anonymised)
+	}
+	if err != nil {
+		return report, err
+	}
+
+	photos, err := s.deleteRejectedKycPhotos(ctx)
+	report.KycPhotosDeleted = photos
+	metrics.RecordRetentionRemoved("rejected_kyc_photos", photos)
+	if err != nil {
+		return report, err
+	}
+
+	events, err := s.deletePublishedEvents(ctx)
+	report.OutboxEventsDeleted = events
+	metrics.RecordRetentionRemoved("published_events", int(events))
+	if err != nil {
+		return report, err
+	}
+
+	deliveries, err := s.deleteDeliveredWebhooks(ctx)
+	report.WebhookDeliveriesDeleted = deliveries
+	metrics.RecordRetentionRemoved("delivered_webhooks", int(deliveries))
+	if err != nil {
+		return report, err
+	}
+
+	report.FinishedAt = time.Now().UnixMilli()
+
+	return report, nil
+}
+
+func (s *retentionServiceImpl) deleteExpiredTokens(ctx context.Context) (int64, error) {
+	expiredBefore := time.Now().Add(-s.opt.RefreshTokensRetainFor).UnixMilli()
+
+	var total int64
+
+	for {
+		deleted, err := s.refreshTokenRepo.DeleteExpiredTokens(ctx, expiredBefore, retentionBatchSize)
+		if err != nil {
+			return total, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[retention_service][deleteExpiredTokens][refreshTokenRepo.DeleteExpiredTokens] Error: %s", err.Error()),
+			})
+		}
+
+		total += deleted
+
+		if deleted < retentionBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *retentionServiceImpl) deletePublishedEvents(ctx context.Context) (int64, error) {
+	publishedBefore := time.Now().Add(-s.opt.PublishedEventsRetainFor).UnixMilli()
+
+	var total int64
+
+	for {
+		deleted, err := s.outboxRepo.DeletePublishedEvents(ctx, publishedBefore, retentionBatchSize)
+		if err != nil {
+			return total, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[retention_service][deletePublishedEvents][outboxRepo.DeletePublishedEvents] Error: %s", err.Error()),
+			})
+		}
+
+		total += deleted
+
+		if deleted < retentionBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *retentionServiceImpl) deleteDeliveredWebhooks(ctx context.Context) (int64, error) {
+	deliveredBefore := time.Now().Add(-s.opt.DeliveredWebhooksRetainFor).UnixMilli()
+
+	var total int64
+
+	for {
+		deleted, err := s.deliveryRepo.DeleteDeliveredDeliveries(ctx, deliveredBefore, retentionBatchSize)
+		if err != nil {
+			return total, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[retention_service][deleteDeliveredWebhooks][deliveryRepo.DeleteDeliveredDeliveries] Error: %s", err.Error()),
+			})
+		}
+
+		total += deleted
+
+		if deleted < retentionBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// deleteRejectedKycPhotos removes the photos of rejected submissions from the consumers and their histories, then
+// deletes the photos no other submission holds
+func (s *retentionServiceImpl) deleteRejectedKycPhotos(ctx context.Context) (int, error) {
+	rejectedBefore := time.Now().Add(-s.opt.RejectedKycPhotosRetainFor).UnixMilli()
+
+	var deleted int
+
+	for {
+		keys, err := s.consumerRepo.GetRejectedPhotoKeys(ctx, rejectedBefore, retentionBatchSize)
+		if err != nil {
+			return deleted, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[retention_service][deleteRejectedKycPhotos][consumerRepo.GetRejectedPhotoKeys] Error: %s", err.Error()),
+			})
+		}
+
+		for _, key := range keys {
+			err = s.consumerRepo.ClearRejectedPhotoKey(ctx, key, rejectedBefore)
+			if err != nil {
+				return deleted, apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[retention_service][deleteRejectedKycPhotos][consumerRepo.ClearRejectedPhotoKey] Error: %s | key: %s", err.Error(), key),
+				})
+			}
+
+			isReferenced, err := s.consumerRepo.IsMediaKeyReferenced(ctx, key)
+			if err != nil {
+				return deleted, apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[retention_service][deleteRejectedKycPhotos][consumerRepo.IsMediaKeyReferenced] Error: %s | key: %s", err.Error(), key),
+				})
+			}
+			if isReferenced {
+				continue
+			}
+
+			err = s.mediaRepo.Delete(ctx, key)
+			if err != nil {
+				return deleted, apperror.InternalServerError(apperror.AppErrorOpt{
+					Message: fmt.Sprintf("[retention_service][deleteRejectedKycPhotos][mediaRepo.Delete] Error: %s | key: %s", err.Error(), key),
+				})
+			}
+
+			deleted++
+		}
+
+		if len(keys) < retentionBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+func (e *testEnv) retentionService(opt RetentionOpt) *retentionServiceImpl {
+	return NewRetentionService(e.account, repository.NewRefreshTokenRepositoryMemory(e.store), e.consumerRepo, e.mediaRepo, repository.NewOutboxRepositoryMemory(e.store), repository.NewWebhookDeliveryRepositoryMemory(e.store), opt)
+}
+
+func (e *testEnv) runRetention(t *testing.T, opt RetentionOpt) *entity.RetentionReport {
+	t.Helper()
+
+	report, err := e.retentionService(opt).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	return report
+}
+
+// rejectKyc resubmits the KYC of the account and rejects it, returns the rejected consumer
+func (e *testEnv) rejectKyc(t *testing.T, accountId int64) *entity.Consumer {
+	t.Helper()
+
+	err := e.consumer.ResubmitKyc(context.Background(), testConsumer(t, accountId, 600000))
+	if err != nil {
+		t.Fatalf("ResubmitKyc() error = %v", err)
+	}
+
+	err = e.consumer.ReviewKyc(context.Background(), accountId, false)
+	if err != nil {
+		t.Fatalf("ReviewKyc() error = %v", err)
+	}
+
+	consumer, _ := e.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+
+	return consumer
+}
+
+func (e *testEnv) isMediaStored(t *testing.T, key string) bool {
+	t.Helper()
+
+	media, err := e.mediaRepo.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	return media != nil
+}
+
+func TestRetention_RefreshTokens(t *testing.T) {
+	env := newTestEnv(t)
+	refreshTokenRepo := repository.NewRefreshTokenRepositoryMemory(env.store)
+
+	accountId := env.register(t, "user@example.com")
+
+	for token, expiredAt := range map[string]time.Time{
+		"past retention":   time.Now().Add(-2 * time.Hour),
+		"within retention": time.Now().Add(-10 * time.Minute),
+	} {
+		err := refreshTokenRepo.InsertToken(context.Background(), token, entity.Session{AccountId: accountId, ExpiredAt: expiredAt.UnixMilli()})
+		if err != nil {
+			t.Fatalf("InsertToken() error = %v", err)
+		}
+	}
+
+	report := env.runRetention(t, RetentionOpt{RefreshTokensRetainFor: time.Hour, RejectedKycPhotosRetainFor: time.Hour})
+
+	if report.RefreshTokensDeleted != 1 {
+		t.Errorf("refresh tokens deleted = %d, want the token expired past retention", report.RefreshTokensDeleted)
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) != 1 {
+		t.Errorf("sessions = %+v, want the active session kept", sessions)
+	}
+
+	if report := env.runRetention(t, RetentionOpt{RejectedKycPhotosRetainFor: time.Hour}); report.RefreshTokensDeleted != 1 {
+		t.Errorf("refresh tokens deleted = %d, want every expired token deleted without retention", report.RefreshTokensDeleted)
+	}
+}
+
+func TestRetention_ClosedAccounts(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.closeAccount(t, env.register(t, "user@example.com"))
+
+	if report := env.runRetention(t, RetentionOpt{}); report.AccountsAnonymised != 0 {
+		t.Errorf("accounts anonymised = %d, want none before the retention period", report.AccountsAnonymised)
+	}
+
+	env.account.accountClosure.AnonymiseAfter = 0
+
+	if report := env.runRetention(t, RetentionOpt{}); report.AccountsAnonymised != 1 {
+		t.Errorf("accounts anonymised = %d, want the closed account", report.AccountsAnonymised)
+	}
+}
+
+func TestRetention_RejectedKycPhotos(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+	approved, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+
+	// The first rejected submission is kept in the history once the KYC is submitted again
+	resubmitted := env.rejectKyc(t, accountId)
+	rejected := env.rejectKyc(t, accountId)
+
+	otherId := env.registerWithKyc(t, "other@example.com", 600000)
+	other, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), otherId, false)
+
+	if report := env.runRetention(t, RetentionOpt{RejectedKycPhotosRetainFor: time.Hour}); report.KycPhotosDeleted != 0 {
+		t.Errorf("kyc photos deleted = %d, want none before the retention period", report.KycPhotosDeleted)
+	}
+
+	report := env.runRetention(t, RetentionOpt{})
+
+	if report.KycPhotosDeleted != 4 {
+		t.Errorf("kyc photos deleted = %d, want both photos of both rejected submissions", report.KycPhotosDeleted)
+	}
+
+	for _, consumer := range []*entity.Consumer{resubmitted, rejected} {
+		for _, key := range []string{consumer.IdentityCardPhoto.Key, consumer.SelfiePhoto.Key} {
+			if env.isMediaStored(t, key) {
+				t.Errorf("photo %s of a rejected submission is stored", key)
+			}
+
+			if isReferenced, _ := env.consumerRepo.IsMediaKeyReferenced(context.Background(), key); isReferenced {
+				t.Errorf("photo %s of a rejected submission is referenced", key)
+			}
+		}
+	}
+
+	for _, consumer := range []*entity.Consumer{approved, other} {
+		for _, key := range []string{consumer.IdentityCardPhoto.Key, consumer.SelfiePhoto.Key} {
+			if !env.isMediaStored(t, key) {
+				t.Errorf("photo %s of a submission that was not rejected is deleted", key)
+			}
+		}
+	}
+
+	consumer, _ := env.consumerRepo.GetConsumerByAccountId(context.Background(), accountId, false)
+	if consumer.KycStatus != appconstant.KycStatusRejected || consumer.FullName == "" {
+		t.Errorf("consumer = %+v, want kept rejected without its photos", consumer)
+	}
+
+	if report := env.runRetention(t, RetentionOpt{}); report.KycPhotosDeleted != 0 {
+		t.Errorf("kyc photos deleted = %d, want a photo deleted once", report.KycPhotosDeleted)
+	}
+}
+
+func TestRetention_PublishedEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.register(t, "user@example.com")
+	relay(t, env.outboxService(&recordingPublisher{}, OutboxOpt{}))
+
+	env.register(t, "other@example.com")
+
+	if report := env.runRetention(t, RetentionOpt{PublishedEventsRetainFor: time.Hour}); report.OutboxEventsDeleted != 0 {
+		t.Errorf("outbox events deleted = %d, want none before the retention period", report.OutboxEventsDeleted)
+	}
+
+	if report := env.runRetention(t, RetentionOpt{}); report.OutboxEventsDeleted != 1 {
+		t.Errorf("outbox events deleted = %d, want the published event", report.OutboxEventsDeleted)
+	}
+
+	if pending := env.pendingEvents(t); len(pending) != 1 {
+		t.Errorf("pending = %+v, want the unpublished event kept", pending)
+	}
+}
+
+func TestRetention_DeliveredWebhooks(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	sender := &recordingSender{}
+	s := env.webhookService(sender, WebhookOpt{})
+
+	apiKey, _ := subscribe(t, s, appconstant.WebhookEventTransactionApproved)
+
+	env.createPartnerTransaction(t, accountId, apiKey)
+	dispatch(t, s)
+
+	sender.fail(http.StatusServiceUnavailable, errors.New("partner unavailable"))
+
+	env.createPartnerTransaction(t, accountId, apiKey)
+	dispatch(t, s)
+
+	if report := env.runRetention(t, RetentionOpt{DeliveredWebhooksRetainFor: time.Hour}); report.WebhookDeliveriesDeleted != 0 {
+		t.Errorf("webhook deliveries deleted = %d, want none before the retention period", report.WebhookDeliveriesDeleted)
+	}
+
+	if report := env.runRetention(t, RetentionOpt{}); report.WebhookDeliveriesDeleted != 1 {
+		t.Errorf("webhook deliveries deleted = %d, want the delivered one", report.WebhookDeliveriesDeleted)
+	}
+
+	deliveries, err := s.GetDeliveries(context.Background(), entity.WebhookDeliveryFilter{})
+	if err != nil || len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].Status != appconstant.WebhookDeliveryStatusPending {
+		t.Errorf("GetDeliveries() = %+v, %v, want the failed delivery kept", deliveries, err)
+	}
+}
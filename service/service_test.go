@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/michaelyusak/go-helper/apperror"
+	hHelper "github.com/michaelyusak/go-helper/helper"
+	"github.com/michaelyusak/xyz-kredit-plus/audit"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/migration"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+)
+
+const (
+	testPassword = "@abcD1234"
+	// Transactions of the tests stay below it unless they test two-factor authentication
+	testMfaRequiredAboveOtr = 1000000
+)
+
+var testClient = entity.ClientInfo{IpAddress: "10.0.0.1", UserAgent: "test-agent/1.0"}
+
+func TestMain(m *testing.M) {
+	audit.SetKey("test")
+
+	os.Exit(m.Run())
+}
+
+// testEnv wires the services to the in-memory repositories
+type testEnv struct {
+	store            *repository.MemoryStore
+	db               *sql.DB
+	accountRepo      repository.AccountRepository
+	consumerRepo     repository.ConsumerRepository
+	accountLimitRepo repository.AccountLimitRepository
+	transactionRepo  repository.TransactionRepository
+	mediaRepo        repository.MediaRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	accountMfaRepo   repository.AccountMfaRepository
+	notifier         *recordingNotifier
+
+	account     *accountServiceImpl
+	consumer    *consumerServiceImpl
+	transaction *transactionServiceImpl
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+
+	env := &testEnv{
+		store:            store,
+		accountRepo:      repository.NewAccountRepositoryMemory(store),
+		consumerRepo:     repository.NewConsumerRepositoryMemory(store),
+		accountLimitRepo: repository.NewAccountLimitRepositoryMemory(store),
+		transactionRepo:  repository.NewTransactionRepositoryMemory(store),
+		mediaRepo:        repository.NewMediaRepositoryMemory(),
+		loginAttemptRepo: repository.NewLoginAttemptRepositoryMemory(store),
+		accountMfaRepo:   repository.NewAccountMfaRepositoryMemory(store),
+		notifier:         &recordingNotifier{},
+	}
+
+	env.wire(repository.NewMemoryTransaction(store), repository.NewRefreshTokenRepositoryMemory(store))
+
+	return env
+}
+
+// newSqliteTestEnv wires the services to the SQL repositories on a SQLite database migrated like in production.
+// Unlike the memory store it runs transactions concurrently, helpers reading env.store cannot be used with it.
+func newSqliteTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?_txlock=immediate&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", filepath.Join(t.TempDir(), "test.db"))
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migration.NewMigrator(repository.DriverSqlite, db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	driver := repository.DriverSqlite
+
+	env := &testEnv{
+		db:               db,
+		accountRepo:      repository.NewAccountRepository(driver, db),
+		consumerRepo:     repository.NewConsumerRepository(driver, db),
+		accountLimitRepo: repository.NewAccountLimitRepository(driver, db),
+		transactionRepo:  repository.NewTransactionRepository(driver, db),
+		mediaRepo:        repository.NewMediaRepositoryMemory(),
+		loginAttemptRepo: repository.NewLoginAttemptRepository(driver, db),
+		accountMfaRepo:   repository.NewAccountMfaRepository(driver, db),
+		notifier:         &recordingNotifier{},
+	}
+
+	env.wire(repository.NewSqlTransaction(db, driver), repository.NewRefreshTokenRepository(driver, db))
+
+	return env
+}
+
+// wire builds the services of the tests on the repositories of e
+func (e *testEnv) wire(transaction repository.Transaction, refreshTokenRepo repository.RefreshTokenRepository) {
+	hash := hHelper.NewHashHelper(hHelper.HashConfig{HashCost: 4})
+	jwtHelper := hHelper.NewJWTHelper(hHelper.JwtConfig{Issuer: "test", Key: "test"}, jwt.SigningMethodHS512)
+
+	e.account = NewAccountService(transaction, hash, jwtHelper, e.accountRepo, e.consumerRepo, refreshTokenRepo,
+		e.loginAttemptRepo, e.accountMfaRepo, e.notifier, LoginProtectionOpt{
+			AccountMaxAttempts: 5,
+			IpMaxAttempts:      8,
+			DelayAfterAttempts: 5,
+			BaseDelay:          time.Millisecond,
+			MaxDelay:           time.Millisecond,
+			Lockout:            time.Hour,
+			Window:             time.Hour,
+		}, EmailVerificationOpt{
+			Key:            []byte("test"),
+			TokenTtl:       time.Hour,
+			ResendCooldown: time.Minute,
+			LinkUrl:        "http://localhost:5173/verify-email",
+		}, PasswordResetOpt{
+			Key:            []byte("test"),
+			TokenTtl:       time.Hour,
+			ResendCooldown: time.Minute,
+			LinkUrl:        "http://localhost:5173/reset-password",
+		}, MfaOpt{
+			Key:          []byte("test"),
+			ChallengeTtl: time.Minute,
+		}, AccountClosureOpt{
+			AnonymiseAfter: 24 * time.Hour,
+		})
+	e.consumer = NewConsumerService(transaction, e.consumerRepo, e.mediaRepo, e.accountLimitRepo)
+	e.transaction = NewTransactionService(transaction, e.accountLimitRepo, e.transactionRepo, testMfaRequiredAboveOtr)
+}
+
+// recordingNotifier keeps every notification instead of delivering it
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+
+	return nil
+}
+
+func (n *recordingNotifier) sent() []entity.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]entity.Notification(nil), n.notifications...)
+}
+
+func (n *recordingNotifier) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = nil
+}
+
+// register an account and return its id, its verification email is discarded
+func (e *testEnv) register(t *testing.T, email string) int64 {
+	t.Helper()
+
+	_, err := e.account.RegisterAccount(context.Background(), entity.Account{Email: email, Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	e.notifier.reset()
+
+	account, err := e.accountRepo.GetAccountByEmail(context.Background(), email, false)
+	if err != nil || account == nil {
+		t.Fatalf("GetAccountByEmail() = %v, %v", account, err)
+	}
+
+	return account.Id
+}
+
+// register an account and complete its KYC with the given salary
+func (e *testEnv) registerWithKyc(t *testing.T, email string, salary int64) int64 {
+	t.Helper()
+
+	accountId := e.register(t, email)
+
+	err := e.consumer.ProcessKyc(context.Background(), testConsumer(t, accountId, salary))
+	if err != nil {
+		t.Fatalf("ProcessKyc() error = %v", err)
+	}
+
+	return accountId
+}
+
+func testPhoto(t *testing.T) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	if err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func testConsumer(t *testing.T, accountId, salary int64) entity.Consumer {
+	return entity.Consumer{
+		AccountId:         accountId,
+		IdentityNumber:    "3171011209900001",
+		FullName:          "user test",
+		LegalName:         "user test",
+		PlaceOfBirth:      "jakarta",
+		DateOfBirth:       "12-09-1990",
+		Salary:            salary,
+		IdentityCardPhoto: entity.Media{Base64: testPhoto(t)},
+		SelfiePhoto:       entity.Media{Base64: testPhoto(t)},
+	}
+}
+
+// assertAppError fails unless err is an *apperror.AppError with the given code
+func assertAppError(t *testing.T, err error, code int) *apperror.AppError {
+	t.Helper()
+
+	var appErr *apperror.AppError
+
+	if !errors.As(err, &appErr) {
+		t.Fatalf("error = %v, want app error with code %d", err, code)
+	}
+
+	if appErr.Code != code {
+		t.Fatalf("error code = %d, want %d | error: %v", appErr.Code, code, err)
+	}
+
+	return appErr
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+// Longest user agent stored with a session, in characters
+const maxUserAgentLength = 255
+
+// newSession describes the refresh token issued to the client. The device is told apart by its user agent, hashed so
+// comparing devices does not depend on how the user agent was truncated.
+func (s *accountServiceImpl) newSession(accountId, expiredAt int64, client entity.ClientInfo) entity.Session {
+	userAgent := strings.TrimSpace(client.UserAgent)
+
+	session := entity.Session{
+		AccountId: accountId,
+		UserAgent: userAgent,
+		IpAddress: client.IpAddress,
+		ExpiredAt: expiredAt,
+	}
+
+	if userAgent != "" {
+		session.DeviceHash = s.hash.HashSHA512(userAgent)
+	}
+
+	if runes := []rune(userAgent); len(runes) > maxUserAgentLength {
+		session.UserAgent = string(runes[:maxUserAgentLength])
+	}
+
+	return session
+}
+
+// newSessionKey is random, so the session of an access token cannot be guessed from the account
+func newSessionKey() (string, error) {
+	key := make([]byte, 16)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+func (s *accountServiceImpl) notifyNewDevice(ctx context.Context, account entity.Account, client entity.ClientInfo) {
+	device := client.UserAgent
+	if device == "" {
+		device = "an unknown device"
+	}
+
+	s.notifier.Notify(ctx, entity.Notification{
+		Recipient: account.Email,
+		Subject:   "New sign-in to your account",
+		Body:      fmt.Sprintf("Your XYZ Kredit Plus account was signed in to from a new device at %s: %s, from IP address %s. If it was not you, sign out that session and change your password right away.", time.Now().UTC().Format(time.RFC1123), device, client.IpAddress),
+	})
+}
+
+// GetSessions lists the sessions of the account that are still signed in
+func (s *accountServiceImpl) GetSessions(ctx context.Context, accountId int64) (*entity.Sessions, error) {
+	sessions, err := s.refreshTokenRepo.GetActiveSessions(ctx, accountId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][GetSessions][refreshTokenRepo.GetActiveSessions] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return &entity.Sessions{Sessions: sessions}, nil
+}
+
+// IsSessionActive reports whether the session an access token was issued with is still signed in, tokens issued without
+// a session key are not
+func (s *accountServiceImpl) IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error) {
+	if sessionKey == "" {
+		return false, nil
+	}
+
+	isActive, err := s.refreshTokenRepo.IsSessionActive(ctx, accountId, sessionKey)
+	if err != nil {
+		return false, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][IsSessionActive][refreshTokenRepo.IsSessionActive] Error: %s | account_id: %v", err.Error(), accountId),
+		})
+	}
+
+	return isActive, nil
+}
+
+// RevokeSession signs out a session of the account by revoking its refresh token, its access token is rejected from
+// then on.
+func (s *accountServiceImpl) RevokeSession(ctx context.Context, accountId, sessionId int64) error {
+	isRevoked, err := s.refreshTokenRepo.RevokeSession(ctx, accountId, sessionId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[account_service][RevokeSession][refreshTokenRepo.RevokeSession] Error: %s | account_id: %v | session_id: %v", err.Error(), accountId, sessionId),
+		})
+	}
+	if !isRevoked {
+		return apperror.NotFoundError()
+	}
+
+	return nil
+}
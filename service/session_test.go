@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func (e *testEnv) sessions(t *testing.T, accountId int64) []entity.Session {
+	t.Helper()
+
+	sessions, err := e.account.GetSessions(context.Background(), accountId)
+	if err != nil {
+		t.Fatalf("GetSessions() error = %v", err)
+	}
+
+	return sessions.Sessions
+}
+
+// isSessionActive reports whether the session the access token was issued with is still signed in
+func (e *testEnv) isSessionActive(t *testing.T, accountId int64, token *entity.TokenData) bool {
+	t.Helper()
+
+	claimsBytes, err := e.account.jwt.ParseAndVerify(token.AccessToken.Token)
+	if err != nil {
+		t.Fatalf("ParseAndVerify() error = %v", err)
+	}
+
+	var claims entity.JwtClaims
+
+	err = json.Unmarshal(claimsBytes, &claims)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	isActive, err := e.account.IsSessionActive(context.Background(), accountId, claims.SessionKey)
+	if err != nil {
+		t.Fatalf("IsSessionActive() error = %v", err)
+	}
+
+	return isActive
+}
+
+func TestSessions_NewDeviceAlert(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.register(t, "user@example.com")
+
+	_, err := env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if sent := env.notifier.sent(); len(sent) != 0 {
+		t.Errorf("notifications = %+v, want none for the device the account registered from", sent)
+	}
+
+	phone := entity.ClientInfo{IpAddress: "10.0.0.2", UserAgent: "phone-agent/2.0"}
+
+	for i := 0; i < 2; i++ {
+		_, err = env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, phone)
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+	}
+
+	sent := env.notifier.sent()
+	if len(sent) != 1 || sent[0].Recipient != "user@example.com" || !strings.Contains(sent[0].Body, phone.UserAgent) || !strings.Contains(sent[0].Body, phone.IpAddress) {
+		t.Errorf("notifications = %+v, want one alert naming the new device", sent)
+	}
+
+	sessions := env.sessions(t, accountId)
+	if len(sessions) != 4 || sessions[0].UserAgent != phone.UserAgent || sessions[0].IpAddress != phone.IpAddress || sessions[3].UserAgent != testClient.UserAgent {
+		t.Errorf("sessions = %+v, want the registration and 3 logins, newest first", sessions)
+	}
+}
+
+func TestSessions_NoAlertWithoutKnownDevice(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Like an account created by the cli, or sessions issued before devices were stored
+	_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, entity.ClientInfo{})
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	env.notifier.reset()
+
+	_, err = env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if sent := env.notifier.sent(); len(sent) != 0 {
+		t.Errorf("notifications = %+v, want none without a known device", sent)
+	}
+}
+
+func TestSessions_RevokeSession(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.register(t, "user@example.com")
+	otherId := env.register(t, "other@example.com")
+
+	session := env.sessions(t, accountId)[0]
+
+	err := env.account.RevokeSession(context.Background(), otherId, session.Id)
+	assertAppError(t, err, http.StatusNotFound)
+
+	err = env.account.RevokeSession(context.Background(), accountId, session.Id)
+	if err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+
+	if sessions := env.sessions(t, accountId); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want the revoked session left out", sessions)
+	}
+
+	err = env.account.RevokeSession(context.Background(), accountId, session.Id)
+	assertAppError(t, err, http.StatusNotFound)
+
+	if sessions := env.sessions(t, otherId); len(sessions) != 1 {
+		t.Errorf("sessions of the other account = %+v, want it kept", sessions)
+	}
+}
+
+// The access token of a session is rejected once the session is signed out or the password changed
+func TestSessions_IsSessionActive(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.register(t, "user@example.com")
+
+	login := func() *entity.TokenData {
+		token, err := env.account.Login(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, testClient)
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+
+		return token.TokenData
+	}
+
+	revoked, kept := login(), login()
+
+	if !env.isSessionActive(t, accountId, revoked) || !env.isSessionActive(t, accountId, kept) {
+		t.Fatal("IsSessionActive() = false, want the sessions of the logins active")
+	}
+
+	// Newest first, the session of the first login is second
+	err := env.account.RevokeSession(context.Background(), accountId, env.sessions(t, accountId)[1].Id)
+	if err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+
+	if env.isSessionActive(t, accountId, revoked) {
+		t.Error("IsSessionActive() of the revoked session = true, want false")
+	}
+	if !env.isSessionActive(t, accountId, kept) {
+		t.Error("IsSessionActive() of the other session = false, want it kept")
+	}
+
+	otherId := env.register(t, "other@example.com")
+	if env.isSessionActive(t, otherId, kept) {
+		t.Error("IsSessionActive() with the session of another account = true, want false")
+	}
+
+	changed, err := env.account.ChangePassword(context.Background(), accountId, entity.ChangePasswordReq{CurrentPassword: testPassword, NewPassword: "@newPass1234"}, testClient)
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+
+	if env.isSessionActive(t, accountId, kept) {
+		t.Error("IsSessionActive() after a password change = true, want the session signed out")
+	}
+	if !env.isSessionActive(t, accountId, changed) {
+		t.Error("IsSessionActive() of the session of the password change = false, want true")
+	}
+
+	// Tokens issued before sessions had keys
+	if isActive, err := env.account.IsSessionActive(context.Background(), accountId, ""); err != nil || isActive {
+		t.Errorf("IsSessionActive() without a key = %v, %v, want false", isActive, err)
+	}
+}
+
+func TestSessions_TruncatesUserAgent(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.account.RegisterAccount(context.Background(), entity.Account{Email: "user@example.com", Password: testPassword}, entity.ClientInfo{UserAgent: strings.Repeat("ü", maxUserAgentLength+10)})
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+
+	account, _ := env.accountRepo.GetAccountByEmail(context.Background(), "user@example.com", false)
+
+	sessions := env.sessions(t, account.Id)
+	if len(sessions) != 1 || len([]rune(sessions[0].UserAgent)) != maxUserAgentLength {
+		t.Errorf("sessions = %+v, want the user agent truncated to %d characters", sessions, maxUserAgentLength)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// signedTokenClaims is handed out by email, the purpose keeps a token of one flow from being used in another.
+// Tokens are stateless, single use is enforced by binding IssuedAt to a value stored with the account.
+type signedTokenClaims struct {
+	Purpose   string `json:"purpose"`
+	AccountId int64  `json:"account_id"`
+	IssuedAt  int64  `json:"issued_at"`
+	ExpiredAt int64  `json:"expired_at"`
+	// The record the token gives access to, e.g. a data export
+	SubjectId int64 `json:"subject_id,omitempty"`
+}
+
+// signToken encodes the claims as base64url(payload).base64url(HMAC-SHA256(payload))
+func signToken(key []byte, claims signedTokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("[signed_token][signToken][json.Marshal] error: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(key, encoded)), nil
+}
+
+// verifyToken returns the claims of a token signed with key for the purpose and not expired at now, nil otherwise
+func verifyToken(key []byte, purpose, token string, now int64) *signedTokenClaims {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil
+	}
+
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, tokenSignature(key, encoded)) {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+
+	var claims signedTokenClaims
+
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Purpose != purpose || claims.ExpiredAt <= now {
+		return nil
+	}
+
+	return &claims
+}
+
+func tokenSignature(key []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+
+	return mac.Sum(nil)
+}
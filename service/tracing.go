@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Services are wrapped to start a span for every method. Only ids are recorded as attributes, never personal data.
+
+const (
+	accountIdAttr     = attribute.Key("app.account_id")
+	transactionIdAttr = attribute.Key("app.transaction_id")
+	partnerIdAttr     = attribute.Key("app.partner_id")
+)
+
+type tracedAccountService struct {
+	next AccountService
+}
+
+func WithAccountTracing(next AccountService) AccountService {
+	return &tracedAccountService{next: next}
+}
+
+func (s *tracedAccountService) RegisterAccount(ctx context.Context, newAccount entity.Account, client entity.ClientInfo) (*entity.TokenData, error) {
+	ctx, span := tracing.Start(ctx, "account_service.RegisterAccount")
+
+	token, err := s.next.RegisterAccount(ctx, newAccount, client)
+	tracing.End(span, err)
+
+	return token, err
+}
+
+func (s *tracedAccountService) Login(ctx context.Context, account entity.Account, client entity.ClientInfo) (*entity.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "account_service.Login")
+
+	result, err := s.next.Login(ctx, account, client)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (s *tracedAccountService) DisableAccount(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "account_service.DisableAccount")
+
+	err := s.next.DisableAccount(ctx, email)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) UnlockLogin(ctx context.Context, email, clientIp string) error {
+	ctx, span := tracing.Start(ctx, "account_service.UnlockLogin")
+
+	err := s.next.UnlockLogin(ctx, email, clientIp)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) VerifyEmail(ctx context.Context, token string, client entity.ClientInfo) (*entity.TokenData, error) {
+	ctx, span := tracing.Start(ctx, "account_service.VerifyEmail")
+
+	tokenData, err := s.next.VerifyEmail(ctx, token, client)
+	tracing.End(span, err)
+
+	return tokenData, err
+}
+
+func (s *tracedAccountService) ResendVerificationEmail(ctx context.Context, accountId int64) error {
+	ctx, span := tracing.Start(ctx, "account_service.ResendVerificationEmail", accountIdAttr.Int64(accountId))
+
+	err := s.next.ResendVerificationEmail(ctx, accountId)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) ForgotPassword(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "account_service.ForgotPassword")
+
+	err := s.next.ForgotPassword(ctx, email)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "account_service.ResetPassword")
+
+	err := s.next.ResetPassword(ctx, token, newPassword)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) ChangePassword(ctx context.Context, accountId int64, req entity.ChangePasswordReq, client entity.ClientInfo) (*entity.TokenData, error) {
+	ctx, span := tracing.Start(ctx, "account_service.ChangePassword", accountIdAttr.Int64(accountId))
+
+	token, err := s.next.ChangePassword(ctx, accountId, req, client)
+	tracing.End(span, err)
+
+	return token, err
+}
+
+func (s *tracedAccountService) EnrollMfa(ctx context.Context, accountId int64) (*entity.MfaEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "account_service.EnrollMfa", accountIdAttr.Int64(accountId))
+
+	enrollment, err := s.next.EnrollMfa(ctx, accountId)
+	tracing.End(span, err)
+
+	return enrollment, err
+}
+
+func (s *tracedAccountService) ConfirmMfa(ctx context.Context, accountId int64, code string) (*entity.MfaRecoveryCodes, error) {
+	ctx, span := tracing.Start(ctx, "account_service.ConfirmMfa", accountIdAttr.Int64(accountId))
+
+	recoveryCodes, err := s.next.ConfirmMfa(ctx, accountId, code)
+	tracing.End(span, err)
+
+	return recoveryCodes, err
+}
+
+func (s *tracedAccountService) CompleteMfaLogin(ctx context.Context, challengeToken, code string, client entity.ClientInfo) (*entity.TokenData, error) {
+	ctx, span := tracing.Start(ctx, "account_service.CompleteMfaLogin")
+
+	token, err := s.next.CompleteMfaLogin(ctx, challengeToken, code, client)
+	tracing.End(span, err)
+
+	return token, err
+}
+
+func (s *tracedAccountService) GetSessions(ctx context.Context, accountId int64) (*entity.Sessions, error) {
+	ctx, span := tracing.Start(ctx, "account_service.GetSessions", accountIdAttr.Int64(accountId))
+
+	sessions, err := s.next.GetSessions(ctx, accountId)
+	tracing.End(span, err)
+
+	return sessions, err
+}
+
+func (s *tracedAccountService) IsSessionActive(ctx context.Context, accountId int64, sessionKey string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "account_service.IsSessionActive", accountIdAttr.Int64(accountId))
+
+	isActive, err := s.next.IsSessionActive(ctx, accountId, sessionKey)
+	tracing.End(span, err)
+
+	return isActive, err
+}
+
+func (s *tracedAccountService) RevokeSession(ctx context.Context, accountId, sessionId int64) error {
+	ctx, span := tracing.Start(ctx, "account_service.RevokeSession", accountIdAttr.Int64(accountId), attribute.Int64("app.session_id", sessionId))
+
+	err := s.next.RevokeSession(ctx, accountId, sessionId)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedAccountService) CloseAccount(ctx context.Context, accountId int64, req entity.CloseAccountReq) (*entity.AccountClosure, error) {
+	ctx, span := tracing.Start(ctx, "account_service.CloseAccount", accountIdAttr.Int64(accountId))
+
+	closure, err := s.next.CloseAccount(ctx, accountId, req)
+	tracing.End(span, err)
+
+	return closure, err
+}
+
+func (s *tracedAccountService) AnonymiseClosedAccounts(ctx context.Context) (*entity.AnonymiseResult, error) {
+	ctx, span := tracing.Start(ctx, "account_service.AnonymiseClosedAccounts")
+
+	result, err := s.next.AnonymiseClosedAccounts(ctx)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+type tracedConsumerService struct {
+	next ConsumerService
+}
+
+func WithConsumerTracing(next ConsumerService) ConsumerService {
+	return &tracedConsumerService{next: next}
+}
+
+func (s *tracedConsumerService) ProcessKyc(ctx context.Context, consumerData entity.Consumer) error {
+	ctx, span := tracing.Start(ctx, "consumer_service.ProcessKyc", accountIdAttr.Int64(consumerData.AccountId))
+
+	err := s.next.ProcessKyc(ctx, consumerData)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedConsumerService) GetKycPhoto(ctx context.Context, accountId int64, photoTag, size string) (*entity.MediaContent, error) {
+	ctx, span := tracing.Start(ctx, "consumer_service.GetKycPhoto",
+		accountIdAttr.Int64(accountId),
+		attribute.String("app.photo_tag", photoTag),
+		attribute.String("app.photo_size", size),
+	)
+
+	content, err := s.next.GetKycPhoto(ctx, accountId, photoTag, size)
+	tracing.End(span, err)
+
+	return content, err
+}
+
+func (s *tracedConsumerService) UpdateProfile(ctx context.Context, accountId int64, req entity.UpdateConsumerProfileReq) error {
+	ctx, span := tracing.Start(ctx, "consumer_service.UpdateProfile", accountIdAttr.Int64(accountId))
+
+	err := s.next.UpdateProfile(ctx, accountId, req)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedConsumerService) ResubmitKyc(ctx context.Context, consumerData entity.Consumer) error {
+	ctx, span := tracing.Start(ctx, "consumer_service.ResubmitKyc", accountIdAttr.Int64(consumerData.AccountId))
+
+	err := s.next.ResubmitKyc(ctx, consumerData)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedConsumerService) ReviewKyc(ctx context.Context, accountId int64, isApproved bool) error {
+	ctx, span := tracing.Start(ctx, "consumer_service.ReviewKyc", accountIdAttr.Int64(accountId))
+
+	err := s.next.ReviewKyc(ctx, accountId, isApproved)
+	tracing.End(span, err)
+
+	return err
+}
+
+type tracedTransactionService struct {
+	next TransactionService
+}
+
+func WithTransactionTracing(next TransactionService) TransactionService {
+	return &tracedTransactionService{next: next}
+}
+
+func (s *tracedTransactionService) CreateTransaction(ctx context.Context, transaction entity.Transaction) (*entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "transaction_service.CreateTransaction",
+		accountIdAttr.Int64(transaction.AccountId),
+		attribute.Int("app.installment_months", transaction.InstallmentMonths),
+	)
+
+	created, err := s.next.CreateTransaction(ctx, transaction)
+	tracing.End(span, err)
+
+	return created, err
+}
+
+func (s *tracedTransactionService) CancelTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "transaction_service.CancelTransaction", transactionIdAttr.Int64(transactionId))
+
+	transaction, err := s.next.CancelTransaction(ctx, transactionId)
+	tracing.End(span, err)
+
+	return transaction, err
+}
+
+func (s *tracedTransactionService) PayOffTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "transaction_service.PayOffTransaction", transactionIdAttr.Int64(transactionId))
+
+	transaction, err := s.next.PayOffTransaction(ctx, transactionId)
+	tracing.End(span, err)
+
+	return transaction, err
+}
+
+type tracedMediaService struct {
+	next MediaService
+}
+
+func WithMediaTracing(next MediaService) MediaService {
+	return &tracedMediaService{next: next}
+}
+
+func (s *tracedMediaService) SweepOrphans(ctx context.Context) (*entity.MediaSweepResult, error) {
+	ctx, span := tracing.Start(ctx, "media_service.SweepOrphans")
+
+	result, err := s.next.SweepOrphans(ctx)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+type tracedDataExportService struct {
+	next DataExportService
+}
+
+func WithDataExportTracing(next DataExportService) DataExportService {
+	return &tracedDataExportService{next: next}
+}
+
+func (s *tracedDataExportService) RequestExport(ctx context.Context, accountId int64, requestedBy string) (*entity.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "data_export_service.RequestExport", accountIdAttr.Int64(accountId), attribute.String("app.requested_by", requestedBy))
+
+	export, err := s.next.RequestExport(ctx, accountId, requestedBy)
+	tracing.End(span, err)
+
+	return export, err
+}
+
+func (s *tracedDataExportService) GetExports(ctx context.Context, accountId int64) (*entity.DataExports, error) {
+	ctx, span := tracing.Start(ctx, "data_export_service.GetExports", accountIdAttr.Int64(accountId))
+
+	exports, err := s.next.GetExports(ctx, accountId)
+	tracing.End(span, err)
+
+	return exports, err
+}
+
+func (s *tracedDataExportService) ProcessExports(ctx context.Context) (*entity.DataExportResult, error) {
+	ctx, span := tracing.Start(ctx, "data_export_service.ProcessExports")
+
+	result, err := s.next.ProcessExports(ctx)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (s *tracedDataExportService) GenerateExport(ctx context.Context, exportId int64) (*entity.DataExport, error) {
+	ctx, span := tracing.Start(ctx, "data_export_service.GenerateExport", attribute.Int64("app.data_export_id", exportId))
+
+	export, err := s.next.GenerateExport(ctx, exportId)
+	tracing.End(span, err)
+
+	return export, err
+}
+
+func (s *tracedDataExportService) DownloadExport(ctx context.Context, token string) (*entity.MediaContent, error) {
+	ctx, span := tracing.Start(ctx, "data_export_service.DownloadExport")
+
+	content, err := s.next.DownloadExport(ctx, token)
+	tracing.End(span, err)
+
+	return content, err
+}
+
+type tracedRetentionService struct {
+	next RetentionService
+}
+
+func WithRetentionTracing(next RetentionService) RetentionService {
+	return &tracedRetentionService{next: next}
+}
+
+func (s *tracedRetentionService) Run(ctx context.Context) (*entity.RetentionReport, error) {
+	ctx, span := tracing.Start(ctx, "retention_service.Run")
+
+	report, err := s.next.Run(ctx)
+	tracing.End(span, err)
+
+	return report, err
+}
+
+type tracedAuditService struct {
+	next AuditService
+}
+
+func WithAuditTracing(next AuditService) AuditService {
+	return &tracedAuditService{next: next}
+}
+
+func (s *tracedAuditService) GetEvents(ctx context.Context, filter entity.AuditEventFilter) (*entity.AuditEvents, error) {
+	ctx, span := tracing.Start(ctx, "audit_service.GetEvents")
+
+	events, err := s.next.GetEvents(ctx, filter)
+	tracing.End(span, err)
+
+	return events, err
+}
+
+func (s *tracedAuditService) VerifyChain(ctx context.Context) (*entity.AuditVerification, error) {
+	ctx, span := tracing.Start(ctx, "audit_service.VerifyChain")
+
+	verification, err := s.next.VerifyChain(ctx)
+	tracing.End(span, err)
+
+	return verification, err
+}
+
+type tracedOutboxService struct {
+	next OutboxService
+}
+
+func WithOutboxTracing(next OutboxService) OutboxService {
+	return &tracedOutboxService{next: next}
+}
+
+func (s *tracedOutboxService) Relay(ctx context.Context) (*entity.OutboxRelayResult, error) {
+	ctx, span := tracing.Start(ctx, "outbox_service.Relay")
+
+	result, err := s.next.Relay(ctx)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+type tracedWebhookService struct {
+	next WebhookService
+}
+
+func WithWebhookTracing(next WebhookService) WebhookService {
+	return &tracedWebhookService{next: next}
+}
+
+func (s *tracedWebhookService) CreatePartner(ctx context.Context, req entity.CreatePartnerReq) (*entity.CreatedPartner, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.CreatePartner")
+
+	partner, err := s.next.CreatePartner(ctx, req)
+	tracing.End(span, err)
+
+	return partner, err
+}
+
+func (s *tracedWebhookService) GetPartners(ctx context.Context) (*entity.Partners, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.GetPartners")
+
+	partners, err := s.next.GetPartners(ctx)
+	tracing.End(span, err)
+
+	return partners, err
+}
+
+func (s *tracedWebhookService) CreateWebhook(ctx context.Context, partnerId int64, req entity.CreatePartnerWebhookReq) (*entity.CreatedPartnerWebhook, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.CreateWebhook", partnerIdAttr.Int64(partnerId))
+
+	webhook, err := s.next.CreateWebhook(ctx, partnerId, req)
+	tracing.End(span, err)
+
+	return webhook, err
+}
+
+func (s *tracedWebhookService) GetWebhooks(ctx context.Context, partnerId int64) (*entity.PartnerWebhooks, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.GetWebhooks", partnerIdAttr.Int64(partnerId))
+
+	webhooks, err := s.next.GetWebhooks(ctx, partnerId)
+	tracing.End(span, err)
+
+	return webhooks, err
+}
+
+func (s *tracedWebhookService) DeleteWebhook(ctx context.Context, partnerId, webhookId int64) error {
+	ctx, span := tracing.Start(ctx, "webhook_service.DeleteWebhook", partnerIdAttr.Int64(partnerId), attribute.Int64("app.webhook_id", webhookId))
+
+	err := s.next.DeleteWebhook(ctx, partnerId, webhookId)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedWebhookService) GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) (*entity.WebhookDeliveries, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.GetDeliveries")
+
+	deliveries, err := s.next.GetDeliveries(ctx, filter)
+	tracing.End(span, err)
+
+	return deliveries, err
+}
+
+func (s *tracedWebhookService) Redeliver(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.Redeliver", attribute.Int64("app.delivery_id", deliveryId))
+
+	delivery, err := s.next.Redeliver(ctx, deliveryId)
+	tracing.End(span, err)
+
+	return delivery, err
+}
+
+func (s *tracedWebhookService) Dispatch(ctx context.Context) (*entity.WebhookDispatchResult, error) {
+	ctx, span := tracing.Start(ctx, "webhook_service.Dispatch")
+
+	result, err := s.next.Dispatch(ctx)
+	tracing.End(span, err)
+
+	return result, err
+}
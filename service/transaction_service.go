@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
 	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
 	"github.com/michaelyusak/xyz-kredit-plus/repository"
 )
 
@@ -13,33 +17,41 @@ type transactionServiceImpl struct {
 	transaction      repository.Transaction
 	accountLimitRepo repository.AccountLimitRepository
 	transactionRepo  repository.TransactionRepository
+	// Transactions with a greater OTR need a token issued after a second factor was checked, 0 never requires it
+	mfaRequiredAboveOtr float64
 }
 
-func NewTransactionService(transaction repository.Transaction, accountLimitRepos repository.AccountLimitRepository, transactionRepo repository.TransactionRepository) *transactionServiceImpl {
+func NewTransactionService(transaction repository.Transaction, accountLimitRepos repository.AccountLimitRepository, transactionRepo repository.TransactionRepository, mfaRequiredAboveOtr float64) *transactionServiceImpl {
 	return &transactionServiceImpl{
-		transaction:      transaction,
-		accountLimitRepo: accountLimitRepos,
-		transactionRepo:  transactionRepo,
+		transaction:         transaction,
+		accountLimitRepo:    accountLimitRepos,
+		transactionRepo:     transactionRepo,
+		mfaRequiredAboveOtr: mfaRequiredAboveOtr,
 	}
 }
 
-func (s *transactionServiceImpl) adjustLimit(limit entity.AccountLimit, otr float64, installemntMonths int) entity.AccountLimit {
-	var lim float64
-
+// limitOf is the limit of the installment months
+func limitOf(limit entity.AccountLimit, installemntMonths int) float64 {
 	switch installemntMonths {
 	case 1:
-		lim = limit.Limit1M
+		return limit.Limit1M
 
 	case 2:
-		lim = limit.Limit2M
+		return limit.Limit2M
 
 	case 3:
-		lim = limit.Limit3M
+		return limit.Limit3M
 
 	case 4:
-		lim = limit.Limit4M
+		return limit.Limit4M
 	}
 
+	return 0
+}
+
+func (s *transactionServiceImpl) adjustLimit(limit entity.AccountLimit, otr float64, installemntMonths int) entity.AccountLimit {
+	lim := limitOf(limit, installemntMonths)
+
 	discount := (lim - otr) / lim
 
 	newLimit := limit
@@ -52,31 +64,143 @@ func (s *transactionServiceImpl) adjustLimit(limit entity.AccountLimit, otr floa
 	return newLimit
 }
 
+// restoreLimit gives back the otr a transaction used, undoing adjustLimit. Every adjustment scales the limits alike, so
+// they are scaled back until the limit of the installment months grew by otr. A limit used up entirely lost its
+// proportions, they are taken from the limits granted by the KYC.
+func (s *transactionServiceImpl) restoreLimit(limit entity.AccountLimit, otr float64, installemntMonths int) entity.AccountLimit {
+	// The term is validated when the transaction is created, there is no other limit to give back
+	if installemntMonths < 1 || installemntMonths > len(limitReferences) {
+		return limit
+	}
+
+	lim := limitOf(limit, installemntMonths)
+
+	newLimit := limit
+
+	if lim <= 0 {
+		reference := limitReferences[installemntMonths-1]
+
+		newLimit.Limit1M = otr * limitReferences[0] / reference
+		newLimit.Limit2M = otr * limitReferences[1] / reference
+		newLimit.Limit3M = otr * limitReferences[2] / reference
+		newLimit.Limit4M = otr * limitReferences[3] / reference
+
+		return newLimit
+	}
+
+	ratio := (lim + otr) / lim
+
+	newLimit.Limit1M = limit.Limit1M * ratio
+	newLimit.Limit2M = limit.Limit2M * ratio
+	newLimit.Limit3M = limit.Limit3M * ratio
+	newLimit.Limit4M = limit.Limit4M * ratio
+
+	return newLimit
+}
+
 func (s *transactionServiceImpl) CreateTransaction(ctx context.Context, transaction entity.Transaction) (*entity.Transaction, error) {
-	err := s.transaction.Begin()
+	if s.mfaRequiredAboveOtr > 0 && transaction.OTR > s.mfaRequiredAboveOtr && !transaction.IsMfaAuthenticated {
+		return nil, apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[transaction_service][CreateTransaction] two-factor authentication required | account_id: %v | otr: %v", transaction.AccountId, transaction.OTR),
+			ResponseMessage: fmt.Sprintf("two-factor authentication required for an otr above %v", s.mfaRequiredAboveOtr),
+		})
+	}
+
+	tx, err := s.transaction.Begin()
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[transaction_service][CreateTransaction][transaction.Begin] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
 		})
 	}
 
-	accountLimitRepo := s.transaction.AccountLimitMysqlTx()
-	transactionRepo := s.transaction.TransactionMysqlTx()
+	accountRepo := tx.AccountTx()
+	consumerRepo := tx.ConsumerTx()
+	accountLimitRepo := tx.AccountLimitTx()
+	transactionRepo := tx.TransactionTx()
+	auditEventRepo := tx.AuditEventTx()
+	outboxRepo := tx.OutboxTx()
+	partnerRepo := tx.PartnerTx()
 
 	defer func() {
 		if err != nil {
-			s.transaction.Rollback()
+			tx.Rollback()
 		}
-
-		s.transaction.Commit()
 	}()
 
+	// Closing or disabling signs out the sessions of the account, a request authorised just before is refused here
+	account, err := accountRepo.GetAccountById(ctx, transaction.AccountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][accountRepo.GetAccountById] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+	if account == nil {
+		err = apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[transaction_service][CreateTransaction] account closed | account_id: %v", transaction.AccountId),
+			ResponseMessage: "account is closed",
+		})
+		return nil, err
+	}
+	if account.DisabledAt != nil {
+		err = apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[transaction_service][CreateTransaction] account disabled | account_id: %v", transaction.AccountId),
+			ResponseMessage: "account disabled",
+		})
+		return nil, err
+	}
+
+	// The is_kyc_completed claim is as old as the access token, a KYC resubmitted for review since it was issued is checked here
+	consumer, err := consumerRepo.GetConsumerByAccountId(ctx, transaction.AccountId, false)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][consumerRepo.GetConsumerByAccountId] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+	if consumer == nil || consumer.KycStatus != appconstant.KycStatusApproved {
+		err = apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusForbidden,
+			Message:         fmt.Sprintf("[transaction_service][CreateTransaction] kyc not approved | account_id: %v", transaction.AccountId),
+			ResponseMessage: "kyc process incomplete",
+		})
+		return nil, err
+	}
+
+	// A partner booking the transaction is called back about it
+	if transaction.PartnerApiKey != "" {
+		var partner *entity.Partner
+
+		partner, err = partnerRepo.GetPartnerByApiKeyHash(ctx, hashPartnerApiKey(transaction.PartnerApiKey))
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[transaction_service][CreateTransaction][partnerRepo.GetPartnerByApiKeyHash] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+			})
+		}
+		if partner == nil {
+			err = apperror.NewAppError(apperror.AppErrorOpt{
+				Code:            http.StatusUnauthorized,
+				Message:         fmt.Sprintf("[transaction_service][CreateTransaction] invalid partner api key | account_id: %v", transaction.AccountId),
+				ResponseMessage: "invalid partner api key",
+			})
+			return nil, err
+		}
+
+		transaction.PartnerId = &partner.Id
+	}
+
 	limit, err := accountLimitRepo.GetAccountLimitByAccountId(ctx, transaction.AccountId, true)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
 			Message: fmt.Sprintf("[transaction_service][CreateTransaction][accountLimitRepo.GetAccountLimitByAccountId] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
 		})
 	}
+	// Deleted when the account closed after it was read above
+	if limit == nil {
+		err = apperror.NotFoundError()
+		return nil, err
+	}
 
 	isLimitSufficient := false
 
@@ -101,15 +225,19 @@ func (s *transactionServiceImpl) CreateTransaction(ctx context.Context, transact
 			isLimitSufficient = true
 		}
 	default:
-		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
 			Message: "maximum installemnt months is 4",
 		})
+		return nil, err
 	}
 
 	if !isLimitSufficient {
-		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+		metrics.RecordLimitInsufficient(transaction.InstallmentMonths)
+
+		err = apperror.BadRequestError(apperror.AppErrorOpt{
 			ResponseMessage: "insufficient limit",
 		})
+		return nil, err
 	}
 
 	newLimit := s.adjustLimit(*limit, transaction.OTR, transaction.InstallmentMonths)
@@ -121,6 +249,17 @@ func (s *transactionServiceImpl) CreateTransaction(ctx context.Context, transact
 		})
 	}
 
+	err = appendAuditEvent(ctx, auditEventRepo, transaction.AccountId, appconstant.AuditActionLimitUpdated, appconstant.AuditEntityAccountLimit, transaction.AccountId, limitAuditDiff(limit, newLimit))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][appendAuditEvent][limit] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+
+	// The last installment is due after the term, the account cannot be closed before
+	transaction.DueAt = time.Now().AddDate(0, transaction.InstallmentMonths, 0).UnixMilli()
+	transaction.Status = appconstant.TransactionStatusApproved
+
 	transactionId, err := transactionRepo.InsertTransaction(ctx, transaction)
 	if err != nil {
 		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
@@ -130,5 +269,182 @@ func (s *transactionServiceImpl) CreateTransaction(ctx context.Context, transact
 
 	transaction.Id = transactionId
 
+	err = appendAuditEvent(ctx, auditEventRepo, transaction.AccountId, appconstant.AuditActionTransactionCreated, appconstant.AuditEntityTransaction, transactionId, auditDiff{}.
+		add("otr", nil, transaction.OTR).
+		add("installment_months", nil, transaction.InstallmentMonths).
+		add("admin_fee", nil, transaction.AdminFee).
+		add("total_installment", nil, transaction.TotalInstallemnt).
+		add("total_interest", nil, transaction.TotalInterest).
+		add("due_at", nil, transaction.DueAt))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][appendAuditEvent][transaction] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+
+	err = appendOutboxEvent(ctx, outboxRepo, appconstant.EventTypeTransactionCreated, appconstant.EventAggregateTransaction, transactionId, entity.TransactionCreatedEvent{
+		TransactionId:     transactionId,
+		AccountId:         transaction.AccountId,
+		ContactNumber:     transaction.ContactNumber,
+		OTR:               transaction.OTR,
+		InstallmentMonths: transaction.InstallmentMonths,
+		AdminFee:          transaction.AdminFee,
+		TotalInstallment:  transaction.TotalInstallemnt,
+		TotalInterest:     transaction.TotalInterest,
+		AssetName:         transaction.AssetName,
+		DueAt:             transaction.DueAt,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][appendOutboxEvent] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][transaction.Commit] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+
+	metrics.RecordTransactionCreated(transaction.InstallmentMonths, transaction.OTR)
+
+	err = queueWebhookDeliveries(ctx, s.transaction, transaction, appconstant.WebhookEventTransactionApproved)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][CreateTransaction][queueWebhookDeliveries] Error: %s | account_id: %v", err.Error(), transaction.AccountId),
+		})
+	}
+
 	return &transaction, nil
 }
+
+// CancelTransaction ends an approved transaction before it is paid off and gives back the limit it used
+func (s *transactionServiceImpl) CancelTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error) {
+	return s.changeStatus(ctx, transactionId, appconstant.TransactionStatusCancelled, appconstant.AuditActionTransactionCancelled, appconstant.WebhookEventTransactionCancelled, true)
+}
+
+// PayOffTransaction ends an approved transaction whose installments were all paid
+func (s *transactionServiceImpl) PayOffTransaction(ctx context.Context, transactionId int64) (*entity.Transaction, error) {
+	return s.changeStatus(ctx, transactionId, appconstant.TransactionStatusPaidOff, appconstant.AuditActionTransactionPaidOff, appconstant.WebhookEventTransactionPaidOff, false)
+}
+
+// changeStatus moves an approved transaction to status, giving back the limit it used when isLimitRestored, and calls
+// its partner back with webhookEvent
+func (s *transactionServiceImpl) changeStatus(ctx context.Context, transactionId int64, status, auditAction, webhookEvent string, isLimitRestored bool) (*entity.Transaction, error) {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][transaction.Begin] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	accountLimitRepo := tx.AccountLimitTx()
+	transactionRepo := tx.TransactionTx()
+	auditEventRepo := tx.AuditEventTx()
+	outboxRepo := tx.OutboxTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	transaction, err := transactionRepo.GetTransactionById(ctx, transactionId, true)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][transactionRepo.GetTransactionById] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+	if transaction == nil {
+		err = apperror.NotFoundError()
+		return nil, err
+	}
+
+	if transaction.Status != appconstant.TransactionStatusApproved {
+		err = apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusConflict,
+			Message:         fmt.Sprintf("[transaction_service][changeStatus] transaction not approved | transaction_id: %v | status: %s", transactionId, transaction.Status),
+			ResponseMessage: fmt.Sprintf("transaction is %s", transaction.Status),
+		})
+		return nil, err
+	}
+
+	err = transactionRepo.UpdateStatus(ctx, transactionId, status)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][transactionRepo.UpdateStatus] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, transaction.AccountId, auditAction, appconstant.AuditEntityTransaction, transactionId, auditDiff{}.
+		add("status", transaction.Status, status))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][appendAuditEvent] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	transaction.Status = status
+
+	if isLimitRestored {
+		err = s.giveLimitBack(ctx, accountLimitRepo, auditEventRepo, *transaction)
+		if err != nil {
+			return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[transaction_service][changeStatus][giveLimitBack] Error: %s | transaction_id: %v", err.Error(), transactionId),
+			})
+		}
+	}
+
+	err = appendOutboxEvent(ctx, outboxRepo, appconstant.EventTypeTransactionStatusChanged, appconstant.EventAggregateTransaction, transactionId, entity.TransactionStatusChangedEvent{
+		TransactionId: transactionId,
+		AccountId:     transaction.AccountId,
+		Status:        status,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][appendOutboxEvent] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][transaction.Commit] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	err = queueWebhookDeliveries(ctx, s.transaction, *transaction, webhookEvent)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[transaction_service][changeStatus][queueWebhookDeliveries] Error: %s | transaction_id: %v", err.Error(), transactionId),
+		})
+	}
+
+	return transaction, nil
+}
+
+// giveLimitBack restores the limit a transaction used, an account closed since has no limit left to restore
+func (s *transactionServiceImpl) giveLimitBack(ctx context.Context, accountLimitRepo repository.AccountLimitRepository, auditEventRepo repository.AuditEventRepository, transaction entity.Transaction) error {
+	limit, err := accountLimitRepo.GetAccountLimitByAccountId(ctx, transaction.AccountId, true)
+	if err != nil {
+		return fmt.Errorf("[transaction_service][giveLimitBack][accountLimitRepo.GetAccountLimitByAccountId] Error: %w", err)
+	}
+	if limit == nil {
+		return nil
+	}
+
+	newLimit := s.restoreLimit(*limit, transaction.OTR, transaction.InstallmentMonths)
+
+	err = accountLimitRepo.UpdateLimit(ctx, newLimit)
+	if err != nil {
+		return fmt.Errorf("[transaction_service][giveLimitBack][accountLimitRepo.UpdateLimit] Error: %w", err)
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, transaction.AccountId, appconstant.AuditActionLimitUpdated, appconstant.AuditEntityAccountLimit, transaction.AccountId, limitAuditDiff(limit, newLimit))
+	if err != nil {
+		return fmt.Errorf("[transaction_service][giveLimitBack][appendAuditEvent] Error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+)
+
+func testTransaction(accountId int64, otr float64, installmentMonths int) entity.Transaction {
+	return entity.Transaction{
+		AccountId:         accountId,
+		ContactNumber:     "081312341234",
+		OTR:               otr,
+		InstallmentMonths: installmentMonths,
+		AdminFee:          10000,
+		TotalInstallemnt:  otr * 1.1,
+		TotalInterest:     otr * 0.1,
+		AssetName:         "smartphone",
+	}
+}
+
+func TestTransactionService_CreateTransaction(t *testing.T) {
+	t.Run("should create transaction and reduce limit", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		// Limits are 600000, 800000, 1000000 and 1200000
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		transaction, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+		if err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+
+		if transaction.Id == 0 {
+			t.Error("CreateTransaction() returned no id")
+		}
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit.Limit2M != 600000 || limit.Limit1M != 450000 {
+			t.Errorf("limit = %+v, want 1 month 450000 and 2 months 600000", *limit)
+		}
+
+		transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+		if len(transactions) != 1 {
+			t.Errorf("transactions = %d, want 1", len(transactions))
+		}
+	})
+
+	t.Run("should reject insufficient limit and keep limit", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 700000, 1))
+		appErr := assertAppError(t, err, http.StatusBadRequest)
+
+		if appErr.ResponseMessage != "insufficient limit" {
+			t.Errorf("response message = %q, want %q", appErr.ResponseMessage, "insufficient limit")
+		}
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit.Limit1M != 600000 {
+			t.Errorf("limit 1 month = %v, want unchanged 600000", limit.Limit1M)
+		}
+
+		transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+		if len(transactions) != 0 {
+			t.Errorf("transactions = %d, want none", len(transactions))
+		}
+	})
+
+	t.Run("should reject invalid installment months", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 100000, 5))
+		assertAppError(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("should reject a KYC resubmitted after the token was issued", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		err := env.consumer.ResubmitKyc(context.Background(), testConsumer(t, accountId, 600000))
+		if err != nil {
+			t.Fatalf("ResubmitKyc() error = %v", err)
+		}
+
+		_, err = env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 100000, 1))
+		appErr := assertAppError(t, err, http.StatusForbidden)
+
+		if appErr.ResponseMessage != "kyc process incomplete" {
+			t.Errorf("response message = %q, want %q", appErr.ResponseMessage, "kyc process incomplete")
+		}
+
+		transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+		if len(transactions) != 0 {
+			t.Errorf("transactions = %d, want none", len(transactions))
+		}
+	})
+
+	t.Run("should book duplicate submissions against the remaining limit", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		// The same transaction submitted twice, the second one no longer fits the reduced limit
+		_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 400000, 1))
+		if err != nil {
+			t.Fatalf("CreateTransaction() first error = %v", err)
+		}
+
+		_, err = env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 400000, 1))
+		assertAppError(t, err, http.StatusBadRequest)
+
+		transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+		if len(transactions) != 1 {
+			t.Errorf("transactions = %d, want 1", len(transactions))
+		}
+	})
+
+	t.Run("should not exceed limit with concurrent submissions", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		errs := make(chan error, 10)
+
+		for i := 0; i < 10; i++ {
+			go func() {
+				_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 250000, 1))
+				errs <- err
+			}()
+		}
+
+		created := 0
+
+		for i := 0; i < 10; i++ {
+			if err := <-errs; err == nil {
+				created++
+			}
+		}
+
+		transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+		if len(transactions) != created {
+			t.Errorf("transactions = %d, want %d", len(transactions), created)
+		}
+
+		limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if limit.Limit1M < 0 {
+			t.Errorf("limit 1 month = %v, want not negative", limit.Limit1M)
+		}
+	})
+}
+
+func TestTransactionService_ChangeStatus(t *testing.T) {
+	t.Run("should cancel an approved transaction once", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+		before, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+
+		created, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+		if err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+
+		if created.Status != appconstant.TransactionStatusApproved {
+			t.Errorf("status = %q, want %q", created.Status, appconstant.TransactionStatusApproved)
+		}
+
+		transaction, err := env.transaction.CancelTransaction(context.Background(), created.Id)
+		if err != nil {
+			t.Fatalf("CancelTransaction() error = %v", err)
+		}
+
+		if transaction.Status != appconstant.TransactionStatusCancelled {
+			t.Errorf("status = %q, want %q", transaction.Status, appconstant.TransactionStatusCancelled)
+		}
+
+		// The limit the transaction used is given back
+		after, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+		if after.Limit2M != before.Limit2M || after.Limit4M != before.Limit4M {
+			t.Errorf("limit = %+v, want %+v", *after, *before)
+		}
+
+		_, err = env.transaction.CancelTransaction(context.Background(), created.Id)
+		assertAppError(t, err, http.StatusConflict)
+
+		_, err = env.transaction.PayOffTransaction(context.Background(), created.Id)
+		assertAppError(t, err, http.StatusConflict)
+
+		// A cancelled transaction has no installments due, so it no longer holds back the closure of the account
+		hasOutstanding, err := env.transactionRepo.HasOutstandingTransactions(context.Background(), accountId)
+		if err != nil || hasOutstanding {
+			t.Errorf("HasOutstandingTransactions() = %v, %v, want false", hasOutstanding, err)
+		}
+	})
+
+	t.Run("should pay off an approved transaction", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+		created, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 200000, 2))
+		if err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+
+		transaction, err := env.transaction.PayOffTransaction(context.Background(), created.Id)
+		if err != nil {
+			t.Fatalf("PayOffTransaction() error = %v", err)
+		}
+
+		if transaction.Status != appconstant.TransactionStatusPaidOff {
+			t.Errorf("status = %q, want %q", transaction.Status, appconstant.TransactionStatusPaidOff)
+		}
+	})
+
+	t.Run("should reject unknown transaction", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		_, err := env.transaction.CancelTransaction(context.Background(), 42)
+		assertAppError(t, err, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,698 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michaelyusak/go-helper/apperror"
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/metrics"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/webhook"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 200
+)
+
+// Event types a webhook can subscribe to
+var webhookEventTypes = []string{
+	appconstant.WebhookEventTransactionApproved,
+	appconstant.WebhookEventTransactionCancelled,
+	appconstant.WebhookEventTransactionPaidOff,
+}
+
+// hashPartnerApiKey is not salted, the keys are random so they cannot be guessed from a leaked hash
+func hashPartnerApiKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// newWebhookCredential is a random api key or signing secret with a prefix telling which one it is
+func newWebhookCredential(prefix string) (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// appendWebhookDeliveries queues the event for every webhook of the partner of the transaction subscribed to it
+func appendWebhookDeliveries(ctx context.Context, webhookRepo repository.PartnerWebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, transaction entity.Transaction, eventType string) error {
+	if transaction.PartnerId == nil {
+		return nil
+	}
+
+	webhooks, err := webhookRepo.GetWebhooksByPartnerId(ctx, *transaction.PartnerId)
+	if err != nil {
+		return fmt.Errorf("[webhook_service][appendWebhookDeliveries][webhookRepo.GetWebhooksByPartnerId] Error: %w | transaction_id: %v", err, transaction.Id)
+	}
+
+	payload, err := json.Marshal(entity.WebhookPayload{
+		EventType:  eventType,
+		OccurredAt: time.Now().UnixMilli(),
+		Data: entity.WebhookTransaction{
+			TransactionId:    transaction.Id,
+			PartnerReference: transaction.PartnerReference,
+			Status:           transaction.Status,
+			OTR:              transaction.OTR,
+			AdminFee:         transaction.AdminFee,
+			TotalInstallment: transaction.TotalInstallemnt,
+			TotalInterest:    transaction.TotalInterest,
+			AssetName:        transaction.AssetName,
+			DueAt:            transaction.DueAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("[webhook_service][appendWebhookDeliveries][json.Marshal] Error: %w | transaction_id: %v", err, transaction.Id)
+	}
+
+	for _, w := range webhooks {
+		if !slices.Contains(w.EventTypes, eventType) {
+			continue
+		}
+
+		_, err = deliveryRepo.InsertDelivery(ctx, entity.WebhookDelivery{
+			WebhookId: w.Id,
+			PartnerId: w.PartnerId,
+			EventType: eventType,
+			Payload:   payload,
+		})
+		if err != nil {
+			return fmt.Errorf("[webhook_service][appendWebhookDeliveries][deliveryRepo.InsertDelivery] Error: %w | transaction_id: %v", err, transaction.Id)
+		}
+	}
+
+	return nil
+}
+
+// queueWebhookDeliveries runs appendWebhookDeliveries in its own transaction, once the change is committed, so the
+// partner is only called back about changes that were committed
+func queueWebhookDeliveries(ctx context.Context, transaction repository.Transaction, t entity.Transaction, eventType string) error {
+	if t.PartnerId == nil {
+		return nil
+	}
+
+	tx, err := transaction.Begin()
+	if err != nil {
+		return fmt.Errorf("[webhook_service][queueWebhookDeliveries][transaction.Begin] Error: %w | transaction_id: %v", err, t.Id)
+	}
+
+	err = appendWebhookDeliveries(ctx, tx.PartnerWebhookTx(), tx.WebhookDeliveryTx(), t, eventType)
+	if err != nil {
+		tx.Rollback()
+
+		return fmt.Errorf("[webhook_service][queueWebhookDeliveries][appendWebhookDeliveries] Error: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("[webhook_service][queueWebhookDeliveries][transaction.Commit] Error: %w | transaction_id: %v", err, t.Id)
+	}
+
+	return nil
+}
+
+type WebhookOpt struct {
+	// Encrypts the secrets of the webhooks
+	Key []byte
+	// Looks up the host of a webhook url when it is created
+	Resolver webhook.Resolver
+	// Deliveries loaded per query by the dispatcher
+	BatchSize int
+	// Wait after the first failed attempt, doubled after every further failure up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// A delivery is given up as dead after this many failed attempts
+	MaxAttempts int
+	// A delivery being sent is skipped by other dispatchers this long, it is sent again when the dispatcher sending it
+	// stopped
+	Lease time.Duration
+}
+
+func (o WebhookOpt) withDefaults() WebhookOpt {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 6 * time.Hour
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.Lease <= 0 {
+		o.Lease = time.Minute
+	}
+	if o.Resolver == nil {
+		o.Resolver = net.DefaultResolver
+	}
+
+	return o
+}
+
+// Prefix of the secrets of webhooks, secrets stored before they were encrypted still start with it
+const webhookSecretPrefix = "whsec_"
+
+func (s *webhookServiceImpl) webhookSecretKey() []byte {
+	mac := hmac.New(sha256.New, s.opt.Key)
+	mac.Write([]byte("webhook_secret"))
+
+	return mac.Sum(nil)
+}
+
+func (s *webhookServiceImpl) encryptWebhookSecret(secret string) (string, error) {
+	block, err := aes.NewCipher(s.webhookSecretKey())
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][encryptWebhookSecret][aes.NewCipher] Error: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][encryptWebhookSecret][cipher.NewGCM] Error: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][encryptWebhookSecret][rand.Read] Error: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// decryptWebhookSecret returns a secret stored before secrets were encrypted as is, base64 never holds its prefix
+func (s *webhookServiceImpl) decryptWebhookSecret(encrypted string) (string, error) {
+	if strings.HasPrefix(encrypted, webhookSecretPrefix) {
+		return encrypted, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][decryptWebhookSecret][base64.DecodeString] Error: %w", err)
+	}
+
+	block, err := aes.NewCipher(s.webhookSecretKey())
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][decryptWebhookSecret][aes.NewCipher] Error: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][decryptWebhookSecret][cipher.NewGCM] Error: %w", err)
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("[webhook_service][decryptWebhookSecret] ciphertext too short")
+	}
+
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("[webhook_service][decryptWebhookSecret][gcm.Open] Error: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+type webhookServiceImpl struct {
+	transaction  repository.Transaction
+	partnerRepo  repository.PartnerRepository
+	webhookRepo  repository.PartnerWebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	sender       webhook.Sender
+	opt          WebhookOpt
+}
+
+func NewWebhookService(transaction repository.Transaction, partnerRepo repository.PartnerRepository, webhookRepo repository.PartnerWebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, sender webhook.Sender, opt WebhookOpt) *webhookServiceImpl {
+	return &webhookServiceImpl{
+		transaction:  transaction,
+		partnerRepo:  partnerRepo,
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		opt:          opt.withDefaults(),
+	}
+}
+
+// CreatePartner returns the api key of the partner, only its hash is kept so it cannot be shown again
+func (s *webhookServiceImpl) CreatePartner(ctx context.Context, req entity.CreatePartnerReq) (*entity.CreatedPartner, error) {
+	apiKey, err := newWebhookCredential("xkp_")
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreatePartner][newWebhookCredential] Error: %s", err.Error()),
+		})
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreatePartner][transaction.Begin] Error: %s", err.Error()),
+		})
+	}
+
+	partnerRepo := tx.PartnerTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	partnerId, err := partnerRepo.InsertPartner(ctx, entity.Partner{
+		Name:       req.Name,
+		ApiKeyHash: hashPartnerApiKey(apiKey),
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreatePartner][partnerRepo.InsertPartner] Error: %s", err.Error()),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, 0, appconstant.AuditActionPartnerCreated, appconstant.AuditEntityPartner, partnerId, auditDiff{}.
+		add("name", nil, req.Name))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreatePartner][appendAuditEvent] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	partner, err := partnerRepo.GetPartnerById(ctx, partnerId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreatePartner][partnerRepo.GetPartnerById] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	return &entity.CreatedPartner{Partner: *partner, ApiKey: apiKey}, nil
+}
+
+func (s *webhookServiceImpl) GetPartners(ctx context.Context) (*entity.Partners, error) {
+	partners, err := s.partnerRepo.GetPartners(ctx)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][GetPartners][partnerRepo.GetPartners] Error: %s", err.Error()),
+		})
+	}
+
+	return &entity.Partners{Partners: partners}, nil
+}
+
+// CreateWebhook returns the secret signing the deliveries of the webhook, it is only shown once. A partner rotates it
+// by creating a new webhook and deleting the old one once it verifies both.
+func (s *webhookServiceImpl) CreateWebhook(ctx context.Context, partnerId int64, req entity.CreatePartnerWebhookReq) (*entity.CreatedPartnerWebhook, error) {
+	u, err := url.Parse(req.Url)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "url must be https",
+		})
+	}
+
+	// Deliveries are sent from inside the network, a webhook must not reach the private services next to the dispatcher
+	err = webhook.CheckHost(ctx, s.opt.Resolver, u.Hostname())
+	if err != nil {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			Message:         fmt.Sprintf("[webhook_service][CreateWebhook][webhook.CheckHost] Error: %s | partner_id: %v", err.Error(), partnerId),
+			ResponseMessage: "url must resolve to public addresses",
+		})
+	}
+
+	if len(req.EventTypes) == 0 {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: "subscribe to at least one event type",
+		})
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+				ResponseMessage: fmt.Sprintf("event type must be one of %s", strings.Join(webhookEventTypes, ", ")),
+			})
+		}
+	}
+
+	secret, err := newWebhookCredential(webhookSecretPrefix)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][newWebhookCredential] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	encryptedSecret, err := s.encryptWebhookSecret(secret)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][encryptWebhookSecret] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][transaction.Begin] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	partnerRepo := tx.PartnerTx()
+	webhookRepo := tx.PartnerWebhookTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	partner, err := partnerRepo.GetPartnerById(ctx, partnerId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][partnerRepo.GetPartnerById] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+	if partner == nil {
+		err = apperror.NotFoundError()
+		return nil, err
+	}
+
+	eventTypes := slices.Compact(slices.Sorted(slices.Values(req.EventTypes)))
+
+	webhookId, err := webhookRepo.InsertWebhook(ctx, entity.PartnerWebhook{
+		PartnerId:  partnerId,
+		Url:        req.Url,
+		Secret:     encryptedSecret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][webhookRepo.InsertWebhook] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, 0, appconstant.AuditActionWebhookCreated, appconstant.AuditEntityWebhook, webhookId, auditDiff{}.
+		add("partner_id", nil, partnerId).
+		add("url", nil, req.Url).
+		add("event_types", nil, strings.Join(eventTypes, ",")))
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][appendAuditEvent] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	w, err := webhookRepo.GetWebhookById(ctx, webhookId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][CreateWebhook][webhookRepo.GetWebhookById] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	return &entity.CreatedPartnerWebhook{PartnerWebhook: *w, Secret: secret}, nil
+}
+
+func (s *webhookServiceImpl) GetWebhooks(ctx context.Context, partnerId int64) (*entity.PartnerWebhooks, error) {
+	partner, err := s.partnerRepo.GetPartnerById(ctx, partnerId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][GetWebhooks][partnerRepo.GetPartnerById] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+	if partner == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	webhooks, err := s.webhookRepo.GetWebhooksByPartnerId(ctx, partnerId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][GetWebhooks][webhookRepo.GetWebhooksByPartnerId] Error: %s | partner_id: %v", err.Error(), partnerId),
+		})
+	}
+
+	return &entity.PartnerWebhooks{Webhooks: webhooks}, nil
+}
+
+// DeleteWebhook stops new deliveries to the webhook, the pending ones are given up as dead
+func (s *webhookServiceImpl) DeleteWebhook(ctx context.Context, partnerId, webhookId int64) error {
+	tx, err := s.transaction.Begin()
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][DeleteWebhook][transaction.Begin] Error: %s | partner_webhook_id: %v", err.Error(), webhookId),
+		})
+	}
+
+	webhookRepo := tx.PartnerWebhookTx()
+	auditEventRepo := tx.AuditEventTx()
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+
+		tx.Commit()
+	}()
+
+	isDeleted, err := webhookRepo.DeleteWebhook(ctx, partnerId, webhookId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][DeleteWebhook][webhookRepo.DeleteWebhook] Error: %s | partner_webhook_id: %v", err.Error(), webhookId),
+		})
+	}
+	if !isDeleted {
+		err = apperror.NotFoundError()
+		return err
+	}
+
+	err = appendAuditEvent(ctx, auditEventRepo, 0, appconstant.AuditActionWebhookDeleted, appconstant.AuditEntityWebhook, webhookId, auditDiff{}.
+		add("partner_id", partnerId, nil))
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][DeleteWebhook][appendAuditEvent] Error: %s | partner_webhook_id: %v", err.Error(), webhookId),
+		})
+	}
+
+	return nil
+}
+
+// GetDeliveries lists the deliveries matching the filter, newest first. Filtering on the dead status is the dead letter
+// view.
+func (s *webhookServiceImpl) GetDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) (*entity.WebhookDeliveries, error) {
+	if filter.Limit < 0 || filter.Limit > maxWebhookDeliveryLimit {
+		return nil, apperror.BadRequestError(apperror.AppErrorOpt{
+			ResponseMessage: fmt.Sprintf("limit must be between 1 and %v", maxWebhookDeliveryLimit),
+		})
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultWebhookDeliveryLimit
+	}
+
+	deliveries, err := s.deliveryRepo.GetDeliveries(ctx, filter)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][GetDeliveries][deliveryRepo.GetDeliveries] Error: %s", err.Error()),
+		})
+	}
+
+	result := &entity.WebhookDeliveries{Deliveries: deliveries}
+
+	if len(deliveries) == filter.Limit {
+		result.NextBeforeId = deliveries[len(deliveries)-1].Id
+	}
+
+	return result, nil
+}
+
+// Redeliver sends a delivered or dead delivery again with a fresh set of attempts. The payload is unchanged, so the
+// partner sees the same X-Webhook-Id.
+func (s *webhookServiceImpl) Redeliver(ctx context.Context, deliveryId int64) (*entity.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepo.GetDeliveryById(ctx, deliveryId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][Redeliver][deliveryRepo.GetDeliveryById] Error: %s | webhook_delivery_id: %v", err.Error(), deliveryId),
+		})
+	}
+	if delivery == nil {
+		return nil, apperror.NotFoundError()
+	}
+
+	w, err := s.webhookRepo.GetWebhookById(ctx, delivery.WebhookId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][Redeliver][webhookRepo.GetWebhookById] Error: %s | webhook_delivery_id: %v", err.Error(), deliveryId),
+		})
+	}
+	if w == nil || w.DeletedAt != nil {
+		return nil, apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusConflict,
+			Message:         fmt.Sprintf("[webhook_service][Redeliver] webhook deleted | webhook_delivery_id: %v", deliveryId),
+			ResponseMessage: "webhook of the delivery was deleted",
+		})
+	}
+
+	isRedelivered, err := s.deliveryRepo.Redeliver(ctx, deliveryId, time.Now().UnixMilli())
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][Redeliver][deliveryRepo.Redeliver] Error: %s | webhook_delivery_id: %v", err.Error(), deliveryId),
+		})
+	}
+	if !isRedelivered {
+		return nil, apperror.NewAppError(apperror.AppErrorOpt{
+			Code:            http.StatusConflict,
+			Message:         fmt.Sprintf("[webhook_service][Redeliver] delivery pending | webhook_delivery_id: %v", deliveryId),
+			ResponseMessage: "delivery is still pending",
+		})
+	}
+
+	delivery, err = s.deliveryRepo.GetDeliveryById(ctx, deliveryId)
+	if err != nil {
+		return nil, apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][Redeliver][deliveryRepo.GetDeliveryById] Error: %s | webhook_delivery_id: %v", err.Error(), deliveryId),
+		})
+	}
+
+	return delivery, nil
+}
+
+// Dispatch sends the deliveries due when it starts. A delivery the partner does not accept is retried after a backoff,
+// and given up as dead after MaxAttempts.
+func (s *webhookServiceImpl) Dispatch(ctx context.Context) (*entity.WebhookDispatchResult, error) {
+	result := &entity.WebhookDispatchResult{}
+
+	now := time.Now().UnixMilli()
+
+	// Claimed deliveries are pushed past now, so every batch loads the ones that follow
+	for {
+		deliveries, err := s.deliveryRepo.GetDueDeliveries(ctx, now, s.opt.BatchSize)
+		if err != nil {
+			return result, apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[webhook_service][Dispatch][deliveryRepo.GetDueDeliveries] Error: %s", err.Error()),
+			})
+		}
+
+		for _, delivery := range deliveries {
+			err = s.deliver(ctx, delivery, now, result)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		if len(deliveries) < s.opt.BatchSize {
+			return result, nil
+		}
+	}
+}
+
+// deliver claims the delivery and makes one attempt, then records the outcome
+func (s *webhookServiceImpl) deliver(ctx context.Context, delivery entity.WebhookDelivery, now int64, result *entity.WebhookDispatchResult) error {
+	isClaimed, err := s.deliveryRepo.ClaimDelivery(ctx, delivery.Id, now, time.Now().Add(s.opt.Lease).UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][deliver][deliveryRepo.ClaimDelivery] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+		})
+	}
+	if !isClaimed {
+		return nil
+	}
+
+	w, err := s.webhookRepo.GetWebhookById(ctx, delivery.WebhookId)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][deliver][webhookRepo.GetWebhookById] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+		})
+	}
+	if w == nil || w.DeletedAt != nil {
+		return s.giveUp(ctx, delivery, "webhook deleted", nil, result)
+	}
+
+	secret, err := s.decryptWebhookSecret(w.Secret)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][deliver][decryptWebhookSecret] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+		})
+	}
+
+	statusCode, sendErr := s.sender.Send(ctx, webhook.Delivery{
+		Id:        delivery.Id,
+		EventType: delivery.EventType,
+		Url:       w.Url,
+		Secret:    secret,
+		Payload:   delivery.Payload,
+	})
+
+	var lastStatusCode *int
+	if statusCode != 0 {
+		lastStatusCode = &statusCode
+	}
+
+	if sendErr == nil {
+		metrics.RecordWebhookDelivery(delivery.EventType, "delivered")
+
+		err = s.deliveryRepo.MarkDelivered(ctx, delivery.Id, statusCode)
+		if err != nil {
+			return apperror.InternalServerError(apperror.AppErrorOpt{
+				Message: fmt.Sprintf("[webhook_service][deliver][deliveryRepo.MarkDelivered] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+			})
+		}
+
+		result.Delivered++
+
+		return nil
+	}
+
+	if delivery.Attempts+1 >= s.opt.MaxAttempts {
+		return s.giveUp(ctx, delivery, sendErr.Error(), lastStatusCode, result)
+	}
+
+	metrics.RecordWebhookDelivery(delivery.EventType, "failed")
+
+	err = s.deliveryRepo.MarkFailed(ctx, delivery.Id, truncate(sendErr.Error(), 255), lastStatusCode, time.Now().Add(exponentialBackoff(s.opt.BaseBackoff, s.opt.MaxBackoff, delivery.Attempts+1)).UnixMilli())
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][deliver][deliveryRepo.MarkFailed] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+		})
+	}
+
+	result.Failed++
+
+	return nil
+}
+
+// giveUp marks the delivery dead, it stays in the dead letter view until it is redelivered
+func (s *webhookServiceImpl) giveUp(ctx context.Context, delivery entity.WebhookDelivery, lastError string, statusCode *int, result *entity.WebhookDispatchResult) error {
+	metrics.RecordWebhookDelivery(delivery.EventType, "dead")
+
+	err := s.deliveryRepo.MarkDead(ctx, delivery.Id, truncate(lastError, 255), statusCode)
+	if err != nil {
+		return apperror.InternalServerError(apperror.AppErrorOpt{
+			Message: fmt.Sprintf("[webhook_service][giveUp][deliveryRepo.MarkDead] Error: %s | webhook_delivery_id: %v", err.Error(), delivery.Id),
+		})
+	}
+
+	result.Dead++
+
+	return nil
+}
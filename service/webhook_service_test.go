@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/michaelyusak/xyz-kredit-plus/appconstant"
+	"github.com/michaelyusak/xyz-kredit-plus/entity"
+	"github.com/michaelyusak/xyz-kredit-plus/repository"
+	"github.com/michaelyusak/xyz-kredit-plus/webhook"
+)
+
+// recordingSender keeps every delivery it accepts, it refuses them with statusCode while err is set
+type recordingSender struct {
+	mu         sync.Mutex
+	err        error
+	statusCode int
+	deliveries []webhook.Delivery
+}
+
+func (s *recordingSender) Send(ctx context.Context, delivery webhook.Delivery) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.statusCode, s.err
+	}
+
+	s.deliveries = append(s.deliveries, delivery)
+
+	return http.StatusOK, nil
+}
+
+func (s *recordingSender) fail(statusCode int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statusCode = statusCode
+	s.err = err
+}
+
+func (s *recordingSender) sent() []webhook.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]webhook.Delivery(nil), s.deliveries...)
+}
+
+// staticResolver resolves every host to the same address, a public one unless set
+type staticResolver string
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if r == "" {
+		r = "93.184.215.14"
+	}
+
+	return []net.IPAddr{{IP: net.ParseIP(string(r))}}, nil
+}
+
+func (e *testEnv) webhookService(sender *recordingSender, opt WebhookOpt) *webhookServiceImpl {
+	opt.Key = []byte("test")
+	if opt.Resolver == nil {
+		opt.Resolver = staticResolver("")
+	}
+
+	return NewWebhookService(e.account.transaction, repository.NewPartnerRepositoryMemory(e.store), repository.NewPartnerWebhookRepositoryMemory(e.store),
+		repository.NewWebhookDeliveryRepositoryMemory(e.store), sender, opt)
+}
+
+// subscribe creates a partner with a webhook subscribed to the event types, and returns its api key with the webhook
+func subscribe(t *testing.T, s *webhookServiceImpl, eventTypes ...string) (string, *entity.CreatedPartnerWebhook) {
+	t.Helper()
+
+	partner, err := s.CreatePartner(context.Background(), entity.CreatePartnerReq{Name: "dog house store"})
+	if err != nil {
+		t.Fatalf("CreatePartner() error = %v", err)
+	}
+
+	w, err := s.CreateWebhook(context.Background(), partner.Id, entity.CreatePartnerWebhookReq{Url: "https://partner.example.com/webhooks", EventTypes: eventTypes})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	return partner.ApiKey, w
+}
+
+func dispatch(t *testing.T, s *webhookServiceImpl) *entity.WebhookDispatchResult {
+	t.Helper()
+
+	result, err := s.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	return result
+}
+
+// createPartnerTransaction books a transaction for the account through the partner
+func (e *testEnv) createPartnerTransaction(t *testing.T, accountId int64, apiKey string) *entity.Transaction {
+	t.Helper()
+
+	req := testTransaction(accountId, 200000, 2)
+	req.PartnerApiKey = apiKey
+	req.PartnerReference = "order-1234"
+
+	transaction, err := e.transaction.CreateTransaction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	return transaction
+}
+
+func TestWebhook_DeliversPartnerTransactions(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	sender := &recordingSender{}
+	s := env.webhookService(sender, WebhookOpt{})
+
+	apiKey, w := subscribe(t, s, appconstant.WebhookEventTransactionCancelled, appconstant.WebhookEventTransactionApproved)
+
+	_, other := subscribe(t, s, appconstant.WebhookEventTransactionApproved)
+
+	if len(w.EventTypes) != 2 || w.EventTypes[0] != appconstant.WebhookEventTransactionApproved || w.Secret == "" {
+		t.Fatalf("webhook = %+v, want sorted event types and a secret", w)
+	}
+
+	// Stored encrypted, the deliveries are signed with the secret decrypted
+	if stored, _ := repository.NewPartnerWebhookRepositoryMemory(env.store).GetWebhookById(context.Background(), w.Id); stored.Secret == w.Secret {
+		t.Errorf("stored secret = %q, want encrypted", stored.Secret)
+	}
+
+	transaction := env.createPartnerTransaction(t, accountId, apiKey)
+
+	_, err := env.transaction.CreateTransaction(context.Background(), testTransaction(accountId, 100000, 1))
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	result := dispatch(t, s)
+	if result.Delivered != 1 || result.Failed != 0 || result.Dead != 0 {
+		t.Fatalf("result = %+v, want only the partner transaction delivered to its webhook", result)
+	}
+
+	_, err = env.transaction.CancelTransaction(context.Background(), transaction.Id)
+	if err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+
+	result = dispatch(t, s)
+	if result.Delivered != 1 {
+		t.Fatalf("result = %+v, want the cancellation delivered", result)
+	}
+
+	sent := sender.sent()
+
+	var types []string
+
+	for _, delivery := range sent {
+		types = append(types, delivery.EventType)
+
+		if delivery.Secret != w.Secret || delivery.Secret == other.Secret || delivery.Url != w.Url {
+			t.Errorf("delivery = %+v, want sent to the webhook of the partner", delivery)
+		}
+	}
+
+	assertActions(t, types,
+		appconstant.WebhookEventTransactionApproved,
+		appconstant.WebhookEventTransactionCancelled,
+	)
+
+	var payload entity.WebhookPayload
+
+	err = json.Unmarshal(sent[1].Payload, &payload)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if payload.EventType != appconstant.WebhookEventTransactionCancelled || payload.Data.TransactionId != transaction.Id ||
+		payload.Data.PartnerReference != "order-1234" || payload.Data.Status != appconstant.TransactionStatusCancelled {
+		t.Errorf("payload = %+v, want the cancelled transaction with the reference of the partner", payload)
+	}
+
+	result = dispatch(t, s)
+	if result.Delivered != 0 {
+		t.Errorf("result = %+v, want nothing delivered again", result)
+	}
+}
+
+func TestWebhook_InvalidPartnerKey(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	req := testTransaction(accountId, 200000, 2)
+	req.PartnerApiKey = "xkp_unknown"
+
+	_, err := env.transaction.CreateTransaction(context.Background(), req)
+	assertAppError(t, err, http.StatusUnauthorized)
+
+	transactions, _ := env.transactionRepo.GetTransactionsByAccountId(context.Background(), accountId)
+	if len(transactions) != 0 {
+		t.Errorf("transactions = %d, want none", len(transactions))
+	}
+
+	limit, _ := env.accountLimitRepo.GetAccountLimitByAccountId(context.Background(), accountId, false)
+	if limit.Limit2M != 800000 {
+		t.Errorf("limit 2 months = %v, want unchanged 800000", limit.Limit2M)
+	}
+}
+
+func TestWebhook_RetriesThenDeadLetters(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	sender := &recordingSender{}
+	sender.fail(http.StatusServiceUnavailable, errors.New("partner unavailable"))
+
+	s := env.webhookService(sender, WebhookOpt{BaseBackoff: 50 * time.Millisecond, MaxBackoff: time.Hour, MaxAttempts: 2})
+
+	apiKey, _ := subscribe(t, s, appconstant.WebhookEventTransactionApproved)
+
+	env.createPartnerTransaction(t, accountId, apiKey)
+
+	result := dispatch(t, s)
+	if result.Failed != 1 {
+		t.Fatalf("result = %+v, want the delivery failed", result)
+	}
+
+	pending, err := s.GetDeliveries(context.Background(), entity.WebhookDeliveryFilter{Status: appconstant.WebhookDeliveryStatusPending})
+	if err != nil {
+		t.Fatalf("GetDeliveries() error = %v", err)
+	}
+
+	if len(pending.Deliveries) != 1 || pending.Deliveries[0].Attempts != 1 || pending.Deliveries[0].LastError == "" ||
+		pending.Deliveries[0].LastStatusCode == nil || *pending.Deliveries[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("pending = %+v, want failed once with the status code of the partner", pending.Deliveries)
+	}
+
+	result = dispatch(t, s)
+	if result.Failed != 0 || result.Dead != 0 {
+		t.Fatalf("result = %+v, want the delivery held back until its backoff passed", result)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	result = dispatch(t, s)
+	if result.Dead != 1 {
+		t.Fatalf("result = %+v, want the delivery given up after the second attempt", result)
+	}
+
+	dead, err := s.GetDeliveries(context.Background(), entity.WebhookDeliveryFilter{Status: appconstant.WebhookDeliveryStatusDead})
+	if err != nil {
+		t.Fatalf("GetDeliveries() error = %v", err)
+	}
+
+	if len(dead.Deliveries) != 1 || dead.Deliveries[0].Attempts != 2 {
+		t.Fatalf("dead = %+v, want the delivery after 2 attempts", dead.Deliveries)
+	}
+
+	delivery, err := s.Redeliver(context.Background(), dead.Deliveries[0].Id)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+
+	if delivery.Status != appconstant.WebhookDeliveryStatusPending || delivery.Attempts != 0 {
+		t.Errorf("delivery = %+v, want pending with a fresh set of attempts", delivery)
+	}
+
+	_, err = s.Redeliver(context.Background(), delivery.Id)
+	assertAppError(t, err, http.StatusConflict)
+
+	sender.fail(0, nil)
+
+	result = dispatch(t, s)
+	if result.Delivered != 1 || len(sender.sent()) != 1 {
+		t.Fatalf("result = %+v, want the redelivery delivered", result)
+	}
+
+	_, err = s.Redeliver(context.Background(), 0)
+	assertAppError(t, err, http.StatusNotFound)
+}
+
+func TestWebhook_DeletedWebhook(t *testing.T) {
+	env := newTestEnv(t)
+
+	accountId := env.registerWithKyc(t, "user@example.com", 600000)
+
+	sender := &recordingSender{}
+	s := env.webhookService(sender, WebhookOpt{})
+
+	apiKey, w := subscribe(t, s, appconstant.WebhookEventTransactionApproved)
+
+	env.createPartnerTransaction(t, accountId, apiKey)
+
+	err := s.DeleteWebhook(context.Background(), w.PartnerId, w.Id)
+	if err != nil {
+		t.Fatalf("DeleteWebhook() error = %v", err)
+	}
+
+	err = s.DeleteWebhook(context.Background(), w.PartnerId, w.Id)
+	assertAppError(t, err, http.StatusNotFound)
+
+	result := dispatch(t, s)
+	if result.Dead != 1 || len(sender.sent()) != 0 {
+		t.Fatalf("result = %+v, want the delivery given up without sending it", result)
+	}
+
+	dead, err := s.GetDeliveries(context.Background(), entity.WebhookDeliveryFilter{Status: appconstant.WebhookDeliveryStatusDead})
+	if err != nil || len(dead.Deliveries) != 1 {
+		t.Fatalf("GetDeliveries() = %v, %v, want the dead delivery", dead, err)
+	}
+
+	_, err = s.Redeliver(context.Background(), dead.Deliveries[0].Id)
+	assertAppError(t, err, http.StatusConflict)
+
+	webhooks, err := s.GetWebhooks(context.Background(), w.PartnerId)
+	if err != nil || len(webhooks.Webhooks) != 0 {
+		t.Errorf("GetWebhooks() = %v, %v, want none", webhooks, err)
+	}
+}
+
+func TestWebhook_RejectsInvalidSubscription(t *testing.T) {
+	env := newTestEnv(t)
+
+	s := env.webhookService(&recordingSender{}, WebhookOpt{})
+
+	partner, err := s.CreatePartner(context.Background(), entity.CreatePartnerReq{Name: "dog house store"})
+	if err != nil {
+		t.Fatalf("CreatePartner() error = %v", err)
+	}
+
+	for _, req := range []entity.CreatePartnerWebhookReq{
+		{Url: "ftp://partner.example.com/webhooks", EventTypes: []string{appconstant.WebhookEventTransactionApproved}},
+		{Url: "http://partner.example.com/webhooks", EventTypes: []string{appconstant.WebhookEventTransactionApproved}},
+		{Url: "https://127.0.0.1/webhooks", EventTypes: []string{appconstant.WebhookEventTransactionApproved}},
+		{Url: "https://169.254.169.254/latest/meta-data", EventTypes: []string{appconstant.WebhookEventTransactionApproved}},
+		{Url: "https://partner.example.com/webhooks", EventTypes: []string{}},
+		{Url: "https://partner.example.com/webhooks", EventTypes: []string{appconstant.EventTypeTransactionCreated}},
+	} {
+		_, err = s.CreateWebhook(context.Background(), partner.Id, req)
+		assertAppError(t, err, http.StatusBadRequest)
+	}
+
+	// A host resolving to a private address
+	private := env.webhookService(&recordingSender{}, WebhookOpt{Resolver: staticResolver("10.0.0.1")})
+
+	_, err = private.CreateWebhook(context.Background(), partner.Id, entity.CreatePartnerWebhookReq{Url: "https://partner.example.com/webhooks", EventTypes: []string{appconstant.WebhookEventTransactionApproved}})
+	assertAppError(t, err, http.StatusBadRequest)
+
+	_, err = s.CreateWebhook(context.Background(), partner.Id+1, entity.CreatePartnerWebhookReq{Url: "https://partner.example.com/webhooks", EventTypes: []string{appconstant.WebhookEventTransactionApproved}})
+	assertAppError(t, err, http.StatusNotFound)
+}
@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers of a delivery
+const (
+	// Id of the delivery, the same on every attempt so the partner can skip a delivery it already handled
+	HeaderId    = "X-Webhook-Id"
+	HeaderEvent = "X-Webhook-Event"
+	// Unix seconds when the attempt was signed
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign is the HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret of the webhook. The timestamp is covered so a
+// captured delivery cannot be replayed later.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify is what a partner checks on receipt: the signature matches, and the timestamp is within tolerance of now
+func Verify(secret string, timestamp int64, body []byte, signature string, now time.Time, tolerance time.Duration) bool {
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+type Delivery struct {
+	Id        int64
+	EventType string
+	Url       string
+	Secret    string
+	Payload   []byte
+}
+
+// Sender makes one attempt of a delivery. It returns the status code of the response, 0 when there was none, and an
+// error unless the partner answered 2xx.
+type Sender interface {
+	Send(ctx context.Context, delivery Delivery) (int, error)
+}
+
+// Resolver looks up the addresses of the host of a webhook url, *net.Resolver implements it
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// IsPublicIp reports whether ip is routable on the internet. Deliveries only go to public addresses, so a webhook
+// cannot reach the network of the dispatcher, e.g. a cloud metadata service on its link-local address.
+func IsPublicIp(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// CheckHost fails unless the host resolves to public addresses only
+func CheckHost(ctx context.Context, resolver Resolver, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIp(ip) {
+			return fmt.Errorf("[webhook][CheckHost] address not public | host: %s", host)
+		}
+
+		return nil
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("[webhook][CheckHost][resolver.LookupIPAddr] error: %w | host: %s", err, host)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicIp(addr.IP) {
+			return fmt.Errorf("[webhook][CheckHost] address not public | host: %s | address: %s", host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+type httpSender struct {
+	client *http.Client
+	// Only the tests deliver to a local server
+	isPrivateAllowed bool
+}
+
+// NewHttpSender POSTs the payload signed at the time of the attempt. Redirects are not followed, they fail the attempt.
+// The address is checked once resolved when connecting, so a host resolving to a private address after the webhook
+// was created is refused too.
+func NewHttpSender(timeout time.Duration) *httpSender {
+	s := &httpSender{}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); !s.isPrivateAllowed && (ip == nil || !IsPublicIp(ip)) {
+				return fmt.Errorf("[webhook][Send] address not public | address: %s", address)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Connect to the partner directly, a proxy would connect to the address on its behalf
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	s.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return s
+}
+
+func (s *httpSender) Send(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("[webhook][Send][http.NewRequestWithContext] error: %w | webhook_delivery_id: %v", err, delivery.Id)
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderId, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("[webhook][Send][client.Do] error: %w | webhook_delivery_id: %v", err, delivery.Id)
+	}
+	defer res.Body.Close()
+
+	// Read even when unused so the connection is reused
+	respBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("[webhook][Send] unexpected status %d: %s | webhook_delivery_id: %v", res.StatusCode, bytes.TrimSpace(respBody), delivery.Id)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret    = "whsec_test"
+	testTimestamp = 1_700_000_000
+	testPayload   = `{"event_type":"transaction.approved"}`
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently, partners check against the same construction
+	want := "sha256=f029c67c46a118cf702c52538fc7366c7b0e0e59f152cf7965b2d88d124a0bbf"
+
+	if got := Sign(testSecret, testTimestamp, []byte(testPayload)); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signature := Sign(testSecret, testTimestamp, []byte(testPayload))
+	signedAt := time.Unix(testTimestamp, 0)
+
+	for _, tt := range []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		now       time.Time
+		want      bool
+	}{
+		{"valid", testSecret, testTimestamp, testPayload, signedAt.Add(time.Minute), true},
+		{"other secret", "whsec_other", testTimestamp, testPayload, signedAt, false},
+		{"modified body", testSecret, testTimestamp, `{"event_type":"transaction.cancelled"}`, signedAt, false},
+		{"modified timestamp", testSecret, testTimestamp + 1, testPayload, signedAt, false},
+		{"replayed later", testSecret, testTimestamp, testPayload, signedAt.Add(time.Hour), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, []byte(tt.body), signature, tt.now, 5*time.Minute); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpSender(t *testing.T) {
+	var header http.Header
+	var body []byte
+
+	status := http.StatusNoContent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewHttpSender(time.Second)
+	sender.isPrivateAllowed = true
+
+	delivery := Delivery{Id: 42, EventType: "transaction.approved", Url: server.URL, Secret: testSecret, Payload: []byte(testPayload)}
+
+	statusCode, err := sender.Send(context.Background(), delivery)
+	if err != nil || statusCode != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v, want 204", statusCode, err)
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q, want unix seconds", HeaderTimestamp, header.Get(HeaderTimestamp))
+	}
+
+	if !Verify(testSecret, timestamp, body, header.Get(HeaderSignature), time.Now(), time.Minute) {
+		t.Errorf("%s = %q does not verify", HeaderSignature, header.Get(HeaderSignature))
+	}
+
+	if header.Get(HeaderId) != "42" || header.Get(HeaderEvent) != "transaction.approved" || string(body) != testPayload {
+		t.Errorf("request = %v %s, want the delivery", header, body)
+	}
+
+	for _, status = range []int{http.StatusServiceUnavailable, http.StatusFound} {
+		statusCode, err = sender.Send(context.Background(), delivery)
+		if err == nil || statusCode != status {
+			t.Errorf("Send() = %d, %v, want %d with an error", statusCode, err, status)
+		}
+	}
+}
+
+// staticResolver resolves every host to the same addresses
+type staticResolver []string
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+
+	for _, ip := range r {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+
+	return addrs, nil
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		resolver staticResolver
+		isPublic bool
+	}{
+		{"public address", "93.184.215.14", nil, true},
+		{"public host", "partner.example.com", staticResolver{"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"}, true},
+		{"loopback", "127.0.0.1", nil, false},
+		{"private", "10.0.0.1", nil, false},
+		{"link-local metadata service", "169.254.169.254", nil, false},
+		{"unspecified", "0.0.0.0", nil, false},
+		{"ipv6 loopback", "::1", nil, false},
+		{"ipv6 unique local", "fd00::1", nil, false},
+		{"host with a private address", "partner.example.com", staticResolver{"93.184.215.14", "192.168.1.1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckHost(context.Background(), tt.resolver, tt.host)
+			if (err == nil) != tt.isPublic {
+				t.Errorf("CheckHost(%q) error = %v, want public %v", tt.host, err, tt.isPublic)
+			}
+		})
+	}
+}
+
+func TestHttpSender_RefusesPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached a private address")
+	}))
+	defer server.Close()
+
+	_, err := NewHttpSender(time.Second).Send(context.Background(), Delivery{Id: 42, Url: server.URL, Secret: testSecret, Payload: []byte(testPayload)})
+	if err == nil {
+		t.Error("Send() to a loopback address error = nil, want refused")
+	}
+}